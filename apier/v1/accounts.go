@@ -418,6 +418,7 @@ type AttrAddBalance struct {
 	Overwrite      bool // When true it will reset if the balance is already there
 	Blocker        *bool
 	Disabled       *bool
+	Currency       *string // currency of the *monetary balance
 }
 
 func (self *ApierV1) AddBalance(attr *AttrAddBalance, reply *string) error {
@@ -469,6 +470,7 @@ func (self *ApierV1) modifyBalance(aType string, attr *AttrAddBalance, reply *st
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
+			Currency:       attr.Currency,
 		},
 	}
 	if attr.Directions != nil {
@@ -536,6 +538,7 @@ func (self *ApierV1) SetBalance(attr *utils.AttrSetBalance, reply *string) error
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
+			Currency:       attr.Currency,
 		},
 	}
 	if attr.Value != nil {
@@ -596,6 +599,7 @@ func (self *ApierV1) RemoveBalances(attr *utils.AttrSetBalance, reply *string) e
 			Weight:         attr.Weight,
 			Blocker:        attr.Blocker,
 			Disabled:       attr.Disabled,
+			Currency:       attr.Currency,
 		},
 	}
 	if attr.Value != nil {
@@ -43,16 +43,17 @@ const (
 )
 
 type ApierV1 struct {
-	StorDb      engine.LoadStorage
-	DataManager *engine.DataManager
-	CdrDb       engine.CdrStorage
-	Config      *config.CGRConfig
-	Responder   *engine.Responder
-	CdrStatsSrv rpcclient.RpcClientConnection
-	Users       rpcclient.RpcClientConnection
-	CDRs        rpcclient.RpcClientConnection // FixMe: populate it from cgr-engine
-	ServManager *servmanager.ServiceManager   // Need to have them capitalize so we can export in V2
-	HTTPPoster  *utils.HTTPPoster
+	StorDb        engine.LoadStorage
+	DataManager   *engine.DataManager
+	CdrDb         engine.CdrStorage
+	Config        *config.CGRConfig
+	Responder     *engine.Responder
+	CdrStatsSrv   rpcclient.RpcClientConnection
+	Users         rpcclient.RpcClientConnection
+	CDRs          rpcclient.RpcClientConnection // FixMe: populate it from cgr-engine
+	ServManager   *servmanager.ServiceManager   // Need to have them capitalize so we can export in V2
+	HTTPPoster    *utils.HTTPPoster
+	CDRsRetention *engine.CDRsRetentionService
 }
 
 func (self *ApierV1) GetDestination(dstId string, reply *engine.Destination) error {
@@ -1154,6 +1155,13 @@ func (self *ApierV1) FlushCache(args utils.AttrReloadCache, reply *string) (err
 	return
 }
 
+// ApplyCacheInvalidation removes out of cache the items changed by a peer engine, over the *rpc invalidation bus
+func (v1 *ApierV1) ApplyCacheInvalidation(inv cache.Invalidation, reply *string) error {
+	cache.ApplyInvalidation(&inv)
+	*reply = utils.OK
+	return nil
+}
+
 func (self *ApierV1) GetCacheStats(attrs utils.AttrCacheStats, reply *utils.CacheStats) error {
 	cs := new(utils.CacheStats)
 	cs.Destinations = cache.CountEntries(utils.DESTINATION_PREFIX)
@@ -1499,6 +1507,8 @@ func (self *ApierV1) LoadTariffPlanFromFolder(attrs utils.AttrLoadTpFromFolder,
 		path.Join(attrs.FolderPath, utils.StatsCsv),
 		path.Join(attrs.FolderPath, utils.ThresholdsCsv),
 		path.Join(attrs.FolderPath, utils.FiltersCsv),
+		path.Join(attrs.FolderPath, utils.ExchangeRatesCsv),
+		path.Join(attrs.FolderPath, utils.TaxRatesCsv),
 	), "", self.Config.DefaultTimezone)
 	if err := loader.LoadAll(); err != nil {
 		return utils.NewErrServerError(err)
@@ -1724,7 +1734,9 @@ type AttrRemoteLock struct {
 }
 
 func (self *ApierV1) RemoteLock(attr AttrRemoteLock, reply *string) error {
-	guardian.Guardian.GuardIDs(attr.Timeout, attr.LockIDs...)
+	if err := guardian.Guardian.GuardIDs(attr.Timeout, attr.LockIDs...); err != nil {
+		return utils.NewErrServerError(err)
+	}
 	*reply = utils.OK
 	return nil
 }
@@ -0,0 +1,52 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// NewCacheSv1 initializes CacheSv1
+func NewCacheSv1() *CacheSv1 {
+	return new(CacheSv1)
+}
+
+// CacheSv1 exports RPC out of the local cache
+type CacheSv1 struct{}
+
+// Call implements rpcclient.RpcClientConnection interface for internal RPC
+func (chSv1 *CacheSv1) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	return utils.APIerRPCCall(chSv1, serviceMethod, args, reply)
+}
+
+// ArgsGetCacheStats selects the cache partitions, all of them if empty
+type ArgsGetCacheStats struct {
+	CacheIDs []string
+}
+
+// GetCacheStats returns the hit/miss/eviction/expiry counters and the approximate memory per cache partition
+func (chSv1 *CacheSv1) GetCacheStats(args *ArgsGetCacheStats, reply *map[string]*cache.PartitionStats) (err error) {
+	pStats, err := cache.GetPartitionStats(args.CacheIDs)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = pStats
+	return
+}
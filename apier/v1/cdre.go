@@ -153,14 +153,12 @@ func (self *ApierV1) ExportCdrsToFile(attr utils.AttrExpFileCdrs, reply *utils.E
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	cdrs, _, err := self.CdrDb.GetCDRs(cdrsFltr, false)
+	cdrsIter, err := self.CdrDb.GetCDRsIterator(cdrsFltr)
 	if err != nil {
 		return err
-	} else if len(cdrs) == 0 {
-		*reply = utils.ExportedFileCdrs{ExportedFilePath: ""}
-		return nil
 	}
-	cdrexp, err := engine.NewCDRExporter(cdrs, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
+	defer cdrsIter.Close()
+	cdrexp, err := engine.NewCDRExporter(cdrsIter, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
 		exportTemplate.Synchronous, exportTemplate.Attempts, fieldSep, usageMultiplyFactor,
 		costMultiplyFactor, self.Config.RoundingDecimals, self.Config.HttpSkipTlsVerify, self.HTTPPoster)
 	if err != nil {
@@ -169,11 +167,14 @@ func (self *ApierV1) ExportCdrsToFile(attr utils.AttrExpFileCdrs, reply *utils.E
 	if err := cdrexp.ExportCDRs(); err != nil {
 		return utils.NewErrServerError(err)
 	}
+	if cdrexp.TotalCdrs() == 0 {
+		return utils.ErrNotFound
+	}
 	if cdrexp.TotalExportedCdrs() == 0 {
 		*reply = utils.ExportedFileCdrs{ExportedFilePath: ""}
 		return nil
 	}
-	*reply = utils.ExportedFileCdrs{ExportedFilePath: filePath, TotalRecords: len(cdrs), TotalCost: cdrexp.TotalCost(), FirstOrderId: cdrexp.FirstOrderId(), LastOrderId: cdrexp.LastOrderId()}
+	*reply = utils.ExportedFileCdrs{ExportedFilePath: filePath, TotalRecords: cdrexp.TotalCdrs(), TotalCost: cdrexp.TotalCost(), FirstOrderId: cdrexp.FirstOrderId(), LastOrderId: cdrexp.LastOrderId()}
 	if !attr.SuppressCgrIds {
 		reply.ExportedCgrIds = cdrexp.PositiveExports()
 		reply.UnexportedCgrIds = cdrexp.NegativeExports()
@@ -290,13 +291,12 @@ func (self *ApierV1) ExportCDRs(arg ArgExportCDRs, reply *RplExportedCDRs) (err
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	cdrs, _, err := self.CdrDb.GetCDRs(cdrsFltr, false)
+	cdrsIter, err := self.CdrDb.GetCDRsIterator(cdrsFltr)
 	if err != nil {
 		return err
-	} else if len(cdrs) == 0 {
-		return
 	}
-	cdrexp, err := engine.NewCDRExporter(cdrs, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
+	defer cdrsIter.Close()
+	cdrexp, err := engine.NewCDRExporter(cdrsIter, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
 		synchronous, attempts, fieldSep, usageMultiplyFactor,
 		costMultiplyFactor, roundingDecimals, self.Config.HttpSkipTlsVerify, self.HTTPPoster)
 	if err != nil {
@@ -305,10 +305,13 @@ func (self *ApierV1) ExportCDRs(arg ArgExportCDRs, reply *RplExportedCDRs) (err
 	if err := cdrexp.ExportCDRs(); err != nil {
 		return utils.NewErrServerError(err)
 	}
+	if cdrexp.TotalCdrs() == 0 {
+		return utils.ErrNotFound
+	}
 	if cdrexp.TotalExportedCdrs() == 0 {
 		return
 	}
-	*reply = RplExportedCDRs{ExportedPath: filePath, TotalRecords: len(cdrs), TotalCost: cdrexp.TotalCost(),
+	*reply = RplExportedCDRs{ExportedPath: filePath, TotalRecords: cdrexp.TotalCdrs(), TotalCost: cdrexp.TotalCost(),
 		FirstOrderID: cdrexp.FirstOrderId(), LastOrderID: cdrexp.LastOrderId()}
 	if arg.Verbose {
 		reply.ExportedCGRIDs = cdrexp.PositiveExports()
@@ -21,6 +21,7 @@ package v1
 import (
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/cgrates/cgrates/engine"
 	"github.com/cgrates/cgrates/utils"
@@ -51,14 +52,22 @@ func (apier *ApierV1) GetCdrs(attrs utils.AttrGetCdrs, reply *[]*engine.External
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	if cdrs, _, err := apier.CdrDb.GetCDRs(cdrsFltr, false); err != nil {
+	cdrsIter, err := apier.CdrDb.GetCDRsIterator(cdrsFltr)
+	if err != nil {
 		return utils.NewErrServerError(err)
-	} else if len(cdrs) == 0 {
-		*reply = make([]*engine.ExternalCDR, 0)
-	} else {
-		for _, cdr := range cdrs {
-			*reply = append(*reply, cdr.AsExternalCDR())
+	}
+	defer cdrsIter.Close()
+	for {
+		cdr, err := cdrsIter.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return utils.NewErrServerError(err)
 		}
+		*reply = append(*reply, cdr.AsExternalCDR())
+	}
+	if len(*reply) == 0 {
+		return utils.NewErrServerError(utils.ErrNotFound)
 	}
 	return nil
 }
@@ -95,3 +104,12 @@ func (apier *ApierV1) RateCDRs(attrs utils.AttrRateCDRs, reply *string) error {
 	}
 	return apier.CDRs.Call("CDRsV1.RateCDRs", attrs, reply)
 }
+
+// RunCDRsRetention applies the CDRs retention rules on demand, returning the report of the run
+func (apier *ApierV1) RunCDRsRetention(args engine.ArgsRunCDRsRetention, reply *engine.CDRsRetentionReport) error {
+	if apier.CDRsRetention == nil {
+		return errors.New("CDRS_RETENTION_NOT_CONFIGURED")
+	}
+	*reply = *apier.CDRsRetention.Run(args.DryRun)
+	return nil
+}
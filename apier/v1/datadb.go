@@ -0,0 +1,82 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package v1
+
+import (
+	"os"
+
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrDumpDataDB struct {
+	Path string // file the dump is written to, on the engine host
+}
+
+// DumpDataDB writes a portable copy of DataDB into a file, replying with the number of items dumped per category and the dump times
+// the items are read while the engine keeps changing them, each one as it was between the reported start and finish times
+func (apier *ApierV1) DumpDataDB(attrs AttrDumpDataDB, reply *engine.DataDBDumpReport) (err error) {
+	if missing := utils.MissingStructFields(&attrs, []string{"Path"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	f, err := os.Create(attrs.Path)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	rpt, err := engine.DumpDataDB(apier.DataManager.DataDB(), f)
+	if err != nil {
+		f.Close()
+		os.Remove(attrs.Path)
+		return utils.NewErrServerError(err)
+	}
+	if err = f.Close(); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = *rpt
+	return
+}
+
+type AttrRestoreDataDB struct {
+	Path  string // file created by DumpDataDB, on the engine host
+	Flush bool   // remove the existing DataDB content before restoring
+}
+
+// RestoreDataDB writes into DataDB the items out of a dump, replying with the number of items restored per category
+func (apier *ApierV1) RestoreDataDB(attrs AttrRestoreDataDB, reply *map[string]int) (err error) {
+	if missing := utils.MissingStructFields(&attrs, []string{"Path"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	f, err := os.Open(attrs.Path)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	defer f.Close()
+	if attrs.Flush {
+		if err = apier.DataManager.DataDB().Flush(""); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	stats, err := engine.RestoreDataDB(apier.DataManager.DataDB(), f, false)
+	cache.Flush() // cached items are outdated even after a partial restore
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = stats
+	return
+}
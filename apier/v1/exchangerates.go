@@ -0,0 +1,76 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// SetExchangeRate stores the ExchangeRate in DataDB, replacing the previous rates of the same currencies
+func (self *ApierV1) SetExchangeRate(attrs utils.TPExchangeRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency", "Rates"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	fx, err := engine.APItoExchangeRate(&attrs, self.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	if err := self.DataManager.DataDB().SetExchangeRate(fx); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrExchangeRate struct {
+	FromCurrency string
+	ToCurrency   string
+}
+
+// GetExchangeRate returns the ExchangeRate stored in DataDB
+func (self *ApierV1) GetExchangeRate(attrs AttrExchangeRate, reply *engine.ExchangeRate) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if fx, err := self.DataManager.DataDB().GetExchangeRate(utils.ConcatenatedKey(attrs.FromCurrency, attrs.ToCurrency)); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	} else {
+		*reply = *fx
+	}
+	return nil
+}
+
+// RemoveExchangeRate removes the ExchangeRate from DataDB
+func (self *ApierV1) RemoveExchangeRate(attrs AttrExchangeRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"FromCurrency", "ToCurrency"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.DataManager.DataDB().RemoveExchangeRate(utils.ConcatenatedKey(attrs.FromCurrency, attrs.ToCurrency)); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = utils.OK
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"os"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// AttrImportRateDeck points to a vendor rate deck and the template mapping its columns
+type AttrImportRateDeck struct {
+	TPid      string
+	DeckID    string // identifies the deck inside the IDs of the TP data created
+	FilePath  string // rate deck file, local to the engine
+	Template  *engine.RateDeckTemplate
+	Direction string // the RatingProfile activating the versions is written only if Tenant is not empty
+	Tenant    string
+	Category  string
+	Subject   string
+}
+
+// ImportRateDeck converts a vendor rate deck into a tariff plan in StorDB, with one RatingPlan per effective date,
+// replying with the rate changes compared with the active RatingPlan of the RatingProfile
+func (self *ApierV1) ImportRateDeck(attrs AttrImportRateDeck, reply *engine.RateDeckReport) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "DeckID", "FilePath"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if attrs.Template == nil {
+		return utils.NewErrMandatoryIeMissing("Template")
+	}
+	fDeck, err := os.Open(attrs.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return utils.ErrInvalidPath
+		}
+		return utils.NewErrServerError(err)
+	}
+	defer fDeck.Close()
+	rdi := &engine.RateDeckImporter{TPid: attrs.TPid, DeckID: attrs.DeckID, StorDb: self.StorDb,
+		DataDB: self.DataManager.DataDB(), Template: attrs.Template, Direction: attrs.Direction,
+		Tenant: attrs.Tenant, Category: attrs.Category, Subject: attrs.Subject,
+		Timezone: self.Config.DefaultTimezone}
+	rpt, err := rdi.Run(fDeck)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = *rpt
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// AttrSimulateRating selects the tariff plan and the CDRs re-rated with it
+type AttrSimulateRating struct {
+	TPid                string // tariff plan out of StorDB
+	utils.RPCCDRsFilter        // Inherit the CDR filter attributes
+}
+
+// SimulateRating re-rates the stored CDRs with a tariff plan out of StorDB, without changing accounts or CDRs,
+// replying with the per account and per destination costs and their deltas against the stored ones
+func (self *ApierV1) SimulateRating(attr AttrSimulateRating, reply *engine.RatingSimulation) error {
+	if attr.TPid == "" {
+		return utils.NewErrMandatoryIeMissing("TPid")
+	}
+	cdrsFltr, err := attr.RPCCDRsFilter.AsCDRsFilter(self.Config.DefaultTimezone)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	rs, err := engine.SimulateRating(self.StorDb, self.CdrDb, attr.TPid, self.Config.DefaultTimezone, cdrsFltr)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = *rs
+	return nil
+}
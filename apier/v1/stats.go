@@ -103,3 +103,8 @@ func (stsv1 *StatSV1) GetQueueStringMetrics(args *utils.TenantID, reply *map[str
 func (stsv1 *StatSV1) GetQueueFloatMetrics(args *utils.TenantID, reply *map[string]float64) (err error) {
 	return stsv1.sS.V1GetQueueFloatMetrics(args, reply)
 }
+
+// GetQueueMetricsHistory returns the metrics history of a Queue as time series
+func (stsv1 *StatSV1) GetQueueMetricsHistory(args *engine.ArgsGetQueueMetricsHistory, reply *[]*engine.StatQueueMetrics) (err error) {
+	return stsv1.sS.V1GetQueueMetricsHistory(args, reply)
+}
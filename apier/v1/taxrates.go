@@ -0,0 +1,107 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// SetTaxRate adds the TaxRate to the ones of its tenant, replacing the one with the same ID
+func (self *ApierV1) SetTaxRate(attrs utils.TPTaxRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "ID", "Type"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	txr, err := engine.APItoTaxRate(&attrs)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) {
+		txrs, err := self.DataManager.DataDB().GetTaxRates(attrs.Tenant)
+		if err != nil && err != utils.ErrNotFound {
+			return nil, err
+		}
+		var replaced bool
+		for i, oldTxr := range txrs {
+			if oldTxr.ID == txr.ID {
+				txrs[i] = txr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			txrs = append(txrs, txr)
+		}
+		txrs.Sort()
+		return nil, self.DataManager.DataDB().SetTaxRates(attrs.Tenant, txrs)
+	}, 0, utils.TaxRatesPrefix+attrs.Tenant)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+// GetTaxRates returns the TaxRates of a tenant, in the order they are applied
+func (self *ApierV1) GetTaxRates(tenant string, reply *engine.TaxRates) error {
+	if tenant == "" {
+		return utils.NewErrMandatoryIeMissing("Tenant")
+	}
+	if txrs, err := self.DataManager.DataDB().GetTaxRates(tenant); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	} else {
+		*reply = txrs
+	}
+	return nil
+}
+
+// RemoveTaxRate removes one TaxRate out of the ones of its tenant
+func (self *ApierV1) RemoveTaxRate(arg utils.TenantID, reply *string) error {
+	if missing := utils.MissingStructFields(&arg, []string{"Tenant", "ID"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	_, err := guardian.Guardian.Guard(func() (interface{}, error) {
+		txrs, err := self.DataManager.DataDB().GetTaxRates(arg.Tenant)
+		if err != nil {
+			return nil, err
+		}
+		for i, txr := range txrs {
+			if txr.ID == arg.ID {
+				txrs = append(txrs[:i], txrs[i+1:]...)
+				if len(txrs) == 0 {
+					return nil, self.DataManager.DataDB().RemoveTaxRates(arg.Tenant)
+				}
+				return nil, self.DataManager.DataDB().SetTaxRates(arg.Tenant, txrs)
+			}
+		}
+		return nil, utils.ErrNotFound
+	}, 0, utils.TaxRatesPrefix+arg.Tenant)
+	if err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	*reply = utils.OK
+	return nil
+}
@@ -58,6 +58,11 @@ func (tSv1 *ThresholdSV1) ProcessEvent(ev *engine.ThresholdEvent, reply *string)
 	return tSv1.tS.V1ProcessEvent(ev, reply)
 }
 
+// ResetThreshold clears the hit counters of a Threshold
+func (tSv1 *ThresholdSV1) ResetThreshold(tntID *utils.TenantID, reply *string) error {
+	return tSv1.tS.V1ResetThreshold(tntID, reply)
+}
+
 // GetThresholdProfile returns a Threshold Profile
 func (apierV1 *ApierV1) GetThresholdProfile(arg *utils.TenantID, reply *engine.ThresholdProfile) (err error) {
 	if missing := utils.MissingStructFields(arg, []string{"Tenant", "ID"}); len(missing) != 0 { //Params missing
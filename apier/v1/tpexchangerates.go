@@ -0,0 +1,71 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Creates a new ExchangeRate within a tariff plan
+func (self *ApierV1) SetTPExchangeRate(attrs utils.TPExchangeRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "FromCurrency", "ToCurrency", "Rates"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.SetTPExchangeRates([]*utils.TPExchangeRate{&attrs}); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetTPExchangeRate struct {
+	TPid         string // Tariff plan id
+	FromCurrency string
+	ToCurrency   string
+}
+
+// Queries specific ExchangeRate on tariff plan
+func (self *ApierV1) GetTPExchangeRate(attr AttrGetTPExchangeRate, reply *utils.TPExchangeRate) error {
+	if missing := utils.MissingStructFields(&attr, []string{"TPid", "FromCurrency", "ToCurrency"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if fxs, err := self.StorDb.GetTPExchangeRates(attr.TPid, attr.FromCurrency, attr.ToCurrency); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	} else {
+		*reply = *fxs[0]
+	}
+	return nil
+}
+
+// Removes specific ExchangeRate on Tariff plan
+func (self *ApierV1) RemTPExchangeRate(attrs AttrGetTPExchangeRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "FromCurrency", "ToCurrency"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.RemTpData(utils.TBLTPExchangeRates, attrs.TPid,
+		map[string]string{"from_currency": attrs.FromCurrency, "to_currency": attrs.ToCurrency}); err != nil {
+		return utils.NewErrServerError(err)
+	} else {
+		*reply = utils.OK
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Creates a new TaxRate within a tariff plan
+func (self *ApierV1) SetTPTaxRate(attrs utils.TPTaxRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "Tenant", "ID", "Type"}); len(missing) != 0 {
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.SetTPTaxRates([]*utils.TPTaxRate{&attrs}); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	*reply = utils.OK
+	return nil
+}
+
+type AttrGetTPTaxRate struct {
+	TPid string // Tariff plan id
+	ID   string // TaxRate id
+}
+
+// Queries specific TaxRate on tariff plan
+func (self *ApierV1) GetTPTaxRate(attr AttrGetTPTaxRate, reply *utils.TPTaxRate) error {
+	if missing := utils.MissingStructFields(&attr, []string{"TPid", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if txrs, err := self.StorDb.GetTPTaxRates(attr.TPid, attr.ID); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	} else {
+		*reply = *txrs[0]
+	}
+	return nil
+}
+
+type AttrGetTPTaxRateIds struct {
+	TPid string // Tariff plan id
+	utils.Paginator
+}
+
+// Queries TaxRate identities on specific tariff plan.
+func (self *ApierV1) GetTPTaxRateIds(attrs AttrGetTPTaxRateIds, reply *[]string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if ids, err := self.StorDb.GetTpTableIds(attrs.TPid, utils.TBLTPTaxRates, utils.TPDistinctIds{"id"}, nil, &attrs.Paginator); err != nil {
+		if err.Error() != utils.ErrNotFound.Error() {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	} else {
+		*reply = ids
+	}
+	return nil
+}
+
+type AttrRemTPTaxRate struct {
+	TPid   string // Tariff plan id
+	Tenant string
+	ID     string // TaxRate id
+}
+
+// Removes specific TaxRate on Tariff plan
+func (self *ApierV1) RemTPTaxRate(attrs AttrRemTPTaxRate, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "Tenant", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if err := self.StorDb.RemTpData(utils.TBLTPTaxRates, attrs.TPid, map[string]string{"tenant": attrs.Tenant, "id": attrs.ID}); err != nil {
+		return utils.NewErrServerError(err)
+	} else {
+		*reply = utils.OK
+	}
+	return nil
+}
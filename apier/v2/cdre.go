@@ -118,18 +118,16 @@ func (self *ApierV2) ExportCdrsToFile(attr AttrExportCdrsToFile, reply *Exported
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	cdrs, _, err := self.CdrDb.GetCDRs(cdrsFltr, false)
+	cdrsIter, err := self.CdrDb.GetCDRsIterator(cdrsFltr)
 	if err != nil {
 		return err
-	} else if len(cdrs) == 0 {
-		*reply = ExportedFileCdrs{ExportedFilePath: ""}
-		return nil
 	}
+	defer cdrsIter.Close()
 	roundingDecimals := self.Config.RoundingDecimals
 	if attr.RoundingDecimals != nil {
 		roundingDecimals = *attr.RoundingDecimals
 	}
-	cdrexp, err := engine.NewCDRExporter(cdrs, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
+	cdrexp, err := engine.NewCDRExporter(cdrsIter, exportTemplate, exportFormat, filePath, utils.META_NONE, exportID,
 		exportTemplate.Synchronous, exportTemplate.Attempts, fieldSep, usageMultiplyFactor,
 		costMultiplyFactor, roundingDecimals, self.Config.HttpSkipTlsVerify, self.HTTPPoster)
 	if err != nil {
@@ -138,11 +136,14 @@ func (self *ApierV2) ExportCdrsToFile(attr AttrExportCdrsToFile, reply *Exported
 	if err := cdrexp.ExportCDRs(); err != nil {
 		return utils.NewErrServerError(err)
 	}
+	if cdrexp.TotalCdrs() == 0 {
+		return utils.ErrNotFound
+	}
 	if cdrexp.TotalExportedCdrs() == 0 {
 		*reply = ExportedFileCdrs{ExportedFilePath: ""}
 		return nil
 	}
-	*reply = ExportedFileCdrs{ExportedFilePath: filePath, TotalRecords: len(cdrs), TotalCost: cdrexp.TotalCost(), FirstOrderId: cdrexp.FirstOrderId(), LastOrderId: cdrexp.LastOrderId()}
+	*reply = ExportedFileCdrs{ExportedFilePath: filePath, TotalRecords: cdrexp.TotalCdrs(), TotalCost: cdrexp.TotalCost(), FirstOrderId: cdrexp.FirstOrderId(), LastOrderId: cdrexp.LastOrderId()}
 	if !attr.Verbose {
 		reply.ExportedCgrIds = cdrexp.PositiveExports()
 		reply.UnexportedCgrIds = cdrexp.NegativeExports()
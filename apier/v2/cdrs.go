@@ -19,6 +19,8 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package v2
 
 import (
+	"io"
+
 	"github.com/cgrates/cgrates/apier/v1"
 
 	"github.com/cgrates/cgrates/engine"
@@ -31,17 +33,22 @@ func (apier *ApierV2) GetCdrs(attrs utils.RPCCDRsFilter, reply *[]*engine.Extern
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	if cdrs, _, err := apier.CdrDb.GetCDRs(cdrsFltr, false); err != nil {
-		if err.Error() != utils.NotFoundCaps {
-			err = utils.NewErrServerError(err)
-		}
-		return err
-	} else if len(cdrs) == 0 {
-		*reply = make([]*engine.ExternalCDR, 0)
-	} else {
-		for _, cdr := range cdrs {
-			*reply = append(*reply, cdr.AsExternalCDR())
+	cdrsIter, err := apier.CdrDb.GetCDRsIterator(cdrsFltr)
+	if err != nil {
+		return utils.NewErrServerError(err)
+	}
+	defer cdrsIter.Close()
+	for {
+		cdr, err := cdrsIter.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return utils.NewErrServerError(err)
 		}
+		*reply = append(*reply, cdr.AsExternalCDR())
+	}
+	if len(*reply) == 0 {
+		return utils.ErrNotFound
 	}
 	return nil
 }
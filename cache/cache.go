@@ -45,8 +45,62 @@ var (
 	transactionBuffer map[string][]*transactionItem // Queue tasks based on transactionID
 	transBufMux       sync.Mutex                    // Protects the transactionBuffer
 	transactionMux    sync.Mutex                    // Queue transactions on commit
+
+	// invalidation stuff
+	invPublisher    InvalidationPublisher // propagates the local changes to the other engines, nil if not enabled
+	invPublisherMux sync.RWMutex
+	invOrigin       = utils.GenUUID() // identifies the invalidations published by this engine
 )
 
+// Invalidation carries the cache items changed by one engine towards the other engines sharing DataDB
+type Invalidation struct {
+	Origin   string // engine publishing it
+	Keys     []string
+	Prefixes []string
+}
+
+// InvalidationPublisher propagates the invalidations over the bus connecting the engines
+type InvalidationPublisher interface {
+	PublishInvalidation(inv *Invalidation) error
+}
+
+// SetInvalidationPublisher enables the propagation of the cache changes to the other engines
+func SetInvalidationPublisher(pub InvalidationPublisher) {
+	invPublisherMux.Lock()
+	invPublisher = pub
+	invPublisherMux.Unlock()
+}
+
+// PublishInvalidation sends the keys and prefixes changed locally over the bus, if enabled
+func PublishInvalidation(keys, prefixes []string) {
+	invPublisherMux.RLock()
+	pub := invPublisher
+	invPublisherMux.RUnlock()
+	if pub == nil || (len(keys) == 0 && len(prefixes) == 0) {
+		return
+	}
+	if err := pub.PublishInvalidation(&Invalidation{Origin: invOrigin, Keys: keys, Prefixes: prefixes}); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<%s> error: %s publishing invalidation for keys: %+v, prefixes: %+v",
+			utils.Cache, err.Error(), keys, prefixes))
+	}
+}
+
+// ApplyInvalidation removes the items changed by other engine, without publishing them further
+// the items are read again out of DataDB on next access
+func ApplyInvalidation(inv *Invalidation) {
+	if inv.Origin == invOrigin { // our own changes, received back from the bus
+		return
+	}
+	cacheMux.Lock()
+	for _, key := range inv.Keys {
+		cache.Delete(key)
+	}
+	for _, prefix := range inv.Prefixes {
+		cache.DeletePrefix(prefix)
+	}
+	cacheMux.Unlock()
+}
+
 type transactionItem struct {
 	verb  string      // action which will be executed on cache
 	key   string      // item key
@@ -83,21 +137,26 @@ func CommitTransaction(transID string) {
 	transactionMux.Lock()
 	transBufMux.Lock()
 	// apply all transactioned items in one shot
+	var invKeys, invPrefixes []string // changed items, invalidated on the other engines
 	cacheMux.Lock()
 	for _, item := range transactionBuffer[transID] {
 		switch item.verb {
 		case REM:
 			RemKey(item.key, true, transID)
+			invKeys = append(invKeys, item.key)
 		case REM_PREFIX:
 			RemPrefixKey(item.key, true, transID)
+			invPrefixes = append(invPrefixes, item.key)
 		case ADD:
 			Set(item.key, item.value, true, transID)
+			invKeys = append(invKeys, item.key)
 		}
 	}
 	cacheMux.Unlock()
 	delete(transactionBuffer, transID)
 	transBufMux.Unlock()
 	transactionMux.Unlock()
+	PublishInvalidation(invKeys, invPrefixes)
 }
 
 // The function to be used to cache a key/value pair when expiration is not needed
@@ -120,6 +179,7 @@ func Set(key string, value interface{}, commit bool, transID string) {
 func RemKey(key string, commit bool, transID string) {
 	if commit {
 		if transID == "" { // Lock per operation not transaction
+			defer PublishInvalidation([]string{key}, nil) // after unlocking
 			cacheMux.Lock()
 			defer cacheMux.Unlock()
 		}
@@ -135,6 +195,7 @@ func RemKey(key string, commit bool, transID string) {
 func RemPrefixKey(prefix string, commit bool, transID string) {
 	if commit {
 		if transID == "" { // Lock locally
+			defer PublishInvalidation(nil, []string{prefix}) // after unlocking
 			cacheMux.Lock()
 			defer cacheMux.Unlock()
 		}
@@ -193,6 +254,17 @@ func CountEntries(prefix string) (result int) {
 	return cache.CountEntriesForPrefix(prefix)
 }
 
+// PrometheusMetrics exports the number of entries for each cache partition
+func PrometheusMetrics() (pms []*utils.PrometheusMetric, err error) {
+	for cacheInstance, prefix := range utils.CacheInstanceToPrefix {
+		pms = append(pms, &utils.PrometheusMetric{Name: "cgrates_cache_entries",
+			Help: "Number of entries in cache, per partition", Type: utils.MetricGauge,
+			Labels: map[string]string{"partition": cacheInstance},
+			Value:  float64(CountEntries(prefix))})
+	}
+	return
+}
+
 func GetEntryKeys(prefix string) (keys []string) {
 	cacheMux.RLock()
 	defer cacheMux.RUnlock()
@@ -0,0 +1,192 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+const (
+	memorySampleSize  = 50 // number of items sized when estimating the memory of a partition
+	memorySampleEvery = 10 // one out of these many sets of items not in the sample enters it, replacing the oldest sampled item
+)
+
+// memorySample keeps the sizes of a rotating set of items, computed when the items are set
+// only the sizes are kept, the items might be changed by their users afterwards
+type memorySample struct {
+	sizes    map[string]int64 // size of the sampled items, including their ID
+	slots    []string         // IDs of the sampled items in the order they entered the sample, "" for the removed ones
+	slotIdx  map[string]int   // position of the sampled items within slots
+	nextSlot int              // oldest slot, replaced by the next item sampled
+	sets     int64            // sets of items not in the sample
+}
+
+func newMemorySample() *memorySample {
+	return &memorySample{sizes: make(map[string]int64), slots: make([]string, memorySampleSize),
+		slotIdx: make(map[string]int)}
+}
+
+// add sizes the item if it is sampled already, while filling the sample or once every memorySampleEvery sets
+func (ms *memorySample) add(itmID string, value interface{}) {
+	if _, has := ms.sizes[itmID]; !has {
+		ms.sets++
+		if len(ms.sizes) == memorySampleSize && ms.sets%memorySampleEvery != 0 {
+			return
+		}
+		if oldID := ms.slots[ms.nextSlot]; oldID != "" {
+			delete(ms.sizes, oldID)
+			delete(ms.slotIdx, oldID)
+		}
+		ms.slots[ms.nextSlot] = itmID
+		ms.slotIdx[itmID] = ms.nextSlot
+		ms.nextSlot = (ms.nextSlot + 1) % memorySampleSize
+	}
+	ms.sizes[itmID] = int64(len(itmID)) + approxSize(reflect.ValueOf(&value).Elem(), make(map[uintptr]bool))
+}
+
+func (ms *memorySample) remove(itmID string) {
+	if slot, has := ms.slotIdx[itmID]; has {
+		ms.slots[slot] = ""
+		delete(ms.slotIdx, itmID)
+		delete(ms.sizes, itmID)
+	}
+}
+
+// PartitionStats are the usage counters of one cache partition
+type PartitionStats struct {
+	Items     int
+	Hits      int64
+	Misses    int64
+	Evictions int64 // items dropped to stay within the partition limit
+	Expiries  int64 // items dropped once their TTL passed
+	Memory    int64 // approximate bytes used by the items, extrapolated out of a sample
+}
+
+func (p *cachePartition) stats() (ps *PartitionStats) {
+	ps = &PartitionStats{
+		Items:     p.Cache.Len(),
+		Hits:      atomic.LoadInt64(&p.hits),
+		Misses:    atomic.LoadInt64(&p.misses),
+		Evictions: atomic.LoadInt64(&p.evictions),
+		Expiries:  atomic.LoadInt64(&p.expiries),
+	}
+	if ps.Items == 0 {
+		return
+	}
+	var sampled, sampleSize int64
+	p.sampleMux.Lock()
+	for _, size := range p.samples.sizes {
+		sampleSize += size
+		sampled++
+	}
+	p.sampleMux.Unlock()
+	if sampled != 0 {
+		ps.Memory = sampleSize * int64(ps.Items) / sampled
+	}
+	return
+}
+
+func (cs cacheLRUTTL) Stats(prefix string) *PartitionStats {
+	if c, has := cs[prefix]; has {
+		return c.stats()
+	}
+	return nil
+}
+
+// GetPartitionStats returns the statistics of the cache partitions, indexed on partition name
+// all partitions are returned if none is specified, the one without own configuration being *default
+func GetPartitionStats(partitions []string) (pStats map[string]*PartitionStats, err error) {
+	if len(partitions) == 0 {
+		partitions = append(partitions, utils.META_DEFAULT)
+		for cacheInstance := range utils.CacheInstanceToPrefix {
+			partitions = append(partitions, cacheInstance)
+		}
+	}
+	pStats = make(map[string]*PartitionStats)
+	cacheMux.RLock()
+	defer cacheMux.RUnlock()
+	for _, partition := range partitions {
+		prefix := utils.ANY
+		if partition != utils.META_DEFAULT {
+			var has bool
+			if prefix, has = utils.CacheInstanceToPrefix[partition]; !has {
+				return nil, fmt.Errorf("unknown cache partition: <%s>", partition)
+			}
+		}
+		if ps := cache.Stats(prefix); ps != nil {
+			pStats[partition] = ps
+		}
+	}
+	return
+}
+
+// approxSize estimates the memory referenced by v, on top of the size of v itself
+// pointers already seen are not counted twice
+func approxSize(v reflect.Value, seen map[uintptr]bool) (size int64) {
+	size = int64(v.Type().Size())
+	return size + indirectSize(v, seen)
+}
+
+func indirectSize(v reflect.Value, seen map[uintptr]bool) (size int64) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		return approxSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		return approxSize(v.Elem(), seen)
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		size = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += indirectSize(v.Index(i), seen)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += indirectSize(v.Index(i), seen)
+		}
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		for _, key := range v.MapKeys() {
+			size += approxSize(key, seen) + approxSize(v.MapIndex(key), seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += indirectSize(v.Field(i), seen)
+		}
+	}
+	return
+}
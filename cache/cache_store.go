@@ -20,6 +20,9 @@ package cache
 
 import (
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/utils"
@@ -34,13 +37,82 @@ type cacheStore interface {
 	CountEntriesForPrefix(string) int
 	GetKeysForPrefix(string) []string
 	Clear()
+	Stats(string) *PartitionStats
 }
 
-type cacheLRUTTL map[string]*ltcache.Cache
+// cachePartition is one ltcache instance together with its usage counters
+type cachePartition struct {
+	hits      int64 // first in struct for atomic alignment
+	misses    int64
+	evictions int64
+	expiries  int64
+	setting   int32         // a Set is in progress, removals seen now are evictions
+	removing  int32         // an explicit removal is in progress, not counted
+	samples   *memorySample // sizes of some of the items, taken when set since reading ltcache alters the LRU order and TTL
+	sampleMux sync.Mutex    // protects samples, expiries being reported by ltcache out of cacheMux
+	*ltcache.Cache
+}
+
+func newCachePartition(maxEntries int, ttl time.Duration, staticTTL bool) (p *cachePartition) {
+	p = &cachePartition{samples: newMemorySample()}
+	p.Cache = ltcache.New(maxEntries, ttl, staticTTL, p.onEvicted)
+	return
+}
+
+// onEvicted classifies the items dropped by ltcache
+// writes are serialized by cacheMux so the only removals racing with them are TTL expiries, hence approximate
+func (p *cachePartition) onEvicted(itmID string, value interface{}) {
+	p.sampleMux.Lock()
+	p.samples.remove(itmID)
+	p.sampleMux.Unlock()
+	if atomic.LoadInt32(&p.removing) != 0 {
+		return
+	}
+	if atomic.LoadInt32(&p.setting) != 0 {
+		atomic.AddInt64(&p.evictions, 1)
+	} else {
+		atomic.AddInt64(&p.expiries, 1)
+	}
+}
+
+func (p *cachePartition) set(itmID string, value interface{}) {
+	atomic.StoreInt32(&p.setting, 1)
+	p.Cache.Set(itmID, value)
+	atomic.StoreInt32(&p.setting, 0)
+	p.sampleMux.Lock()
+	p.samples.add(itmID, value)
+	p.sampleMux.Unlock()
+}
+
+func (p *cachePartition) get(itmID string) (value interface{}, has bool) {
+	if value, has = p.Cache.Get(itmID); has {
+		atomic.AddInt64(&p.hits, 1)
+	} else {
+		atomic.AddInt64(&p.misses, 1)
+	}
+	return
+}
+
+func (p *cachePartition) remove(itmID string) {
+	atomic.StoreInt32(&p.removing, 1)
+	p.Cache.Remove(itmID)
+	atomic.StoreInt32(&p.removing, 0)
+}
+
+func (p *cachePartition) clear() {
+	atomic.StoreInt32(&p.removing, 1)
+	p.Cache.Clear()
+	atomic.StoreInt32(&p.removing, 0)
+	p.sampleMux.Lock()
+	p.samples = newMemorySample()
+	p.sampleMux.Unlock()
+}
+
+type cacheLRUTTL map[string]*cachePartition
 
 func newLRUTTL(cfg config.CacheConfig) (c cacheLRUTTL) {
-	c = map[string]*ltcache.Cache{
-		utils.ANY: ltcache.New(ltcache.UnlimitedCaching, ltcache.UnlimitedCaching, false, nil), // no limits for default cache instance
+	c = map[string]*cachePartition{
+		utils.ANY: newCachePartition(ltcache.UnlimitedCaching, ltcache.UnlimitedCaching, false), // no limits for default cache instance
 	}
 	if cfg == nil {
 		return
@@ -51,12 +123,12 @@ func newLRUTTL(cfg config.CacheConfig) (c cacheLRUTTL) {
 		if prefixKey, has := utils.CacheInstanceToPrefix[cfgKey]; has {
 			cacheInstanceID = prefixKey // old aliases, backwards compatibility purpose
 		}
-		c[cacheInstanceID] = ltcache.New(cfg[cfgKey].Limit, cfg[cfgKey].TTL, cfg[cfgKey].StaticTTL, nil)
+		c[cacheInstanceID] = newCachePartition(cfg[cfgKey].Limit, cfg[cfgKey].TTL, cfg[cfgKey].StaticTTL)
 	}
 	return
 }
 
-func (cs cacheLRUTTL) cacheInstance(instID string) (c *ltcache.Cache) {
+func (cs cacheLRUTTL) cacheInstance(instID string) (c *cachePartition) {
 	var ok bool
 	if c, ok = cs[instID]; !ok {
 		c = cs[utils.ANY]
@@ -65,20 +137,20 @@ func (cs cacheLRUTTL) cacheInstance(instID string) (c *ltcache.Cache) {
 }
 
 func (cs cacheLRUTTL) Put(key string, value interface{}) {
-	cs.cacheInstance(key[:PREFIX_LEN]).Set(key[PREFIX_LEN:], value)
+	cs.cacheInstance(key[:PREFIX_LEN]).set(key[PREFIX_LEN:], value)
 }
 
 func (cs cacheLRUTTL) Get(key string) (interface{}, bool) {
-	return cs.cacheInstance(key[:PREFIX_LEN]).Get(key[PREFIX_LEN:])
+	return cs.cacheInstance(key[:PREFIX_LEN]).get(key[PREFIX_LEN:])
 }
 
 func (cs cacheLRUTTL) Delete(key string) {
-	cs.cacheInstance(key[:PREFIX_LEN]).Remove(key[PREFIX_LEN:])
+	cs.cacheInstance(key[:PREFIX_LEN]).remove(key[PREFIX_LEN:])
 }
 
 func (cs cacheLRUTTL) DeletePrefix(prefix string) {
 	if c, hasInst := cs[prefix]; hasInst {
-		c.Clear()
+		c.clear()
 	}
 }
 
@@ -104,6 +176,6 @@ func (cs cacheLRUTTL) GetKeysForPrefix(prefix string) (keys []string) {
 
 func (cs cacheLRUTTL) Clear() {
 	for _, cInst := range cs {
-		cInst.Clear()
+		cInst.clear()
 	}
 }
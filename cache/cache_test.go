@@ -19,11 +19,14 @@ package cache
 
 import (
 	"math/rand"
+	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/ltcache"
 )
 
 func TestRemKey(t *testing.T) {
@@ -155,6 +158,46 @@ func TestCacheConcurrent(t *testing.T) {
 	wg.Wait()
 }
 
+type testInvPublisher struct {
+	invs []*Invalidation
+}
+
+func (pub *testInvPublisher) PublishInvalidation(inv *Invalidation) error {
+	pub.invs = append(pub.invs, inv)
+	return nil
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	pub := new(testInvPublisher)
+	SetInvalidationPublisher(pub)
+	defer SetInvalidationPublisher(nil)
+	Set("inv_t1", "test", true, "") // caching out of DataDB is not propagated
+	transID := BeginTransaction()
+	Set("inv_t2", "test", false, transID)
+	RemKey("inv_t1", false, transID)
+	CommitTransaction(transID)
+	RemPrefixKey("inv_", true, "")
+	eInvs := []*Invalidation{
+		&Invalidation{Origin: invOrigin, Keys: []string{"inv_t2", "inv_t1"}},
+		&Invalidation{Origin: invOrigin, Prefixes: []string{"inv_"}},
+	}
+	if !reflect.DeepEqual(eInvs, pub.invs) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eInvs), utils.ToJSON(pub.invs))
+	}
+	Set("inv_t3", "test", true, "")
+	ApplyInvalidation(&Invalidation{Origin: invOrigin, Keys: []string{"inv_t3"}})
+	if _, hasIt := Get("inv_t3"); !hasIt {
+		t.Error("Own invalidation applied")
+	}
+	ApplyInvalidation(&Invalidation{Origin: "peer", Keys: []string{"inv_t3"}})
+	if _, hasIt := Get("inv_t3"); hasIt {
+		t.Error("Peer invalidation not applied")
+	}
+	if len(pub.invs) != 2 {
+		t.Errorf("Peer invalidation published again: %s", utils.ToJSON(pub.invs))
+	}
+}
+
 // BenchmarkSet 	 5000000	       313 ns/op
 func BenchmarkSet(b *testing.B) {
 	cacheItems := [][]string{
@@ -190,3 +233,40 @@ func BenchmarkGet(b *testing.B) {
 		cache.Get(cacheItems[rand.Intn(max-min)+min][0])
 	}
 }
+
+func TestCachePartitionStats(t *testing.T) {
+	p := newCachePartition(2, 0, false)
+	p.set("1001", "value1")
+	p.set("1002", "value2")
+	p.get("1001")
+	p.get("1003")
+	p.set("1003", "value3")       // over the limit, one item evicted
+	p.remove("1003")              // explicit removal, not counted
+	p.onEvicted("1004", "value4") // as called out of the TTL cleanup
+	ps := p.stats()
+	eStats := &PartitionStats{Items: 1, Hits: 1, Misses: 1, Evictions: 1, Expiries: 1, Memory: ps.Memory}
+	if !reflect.DeepEqual(eStats, ps) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eStats), utils.ToJSON(ps))
+	}
+	if ps.Memory < int64(len("1001")+len("value1")) {
+		t.Errorf("Memory underestimated: %d", ps.Memory)
+	}
+	pUnlimited := newCachePartition(ltcache.UnlimitedCaching, ltcache.UnlimitedCaching, false)
+	for i := 0; i < 10*memorySampleSize; i++ {
+		pUnlimited.set(strconv.Itoa(i), "value")
+	}
+	_, hasFirst := pUnlimited.samples.sizes["0"]
+	_, hasLast := pUnlimited.samples.sizes[strconv.Itoa(10*memorySampleSize-1)]
+	nrSampled := len(pUnlimited.samples.sizes)
+	if hasFirst || !hasLast || nrSampled != memorySampleSize {
+		t.Errorf("Expecting the sample rotated, first: %v, last: %v, sampled: %d", hasFirst, hasLast, nrSampled)
+	}
+	if _, err := GetPartitionStats([]string{"not_a_partition"}); err == nil {
+		t.Error("Expecting error for unknown partition")
+	}
+	if pStats, err := GetPartitionStats([]string{utils.CacheDestinations}); err != nil {
+		t.Error(err)
+	} else if _, has := pStats[utils.CacheDestinations]; !has || len(pStats) != 1 {
+		t.Errorf("Received: %s", utils.ToJSON(pStats))
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/cgrates/cgrates/cdrc"
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/guardian"
 	"github.com/cgrates/cgrates/history"
 	"github.com/cgrates/cgrates/scheduler"
 	"github.com/cgrates/cgrates/servmanager"
@@ -131,7 +132,8 @@ func startCdrc(internalCdrSChan, internalRaterChan chan rpcclient.RpcClientConne
 	}
 }
 
-func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRaterChan, internalCDRSChan chan rpcclient.RpcClientConnection, server *utils.Server, exitChan chan bool) {
+func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRaterChan, internalCDRSChan chan rpcclient.RpcClientConnection,
+	server *utils.Server, metricsHandler *utils.MetricsHandler, exitChan chan bool) {
 	utils.Logger.Info("Starting CGRateS SMGeneric service.")
 	var ralsConns, cdrsConn *rpcclient.RpcClientPool
 	if len(cfg.SmGenericConfig.RALsConns) != 0 {
@@ -162,6 +164,9 @@ func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRate
 	if err = sm.Connect(); err != nil {
 		utils.Logger.Err(fmt.Sprintf("<SMGeneric> error: %s!", err))
 	}
+	if metricsHandler != nil {
+		metricsHandler.AddCollector(utils.SMG, sm.PrometheusMetrics)
+	}
 	// Pass internal connection via BiRPCClient
 	internalSMGChan <- sm
 	// Register RPC handler
@@ -179,6 +184,28 @@ func startSmGeneric(internalSMGChan chan *sessionmanager.SMGeneric, internalRate
 	}
 }
 
+// startCacheInvalidation propagates the cache invalidations to the engines sharing dataDb and applies theirs
+func startCacheInvalidation(dm *engine.DataManager, exitChan chan bool) error {
+	switch cfg.CacheInvalidationCfg().Bus {
+	case utils.MetaDataDB:
+		bus, canPublish := dm.DataDB().(engine.CacheInvalidationBus)
+		if !canPublish {
+			return fmt.Errorf("dataDb: %s cannot be used as invalidation bus", cfg.DataDbType)
+		}
+		cache.SetInvalidationPublisher(bus)
+		go engine.ListenCacheInvalidations(bus, exitChan)
+	case utils.MetaRPC: // peers apply the invalidations over ApierV1
+		peerConns, err := engine.NewRPCPool(rpcclient.POOL_BROADCAST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
+			cfg.CacheInvalidationCfg().PeerConns, nil, cfg.InternalTtl)
+		if err != nil {
+			return fmt.Errorf("could not connect to peers: %s", err.Error())
+		}
+		cache.SetInvalidationPublisher(engine.NewRPCInvalidationPublisher(peerConns))
+	}
+	utils.Logger.Info(fmt.Sprintf("<%s> started over %s bus", utils.CacheInvalidation, cfg.CacheInvalidationCfg().Bus))
+	return nil
+}
+
 func startSMAsterisk(internalSMGChan chan *sessionmanager.SMGeneric, exitChan chan bool) {
 	utils.Logger.Info("Starting CGRateS SMAsterisk service.")
 	/*
@@ -391,7 +418,7 @@ func startCDRS(internalCdrSChan chan rpcclient.RpcClientConnection,
 	cdrDb engine.CdrStorage, dm *engine.DataManager,
 	internalRaterChan, internalPubSubSChan, internalUserSChan, internalAliaseSChan,
 	internalCdrStatSChan, internalStatSChan chan rpcclient.RpcClientConnection,
-	server *utils.Server, exitChan chan bool) {
+	server *utils.Server, metricsHandler *utils.MetricsHandler, exitChan chan bool) {
 	utils.Logger.Info("Starting CGRateS CDRS service.")
 	var ralConn, pubSubConn, usersConn, aliasesConn, cdrstatsConn, statsConn *rpcclient.RpcClientPool
 	if len(cfg.CDRSRaterConns) != 0 { // Conn pool towards RAL
@@ -459,6 +486,9 @@ func startCDRS(internalCdrSChan chan rpcclient.RpcClientConnection,
 	server.RpcRegister(&v2.CdrsV2{CdrsV1: cdrSrv})
 	// Make the cdr server available for internal communication
 	server.RpcRegister(cdrServer) // register CdrServer for internal usage (TODO: refactor this)
+	if metricsHandler != nil {
+		metricsHandler.AddCollector(utils.CDRService, cdrServer.PrometheusMetrics)
+	}
 	internalCdrSChan <- cdrServer // Signal that cdrS is operational
 }
 
@@ -527,9 +557,9 @@ func startUsersServer(internalUserSChan chan rpcclient.RpcClientConnection, dm *
 	internalUserSChan <- userServer
 }
 
-func startResourceService(internalRsChan, internalStatSConn chan rpcclient.RpcClientConnection, cfg *config.CGRConfig,
+func startResourceService(internalRsChan, internalStatSConn, internalThresholdSConn chan rpcclient.RpcClientConnection, cfg *config.CGRConfig,
 	dm *engine.DataManager, server *utils.Server, exitChan chan bool) {
-	var statsConn *rpcclient.RpcClientPool
+	var statsConn, thdSConn *rpcclient.RpcClientPool
 	if len(cfg.ResourceSCfg().StatSConns) != 0 { // Stats connection init
 		statsConn, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
 			cfg.ResourceSCfg().StatSConns, internalStatSConn, cfg.InternalTtl)
@@ -539,7 +569,16 @@ func startResourceService(internalRsChan, internalStatSConn chan rpcclient.RpcCl
 			return
 		}
 	}
-	rS, err := engine.NewResourceService(dm, cfg.ResourceSCfg().StoreInterval, statsConn)
+	if len(cfg.ResourceSCfg().ThresholdSConns) != 0 { // Thresholds connection init
+		thdSConn, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
+			cfg.ResourceSCfg().ThresholdSConns, internalThresholdSConn, cfg.InternalTtl)
+		if err != nil {
+			utils.Logger.Crit(fmt.Sprintf("<ResourceS> Could not connect to ThresholdS: %s", err.Error()))
+			exitChan <- true
+			return
+		}
+	}
+	rS, err := engine.NewResourceService(dm, cfg.ResourceSCfg().StoreInterval, statsConn, thdSConn)
 	if err != nil {
 		utils.Logger.Crit(fmt.Sprintf("<ResourceS> Could not init, error: %s", err.Error()))
 		exitChan <- true
@@ -561,9 +600,20 @@ func startResourceService(internalRsChan, internalStatSConn chan rpcclient.RpcCl
 }
 
 // startStatService fires up the StatS
-func startStatService(internalStatSChan chan rpcclient.RpcClientConnection, cfg *config.CGRConfig,
-	dm *engine.DataManager, server *utils.Server, exitChan chan bool) {
-	sS, err := engine.NewStatService(dm, cfg.StatSCfg().StoreInterval)
+func startStatService(internalStatSChan, internalThresholdSConn chan rpcclient.RpcClientConnection, cfg *config.CGRConfig,
+	dm *engine.DataManager, cdrDb engine.CdrStorage, server *utils.Server, metricsHandler *utils.MetricsHandler, exitChan chan bool) {
+	var thdSConn *rpcclient.RpcClientPool
+	if len(cfg.StatSCfg().ThresholdSConns) != 0 { // Thresholds connection init
+		thdSConn, err = engine.NewRPCPool(rpcclient.POOL_FIRST, cfg.ConnectAttempts, cfg.Reconnects, cfg.ConnectTimeout, cfg.ReplyTimeout,
+			cfg.StatSCfg().ThresholdSConns, internalThresholdSConn, cfg.InternalTtl)
+		if err != nil {
+			utils.Logger.Crit(fmt.Sprintf("<StatS> Could not connect to ThresholdS: %s", err.Error()))
+			exitChan <- true
+			return
+		}
+	}
+	sS, err := engine.NewStatService(dm, cfg.StatSCfg().StoreInterval, thdSConn,
+		cdrDb, cfg.StatSCfg().HistoryInterval, cfg.StatSCfg().HistoryTTL)
 	if err != nil {
 		utils.Logger.Crit(fmt.Sprintf("<StatS> Could not init, error: %s", err.Error()))
 		exitChan <- true
@@ -578,6 +628,9 @@ func startStatService(internalStatSChan chan rpcclient.RpcClientConnection, cfg
 		exitChan <- true
 		return
 	}()
+	if metricsHandler != nil {
+		metricsHandler.AddCollector(utils.StatService, sS.PrometheusMetrics)
+	}
 	stsV1 := v1.NewStatSV1(sS)
 	server.RpcRegister(stsV1)
 	internalStatSChan <- stsV1
@@ -740,8 +793,23 @@ func main() {
 			fmt.Println(err.Error())
 			return
 		}
+		if cfg.LockingBackend == utils.MetaDataDB { // share the locks with the other engines using the same dataDb
+			lckBackend, canLock := dm2.DataDB().(guardian.LockBackend)
+			if !canLock {
+				utils.Logger.Crit(fmt.Sprintf("dataDb: %s cannot be used as locking backend, exiting!", cfg.DataDbType))
+				return
+			}
+			guardian.Guardian.SetLockBackend(lckBackend, cfg.LockingTimeout)
+		}
+		if cfg.CacheInvalidationCfg().Enabled {
+			if err := startCacheInvalidation(dm2, exitChan); err != nil {
+				utils.Logger.Crit(fmt.Sprintf("<%s> %s, exiting!", utils.CacheInvalidation, err.Error()))
+				return
+			}
+		}
 	}
-	if cfg.RALsEnabled || cfg.CDRSEnabled || cfg.SchedulerEnabled { // Only connect to storDb if necessary
+	if cfg.RALsEnabled || cfg.CDRSEnabled || cfg.SchedulerEnabled || cfg.CdrsRetentionCfg().Enabled ||
+		(cfg.StatSCfg().Enabled && cfg.StatSCfg().HistoryInterval > 0) { // Only connect to storDb if necessary
 		storDb, err := engine.ConfigureStorStorage(cfg.StorDBType, cfg.StorDBHost, cfg.StorDBPort,
 			cfg.StorDBName, cfg.StorDBUser, cfg.StorDBPass, cfg.DBDataEncoding, cfg.StorDBMaxOpenConns, cfg.StorDBMaxIdleConns, cfg.StorDBConnMaxLifetime, cfg.StorDBCDRSIndexes)
 		if err != nil { // Cannot configure logger database, show stopper
@@ -766,9 +834,26 @@ func main() {
 	engine.SetLcrSubjectPrefixMatching(cfg.LcrSubjectPrefixMatching)
 	stopHandled := false
 
+	var cdrsRetention *engine.CDRsRetentionService
+	if cdrDb != nil {
+		cdrsRetention = engine.NewCDRsRetentionService(cfg, cdrDb)
+		if cfg.CdrsRetentionCfg().Enabled {
+			utils.Logger.Info("Starting CDRs Retention service.")
+			go cdrsRetention.ListenAndServe(exitChan)
+		}
+	}
+
 	// Rpc/http server
 	server := new(utils.Server)
 
+	// Metrics exposed over HTTP, registered before serving so the RPC calls are recorded
+	var metricsHandler *utils.MetricsHandler
+	if cfg.MetricsCfg().Enabled {
+		metricsHandler = utils.NewMetricsHandler()
+		metricsHandler.AddCollector(utils.Cache, cache.PrometheusMetrics)
+		server.RegisterMetricsHandler(cfg.MetricsCfg().URL, metricsHandler)
+	}
+
 	// Async starts here, will follow cgrates.json start order
 
 	// Define internal connections via channels
@@ -787,12 +872,21 @@ func main() {
 
 	// Start ServiceManager
 	srvManager := servmanager.NewServiceManager(cfg, dm, exitChan, cacheDoneChan)
+	if metricsHandler != nil && cfg.SchedulerEnabled {
+		metricsHandler.AddCollector(utils.SchedulerService, func() ([]*utils.PrometheusMetric, error) {
+			sched := srvManager.GetScheduler()
+			if sched == nil { // scheduler stopped
+				return nil, nil
+			}
+			return sched.PrometheusMetrics()
+		})
+	}
 
 	// Start rater service
 	if cfg.RALsEnabled {
 		go startRater(internalRaterChan, cacheDoneChan, internalCdrStatSChan, internalStatSChan,
 			internalHistorySChan, internalPubSubSChan, internalUserSChan, internalAliaseSChan,
-			srvManager, server, dm, loadDb, cdrDb, &stopHandled, exitChan)
+			srvManager, server, dm, loadDb, cdrDb, cdrsRetention, &stopHandled, exitChan)
 	}
 
 	// Start Scheduler
@@ -804,7 +898,7 @@ func main() {
 	if cfg.CDRSEnabled {
 		go startCDRS(internalCdrSChan, cdrDb, dm,
 			internalRaterChan, internalPubSubSChan, internalUserSChan, internalAliaseSChan,
-			internalCdrStatSChan, internalStatSChan, server, exitChan)
+			internalCdrStatSChan, internalStatSChan, server, metricsHandler, exitChan)
 	}
 
 	// Start CDR Stats server
@@ -817,7 +911,7 @@ func main() {
 
 	// Start SM-Generic
 	if cfg.SmGenericConfig.Enabled {
-		go startSmGeneric(internalSMGChan, internalRaterChan, internalCdrSChan, server, exitChan)
+		go startSmGeneric(internalSMGChan, internalRaterChan, internalCdrSChan, server, metricsHandler, exitChan)
 	}
 	// Start SM-FreeSWITCH
 	if cfg.SmFsConfig.Enabled {
@@ -877,11 +971,11 @@ func main() {
 	// Start RL service
 	if cfg.ResourceSCfg().Enabled {
 		go startResourceService(internalRsChan,
-			internalStatSChan, cfg, dm, server, exitChan)
+			internalStatSChan, internalThresholdSChan, cfg, dm, server, exitChan)
 	}
 
 	if cfg.StatSCfg().Enabled {
-		go startStatService(internalStatSChan, cfg, dm, server, exitChan)
+		go startStatService(internalStatSChan, internalThresholdSChan, cfg, dm, cdrDb, server, metricsHandler, exitChan)
 	}
 
 	if cfg.ThresholdSCfg().Enabled {
@@ -35,7 +35,8 @@ func startRater(internalRaterChan chan rpcclient.RpcClientConnection, cacheDoneC
 	internalCdrStatSChan, internalStatSChan, internalHistorySChan,
 	internalPubSubSChan, internalUserSChan, internalAliaseSChan chan rpcclient.RpcClientConnection,
 	serviceManager *servmanager.ServiceManager, server *utils.Server,
-	dm *engine.DataManager, loadDb engine.LoadStorage, cdrDb engine.CdrStorage, stopHandled *bool, exitChan chan bool) {
+	dm *engine.DataManager, loadDb engine.LoadStorage, cdrDb engine.CdrStorage,
+	cdrsRetention *engine.CDRsRetentionService, stopHandled *bool, exitChan chan bool) {
 	var waitTasks []chan struct{}
 
 	//Cache load
@@ -195,7 +196,7 @@ func startRater(internalRaterChan chan rpcclient.RpcClientConnection, cacheDoneC
 	}
 	responder := &engine.Responder{ExitChan: exitChan}
 	responder.SetTimeToLive(cfg.ResponseCacheTTL, nil)
-	apierRpcV1 := &v1.ApierV1{StorDb: loadDb, DataManager: dm, CdrDb: cdrDb,
+	apierRpcV1 := &v1.ApierV1{StorDb: loadDb, DataManager: dm, CdrDb: cdrDb, CDRsRetention: cdrsRetention,
 		Config: cfg, Responder: responder, ServManager: serviceManager, HTTPPoster: utils.NewHTTPPoster(cfg.HttpSkipTlsVerify, cfg.ReplyTimeout)}
 	if cdrStats != nil { // ToDo: Fix here properly the init of stats
 		responder.Stats = cdrStats
@@ -210,6 +211,8 @@ func startRater(internalRaterChan chan rpcclient.RpcClientConnection, cacheDoneC
 	server.RpcRegister(responder)
 	server.RpcRegister(apierRpcV1)
 	server.RpcRegister(apierRpcV2)
+	cacheSv1 := v1.NewCacheSv1()
+	server.RpcRegister(cacheSv1)
 
 	utils.RegisterRpcParams("", &engine.Stats{})
 	utils.RegisterRpcParams("", &v1.CDRStatsV1{})
@@ -224,6 +227,7 @@ func startRater(internalRaterChan chan rpcclient.RpcClientConnection, cacheDoneC
 	utils.RegisterRpcParams("", responder)
 	utils.RegisterRpcParams("", apierRpcV1)
 	utils.RegisterRpcParams("", apierRpcV2)
+	utils.RegisterRpcParams("", cacheSv1)
 	utils.GetRpcParams("")
 	internalRaterChan <- responder // Rater done
 }
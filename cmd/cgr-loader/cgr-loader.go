@@ -19,10 +19,13 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/cgrates/cgrates/config"
@@ -39,7 +42,7 @@ var (
 	datadb_user = flag.String("datadb_user", config.CgrConfig().DataDbUser, "The DataDb user to sign in as.")
 	datadb_pass = flag.String("datadb_passwd", config.CgrConfig().DataDbPass, "The DataDb user's password.")
 
-	stor_db_type = flag.String("stordb_type", config.CgrConfig().StorDBType, "The type of the storDb database <mysql>")
+	stor_db_type = flag.String("stordb_type", config.CgrConfig().StorDBType, "The type of the storDb database <mysql|postgres|sqlite>")
 	stor_db_host = flag.String("stordb_host", config.CgrConfig().StorDBHost, "The storDb host to connect to.")
 	stor_db_port = flag.String("stordb_port", config.CgrConfig().StorDBPort, "The storDb port to bind to.")
 	stor_db_name = flag.String("stordb_name", config.CgrConfig().StorDBName, "The name/number of the storDb to connect to.")
@@ -67,6 +70,12 @@ var (
 	loadHistorySize = flag.Int("load_history_size", config.CgrConfig().LoadHistorySize, "Limit the number of records in the load history")
 	timezone        = flag.String("timezone", config.CgrConfig().DefaultTimezone, `Timezone for timestamps where not specified <""|UTC|Local|$IANA_TZ_DB>`)
 	disable_reverse = flag.Bool("disable_reverse_mappings", false, "Will disable reverse mappings rebuilding")
+	simulateRating  = flag.Bool("simulate_rating", false, "Re-rate the CDRs in storDb with the tariff plan from storDb, without changing them or the accounts")
+	cdrsFilter      = flag.String("cdrs_filter", "{}", "JSON encoded filter of the CDRs re-rated with -simulate_rating")
+	rateDeck        = flag.String("ratedeck", "", "Rate deck file imported into storDb, as tariff plan with the id from -tpid")
+	rateDeckID      = flag.String("ratedeck_id", "", "Identifies the rate deck inside the ids of the tariff plan data created")
+	rateDeckTpl     = flag.String("ratedeck_template", `{"HeaderLines":1,"PrefixIndex":0,"RateIndex":1}`, "JSON encoded template mapping the columns of the rate deck")
+	rateDeckRpf     = flag.String("ratedeck_profile", "", "Rating profile activating the rate deck versions <$direction:$tenant:$category:$subject>, empty to not write it")
 )
 
 func main() {
@@ -81,6 +90,14 @@ func main() {
 	var rater, cdrstats, users rpcclient.RpcClientConnection
 	var loader engine.LoadReader
 
+	if *simulateRating {
+		runRatingSimulation()
+		return
+	}
+	if *rateDeck != "" {
+		runRateDeckImport()
+		return
+	}
 	// Init necessary db connections, only if not already
 	if !*dryRun { // make sure we do not need db connections on dry run, also not importing into any stordb
 		if *fromStorDb {
@@ -153,6 +170,8 @@ func main() {
 			path.Join(*dataPath, utils.StatsCsv),
 			path.Join(*dataPath, utils.ThresholdsCsv),
 			path.Join(*dataPath, utils.FiltersCsv),
+			path.Join(*dataPath, utils.ExchangeRatesCsv),
+			path.Join(*dataPath, utils.TaxRatesCsv),
 		)
 	}
 
@@ -323,3 +342,75 @@ func main() {
 		}
 	}
 }
+
+// runRatingSimulation prints the costs of the CDRs in storDb re-rated with the tariff plan
+func runRatingSimulation() {
+	if *tpid == "" {
+		log.Fatal("TPid required, please define it via *-tpid* command argument.")
+	}
+	var rpcFltr utils.RPCCDRsFilter
+	if err := json.Unmarshal([]byte(*cdrsFilter), &rpcFltr); err != nil {
+		log.Fatalf("Could not parse the CDRs filter: %v", err)
+	}
+	cdrsFltr, err := rpcFltr.AsCDRsFilter(*timezone)
+	if err != nil {
+		log.Fatalf("Could not parse the CDRs filter: %v", err)
+	}
+	db, err := engine.ConfigureStorStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
+		config.CgrConfig().StorDBMaxOpenConns, config.CgrConfig().StorDBMaxIdleConns, config.CgrConfig().StorDBConnMaxLifetime, config.CgrConfig().StorDBCDRSIndexes)
+	if err != nil {
+		log.Fatalf("Could not open database connection: %v", err)
+	}
+	defer db.Close()
+	storDb, canCast := db.(engine.StorDB)
+	if !canCast {
+		log.Fatalf("StorDB of type %s cannot be used for rating simulations", *stor_db_type)
+	}
+	rs, err := engine.SimulateRating(storDb, storDb, *tpid, *timezone, cdrsFltr)
+	if err != nil {
+		log.Fatalf("Could not simulate rating: %v", err)
+	}
+	fmt.Println(utils.ToIJSON(rs))
+}
+
+// runRateDeckImport imports the rate deck into storDb, printing the rate changes it brings
+func runRateDeckImport() {
+	if *tpid == "" || *rateDeckID == "" {
+		log.Fatal("TPid and rate deck id required, please define them via *-tpid* and *-ratedeck_id* command arguments.")
+	}
+	rdi := &engine.RateDeckImporter{TPid: *tpid, DeckID: *rateDeckID, Template: new(engine.RateDeckTemplate),
+		Timezone: *timezone, Verbose: *verbose}
+	if err := json.Unmarshal([]byte(*rateDeckTpl), rdi.Template); err != nil {
+		log.Fatalf("Could not parse the rate deck template: %v", err)
+	}
+	if *rateDeckRpf != "" {
+		rpfFlds := strings.Split(*rateDeckRpf, utils.CONCATENATED_KEY_SEP)
+		if len(rpfFlds) != 4 {
+			log.Fatalf("Invalid rate deck profile: %s", *rateDeckRpf)
+		}
+		rdi.Direction, rdi.Tenant, rdi.Category, rdi.Subject = rpfFlds[0], rpfFlds[1], rpfFlds[2], rpfFlds[3]
+		dm, err := engine.ConfigureDataStorage(*datadb_type, *datadb_host, *datadb_port, *datadb_name, *datadb_user, *datadb_pass, *dbdata_encoding, config.CgrConfig().CacheConfig, *loadHistorySize)
+		if err != nil {
+			log.Fatalf("Could not open database connection: %v", err)
+		}
+		defer dm.DataDB().Close()
+		rdi.DataDB = dm.DataDB()
+	}
+	storDb, err := engine.ConfigureLoadStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
+		config.CgrConfig().StorDBMaxOpenConns, config.CgrConfig().StorDBMaxIdleConns, config.CgrConfig().StorDBConnMaxLifetime, config.CgrConfig().StorDBCDRSIndexes)
+	if err != nil {
+		log.Fatalf("Could not open database connection: %v", err)
+	}
+	defer storDb.Close()
+	rdi.StorDb = storDb
+	fDeck, err := os.Open(*rateDeck)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fDeck.Close()
+	rpt, err := rdi.Run(fDeck)
+	if err != nil {
+		log.Fatalf("Could not import rate deck: %v", err)
+	}
+	fmt.Println(utils.ToIJSON(rpt))
+}
@@ -0,0 +1,46 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package config
+
+// CacheInvalidationCfg configures the propagation of cache invalidations between engines sharing DataDB
+type CacheInvalidationCfg struct {
+	Enabled   bool
+	Bus       string          // transport of the invalidations: <*datadb|*rpc>
+	PeerConns []*HaPoolConfig // engines notified over *rpc bus
+}
+
+func (ci *CacheInvalidationCfg) loadFromJsonCfg(jsnCfg *CacheInvalidationJsonCfg) (err error) {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Enabled != nil {
+		ci.Enabled = *jsnCfg.Enabled
+	}
+	if jsnCfg.Bus != nil {
+		ci.Bus = *jsnCfg.Bus
+	}
+	if jsnCfg.Peer_conns != nil {
+		ci.PeerConns = make([]*HaPoolConfig, len(*jsnCfg.Peer_conns))
+		for idx, jsnHaCfg := range *jsnCfg.Peer_conns {
+			ci.PeerConns[idx] = NewDfltHaPoolConfig()
+			ci.PeerConns[idx].loadFromJsonCfg(jsnHaCfg)
+		}
+	}
+	return nil
+}
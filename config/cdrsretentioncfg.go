@@ -0,0 +1,103 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package config
+
+import (
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// CdrsRetentionRule decides for how long the CDRs of one tenant and ToR are kept in StorDB
+type CdrsRetentionRule struct {
+	Tenant        string // *any for all tenants
+	ToR           string // *any for all ToRs
+	RetentionDays int    // CDRs with setup time older than this are removed
+	Archive       string // where CDRs are moved before removal: <*none|*table|*file>
+	CdreProfile   string // CDRE template used for *file archives
+	ArchivePath   string // directory of the *file archives
+	Compress      bool   // gzip the *file archives
+}
+
+func (rr *CdrsRetentionRule) loadFromJsonCfg(jsnCfg *CdrsRetentionRuleJsonCfg) (err error) {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Tenant != nil {
+		rr.Tenant = *jsnCfg.Tenant
+	}
+	if jsnCfg.Tor != nil {
+		rr.ToR = *jsnCfg.Tor
+	}
+	if jsnCfg.Retention_days != nil {
+		rr.RetentionDays = *jsnCfg.Retention_days
+	}
+	if jsnCfg.Archive != nil {
+		rr.Archive = *jsnCfg.Archive
+	}
+	if jsnCfg.Cdre_profile != nil {
+		rr.CdreProfile = *jsnCfg.Cdre_profile
+	}
+	if jsnCfg.Archive_path != nil {
+		rr.ArchivePath = *jsnCfg.Archive_path
+	}
+	if jsnCfg.Compress != nil {
+		rr.Compress = *jsnCfg.Compress
+	}
+	return nil
+}
+
+// CdrsRetentionCfg configures the removal of old CDRs out of StorDB
+type CdrsRetentionCfg struct {
+	Enabled           bool
+	RunInterval       time.Duration
+	Rules             []*CdrsRetentionRule
+	PartitionInterval string // partition the cdrs table on time: <""|*daily|*monthly>
+	PartitionsPremake int    // number of partitions created in advance
+}
+
+func (cr *CdrsRetentionCfg) loadFromJsonCfg(jsnCfg *CdrsRetentionJsonCfg) (err error) {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Enabled != nil {
+		cr.Enabled = *jsnCfg.Enabled
+	}
+	if jsnCfg.Run_interval != nil {
+		if cr.RunInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Run_interval); err != nil {
+			return
+		}
+	}
+	if jsnCfg.Rules != nil {
+		cr.Rules = make([]*CdrsRetentionRule, len(*jsnCfg.Rules))
+		for i, jsnRule := range *jsnCfg.Rules {
+			cr.Rules[i] = &CdrsRetentionRule{Tenant: utils.ANY, ToR: utils.ANY, Archive: utils.META_NONE}
+			if err = cr.Rules[i].loadFromJsonCfg(jsnRule); err != nil {
+				return
+			}
+		}
+	}
+	if jsnCfg.Partition_interval != nil {
+		cr.PartitionInterval = *jsnCfg.Partition_interval
+	}
+	if jsnCfg.Partitions_premake != nil {
+		cr.PartitionsPremake = *jsnCfg.Partitions_premake
+	}
+	return nil
+}
@@ -189,6 +189,9 @@ type CGRConfig struct {
 	DataDbUser               string // The user to sign in as.
 	DataDbPass               string // The user's password.
 	LoadHistorySize          int    // Maximum number of records to archive in load history
+	DataDbPath               string // Directory holding the *internal_persistent files
+	DataDbSnapshotInterval   time.Duration
+	DataDbSyncInterval       time.Duration // 0 to sync the *internal_persistent changes log after every change, -1 to leave it to the OS
 	StorDBType               string // Should reflect the database type used to store logs
 	StorDBHost               string // The host to connect to. Values that start with / are for UNIX domain sockets.
 	StorDBPort               string // Th e port to bind to.
@@ -199,6 +202,7 @@ type CGRConfig struct {
 	StorDBMaxIdleConns       int    // Maximum idle connections to keep opened
 	StorDBConnMaxLifetime    int
 	StorDBCDRSIndexes        []string
+	StorDBPath               string // File holding the *internal stor_db dump
 	DBDataEncoding           string // The encoding used to store object data in strings: <msgpack|json>
 	CacheConfig              CacheConfig
 	RPCJSONListen            string            // RPC JSON listening address
@@ -225,6 +229,7 @@ type CGRConfig struct {
 	FailedPostsDir           string          // Directory path where we store failed http requests
 	MaxCallDuration          time.Duration   // The maximum call duration (used by responder when querying DerivedCharging) // ToDo: export it in configuration file
 	LockingTimeout           time.Duration   // locking mechanism timeout to avoid deadlocks
+	LockingBackend           string          // where the locks are shared: <*internal|*datadb>
 	Logger                   string          // dictates the way logs are displayed/stored
 	LogLevel                 int             // system wide log level, nothing higher than this will be logged
 	RALsEnabled              bool            // start standalone server (no balancer)
@@ -249,6 +254,7 @@ type CGRConfig struct {
 	CDRSCDRStatSConns        []*HaPoolConfig // address where to reach the cdrstats service. Empty to disable cdrstats gathering  <""|internal|x.y.z.y:1234>
 	CDRSStatSConns           []*HaPoolConfig
 	CDRSOnlineCDRExports     []string      // list of CDRE templates to use for real-time CDR exports
+	CDRSTaxProvider          string        // taxes calculated for the rated CDRs: <""|*local|*sure_tax>
 	CDRStatsEnabled          bool          // Enable CDR Stats service
 	CDRStatsSaveInterval     time.Duration // Save interval duration
 	CdreProfiles             map[string]*CdreConfig
@@ -270,6 +276,9 @@ type CGRConfig struct {
 	resourceSCfg             *ResourceSConfig         // Configuration for resource limiter
 	statsCfg                 *StatSCfg                // Configuration for StatS
 	thresholdSCfg            *ThresholdSCfg           // configuration for ThresholdS
+	metricsCfg               *MetricsCfg              // configuration for the metrics HTTP handler
+	cdrsRetentionCfg         *CdrsRetentionCfg        // configuration for the removal of old CDRs
+	cacheInvalidationCfg     *CacheInvalidationCfg    // configuration for propagating cache invalidations
 	MailerServer             string                   // The server to use when sending emails out
 	MailerAuthUser           string                   // Authenticate to email server using this user
 	MailerAuthPass           string                   // Authenticate to email server with this password
@@ -353,6 +362,32 @@ func (self *CGRConfig) checkConfigSanity() error {
 				return fmt.Errorf("<CDRS> Cannot find CDR export template with ID: <%s>", cdrePrfl)
 			}
 		}
+		if !utils.IsSliceMember([]string{"", utils.MetaLocal, utils.META_SURETAX}, self.CDRSTaxProvider) {
+			return fmt.Errorf("<CDRS> Unsupported tax provider: <%s>", self.CDRSTaxProvider)
+		}
+	}
+	// CDRs retention sanity checks
+	if self.cdrsRetentionCfg != nil && self.cdrsRetentionCfg.Enabled {
+		for _, rule := range self.cdrsRetentionCfg.Rules {
+			switch rule.Archive {
+			case utils.META_NONE, utils.MetaTable:
+			case utils.MetaFile:
+				if _, hasIt := self.CdreProfiles[rule.CdreProfile]; !hasIt {
+					return fmt.Errorf("<CDRsRetention> Cannot find CDR export template with ID: <%s>", rule.CdreProfile)
+				}
+				if rule.ArchivePath == "" {
+					return errors.New("<CDRsRetention> archive_path is mandatory for *file archives")
+				}
+			default:
+				return fmt.Errorf("<CDRsRetention> Unsupported archive: <%s>", rule.Archive)
+			}
+			if rule.RetentionDays <= 0 {
+				return fmt.Errorf("<CDRsRetention> retention_days should be positive, received: %d", rule.RetentionDays)
+			}
+		}
+		if !utils.IsSliceMember([]string{"", utils.MetaDaily, utils.MetaMonthly}, self.cdrsRetentionCfg.PartitionInterval) {
+			return fmt.Errorf("<CDRsRetention> Unsupported partition_interval: <%s>", self.cdrsRetentionCfg.PartitionInterval)
+		}
 	}
 	// CDRC sanity checks
 	for _, cdrcCfgs := range self.CdrcProfiles {
@@ -511,6 +546,47 @@ func (self *CGRConfig) checkConfigSanity() error {
 				return errors.New("StatS not enabled but requested by ResourceLimiter component.")
 			}
 		}
+		for _, connCfg := range self.resourceSCfg.ThresholdSConns {
+			if connCfg.Address == utils.MetaInternal && !self.thresholdSCfg.Enabled {
+				return errors.New("ThresholdS not enabled but requested by ResourceLimiter component.")
+			}
+		}
+	}
+	// StatS checks
+	if self.statsCfg != nil && self.statsCfg.Enabled {
+		for _, connCfg := range self.statsCfg.ThresholdSConns {
+			if connCfg.Address == utils.MetaInternal && !self.thresholdSCfg.Enabled {
+				return errors.New("ThresholdS not enabled but requested by StatS component.")
+			}
+		}
+	}
+	// Cache invalidation checks
+	if self.cacheInvalidationCfg != nil && self.cacheInvalidationCfg.Enabled {
+		switch self.cacheInvalidationCfg.Bus {
+		case utils.MetaDataDB:
+			if self.DataDbType != utils.REDIS {
+				return fmt.Errorf("<CacheInvalidation> bus %s not supported by data_db type: %s", utils.MetaDataDB, self.DataDbType)
+			}
+		case utils.MetaRPC:
+			if len(self.cacheInvalidationCfg.PeerConns) == 0 {
+				return errors.New("<CacheInvalidation> peer_conns are mandatory for *rpc bus")
+			}
+		default:
+			return fmt.Errorf("<CacheInvalidation> unsupported bus: %s", self.cacheInvalidationCfg.Bus)
+		}
+	}
+	// Locking checks
+	switch self.LockingBackend {
+	case utils.MetaInternal:
+	case utils.MetaDataDB:
+		if self.DataDbType != utils.REDIS && self.DataDbType != utils.MONGO {
+			return fmt.Errorf("locking backend %s not supported by data_db type: %s", utils.MetaDataDB, self.DataDbType)
+		}
+		if self.LockingTimeout <= 0 {
+			return fmt.Errorf("locking backend %s requires a locking_timeout", utils.MetaDataDB)
+		}
+	default:
+		return fmt.Errorf("unsupported locking backend: %s", self.LockingBackend)
 	}
 	return nil
 }
@@ -650,6 +726,21 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		return err
 	}
 
+	jsnMetricsCfg, err := jsnCfg.MetricsJsonCfg()
+	if err != nil {
+		return err
+	}
+
+	jsnCdrsRetentionCfg, err := jsnCfg.CdrsRetentionJsonCfg()
+	if err != nil {
+		return err
+	}
+
+	jsnCacheInvalidationCfg, err := jsnCfg.CacheInvalidationJsonCfg()
+	if err != nil {
+		return err
+	}
+
 	jsnMailerCfg, err := jsnCfg.MailerJsonCfg()
 	if err != nil {
 		return err
@@ -682,6 +773,19 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnDataDbCfg.Load_history_size != nil {
 			self.LoadHistorySize = *jsnDataDbCfg.Load_history_size
 		}
+		if jsnDataDbCfg.Db_path != nil {
+			self.DataDbPath = *jsnDataDbCfg.Db_path
+		}
+		if jsnDataDbCfg.Snapshot_interval != nil {
+			if self.DataDbSnapshotInterval, err = utils.ParseDurationWithSecs(*jsnDataDbCfg.Snapshot_interval); err != nil {
+				return err
+			}
+		}
+		if jsnDataDbCfg.Sync_interval != nil {
+			if self.DataDbSyncInterval, err = utils.ParseDurationWithSecs(*jsnDataDbCfg.Sync_interval); err != nil {
+				return err
+			}
+		}
 	}
 
 	if jsnStorDbCfg != nil {
@@ -715,6 +819,9 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnStorDbCfg.Cdrs_indexes != nil {
 			self.StorDBCDRSIndexes = *jsnStorDbCfg.Cdrs_indexes
 		}
+		if jsnStorDbCfg.Db_path != nil {
+			self.StorDBPath = *jsnStorDbCfg.Db_path
+		}
 	}
 
 	if jsnGeneralCfg != nil {
@@ -789,6 +896,9 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 				return err
 			}
 		}
+		if jsnGeneralCfg.Locking_backend != nil {
+			self.LockingBackend = *jsnGeneralCfg.Locking_backend
+		}
 	}
 
 	if jsnCacheCfg != nil {
@@ -945,6 +1055,9 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 				self.CDRSOnlineCDRExports = append(self.CDRSOnlineCDRExports, expProfile)
 			}
 		}
+		if jsnCdrsCfg.Tax_provider != nil {
+			self.CDRSTaxProvider = *jsnCdrsCfg.Tax_provider
+		}
 	}
 
 	if jsnCdrstatsCfg != nil {
@@ -1113,6 +1226,33 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		}
 	}
 
+	if jsnMetricsCfg != nil {
+		if self.metricsCfg == nil {
+			self.metricsCfg = new(MetricsCfg)
+		}
+		if err := self.metricsCfg.loadFromJsonCfg(jsnMetricsCfg); err != nil {
+			return err
+		}
+	}
+
+	if jsnCdrsRetentionCfg != nil {
+		if self.cdrsRetentionCfg == nil {
+			self.cdrsRetentionCfg = new(CdrsRetentionCfg)
+		}
+		if err = self.cdrsRetentionCfg.loadFromJsonCfg(jsnCdrsRetentionCfg); err != nil {
+			return err
+		}
+	}
+
+	if jsnCacheInvalidationCfg != nil {
+		if self.cacheInvalidationCfg == nil {
+			self.cacheInvalidationCfg = new(CacheInvalidationCfg)
+		}
+		if err = self.cacheInvalidationCfg.loadFromJsonCfg(jsnCacheInvalidationCfg); err != nil {
+			return err
+		}
+	}
+
 	if jsnUserServCfg != nil {
 		if jsnUserServCfg.Enabled != nil {
 			self.UserServerEnabled = *jsnUserServCfg.Enabled
@@ -1180,6 +1320,18 @@ func (cfg *CGRConfig) ThresholdSCfg() *ThresholdSCfg {
 	return cfg.thresholdSCfg
 }
 
+func (cfg *CGRConfig) MetricsCfg() *MetricsCfg {
+	return cfg.metricsCfg
+}
+
+func (cfg *CGRConfig) CdrsRetentionCfg() *CdrsRetentionCfg {
+	return cfg.cdrsRetentionCfg
+}
+
+func (cfg *CGRConfig) CacheInvalidationCfg() *CacheInvalidationCfg {
+	return cfg.cacheInvalidationCfg
+}
+
 // ToDo: fix locking here
 func (self *CGRConfig) SMAsteriskCfg() *SMAsteriskCfg {
 	cfgChan := <-self.ConfigReloads[utils.SMAsterisk] // Lock config for read or reloads
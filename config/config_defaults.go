@@ -48,6 +48,7 @@ const CGRATES_CFG_JSON = `
 	"response_cache_ttl": "0s",								// the life span of a cached response
 	"internal_ttl": "2m",									// maximum duration to wait for internal connections before giving up
 	"locking_timeout": "5s",								// timeout internal locks to avoid deadlocks
+	"locking_backend": "*internal",							// where locks are shared: <*internal|*datadb>, *datadb serialising engines sharing DataDB
 },
 
 
@@ -75,6 +76,13 @@ const CGRATES_CFG_JSON = `
 },
 
 
+"cache_invalidation": {
+	"enabled": false,						// propagate the cache invalidations to the engines sharing data_db: <true|false>
+	"bus": "*datadb",						// transport of the invalidations: <*datadb|*rpc>, *datadb using redis pub/sub
+	"peer_conns": [],						// engines notified over *rpc bus: [{"address": "x.y.z.y:2012", "transport": "*json"}]
+},
+
+
 "listen": {
 	"rpc_json": "127.0.0.1:2012",			// RPC JSON listening address
 	"rpc_gob": "127.0.0.1:2013",			// RPC GOB listening address
@@ -90,28 +98,38 @@ const CGRATES_CFG_JSON = `
 },
 
 
+"metrics": {
+	"enabled": false,						// expose the engine metrics for Prometheus scraping over HTTP: <true|false>
+	"url": "/metrics",						// metrics relative URL
+},
+
+
 "data_db": {								// database used to store runtime data (eg: accounts, cdr stats)
-	"db_type": "redis",						// data_db type: <redis|mongo>
+	"db_type": "redis",						// data_db type: <redis|mongo|*internal_persistent>
 	"db_host": "127.0.0.1",					// data_db host address
 	"db_port": 6379, 						// data_db port to reach the database
 	"db_name": "10", 						// data_db database name to connect to
 	"db_user": "cgrates", 					// username to use when connecting to data_db
 	"db_password": "", 						// password to use when connecting to data_db
 	"load_history_size": 10,				// Number of records in the load history
+	"db_path": "/var/lib/cgrates/internal_db",	// directory holding the files of the *internal_persistent data_db
+	"snapshot_interval": "1h",				// compact the *internal_persistent changes log into a snapshot at this interval, 0 to disable
+	"sync_interval": "0s",					// sync the *internal_persistent changes log to disk: <0: after every change|$dur: at this interval|-1: left to the OS>
 },
 
 
 "stor_db": {								// database used to store offline tariff plans and CDRs
-	"db_type": "mysql",						// stor database type to use: <mongo|mysql|postgres>
+	"db_type": "mysql",						// stor database type to use: <*internal|mongo|mysql|postgres|sqlite>
 	"db_host": "127.0.0.1",					// the host to connect to
 	"db_port": 3306,						// the port to reach the stordb
-	"db_name": "cgrates",					// stor database name
+	"db_name": "cgrates",					// stor database name, path to the database file for sqlite
 	"db_user": "cgrates",					// username to use when connecting to stordb
 	"db_password": "",						// password to use when connecting to stordb
 	"max_open_conns": 100,					// maximum database connections opened, not applying for mongo
 	"max_idle_conns": 10,					// maximum database connections idle, not applying for mongo
 	"conn_max_lifetime": 0, 				// maximum amount of time in seconds a connection may be reused (0 for unlimited), not applying for mongo
 	"cdrs_indexes": [],						// indexes on cdrs table to speed up queries, used only in case of mongo
+	"db_path": "",							// file where the *internal stor_db is saved on shutdown and loaded at start, empty to keep it only in memory
 },
 
 
@@ -148,6 +166,26 @@ const CGRATES_CFG_JSON = `
 	"cdrstats_conns": [],					// address where to reach the cdrstats service, empty to disable cdrstats functionality: <""|*internal|x.y.z.y:1234>
 	"stats_conns": [],						// address where to reach the stat service, empty to disable stats functionality: <""|*internal|x.y.z.y:1234>
 	"online_cdr_exports":[],				// list of CDRE profiles to use for real-time CDR exports
+	"tax_provider": "",						// taxes calculated for the rated CDRs, empty to disable: <""|*local|*sure_tax>
+},
+
+
+"cdrs_retention": {
+	"enabled": false,						// remove the old CDRs out of stor_db periodically: <true|false>
+	"run_interval": "24h",					// interval between two retention runs
+	"rules": [								// rules apply independently, on overlap the shortest retention wins; CDRs not matching any rule are kept forever
+		// {
+		// 	"tenant": "*any",				// tenant of the CDRs: <*any|$tenant>
+		// 	"tor": "*any",					// type of record of the CDRs: <*any|*voice|*data|*sms...>
+		// 	"retention_days": 365,			// CDRs with setup_time older than this number of days are removed
+		// 	"archive": "*none",				// archive the CDRs before removal: <*none|*table|*file>
+		// 	"cdre_profile": "*default",		// CDRE profile used to build *file archives
+		// 	"archive_path": "/var/spool/cgrates/cdre/archive",	// directory of the *file archives
+		// 	"compress": true,				// gzip the *file archives
+		// },
+	],
+	"partition_interval": "",				// partition the cdrs table on setup_time (mysql and postgres only): <""|*daily|*monthly>
+	"partitions_premake": 2,				// number of future partitions created in advance
 },
 
 
@@ -414,12 +452,16 @@ const CGRATES_CFG_JSON = `
 	"enabled": false,				// starts ResourceLimiter service: <true|false>.
 	"store_interval": "",			// dump cache regularly to dataDB, 0 - dump at start/shutdown: <""|$dur>
 	"stats_conns": [],				// address where to reach the stats service, empty to disable stats functionality: <""|*internal|x.y.z.y:1234>
+	"thresholds_conns": [],			// address where to reach the thresholds service, empty to disable thresholds functionality: <""|*internal|x.y.z.y:1234>
 },
 
 
 "stats": {
 	"enabled": false,				// starts Stat service: <true|false>.
 	"store_interval": "",			// dump cache regularly to dataDB, 0 - dump at start/shutdown: <""|$dur>
+	"thresholds_conns": [],			// address where to reach the thresholds service, empty to disable thresholds functionality: <""|*internal|x.y.z.y:1234>
+	"history_interval": "",			// snapshot the metrics of the queues regularly into storDB, empty to disable metrics history: <""|$dur>
+	"history_ttl": "",				// remove the metrics history older than this, empty to keep it forever: <""|$dur>
 },
 
 
@@ -30,6 +30,7 @@ import (
 const (
 	GENERAL_JSN     = "general"
 	CACHE_JSN       = "cache"
+	CACHE_INV_JSN   = "cache_invalidation"
 	LISTEN_JSN      = "listen"
 	HTTP_JSN        = "http"
 	DATADB_JSN      = "data_db"
@@ -37,6 +38,7 @@ const (
 	RALS_JSN        = "rals"
 	SCHEDULER_JSN   = "scheduler"
 	CDRS_JSN        = "cdrs"
+	CDRS_RET_JSN    = "cdrs_retention"
 	MEDIATOR_JSN    = "mediator"
 	CDRSTATS_JSN    = "cdrstats"
 	CDRE_JSN        = "cdre"
@@ -59,6 +61,7 @@ const (
 	RESOURCES_JSON  = "resources"
 	STATS_JSON      = "stats"
 	THRESHOLDS_JSON = "thresholds"
+	METRICS_JSON    = "metrics"
 	MAILER_JSN      = "mailer"
 	SURETAX_JSON    = "suretax"
 )
@@ -110,6 +113,18 @@ func (self CgrJsonCfg) CacheJsonCfg() (*CacheJsonCfg, error) {
 	return cfg, nil
 }
 
+func (self CgrJsonCfg) CacheInvalidationJsonCfg() (*CacheInvalidationJsonCfg, error) {
+	rawCfg, hasKey := self[CACHE_INV_JSN]
+	if !hasKey {
+		return nil, nil
+	}
+	cfg := new(CacheInvalidationJsonCfg)
+	if err := json.Unmarshal(*rawCfg, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func (self CgrJsonCfg) ListenJsonCfg() (*ListenJsonCfg, error) {
 	rawCfg, hasKey := self[LISTEN_JSN]
 	if !hasKey {
@@ -386,6 +401,30 @@ func (self CgrJsonCfg) ThresholdSJsonCfg() (*ThresholdSJsonCfg, error) {
 	return cfg, nil
 }
 
+func (self CgrJsonCfg) CdrsRetentionJsonCfg() (*CdrsRetentionJsonCfg, error) {
+	rawCfg, hasKey := self[CDRS_RET_JSN]
+	if !hasKey {
+		return nil, nil
+	}
+	cfg := new(CdrsRetentionJsonCfg)
+	if err := json.Unmarshal(*rawCfg, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (self CgrJsonCfg) MetricsJsonCfg() (*MetricsJsonCfg, error) {
+	rawCfg, hasKey := self[METRICS_JSON]
+	if !hasKey {
+		return nil, nil
+	}
+	cfg := new(MetricsJsonCfg)
+	if err := json.Unmarshal(*rawCfg, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func (self CgrJsonCfg) MailerJsonCfg() (*MailerJsonCfg, error) {
 	rawCfg, hasKey := self[MAILER_JSN]
 	if !hasKey {
@@ -58,6 +58,7 @@ func TestDfGeneralJsonCfg(t *testing.T) {
 		Response_cache_ttl:   utils.StringPointer("0s"),
 		Internal_ttl:         utils.StringPointer("2m"),
 		Locking_timeout:      utils.StringPointer("5s"),
+		Locking_backend:      utils.StringPointer(utils.MetaInternal),
 	}
 	if gCfg, err := dfCgrJsonCfg.GeneralJsonCfg(); err != nil {
 		t.Error(err)
@@ -157,6 +158,9 @@ func TestDfDataDbJsonCfg(t *testing.T) {
 		Db_user:           utils.StringPointer("cgrates"),
 		Db_password:       utils.StringPointer(""),
 		Load_history_size: utils.IntPointer(10),
+		Db_path:           utils.StringPointer("/var/lib/cgrates/internal_db"),
+		Snapshot_interval: utils.StringPointer("1h"),
+		Sync_interval:     utils.StringPointer("0s"),
 	}
 	if cfg, err := dfCgrJsonCfg.DbJsonCfg(DATADB_JSN); err != nil {
 		t.Error(err)
@@ -177,6 +181,7 @@ func TestDfStorDBJsonCfg(t *testing.T) {
 		Max_idle_conns:    utils.IntPointer(10),
 		Conn_max_lifetime: utils.IntPointer(0),
 		Cdrs_indexes:      utils.StringSlicePointer([]string{}),
+		Db_path:           utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.DbJsonCfg(STORDB_JSN); err != nil {
 		t.Error(err)
@@ -223,6 +228,7 @@ func TestDfCdrsJsonCfg(t *testing.T) {
 		Cdrstats_conns:     &[]*HaPoolJsonCfg{},
 		Stats_conns:        &[]*HaPoolJsonCfg{},
 		Online_cdr_exports: &[]string{},
+		Tax_provider:       utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.CdrsJsonCfg(); err != nil {
 		t.Error(err)
@@ -679,9 +685,10 @@ func TestDfUserServJsonCfg(t *testing.T) {
 
 func TestDfResourceLimiterSJsonCfg(t *testing.T) {
 	eCfg := &ResourceSJsonCfg{
-		Enabled:        utils.BoolPointer(false),
-		Stats_conns:    &[]*HaPoolJsonCfg{},
-		Store_interval: utils.StringPointer(""),
+		Enabled:          utils.BoolPointer(false),
+		Stats_conns:      &[]*HaPoolJsonCfg{},
+		Thresholds_conns: &[]*HaPoolJsonCfg{},
+		Store_interval:   utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.ResourceSJsonCfg(); err != nil {
 		t.Error(err)
@@ -692,8 +699,11 @@ func TestDfResourceLimiterSJsonCfg(t *testing.T) {
 
 func TestDfStatServiceJsonCfg(t *testing.T) {
 	eCfg := &StatServJsonCfg{
-		Enabled:        utils.BoolPointer(false),
-		Store_interval: utils.StringPointer(""),
+		Enabled:          utils.BoolPointer(false),
+		Store_interval:   utils.StringPointer(""),
+		Thresholds_conns: &[]*HaPoolJsonCfg{},
+		History_interval: utils.StringPointer(""),
+		History_ttl:      utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.StatSJsonCfg(); err != nil {
 		t.Error(err)
@@ -715,6 +725,46 @@ func TestDfThresholdSJsonCfg(t *testing.T) {
 	}
 }
 
+func TestDfMetricsJsonCfg(t *testing.T) {
+	eCfg := &MetricsJsonCfg{
+		Enabled: utils.BoolPointer(false),
+		Url:     utils.StringPointer("/metrics"),
+	}
+	if cfg, err := dfCgrJsonCfg.MetricsJsonCfg(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cfg) {
+		t.Errorf("expecting: %+v, received: %+v", eCfg, cfg)
+	}
+}
+
+func TestDfCdrsRetentionJsonCfg(t *testing.T) {
+	eCfg := &CdrsRetentionJsonCfg{
+		Enabled:            utils.BoolPointer(false),
+		Run_interval:       utils.StringPointer("24h"),
+		Rules:              &[]*CdrsRetentionRuleJsonCfg{},
+		Partition_interval: utils.StringPointer(""),
+		Partitions_premake: utils.IntPointer(2),
+	}
+	if cfg, err := dfCgrJsonCfg.CdrsRetentionJsonCfg(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cfg) {
+		t.Errorf("expecting: %+v, received: %+v", eCfg, cfg)
+	}
+}
+
+func TestDfCacheInvalidationJsonCfg(t *testing.T) {
+	eCfg := &CacheInvalidationJsonCfg{
+		Enabled:    utils.BoolPointer(false),
+		Bus:        utils.StringPointer(utils.MetaDataDB),
+		Peer_conns: &[]*HaPoolJsonCfg{},
+	}
+	if cfg, err := dfCgrJsonCfg.CacheInvalidationJsonCfg(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cfg) {
+		t.Errorf("expecting: %+v, received: %+v", eCfg, cfg)
+	}
+}
+
 func TestDfMailerJsonCfg(t *testing.T) {
 	eCfg := &MailerJsonCfg{
 		Server:        utils.StringPointer("localhost"),
@@ -193,6 +193,9 @@ func TestCgrCfgJSONDefaultsGeneral(t *testing.T) {
 	if cgrCfg.LockingTimeout != 5*time.Second {
 		t.Error(cgrCfg.LockingTimeout)
 	}
+	if cgrCfg.LockingBackend != utils.MetaInternal {
+		t.Error(cgrCfg.LockingBackend)
+	}
 	if cgrCfg.Logger != utils.MetaSysLog {
 		t.Error(cgrCfg.Logger)
 	}
@@ -235,6 +238,15 @@ func TestCgrCfgJSONDefaultsjsnDataDb(t *testing.T) {
 	if cgrCfg.LoadHistorySize != 10 {
 		t.Error(cgrCfg.LoadHistorySize)
 	}
+	if cgrCfg.DataDbPath != "/var/lib/cgrates/internal_db" {
+		t.Error(cgrCfg.DataDbPath)
+	}
+	if cgrCfg.DataDbSnapshotInterval != time.Duration(time.Hour) {
+		t.Error(cgrCfg.DataDbSnapshotInterval)
+	}
+	if cgrCfg.DataDbSyncInterval != 0 {
+		t.Error(cgrCfg.DataDbSyncInterval)
+	}
 }
 
 func TestCgrCfgJSONDefaultsStorDB(t *testing.T) {
@@ -266,6 +278,9 @@ func TestCgrCfgJSONDefaultsStorDB(t *testing.T) {
 	if !reflect.DeepEqual(cgrCfg.StorDBCDRSIndexes, Eslice) {
 		t.Error(cgrCfg.StorDBCDRSIndexes)
 	}
+	if cgrCfg.StorDBPath != "" {
+		t.Error(cgrCfg.StorDBPath)
+	}
 }
 
 func TestCgrCfgJSONDefaultsRALs(t *testing.T) {
@@ -340,6 +355,9 @@ func TestCgrCfgJSONDefaultsCDRS(t *testing.T) {
 	if cgrCfg.CDRSOnlineCDRExports != nil {
 		t.Error(cgrCfg.CDRSOnlineCDRExports)
 	}
+	if cgrCfg.CDRSTaxProvider != "" {
+		t.Error(cgrCfg.CDRSTaxProvider)
+	}
 }
 
 func TestCgrCfgJSONDefaultsCDRStats(t *testing.T) {
@@ -567,9 +585,10 @@ func TestCgrCfgJSONDefaultsUserS(t *testing.T) {
 
 func TestCgrCfgJSONDefaultsResLimCfg(t *testing.T) {
 	eResLiCfg := &ResourceSConfig{
-		Enabled:       false,
-		StatSConns:    []*HaPoolConfig{},
-		StoreInterval: 0,
+		Enabled:         false,
+		StatSConns:      []*HaPoolConfig{},
+		ThresholdSConns: []*HaPoolConfig{},
+		StoreInterval:   0,
 	}
 	if !reflect.DeepEqual(cgrCfg.resourceSCfg, eResLiCfg) {
 		t.Errorf("expecting: %s, received: %s", utils.ToJSON(eResLiCfg), utils.ToJSON(cgrCfg.resourceSCfg))
@@ -579,8 +598,11 @@ func TestCgrCfgJSONDefaultsResLimCfg(t *testing.T) {
 
 func TestCgrCfgJSONDefaultStatsCfg(t *testing.T) {
 	eStatsCfg := &StatSCfg{
-		Enabled:       false,
-		StoreInterval: 0,
+		Enabled:         false,
+		StoreInterval:   0,
+		ThresholdSConns: []*HaPoolConfig{},
+		HistoryInterval: 0,
+		HistoryTTL:      0,
 	}
 	if !reflect.DeepEqual(cgrCfg.statsCfg, eStatsCfg) {
 		t.Errorf("received: %+v, expecting: %+v", cgrCfg.statsCfg, eStatsCfg)
@@ -597,6 +619,78 @@ func TestCgrCfgJSONDefaultThresholdSCfg(t *testing.T) {
 	}
 }
 
+func TestCgrCfgJSONDefaultMetricsCfg(t *testing.T) {
+	eMetricsCfg := &MetricsCfg{
+		Enabled: false,
+		URL:     "/metrics",
+	}
+	if !reflect.DeepEqual(eMetricsCfg, cgrCfg.MetricsCfg()) {
+		t.Errorf("received: %+v, expecting: %+v", cgrCfg.MetricsCfg(), eMetricsCfg)
+	}
+}
+
+func TestCgrCfgJSONDefaultCdrsRetentionCfg(t *testing.T) {
+	eCfg := &CdrsRetentionCfg{
+		Enabled:           false,
+		RunInterval:       time.Duration(24 * time.Hour),
+		Rules:             []*CdrsRetentionRule{},
+		PartitionInterval: "",
+		PartitionsPremake: 2,
+	}
+	if !reflect.DeepEqual(eCfg, cgrCfg.CdrsRetentionCfg()) {
+		t.Errorf("received: %+v, expecting: %+v", cgrCfg.CdrsRetentionCfg(), eCfg)
+	}
+}
+
+func TestCgrCfgJSONDefaultCacheInvalidationCfg(t *testing.T) {
+	eCfg := &CacheInvalidationCfg{
+		Enabled:   false,
+		Bus:       utils.MetaDataDB,
+		PeerConns: []*HaPoolConfig{},
+	}
+	if !reflect.DeepEqual(eCfg, cgrCfg.CacheInvalidationCfg()) {
+		t.Errorf("received: %+v, expecting: %+v", cgrCfg.CacheInvalidationCfg(), eCfg)
+	}
+}
+
+func TestCgrCfgCdrsRetentionRules(t *testing.T) {
+	jsnCfg := `{
+"cdrs_retention": {
+	"enabled": true,
+	"rules": [
+		{"tenant": "cgrates.org", "retention_days": 30, "archive": "*file", "cdre_profile": "*default", "archive_path": "/tmp"},
+		{"retention_days": 365},
+	],
+	"partition_interval": "*monthly",
+},
+}`
+	eRules := []*CdrsRetentionRule{
+		&CdrsRetentionRule{Tenant: "cgrates.org", ToR: utils.ANY, RetentionDays: 30,
+			Archive: utils.MetaFile, CdreProfile: utils.META_DEFAULT, ArchivePath: "/tmp"},
+		&CdrsRetentionRule{Tenant: utils.ANY, ToR: utils.ANY, RetentionDays: 365, Archive: utils.META_NONE},
+	}
+	if cfg, err := NewCGRConfigFromJsonStringWithDefaults(jsnCfg); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eRules, cfg.CdrsRetentionCfg().Rules) {
+		t.Errorf("expecting: %s, received: %s", utils.ToJSON(eRules), utils.ToJSON(cfg.CdrsRetentionCfg().Rules))
+	} else if cfg.CdrsRetentionCfg().PartitionInterval != utils.MetaMonthly {
+		t.Errorf("received: %s", cfg.CdrsRetentionCfg().PartitionInterval)
+	}
+	jsnCfg = `{
+"cdrs_retention": {
+	"enabled": true,
+	"rules": [
+		{"retention_days": 30, "archive": "*file", "cdre_profile": "NOT_EXISTING", "archive_path": "/tmp"},
+	],
+},
+}`
+	if cfg, err := NewCGRConfigFromJsonStringWithDefaults(jsnCfg); err != nil {
+		t.Error(err)
+	} else if err := cfg.checkConfigSanity(); err == nil {
+		t.Error("Expecting error for missing CDRE profile")
+	}
+}
+
 func TestCgrCfgJSONDefaultsDiameterAgentCfg(t *testing.T) {
 	testDA := &DiameterAgentCfg{
 		Enabled:           false,
@@ -40,6 +40,14 @@ type GeneralJsonCfg struct {
 	Response_cache_ttl   *string
 	Internal_ttl         *string
 	Locking_timeout      *string
+	Locking_backend      *string
+}
+
+// Cache invalidation config section
+type CacheInvalidationJsonCfg struct {
+	Enabled    *bool
+	Bus        *string
+	Peer_conns *[]*HaPoolJsonCfg
 }
 
 // Listen config section
@@ -70,6 +78,9 @@ type DbJsonCfg struct {
 	Conn_max_lifetime *int // Used only in case of storDb
 	Load_history_size *int // Used in case of dataDb to limit the length of the loads history
 	Cdrs_indexes      *[]string
+	Db_path           *string // Used only in case of file based databases
+	Snapshot_interval *string // Used only in case of *internal_persistent dataDb
+	Sync_interval     *string // Used only in case of *internal_persistent dataDb
 }
 
 // Rater config section
@@ -104,6 +115,7 @@ type CdrsJsonCfg struct {
 	Cdrstats_conns      *[]*HaPoolJsonCfg
 	Stats_conns         *[]*HaPoolJsonCfg
 	Online_cdr_exports  *[]string
+	Tax_provider        *string
 }
 
 type CdrReplicationJsonCfg struct {
@@ -382,15 +394,19 @@ type UserServJsonCfg struct {
 
 // ResourceLimiter service config section
 type ResourceSJsonCfg struct {
-	Enabled        *bool
-	Stats_conns    *[]*HaPoolJsonCfg
-	Store_interval *string
+	Enabled          *bool
+	Stats_conns      *[]*HaPoolJsonCfg
+	Thresholds_conns *[]*HaPoolJsonCfg
+	Store_interval   *string
 }
 
 // Stat service config section
 type StatServJsonCfg struct {
-	Enabled        *bool
-	Store_interval *string
+	Enabled          *bool
+	Store_interval   *string
+	Thresholds_conns *[]*HaPoolJsonCfg
+	History_interval *string
+	History_ttl      *string
 }
 
 // Threshold service config section
@@ -400,6 +416,31 @@ type ThresholdSJsonCfg struct {
 	Filtered_fields *[]string
 }
 
+// CDRs retention config section
+type CdrsRetentionJsonCfg struct {
+	Enabled            *bool
+	Run_interval       *string
+	Rules              *[]*CdrsRetentionRuleJsonCfg
+	Partition_interval *string
+	Partitions_premake *int
+}
+
+type CdrsRetentionRuleJsonCfg struct {
+	Tenant         *string
+	Tor            *string
+	Retention_days *int
+	Archive        *string
+	Cdre_profile   *string
+	Archive_path   *string
+	Compress       *bool
+}
+
+// Metrics config section
+type MetricsJsonCfg struct {
+	Enabled *bool
+	Url     *string
+}
+
 // Mailer config section
 type MailerJsonCfg struct {
 	Server        *string
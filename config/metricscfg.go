@@ -0,0 +1,38 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package config
+
+// MetricsCfg configures the HTTP handler exposing metrics for Prometheus
+type MetricsCfg struct {
+	Enabled bool
+	URL     string
+}
+
+func (m *MetricsCfg) loadFromJsonCfg(jsnCfg *MetricsJsonCfg) (err error) {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Enabled != nil {
+		m.Enabled = *jsnCfg.Enabled
+	}
+	if jsnCfg.Url != nil {
+		m.URL = *jsnCfg.Url
+	}
+	return nil
+}
@@ -25,9 +25,10 @@ import (
 )
 
 type ResourceSConfig struct {
-	Enabled       bool
-	StatSConns    []*HaPoolConfig // Connections towards StatS
-	StoreInterval time.Duration   // Dump regularly from cache into dataDB
+	Enabled         bool
+	StatSConns      []*HaPoolConfig // Connections towards StatS
+	ThresholdSConns []*HaPoolConfig // Connections towards ThresholdS
+	StoreInterval   time.Duration   // Dump regularly from cache into dataDB
 }
 
 func (rlcfg *ResourceSConfig) loadFromJsonCfg(jsnCfg *ResourceSJsonCfg) (err error) {
@@ -44,6 +45,13 @@ func (rlcfg *ResourceSConfig) loadFromJsonCfg(jsnCfg *ResourceSJsonCfg) (err err
 			rlcfg.StatSConns[idx].loadFromJsonCfg(jsnHaCfg)
 		}
 	}
+	if jsnCfg.Thresholds_conns != nil {
+		rlcfg.ThresholdSConns = make([]*HaPoolConfig, len(*jsnCfg.Thresholds_conns))
+		for idx, jsnHaCfg := range *jsnCfg.Thresholds_conns {
+			rlcfg.ThresholdSConns[idx] = NewDfltHaPoolConfig()
+			rlcfg.ThresholdSConns[idx].loadFromJsonCfg(jsnHaCfg)
+		}
+	}
 	if jsnCfg.Store_interval != nil {
 		if rlcfg.StoreInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Store_interval); err != nil {
 			return
@@ -25,8 +25,11 @@ import (
 )
 
 type StatSCfg struct {
-	Enabled       bool
-	StoreInterval time.Duration // Dump regularly from cache into dataDB
+	Enabled         bool
+	StoreInterval   time.Duration   // Dump regularly from cache into dataDB
+	ThresholdSConns []*HaPoolConfig // Connections towards ThresholdS
+	HistoryInterval time.Duration   // Snapshot regularly the metrics into storDB
+	HistoryTTL      time.Duration   // Remove the metrics history older than this, 0 to keep it forever
 }
 
 func (st *StatSCfg) loadFromJsonCfg(jsnCfg *StatServJsonCfg) (err error) {
@@ -41,5 +44,22 @@ func (st *StatSCfg) loadFromJsonCfg(jsnCfg *StatServJsonCfg) (err error) {
 			return err
 		}
 	}
+	if jsnCfg.Thresholds_conns != nil {
+		st.ThresholdSConns = make([]*HaPoolConfig, len(*jsnCfg.Thresholds_conns))
+		for idx, jsnHaCfg := range *jsnCfg.Thresholds_conns {
+			st.ThresholdSConns[idx] = NewDfltHaPoolConfig()
+			st.ThresholdSConns[idx].loadFromJsonCfg(jsnHaCfg)
+		}
+	}
+	if jsnCfg.History_interval != nil {
+		if st.HistoryInterval, err = utils.ParseDurationWithSecs(*jsnCfg.History_interval); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.History_ttl != nil {
+		if st.HistoryTTL, err = utils.ParseDurationWithSecs(*jsnCfg.History_ttl); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,64 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package console
+
+import (
+	"github.com/cgrates/cgrates/apier/v1"
+	"github.com/cgrates/cgrates/engine"
+)
+
+func init() {
+	c := &CmdImportRateDeck{
+		name:      "ratedeck_import",
+		rpcMethod: "ApierV1.ImportRateDeck",
+	}
+	commands[c.Name()] = c
+	c.CommandExecuter = &CommandExecuter{c}
+}
+
+// Commander implementation
+type CmdImportRateDeck struct {
+	name      string
+	rpcMethod string
+	rpcParams *v1.AttrImportRateDeck
+	*CommandExecuter
+}
+
+func (self *CmdImportRateDeck) Name() string {
+	return self.name
+}
+
+func (self *CmdImportRateDeck) RpcMethod() string {
+	return self.rpcMethod
+}
+
+func (self *CmdImportRateDeck) RpcParams(reset bool) interface{} {
+	if reset || self.rpcParams == nil {
+		self.rpcParams = new(v1.AttrImportRateDeck)
+	}
+	return self.rpcParams
+}
+
+func (self *CmdImportRateDeck) PostprocessRpcParams() error {
+	return nil
+}
+
+func (self *CmdImportRateDeck) RpcResult() interface{} {
+	return new(engine.RateDeckReport)
+}
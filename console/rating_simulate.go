@@ -0,0 +1,64 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package console
+
+import (
+	"github.com/cgrates/cgrates/apier/v1"
+	"github.com/cgrates/cgrates/engine"
+)
+
+func init() {
+	c := &CmdSimulateRating{
+		name:      "rating_simulate",
+		rpcMethod: "ApierV1.SimulateRating",
+	}
+	commands[c.Name()] = c
+	c.CommandExecuter = &CommandExecuter{c}
+}
+
+// Commander implementation
+type CmdSimulateRating struct {
+	name      string
+	rpcMethod string
+	rpcParams *v1.AttrSimulateRating
+	*CommandExecuter
+}
+
+func (self *CmdSimulateRating) Name() string {
+	return self.name
+}
+
+func (self *CmdSimulateRating) RpcMethod() string {
+	return self.rpcMethod
+}
+
+func (self *CmdSimulateRating) RpcParams(reset bool) interface{} {
+	if reset || self.rpcParams == nil {
+		self.rpcParams = new(v1.AttrSimulateRating)
+	}
+	return self.rpcParams
+}
+
+func (self *CmdSimulateRating) PostprocessRpcParams() error {
+	return nil
+}
+
+func (self *CmdSimulateRating) RpcResult() interface{} {
+	return new(engine.RatingSimulation)
+}
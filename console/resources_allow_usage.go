@@ -0,0 +1,62 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package console
+
+import "github.com/cgrates/cgrates/utils"
+
+func init() {
+	c := &CmdResourcesAllowUsage{
+		name:      "resources_allow_usage",
+		rpcMethod: "ResourceSV1.AllowUsage",
+	}
+	commands[c.Name()] = c
+	c.CommandExecuter = &CommandExecuter{c}
+}
+
+// Commander implementation
+type CmdResourcesAllowUsage struct {
+	name      string
+	rpcMethod string
+	rpcParams *utils.ArgRSv1ResourceUsage
+	*CommandExecuter
+}
+
+func (self *CmdResourcesAllowUsage) Name() string {
+	return self.name
+}
+
+func (self *CmdResourcesAllowUsage) RpcMethod() string {
+	return self.rpcMethod
+}
+
+func (self *CmdResourcesAllowUsage) RpcParams(reset bool) interface{} {
+	if reset || self.rpcParams == nil {
+		self.rpcParams = &utils.ArgRSv1ResourceUsage{}
+	}
+	return self.rpcParams
+}
+
+func (self *CmdResourcesAllowUsage) PostprocessRpcParams() error {
+	return nil
+}
+
+func (self *CmdResourcesAllowUsage) RpcResult() interface{} {
+	var allowed bool
+	return &allowed
+}
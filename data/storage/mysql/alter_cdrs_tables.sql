@@ -0,0 +1,47 @@
+--
+-- Upgrade cdrs tables created with a previous create_cdrs_tables.sql
+--
+
+CREATE TABLE IF NOT EXISTS stat_metrics (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  tenant varchar(64) NOT NULL,
+  queue_id varchar(64) NOT NULL,
+  metrics text NOT NULL,
+  snapshot_time TIMESTAMP NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY queue_time_idx (tenant, queue_id, snapshot_time)
+);
+
+CREATE TABLE IF NOT EXISTS cdrs_archive (
+  id int(11) NOT NULL,
+  cgrid char(40) NOT NULL,
+  run_id  varchar(64) NOT NULL,
+  origin_host varchar(64) NOT NULL,
+  source varchar(64) NOT NULL,
+  origin_id varchar(64) NOT NULL,
+  tor  varchar(16) NOT NULL,
+  request_type varchar(24) NOT NULL,
+  direction varchar(8) NOT NULL,
+  tenant varchar(64) NOT NULL,
+  category varchar(32) NOT NULL,
+  account varchar(128) NOT NULL,
+  subject varchar(128) NOT NULL,
+  destination varchar(128) NOT NULL,
+  setup_time datetime NOT NULL,
+  pdd DECIMAL(12,9) NOT NULL,
+  answer_time datetime NOT NULL,
+  `usage` DECIMAL(30,9) NOT NULL,
+  supplier varchar(128) NOT NULL,
+  disconnect_cause varchar(64) NOT NULL,
+  extra_fields text NOT NULL,
+  cost_source varchar(64) NOT NULL,
+  cost DECIMAL(20,4) NOT NULL,
+  cost_details text,
+  account_summary text,
+  extra_info text,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL,
+  deleted_at TIMESTAMP NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY cdrrun (cgrid, run_id, origin_id)
+);
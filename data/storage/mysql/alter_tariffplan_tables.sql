@@ -0,0 +1,49 @@
+--
+-- Upgrade tariffplan tables created with a previous create_tariffplan_tables.sql
+--
+
+ALTER TABLE `tp_stats`
+	ADD COLUMN `bucket_size` varchar(32) NOT NULL DEFAULT '' AFTER `thresholds`;
+
+ALTER TABLE `tp_thresholds`
+	ADD COLUMN `min_hits` int(11) NOT NULL DEFAULT 0 AFTER `action_ids`,
+	ADD COLUMN `max_hits` int(11) NOT NULL DEFAULT 0 AFTER `min_hits`,
+	ADD COLUMN `recover_action_ids` varchar(64) NOT NULL DEFAULT '' AFTER `max_hits`;
+
+ALTER TABLE `tp_destination_rates`
+	ADD COLUMN `tier_usage_start` varchar(32) NOT NULL DEFAULT '' AFTER `max_cost_strategy`,
+	ADD COLUMN `tier_period` varchar(16) NOT NULL DEFAULT '' AFTER `tier_usage_start`,
+	ADD COLUMN `currency` varchar(8) NOT NULL DEFAULT '' AFTER `tier_period`,
+	DROP INDEX `tpid_drid_dstid`,
+	ADD UNIQUE KEY `tpid_drid_dstid` (`tpid`,`tag`,`destinations_tag`,`tier_usage_start`);
+
+CREATE TABLE IF NOT EXISTS tp_exchange_rates (
+  `pk` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `from_currency` varchar(8) NOT NULL,
+  `to_currency` varchar(8) NOT NULL,
+  `activation_time` varchar(32) NOT NULL,
+  `rate` DECIMAL(20,10) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tp_exchange_rates` (`tpid`, `from_currency`, `to_currency`, `activation_time`)
+);
+
+CREATE TABLE IF NOT EXISTS tp_tax_rates (
+  `pk` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tenant` varchar(64) NOT NULL,
+  `id` varchar(64) NOT NULL,
+  `categories` varchar(255) NOT NULL,
+  `destination_ids` varchar(255) NOT NULL,
+  `accounts` varchar(255) NOT NULL,
+  `type` varchar(16) NOT NULL,
+  `value` DECIMAL(20,4) NOT NULL,
+  `compound` BOOLEAN NOT NULL,
+  `weight` decimal(8,2) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tp_tax_rates` (`tpid`, `tenant`, `id`)
+);
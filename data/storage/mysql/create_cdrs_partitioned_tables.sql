@@ -0,0 +1,44 @@
+--
+-- Table structure for table `cdrs`, partitioned on setup_time
+-- Use it instead of the cdrs table in create_cdrs_tables.sql when cdrs_retention.partition_interval is set,
+-- the partitions are created by the engine out of pmax before they are needed.
+-- setup_time does not change for the same CDR so (cgrid, run_id, origin_id) stays unique.
+--
+
+DROP TABLE IF EXISTS cdrs;
+CREATE TABLE cdrs (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  cgrid char(40) NOT NULL,
+  run_id  varchar(64) NOT NULL,
+  origin_host varchar(64) NOT NULL,
+  source varchar(64) NOT NULL,
+  origin_id varchar(64) NOT NULL,
+  tor  varchar(16) NOT NULL,
+  request_type varchar(24) NOT NULL,
+  direction varchar(8) NOT NULL,
+  tenant varchar(64) NOT NULL,
+  category varchar(32) NOT NULL,
+  account varchar(128) NOT NULL,
+  subject varchar(128) NOT NULL,
+  destination varchar(128) NOT NULL,
+  setup_time datetime NOT NULL,
+  pdd DECIMAL(12,9) NOT NULL,
+  answer_time datetime NOT NULL,
+  `usage` DECIMAL(30,9) NOT NULL,
+  supplier varchar(128) NOT NULL,
+  disconnect_cause varchar(64) NOT NULL,
+  extra_fields text NOT NULL,
+  cost_source varchar(64) NOT NULL,
+  cost DECIMAL(20,4) NOT NULL,
+  cost_details text,
+  account_summary text,
+  extra_info text,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NULL,
+  deleted_at TIMESTAMP NULL,
+  PRIMARY KEY (id, setup_time),
+  UNIQUE KEY cdrrun (cgrid, run_id, origin_id, setup_time)
+)
+PARTITION BY RANGE COLUMNS (setup_time) (
+  PARTITION pmax VALUES LESS THAN (MAXVALUE)
+);
@@ -37,6 +37,45 @@ CREATE TABLE cdrs (
   UNIQUE KEY cdrrun (cgrid, run_id, origin_id)
 );
 
+--
+-- Table structure for table `cdrs_archive`, CDRs moved out of cdrs by the retention rules
+--
+
+DROP TABLE IF EXISTS cdrs_archive;
+CREATE TABLE cdrs_archive (
+  id int(11) NOT NULL,
+  cgrid char(40) NOT NULL,
+  run_id  varchar(64) NOT NULL,
+  origin_host varchar(64) NOT NULL,
+  source varchar(64) NOT NULL,
+  origin_id varchar(64) NOT NULL,
+  tor  varchar(16) NOT NULL,
+  request_type varchar(24) NOT NULL,
+  direction varchar(8) NOT NULL,
+  tenant varchar(64) NOT NULL,
+  category varchar(32) NOT NULL,
+  account varchar(128) NOT NULL,
+  subject varchar(128) NOT NULL,
+  destination varchar(128) NOT NULL,
+  setup_time datetime NOT NULL,
+  pdd DECIMAL(12,9) NOT NULL,
+  answer_time datetime NOT NULL,
+  `usage` DECIMAL(30,9) NOT NULL,
+  supplier varchar(128) NOT NULL,
+  disconnect_cause varchar(64) NOT NULL,
+  extra_fields text NOT NULL,
+  cost_source varchar(64) NOT NULL,
+  cost DECIMAL(20,4) NOT NULL,
+  cost_details text,
+  account_summary text,
+  extra_info text,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL,
+  deleted_at TIMESTAMP NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY cdrrun (cgrid, run_id, origin_id)
+);
+
 DROP TABLE IF EXISTS sm_costs;
 CREATE TABLE sm_costs (
   id int(11) NOT NULL AUTO_INCREMENT,
@@ -55,3 +94,14 @@ CREATE TABLE sm_costs (
   KEY run_origin_idx (run_id, origin_id),
   KEY deleted_at_idx (deleted_at)
 );
+
+DROP TABLE IF EXISTS stat_metrics;
+CREATE TABLE stat_metrics (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  tenant varchar(64) NOT NULL,
+  queue_id varchar(64) NOT NULL,
+  metrics text NOT NULL,
+  snapshot_time TIMESTAMP NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY queue_time_idx (tenant, queue_id, snapshot_time)
+);
@@ -71,11 +71,14 @@ CREATE TABLE `tp_destination_rates` (
   `rounding_decimals` tinyint(4) NOT NULL,
   `max_cost` decimal(7,4) NOT NULL,
   `max_cost_strategy` varchar(16) NOT NULL,
+  `tier_usage_start` varchar(32) NOT NULL,
+  `tier_period` varchar(16) NOT NULL,
+  `currency` varchar(8) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `tpid` (`tpid`),
   KEY `tpid_drid` (`tpid`,`tag`),
-  UNIQUE KEY `tpid_drid_dstid` (`tpid`,`tag`,`destinations_tag`)
+  UNIQUE KEY `tpid_drid_dstid` (`tpid`,`tag`,`destinations_tag`,`tier_usage_start`)
 );
 
 --
@@ -440,6 +443,7 @@ CREATE TABLE tp_stats (
   `weight` decimal(8,2) NOT NULL,
   `min_items` int(11) NOT NULL,
   `thresholds` varchar(64) NOT NULL,
+  `bucket_size` varchar(32) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`pk`),
   KEY `tpid` (`tpid`),
@@ -465,6 +469,9 @@ CREATE TABLE tp_thresholds (
   `blocker` BOOLEAN NOT NULL,
   `weight` decimal(8,2) NOT NULL,
   `action_ids` varchar(64) NOT NULL,
+  `min_hits` int(11) NOT NULL,
+  `max_hits` int(11) NOT NULL,
+  `recover_action_ids` varchar(64) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`pk`),
   KEY `tpid` (`tpid`),
@@ -490,6 +497,47 @@ CREATE TABLE tp_filters (
   UNIQUE KEY `unique_tp_filters` (`tpid`,`tenant`, `id`, `filter_type`, `filter_field_name`)
 );
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS tp_exchange_rates;
+CREATE TABLE tp_exchange_rates (
+  `pk` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `from_currency` varchar(8) NOT NULL,
+  `to_currency` varchar(8) NOT NULL,
+  `activation_time` varchar(32) NOT NULL,
+  `rate` DECIMAL(20,10) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tp_exchange_rates` (`tpid`, `from_currency`, `to_currency`, `activation_time`)
+);
+
+--
+-- Table structure for table `tp_tax_rates`
+--
+
+DROP TABLE IF EXISTS tp_tax_rates;
+CREATE TABLE tp_tax_rates (
+  `pk` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tenant` varchar(64) NOT NULL,
+  `id` varchar(64) NOT NULL,
+  `categories` varchar(255) NOT NULL,
+  `destination_ids` varchar(255) NOT NULL,
+  `accounts` varchar(255) NOT NULL,
+  `type` varchar(16) NOT NULL,
+  `value` DECIMAL(20,4) NOT NULL,
+  `compound` BOOLEAN NOT NULL,
+  `weight` decimal(8,2) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tp_tax_rates` (`tpid`, `tenant`, `id`)
+);
+
 --
 -- Table structure for table `versions`
 --
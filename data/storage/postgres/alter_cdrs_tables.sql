@@ -0,0 +1,45 @@
+--
+-- Upgrade cdrs tables created with a previous create_cdrs_tables.sql
+--
+
+CREATE TABLE IF NOT EXISTS stat_metrics (
+  id SERIAL PRIMARY KEY,
+  tenant VARCHAR(64) NOT NULL,
+  queue_id VARCHAR(64) NOT NULL,
+  metrics jsonb NOT NULL,
+  snapshot_time TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS queue_time_stat_metrics_idx ON stat_metrics (tenant, queue_id, snapshot_time);
+
+CREATE TABLE IF NOT EXISTS cdrs_archive (
+ id INTEGER PRIMARY KEY,
+ cgrid CHAR(40) NOT NULL,
+ run_id VARCHAR(64) NOT NULL,
+ origin_host VARCHAR(64) NOT NULL,
+ source VARCHAR(64) NOT NULL,
+ origin_id VARCHAR(64) NOT NULL,
+ tor VARCHAR(16) NOT NULL,
+ request_type VARCHAR(24) NOT NULL,
+ direction VARCHAR(8) NOT NULL,
+ tenant VARCHAR(64) NOT NULL,
+ category VARCHAR(32) NOT NULL,
+ account VARCHAR(128) NOT NULL,
+ subject VARCHAR(128) NOT NULL,
+ destination VARCHAR(128) NOT NULL,
+ setup_time TIMESTAMP WITH TIME ZONE NOT NULL,
+ pdd NUMERIC(12,9) NOT NULL,
+ answer_time TIMESTAMP WITH TIME ZONE NOT NULL,
+ usage NUMERIC(30,9) NOT NULL,
+ supplier VARCHAR(128) NOT NULL,
+ disconnect_cause VARCHAR(64) NOT NULL,
+ extra_fields jsonb NOT NULL,
+ cost_source VARCHAR(64) NOT NULL,
+ cost NUMERIC(20,4) DEFAULT NULL,
+ cost_details jsonb,
+ account_summary jsonb,
+ extra_info text,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE NULL,
+ deleted_at TIMESTAMP WITH TIME ZONE NULL,
+ UNIQUE (cgrid, run_id, origin_id)
+);
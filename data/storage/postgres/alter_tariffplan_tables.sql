@@ -0,0 +1,47 @@
+--
+-- Upgrade tariffplan tables created with a previous create_tariffplan_tables.sql
+--
+
+ALTER TABLE tp_stats
+	ADD COLUMN "bucket_size" varchar(32) NOT NULL DEFAULT '';
+
+ALTER TABLE tp_thresholds
+	ADD COLUMN "min_hits" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "max_hits" INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN "recover_action_ids" varchar(64) NOT NULL DEFAULT '';
+
+ALTER TABLE tp_destination_rates
+	ADD COLUMN "tier_usage_start" VARCHAR(32) NOT NULL DEFAULT '',
+	ADD COLUMN "tier_period" VARCHAR(16) NOT NULL DEFAULT '',
+	ADD COLUMN "currency" VARCHAR(8) NOT NULL DEFAULT '',
+	DROP CONSTRAINT tp_destination_rates_tpid_tag_destinations_tag_key,
+	ADD UNIQUE (tpid, tag, destinations_tag, tier_usage_start);
+
+CREATE TABLE IF NOT EXISTS tp_exchange_rates (
+  "pk" SERIAL PRIMARY KEY,
+  "tpid" varchar(64) NOT NULL,
+  "from_currency" varchar(8) NOT NULL,
+  "to_currency" varchar(8) NOT NULL,
+  "activation_time" varchar(32) NOT NULL,
+  "rate" NUMERIC(20,10) NOT NULL,
+  "created_at" TIMESTAMP WITH TIME ZONE,
+  UNIQUE ("tpid", "from_currency", "to_currency", "activation_time")
+);
+CREATE INDEX IF NOT EXISTS tp_exchange_rates_idx ON tp_exchange_rates (tpid);
+
+CREATE TABLE IF NOT EXISTS tp_tax_rates (
+  "pk" SERIAL PRIMARY KEY,
+  "tpid" varchar(64) NOT NULL,
+  "tenant" varchar(64) NOT NULL,
+  "id" varchar(64) NOT NULL,
+  "categories" varchar(255) NOT NULL,
+  "destination_ids" varchar(255) NOT NULL,
+  "accounts" varchar(255) NOT NULL,
+  "type" varchar(16) NOT NULL,
+  "value" NUMERIC(20,4) NOT NULL,
+  "compound" BOOLEAN NOT NULL,
+  "weight" decimal(8,2) NOT NULL,
+  "created_at" TIMESTAMP WITH TIME ZONE,
+  UNIQUE ("tpid", "tenant", "id")
+);
+CREATE INDEX IF NOT EXISTS tp_tax_rates_idx ON tp_tax_rates (tpid);
@@ -0,0 +1,45 @@
+--
+-- Table structure for table `cdrs`, partitioned on setup_time (PostgreSQL 11+)
+-- Use it instead of the cdrs table in create_cdrs_tables.sql when cdrs_retention.partition_interval is set,
+-- the partitions are created by the engine before they are needed, cdrs_default catching anything outside them.
+-- setup_time does not change for the same CDR so (cgrid, run_id, origin_id) stays unique.
+--
+
+DROP TABLE IF EXISTS cdrs CASCADE;
+CREATE TABLE cdrs (
+ id SERIAL,
+ cgrid CHAR(40) NOT NULL,
+ run_id VARCHAR(64) NOT NULL,
+ origin_host VARCHAR(64) NOT NULL,
+ source VARCHAR(64) NOT NULL,
+ origin_id VARCHAR(64) NOT NULL,
+ tor VARCHAR(16) NOT NULL,
+ request_type VARCHAR(24) NOT NULL,
+ direction VARCHAR(8) NOT NULL,
+ tenant VARCHAR(64) NOT NULL,
+ category VARCHAR(32) NOT NULL,
+ account VARCHAR(128) NOT NULL,
+ subject VARCHAR(128) NOT NULL,
+ destination VARCHAR(128) NOT NULL,
+ setup_time TIMESTAMP WITH TIME ZONE NOT NULL,
+ pdd NUMERIC(12,9) NOT NULL,
+ answer_time TIMESTAMP WITH TIME ZONE NOT NULL,
+ usage NUMERIC(30,9) NOT NULL,
+ supplier VARCHAR(128) NOT NULL,
+ disconnect_cause VARCHAR(64) NOT NULL,
+ extra_fields jsonb NOT NULL,
+ cost_source VARCHAR(64) NOT NULL,
+ cost NUMERIC(20,4) DEFAULT NULL,
+ cost_details jsonb,
+ account_summary jsonb,
+ extra_info text,
+ created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+ updated_at TIMESTAMP WITH TIME ZONE NULL,
+ deleted_at TIMESTAMP WITH TIME ZONE NULL,
+ PRIMARY KEY (id, setup_time),
+ UNIQUE (cgrid, run_id, origin_id, setup_time)
+) PARTITION BY RANGE (setup_time);
+
+CREATE TABLE cdrs_default PARTITION OF cdrs DEFAULT;
+DROP INDEX IF EXISTS deleted_at_cp_idx;
+CREATE INDEX deleted_at_cp_idx ON cdrs (deleted_at);
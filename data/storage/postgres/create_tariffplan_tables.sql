@@ -68,8 +68,11 @@ CREATE TABLE tp_destination_rates (
   rounding_decimals SMALLINT NOT NULL,
   max_cost NUMERIC(7,4) NOT NULL,
   max_cost_strategy VARCHAR(16) NOT NULL,
+  tier_usage_start VARCHAR(32) NOT NULL,
+  tier_period VARCHAR(16) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
   created_at TIMESTAMP WITH TIME ZONE,
-  UNIQUE (tpid, tag , destinations_tag)
+  UNIQUE (tpid, tag , destinations_tag, tier_usage_start)
 );
 CREATE INDEX tpdestrates_tpid_idx ON tp_destination_rates (tpid);
 CREATE INDEX tpdestrates_idx ON tp_destination_rates (tpid,tag);
@@ -436,6 +439,7 @@ CREATE TABLE tp_stats (
   "weight" decimal(8,2) NOT NULL,
   "min_items" INTEGER NOT NULL,
   "thresholds" varchar(64) NOT NULL,
+  "bucket_size" varchar(32) NOT NULL,
   "created_at" TIMESTAMP WITH TIME ZONE
 );
 CREATE INDEX tp_stats_idx ON tp_stats (tpid);
@@ -460,6 +464,9 @@ CREATE TABLE tp_thresholds (
   "blocker" BOOLEAN NOT NULL,
   "weight" decimal(8,2) NOT NULL,
   "action_ids" varchar(64) NOT NULL,
+  "min_hits" INTEGER NOT NULL,
+  "max_hits" INTEGER NOT NULL,
+  "recover_action_ids" varchar(64) NOT NULL,
   "created_at" TIMESTAMP WITH TIME ZONE
 );
 CREATE INDEX tp_thresholds_idx ON tp_thresholds (tpid);
@@ -483,6 +490,45 @@ CREATE TABLE tp_filters (
   CREATE INDEX tp_filters_idx ON tp_filters (tpid);
   CREATE INDEX tp_filters_unique ON tp_filters  ("tpid","tenant", "id", "filter_type", "filter_field_name");
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS tp_exchange_rates;
+CREATE TABLE tp_exchange_rates (
+  "pk" SERIAL PRIMARY KEY,
+  "tpid" varchar(64) NOT NULL,
+  "from_currency" varchar(8) NOT NULL,
+  "to_currency" varchar(8) NOT NULL,
+  "activation_time" varchar(32) NOT NULL,
+  "rate" NUMERIC(20,10) NOT NULL,
+  "created_at" TIMESTAMP WITH TIME ZONE,
+  UNIQUE ("tpid", "from_currency", "to_currency", "activation_time")
+);
+  CREATE INDEX tp_exchange_rates_idx ON tp_exchange_rates (tpid);
+
+--
+-- Table structure for table `tp_tax_rates`
+--
+
+DROP TABLE IF EXISTS tp_tax_rates;
+CREATE TABLE tp_tax_rates (
+  "pk" SERIAL PRIMARY KEY,
+  "tpid" varchar(64) NOT NULL,
+  "tenant" varchar(64) NOT NULL,
+  "id" varchar(64) NOT NULL,
+  "categories" varchar(255) NOT NULL,
+  "destination_ids" varchar(255) NOT NULL,
+  "accounts" varchar(255) NOT NULL,
+  "type" varchar(16) NOT NULL,
+  "value" NUMERIC(20,4) NOT NULL,
+  "compound" BOOLEAN NOT NULL,
+  "weight" decimal(8,2) NOT NULL,
+  "created_at" TIMESTAMP WITH TIME ZONE,
+  UNIQUE ("tpid", "tenant", "id")
+);
+  CREATE INDEX tp_tax_rates_idx ON tp_tax_rates (tpid);
+
 
 
 --
@@ -41,7 +41,9 @@ type Account struct {
 	ActionTriggers    ActionTriggers
 	AllowNegative     bool
 	Disabled          bool
+	Revision          int64 // incremented on each store, protecting against concurrent updates
 	executingTriggers bool
+	triggersDeferred  bool // debit in progress, the action triggers are executed once the account is stored
 }
 
 // User's available minutes for the specified destination
@@ -500,12 +502,14 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 		initialLength := len(cc.Timespans)
 		cc.Timespans = append(cc.Timespans, leftCC.Timespans...)
 
-		var debitedConnectFeeBalance Balance
+		var debitedConnectFee *MonetaryInfo
 		var ok bool
 
 		if initialLength == 0 {
 			// this is the first add, debit the connect fee
-			ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, usefulMoneyBalances, count, true)
+			if ok, debitedConnectFee, err = ub.DebitConnectionFee(cd, cc, usefulMoneyBalances, count, true); err != nil {
+				return nil, err
+			}
 		}
 		//log.Printf("Left CC: %+v ", leftCC)
 		// get the default money balanance
@@ -525,11 +529,7 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					Duration: 0,
 					Cost:     ts.RateInterval.Rating.ConnectFee,
 					BalanceInfo: &DebitInfo{
-						Monetary: &MonetaryInfo{
-							UUID:  debitedConnectFeeBalance.Uuid,
-							ID:    debitedConnectFeeBalance.ID,
-							Value: debitedConnectFeeBalance.Value,
-						},
+						Monetary:  debitedConnectFee,
 						AccountID: ub.ID,
 					},
 				}
@@ -545,13 +545,18 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					continue
 				}
 
-				cost := increment.Cost
 				defaultBalance := ub.GetDefaultMoneyBalance()
+				cost, fxRate, errFx := cd.balanceAmount(defaultBalance, increment.Cost, ts.RateInterval.Rating.Currency, ts.TimeStart)
+				if errFx != nil {
+					return nil, errFx
+				}
 				defaultBalance.SubstractValue(cost)
 				increment.BalanceInfo.Monetary = &MonetaryInfo{
-					UUID:  defaultBalance.Uuid,
-					ID:    defaultBalance.ID,
-					Value: defaultBalance.Value,
+					UUID:         defaultBalance.Uuid,
+					ID:           defaultBalance.ID,
+					Value:        defaultBalance.Value,
+					Currency:     defaultBalance.Currency,
+					ExchangeRate: fxRate,
 				}
 				increment.BalanceInfo.AccountID = ub.ID
 				increment.paid = true
@@ -572,9 +577,8 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 
 COMMIT:
 	if !dryRun {
-		// save darty shared balances
-		usefulMoneyBalances.SaveDirtyBalances(ub)
-		usefulUnitBalances.SaveDirtyBalances(ub)
+		// dirty shared balances are stored together with the account by the caller
+		cd.debitedBalances = append(usefulMoneyBalances, usefulUnitBalances...)
 	}
 	//log.Printf("Final CC: %+v", cc)
 	return
@@ -600,7 +604,7 @@ func (ub *Account) GetDefaultMoneyBalance() *Balance {
 
 // Scans the action trigers and execute the actions for which trigger is met
 func (acc *Account) ExecuteActionTriggers(a *Action) {
-	if acc.executingTriggers {
+	if acc.executingTriggers || acc.triggersDeferred {
 		return
 	}
 	acc.executingTriggers = true
@@ -834,6 +838,7 @@ func (acc *Account) Clone() *Account {
 		ActionTriggers: nil, // not used when cloned (dryRun)
 		AllowNegative:  acc.AllowNegative,
 		Disabled:       acc.Disabled,
+		Revision:       acc.Revision,
 	}
 	for key, balanceChain := range acc.BalanceMap {
 		newAcc.BalanceMap[key] = balanceChain.Clone()
@@ -841,26 +846,61 @@ func (acc *Account) Clone() *Account {
 	return newAcc
 }
 
-func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances Balances, count bool, block bool) (bool, Balance) {
-	var debitedBalance Balance
+// accountToStore prepares the compare-and-set of acc over stored, the account found in DataDB (nil if missing)
+// acc without revision (not read out of DataDB) overrides the stored one unconditionally
+// the returned account carries the next revision, acc should be updated to it only after storing
+func accountToStore(acc, stored *Account) (toStore *Account, err error) {
+	var storedRev int64
+	if stored != nil {
+		storedRev = stored.Revision
+	}
+	if acc.Revision != 0 && acc.Revision != storedRev { // updated by someone else since acc was read
+		return nil, utils.ErrRevisionConflict
+	}
+	// never override existing account with an empty one
+	// UPDATE: if all balances expired and were cleaned it makes
+	// sense to write empty balance map
+	if len(acc.BalanceMap) == 0 && stored != nil && !stored.allBalancesExpired() {
+		stored.ActionTriggers = acc.ActionTriggers
+		stored.UnitCounters = acc.UnitCounters
+		stored.AllowNegative = acc.AllowNegative
+		stored.Disabled = acc.Disabled
+		toStore = stored
+	} else {
+		accCpy := *acc
+		toStore = &accCpy
+	}
+	toStore.Revision = storedRev + 1
+	return
+}
+
+// DebitConnectionFee debits the connect fee of cc, converted into the currency of the balance paying it
+func (acc *Account) DebitConnectionFee(cd *CallDescriptor, cc *CallCost, usefulMoneyBalances Balances, count bool, block bool) (bool, *MonetaryInfo, error) {
+	debitedBalance := new(MonetaryInfo)
 
 	if cc.deductConnectFee {
 		connectFee := cc.GetConnectFee()
+		currency, feeTime := cc.GetCurrency(), cc.GetStartTime()
 		//log.Print("CONNECT FEE: %f", connectFee)
 		connectFeePaid := false
 		for _, b := range usefulMoneyBalances {
-			if b.GetValue() >= connectFee {
-				b.SubstractValue(connectFee)
+			amount, fxRate, err := cd.balanceAmount(b, connectFee, currency, feeTime)
+			if err != nil {
+				return false, nil, err
+			}
+			if b.GetValue() >= amount {
+				b.SubstractValue(amount)
 				// the conect fee is not refundable!
 				if count {
-					acc.countUnits(connectFee, utils.MONETARY, cc, b)
+					acc.countUnits(amount, utils.MONETARY, cc, b)
 				}
 				connectFeePaid = true
-				debitedBalance = *b
+				debitedBalance = &MonetaryInfo{UUID: b.Uuid, ID: b.ID, Value: b.Value,
+					Currency: b.Currency, ExchangeRate: fxRate}
 				break
 			}
 			if b.Blocker && block { // stop here
-				return false, debitedBalance
+				return false, debitedBalance, nil
 			}
 		}
 		// debit connect fee
@@ -868,15 +908,20 @@ func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances Balance
 			cc.negativeConnectFee = true
 			// there are no money for the connect fee; go negative
 			b := acc.GetDefaultMoneyBalance()
-			b.SubstractValue(connectFee)
-			debitedBalance = *b
+			amount, fxRate, err := cd.balanceAmount(b, connectFee, currency, feeTime)
+			if err != nil {
+				return false, nil, err
+			}
+			b.SubstractValue(amount)
+			debitedBalance = &MonetaryInfo{UUID: b.Uuid, ID: b.ID, Value: b.Value,
+				Currency: b.Currency, ExchangeRate: fxRate}
 			// the conect fee is not refundable!
 			if count {
-				acc.countUnits(connectFee, utils.MONETARY, cc, b)
+				acc.countUnits(amount, utils.MONETARY, cc, b)
 			}
 		}
 	}
-	return true, debitedBalance
+	return true, debitedBalance, nil
 }
 
 func (acc *Account) matchActionFilter(condition string) (bool, error) {
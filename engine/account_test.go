@@ -1075,7 +1075,7 @@ func TestAccountExecuteTriggeredDayWeek(t *testing.T) {
 	}
 
 	// we can reset them
-	resetCountersAction(ub, nil, &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), ID: utils.StringPointer("day_trigger")}}, nil)
+	resetCountersAction(ub, nil, &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), ID: utils.StringPointer("day_trigger")}}, nil, nil)
 	if ub.UnitCounters[utils.MONETARY][0].Counters[0].Value != 0 ||
 		ub.UnitCounters[utils.MONETARY][0].Counters[1].Value != 1 {
 		t.Error("Error reseting both counters", ub.UnitCounters[utils.MONETARY][0].Counters[0].Value, ub.UnitCounters[utils.MONETARY][0].Counters[1].Value)
@@ -1256,6 +1256,12 @@ func TestDebitShared(t *testing.T) {
 	if rif.BalanceMap[utils.MONETARY][0].GetValue() != 0 {
 		t.Errorf("Error debiting from shared group: %+v", rif.BalanceMap[utils.MONETARY][0])
 	}
+	if storedGroupie, _ := dm.DataDB().GetAccount("groupie"); storedGroupie.BalanceMap[utils.MONETARY][0].GetValue() != 130 {
+		t.Errorf("Shared group account stored before the debit commit: %+v", storedGroupie.BalanceMap[utils.MONETARY][0])
+	}
+	if err := storeAccounts(append([]*Account{rif}, cd.debitedBalances.dirtyAccounts(rif)...)); err != nil {
+		t.Error(err)
+	}
 	groupie, _ = dm.DataDB().GetAccount("groupie")
 	if groupie.BalanceMap[utils.MONETARY][0].GetValue() != 10 {
 		t.Errorf("Error debiting from shared group: %+v", groupie.BalanceMap[utils.MONETARY][0])
@@ -87,7 +87,7 @@ func (a *Action) Clone() *Action {
 	return &clonedAction
 }
 
-type actionTypeFunc func(*Account, *CDRStatsQueueTriggered, *Action, Actions) error
+type actionTypeFunc func(*Account, *CDRStatsQueueTriggered, *Action, Actions, *ThresholdEvent) error
 
 func getActionFunc(typ string) (actionTypeFunc, bool) {
 	actionFuncMap := map[string]actionTypeFunc{
@@ -120,7 +120,7 @@ func getActionFunc(typ string) (actionTypeFunc, bool) {
 	return f, exists
 }
 
-func logAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func logAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub != nil {
 		body, _ := json.Marshal(ub)
 		utils.Logger.Info(fmt.Sprintf("Threshold hit, Balance: %s", body))
@@ -129,11 +129,16 @@ func logAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions)
 		body, _ := json.Marshal(sq)
 		utils.Logger.Info(fmt.Sprintf("Threshold hit, CDRStatsQueue: %s", body))
 	}
+	if thEv != nil {
+		body, _ := json.Marshal(thEv)
+		utils.Logger.Info(fmt.Sprintf("Threshold hit, Event: %s", body))
+	}
 	return
 }
 
-// Used by cdrLogAction to dynamically parse values out of account and action
-func parseTemplateValue(rsrFlds utils.RSRFields, acnt *Account, action *Action) string {
+// Used by cdrLogAction to dynamically parse values out of account, action and the event triggering it
+// fields not known are looked up in the ThresholdEvent, if present
+func parseTemplateValue(rsrFlds utils.RSRFields, acnt *Account, action *Action, thEv *ThresholdEvent) string {
 	var err error
 	var dta *utils.TenantAccount
 	if acnt != nil {
@@ -179,13 +184,19 @@ func parseTemplateValue(rsrFlds utils.RSRFields, acnt *Account, action *Action)
 		case "SharedGroups":
 			parsedValue += rsrFld.ParseValue(action.Balance.SharedGroups.String())
 		default:
-			parsedValue += rsrFld.ParseValue("") // Mostly for static values
+			var evVal string
+			if thEv != nil {
+				if fldIf, has := thEv.Fields[rsrFld.Id]; has {
+					evVal, _ = utils.CastFieldIfToString(fldIf)
+				}
+			}
+			parsedValue += rsrFld.ParseValue(evVal) // Mostly for static values
 		}
 	}
 	return parsedValue
 }
 
-func cdrLogAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func cdrLogAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	defaultTemplate := map[string]utils.RSRFields{
 		utils.TOR:       utils.ParseRSRFieldsMustCompile("BalanceType", utils.INFIELD_SEP),
 		utils.CDRHOST:   utils.ParseRSRFieldsMustCompile("^127.0.0.1", utils.INFIELD_SEP),
@@ -222,7 +233,7 @@ func cdrLogAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actio
 		cdr.Usage = time.Duration(1) * time.Second
 		elem := reflect.ValueOf(cdr).Elem()
 		for key, rsrFlds := range defaultTemplate {
-			parsedValue := parseTemplateValue(rsrFlds, acc, action)
+			parsedValue := parseTemplateValue(rsrFlds, acc, action, thEv)
 			field := elem.FieldByName(key)
 			if field.IsValid() && field.CanSet() {
 				switch field.Kind() {
@@ -252,7 +263,7 @@ func cdrLogAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actio
 	return
 }
 
-func resetTriggersAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func resetTriggersAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -260,7 +271,7 @@ func resetTriggersAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return
 }
 
-func setRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func setRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -268,7 +279,7 @@ func setRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return
 }
 
-func unsetRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func unsetRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -276,7 +287,7 @@ func unsetRecurrentAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, ac
 	return
 }
 
-func allowNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func allowNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -284,7 +295,7 @@ func allowNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return
 }
 
-func denyNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func denyNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -292,14 +303,14 @@ func denyNegativeAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return
 }
 
-func resetAccountAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func resetAccountAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
 	return genericReset(ub)
 }
 
-func topupResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func topupResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -313,7 +324,7 @@ func topupResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Ac
 	return
 }
 
-func topupAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func topupAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -324,7 +335,7 @@ func topupAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions
 	return
 }
 
-func debitResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func debitResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -334,7 +345,7 @@ func debitResetAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Ac
 	return genericDebit(ub, a, true)
 }
 
-func debitAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func debitAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -342,7 +353,7 @@ func debitAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions
 	return
 }
 
-func resetCountersAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func resetCountersAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -368,7 +379,7 @@ func genericDebit(ub *Account, a *Action, reset bool) (err error) {
 	return ub.debitBalanceAction(a, reset)
 }
 
-func enableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func enableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if acc == nil {
 		return errors.New("nil account")
 	}
@@ -376,7 +387,7 @@ func enableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, ac
 	return
 }
 
-func disableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func disableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if acc == nil {
 		return errors.New("nil account")
 	}
@@ -384,7 +395,7 @@ func disableAccountAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, a
 	return
 }
 
-/*func enableDisableBalanceAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+/*func enableDisableBalanceAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	if ub == nil {
 		return errors.New("nil account")
 	}
@@ -401,15 +412,32 @@ func genericReset(ub *Account) error {
 	return nil
 }
 
-func callUrl(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
-	var o interface{}
+// ThresholdActionData is posted by *call_url actions executed by ThresholdS for an event having an account
+type ThresholdActionData struct {
+	Account        *Account
+	ThresholdEvent *ThresholdEvent
+}
+
+// actionPostData returns the object posted by *call_url actions
+// the ThresholdEvent is posted together with the account if there is one
+func actionPostData(ub *Account, sq *CDRStatsQueueTriggered, thEv *ThresholdEvent) (o interface{}) {
 	if ub != nil {
 		o = ub
 	}
+	if thEv != nil {
+		o = thEv
+		if ub != nil {
+			o = &ThresholdActionData{Account: ub, ThresholdEvent: thEv}
+		}
+	}
 	if sq != nil {
 		o = sq
 	}
-	jsn, err := json.Marshal(o)
+	return
+}
+
+func callUrl(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
+	jsn, err := json.Marshal(actionPostData(ub, sq, thEv))
 	if err != nil {
 		return err
 	}
@@ -424,15 +452,8 @@ func callUrl(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) er
 }
 
 // Does not block for posts, no error reports
-func callUrlAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
-	var o interface{}
-	if ub != nil {
-		o = ub
-	}
-	if sq != nil {
-		o = sq
-	}
-	jsn, err := json.Marshal(o)
+func callUrlAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
+	jsn, err := json.Marshal(actionPostData(ub, sq, thEv))
 	if err != nil {
 		return err
 	}
@@ -447,7 +468,7 @@ func callUrlAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Action
 }
 
 // Mails the balance hitting the threshold towards predefined list of addresses
-func mailAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func mailAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 	cgrCfg := config.CgrConfig()
 	params := strings.Split(a.ExtraParameters, string(utils.CSV_SEP))
 	if len(params) == 0 {
@@ -462,7 +483,13 @@ func mailAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions)
 		toAddrStr += addr
 	}
 	var message []byte
-	if ub != nil {
+	if thEv != nil {
+		evJsn, err := json.Marshal(thEv)
+		if err != nil {
+			return err
+		}
+		message = []byte(fmt.Sprintf("To: %s\r\nSubject: [CGR Notification] Threshold hit on Event: %s\r\n\r\nTime: \r\n\t%s\r\n\r\nEvent:\r\n\t%s\r\n\r\nYours faithfully,\r\nCGR Threshold Monitor\r\n", toAddrStr, thEv.TenantID(), time.Now(), evJsn))
+	} else if ub != nil {
 		balJsn, err := json.Marshal(ub)
 		if err != nil {
 			return err
@@ -478,7 +505,9 @@ func mailAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions)
 			if err := smtp.SendMail(cgrCfg.MailerServer, auth, cgrCfg.MailerFromAddr, toAddrs, message); err == nil {
 				break
 			} else if i == 4 {
-				if ub != nil {
+				if thEv != nil {
+					utils.Logger.Warning(fmt.Sprintf("<Triggers> WARNING: Failed emailing, params: [%s], error: [%s], ThresholdEvent: %s", a.ExtraParameters, err.Error(), thEv.TenantID()))
+				} else if ub != nil {
 					utils.Logger.Warning(fmt.Sprintf("<Triggers> WARNING: Failed emailing, params: [%s], error: [%s], BalanceId: %s", a.ExtraParameters, err.Error(), ub.ID))
 				} else if sq != nil {
 					utils.Logger.Warning(fmt.Sprintf("<Triggers> WARNING: Failed emailing, params: [%s], error: [%s], CDRStatsQueueTriggeredId: %s", a.ExtraParameters, err.Error(), sq.Id))
@@ -491,7 +520,7 @@ func mailAsync(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions)
 	return nil
 }
 
-func setddestinations(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) (err error) {
+func setddestinations(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) (err error) {
 	var ddcDestId string
 	for _, bchain := range ub.BalanceMap {
 		for _, b := range bchain {
@@ -541,7 +570,7 @@ func setddestinations(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Ac
 	return nil
 }
 
-func removeAccountAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func removeAccountAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 
 	var accID string
 	if ub != nil {
@@ -603,7 +632,7 @@ func removeAccountAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return nil
 }
 
-func removeBalanceAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func removeBalanceAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 	if ub == nil {
 		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
 	}
@@ -628,14 +657,14 @@ func removeBalanceAction(ub *Account, sq *CDRStatsQueueTriggered, a *Action, acs
 	return nil
 }
 
-func setBalanceAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func setBalanceAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 	if acc == nil {
 		return fmt.Errorf("nil account for %s action", utils.ToJSON(a))
 	}
 	return acc.setBalanceAction(a)
 }
 
-func transferMonetaryDefaultAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func transferMonetaryDefaultAction(acc *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 	if acc == nil {
 		utils.Logger.Err("*transfer_monetary_default called without account")
 		return utils.ErrAccountNotFound
@@ -679,10 +708,11 @@ Account -  the account that this action is called on
 Action - the action with all it's attributs
 Actions - the list of actions in the current action set
 Sq - CDRStatsQueueTriggered object
+ThresholdEvent - the event triggering a ThresholdS action, fields available as << index .ThresholdEvent.Fields "StatID" >>
 
 We can actually use everythiong that go templates offer. You can read more here: https://golang.org/pkg/text/template/
 */
-func cgrRPCAction(account *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions) error {
+func cgrRPCAction(account *Account, sq *CDRStatsQueueTriggered, a *Action, acs Actions, thEv *ThresholdEvent) error {
 	// parse template
 	tmpl := template.New("extra_params")
 	tmpl.Delims("<<", ">>")
@@ -693,11 +723,12 @@ func cgrRPCAction(account *Account, sq *CDRStatsQueueTriggered, a *Action, acs A
 	}
 	var buf bytes.Buffer
 	if err = t.Execute(&buf, struct {
-		Account *Account
-		Sq      *CDRStatsQueueTriggered
-		Action  *Action
-		Actions Actions
-	}{account, sq, a, acs}); err != nil {
+		Account        *Account
+		Sq             *CDRStatsQueueTriggered
+		Action         *Action
+		Actions        Actions
+		ThresholdEvent *ThresholdEvent
+	}{account, sq, a, acs, thEv}); err != nil {
 		utils.Logger.Err(fmt.Sprintf("error executing *cgr_rpc template %s:", err.Error()))
 		return err
 	}
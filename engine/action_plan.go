@@ -41,6 +41,7 @@ type ActionTiming struct {
 	accountIDs   utils.StringMap // copy of action plans accounts
 	actionPlanID string          // the id of the belonging action plan (info only)
 	stCache      time.Time       // cached time of the next start
+	thEv         *ThresholdEvent // event triggering the execution, passed to the actions
 }
 
 type Task struct {
@@ -331,7 +332,7 @@ func (at *ActionTiming) Execute(successActions, failedActions chan *Action) (err
 					transactionFailed = true
 					break
 				}
-				if err := actionFunction(acc, nil, a, aac); err != nil {
+				if err := actionFunction(acc, nil, a, aac, at.thEv); err != nil {
 					utils.Logger.Err(fmt.Sprintf("Error executing action %s: %v!", a.ActionType, err))
 					transactionFailed = true
 					if failedActions != nil {
@@ -347,7 +348,9 @@ func (at *ActionTiming) Execute(successActions, failedActions chan *Action) (err
 				}
 			}
 			if !transactionFailed && !removeAccountActionFound {
-				dm.DataDB().SetAccount(acc)
+				if err := dm.DataDB().SetAccount(acc); err != nil {
+					utils.Logger.Warning(fmt.Sprintf("Error saving account %s after executing actions: %s", accID, err.Error()))
+				}
 			}
 			return 0, nil
 		}, 0, accID)
@@ -370,7 +373,7 @@ func (at *ActionTiming) Execute(successActions, failedActions chan *Action) (err
 				}
 				break
 			}
-			if err := actionFunction(nil, nil, a, aac); err != nil {
+			if err := actionFunction(nil, nil, a, aac, at.thEv); err != nil {
 				utils.Logger.Err(fmt.Sprintf("Error executing accountless action %s: %v!", a.ActionType, err))
 				if failedActions != nil {
 					go func() { failedActions <- a }()
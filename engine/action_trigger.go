@@ -94,7 +94,7 @@ func (at *ActionTrigger) Execute(ub *Account, sq *CDRStatsQueueTriggered) (err e
 			break
 		}
 		//go utils.Logger.Info(fmt.Sprintf("Executing %v, %v: %v", ub, sq, a))
-		if err := actionFunction(ub, sq, a, aac); err != nil {
+		if err := actionFunction(ub, sq, a, aac, nil); err != nil {
 			utils.Logger.Err(fmt.Sprintf("Error executing action %s: %v!", a.ActionType, err))
 			transactionFailed = false
 			break
@@ -113,7 +113,9 @@ func (at *ActionTrigger) Execute(ub *Account, sq *CDRStatsQueueTriggered) (err e
 			"Id":        at.ID,
 			"ActionIds": at.ActionsID,
 		})
-		dm.DataDB().SetAccount(ub)
+		if err := dm.DataDB().SetAccount(ub); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<ActionTrigger> error: %s saving account: %s", err.Error(), ub.ID))
+		}
 	}
 	return
 }
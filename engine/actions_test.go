@@ -772,7 +772,7 @@ func TestActionResetTriggres(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	resetTriggersAction(ub, nil, nil, nil)
+	resetTriggersAction(ub, nil, nil, nil, nil)
 	if ub.ActionTriggers[0].Executed == true || ub.ActionTriggers[1].Executed == true {
 		t.Error("Reset triggers action failed!")
 	}
@@ -785,7 +785,7 @@ func TestActionResetTriggresExecutesThem(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	resetTriggersAction(ub, nil, nil, nil)
+	resetTriggersAction(ub, nil, nil, nil, nil)
 	if ub.ActionTriggers[0].Executed == true || ub.BalanceMap[utils.MONETARY][0].GetValue() == 12 {
 		t.Error("Reset triggers action failed!")
 	}
@@ -798,7 +798,7 @@ func TestActionResetTriggresActionFilter(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	resetTriggersAction(ub, nil, &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}}, nil)
+	resetTriggersAction(ub, nil, &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}}, nil, nil)
 	if ub.ActionTriggers[0].Executed == false || ub.ActionTriggers[1].Executed == false {
 		t.Error("Reset triggers action failed!")
 	}
@@ -811,7 +811,7 @@ func TestActionSetPostpaid(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	allowNegativeAction(ub, nil, nil, nil)
+	allowNegativeAction(ub, nil, nil, nil, nil)
 	if !ub.AllowNegative {
 		t.Error("Set postpaid action failed!")
 	}
@@ -825,7 +825,7 @@ func TestActionSetPrepaid(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	denyNegativeAction(ub, nil, nil, nil)
+	denyNegativeAction(ub, nil, nil, nil, nil)
 	if ub.AllowNegative {
 		t.Error("Set prepaid action failed!")
 	}
@@ -839,7 +839,7 @@ func TestActionResetPrepaid(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	resetAccountAction(ub, nil, nil, nil)
+	resetAccountAction(ub, nil, nil, nil, nil)
 	if !ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 0 ||
 		len(ub.UnitCounters) != 0 ||
@@ -857,7 +857,7 @@ func TestActionResetPostpaid(t *testing.T) {
 		UnitCounters:   UnitCounters{utils.MONETARY: []*UnitCounter{&UnitCounter{Counters: CounterFilters{&CounterFilter{Value: 1}}}}},
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.SMS)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
-	resetAccountAction(ub, nil, nil, nil)
+	resetAccountAction(ub, nil, nil, nil, nil)
 	if ub.BalanceMap[utils.MONETARY].GetTotalValue() != 0 ||
 		len(ub.UnitCounters) != 0 ||
 		ub.BalanceMap[utils.VOICE][0].GetValue() != 0 ||
@@ -874,7 +874,7 @@ func TestActionTopupResetCredit(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Value: &utils.ValueFormula{Static: 10}, Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupResetAction(ub, nil, a, nil)
+	topupResetAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 10 ||
 		len(ub.UnitCounters) != 0 || // InitCounters finds no counters
@@ -897,7 +897,7 @@ func TestActionTopupValueFactor(t *testing.T) {
 		},
 		ExtraParameters: `{"*monetary":2.0}`,
 	}
-	topupResetAction(ub, nil, a, nil)
+	topupResetAction(ub, nil, a, nil, nil)
 	if len(ub.BalanceMap) != 1 || ub.BalanceMap[utils.MONETARY][0].Factor[utils.MONETARY] != 2.0 {
 		t.Errorf("Topup reset action failed to set Factor: %+v", ub.BalanceMap[utils.MONETARY][0].Factor)
 	}
@@ -914,7 +914,7 @@ func TestActionTopupResetCreditId(t *testing.T) {
 		},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), ID: utils.StringPointer("TEST_B"), Value: &utils.ValueFormula{Static: 10}, Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupResetAction(ub, nil, a, nil)
+	topupResetAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 110 ||
 		len(ub.BalanceMap[utils.MONETARY]) != 2 {
@@ -933,7 +933,7 @@ func TestActionTopupResetCreditNoId(t *testing.T) {
 		},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Value: &utils.ValueFormula{Static: 10}, Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupResetAction(ub, nil, a, nil)
+	topupResetAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 20 ||
 		len(ub.BalanceMap[utils.MONETARY]) != 2 {
@@ -951,7 +951,7 @@ func TestActionTopupResetMinutes(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.VOICE), Value: &utils.ValueFormula{Static: 5}, Weight: utils.Float64Pointer(20), DestinationIDs: utils.StringMapPointer(utils.NewStringMap("NAT")), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupResetAction(ub, nil, a, nil)
+	topupResetAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.VOICE].GetTotalValue() != 5 ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
@@ -970,7 +970,7 @@ func TestActionTopupCredit(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Value: &utils.ValueFormula{Static: 10}, Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupAction(ub, nil, a, nil)
+	topupAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 110 ||
 		len(ub.UnitCounters) != 0 ||
@@ -988,7 +988,7 @@ func TestActionTopupMinutes(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.VOICE), Value: &utils.ValueFormula{Static: 5}, Weight: utils.Float64Pointer(20), DestinationIDs: utils.StringMapPointer(utils.NewStringMap("NAT")), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	topupAction(ub, nil, a, nil)
+	topupAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.VOICE].GetTotalValue() != 15 ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
@@ -1007,7 +1007,7 @@ func TestActionDebitCredit(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Value: &utils.ValueFormula{Static: 10}, Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	debitAction(ub, nil, a, nil)
+	debitAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 90 ||
 		len(ub.UnitCounters) != 0 ||
@@ -1025,7 +1025,7 @@ func TestActionDebitMinutes(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}, &ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.VOICE), Value: &utils.ValueFormula{Static: 5}, Weight: utils.Float64Pointer(20), DestinationIDs: utils.StringMapPointer(utils.NewStringMap("NAT")), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}}
-	debitAction(ub, nil, a, nil)
+	debitAction(ub, nil, a, nil, nil)
 	if ub.AllowNegative ||
 		ub.BalanceMap[utils.VOICE][0].GetValue() != 5 ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
@@ -1049,7 +1049,7 @@ func TestActionResetAllCounters(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{ThresholdType: utils.TRIGGER_MAX_EVENT_COUNTER, ThresholdValue: 2, Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), DestinationIDs: utils.StringMapPointer(utils.NewStringMap("NAT")), Weight: utils.Float64Pointer(20)}, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	ub.InitCounters()
-	resetCountersAction(ub, nil, nil, nil)
+	resetCountersAction(ub, nil, nil, nil, nil)
 	if !ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
 		len(ub.UnitCounters) != 1 ||
@@ -1078,7 +1078,7 @@ func TestActionResetCounterOnlyDefault(t *testing.T) {
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}}
 	ub.InitCounters()
-	resetCountersAction(ub, nil, a, nil)
+	resetCountersAction(ub, nil, a, nil, nil)
 	if !ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
 		len(ub.UnitCounters) != 1 ||
@@ -1108,7 +1108,7 @@ func TestActionResetCounterCredit(t *testing.T) {
 		ActionTriggers: ActionTriggers{&ActionTrigger{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY), Directions: utils.StringMapPointer(utils.NewStringMap(utils.OUT))}, ThresholdValue: 2, ActionsID: "TEST_ACTIONS", Executed: true}},
 	}
 	a := &Action{Balance: &BalanceFilter{Type: utils.StringPointer(utils.MONETARY)}}
-	resetCountersAction(ub, nil, a, nil)
+	resetCountersAction(ub, nil, a, nil, nil)
 	if !ub.AllowNegative ||
 		ub.BalanceMap[utils.MONETARY].GetTotalValue() != 100 ||
 		len(ub.UnitCounters) != 2 ||
@@ -1286,7 +1286,7 @@ func TestActionCdrlogEmpty(t *testing.T) {
 			ActionType: DEBIT,
 			Balance:    &BalanceFilter{Value: &utils.ValueFormula{Static: 25}, DestinationIDs: utils.StringMapPointer(utils.NewStringMap("RET")), Weight: utils.Float64Pointer(20)},
 		},
-	})
+	}, nil)
 	if err != nil {
 		t.Error("Error performing cdrlog action: ", err)
 	}
@@ -1312,7 +1312,7 @@ func TestActionCdrlogWithParams(t *testing.T) {
 			ActionType: DEBIT_RESET,
 			Balance:    &BalanceFilter{Value: &utils.ValueFormula{Static: 25}, DestinationIDs: utils.StringMapPointer(utils.NewStringMap("RET")), Weight: utils.Float64Pointer(20)},
 		},
-	})
+	}, nil)
 	if err != nil {
 		t.Error("Error performing cdrlog action: ", err)
 	}
@@ -1324,6 +1324,52 @@ func TestActionCdrlogWithParams(t *testing.T) {
 	}
 }
 
+func TestActionCdrlogThresholdEvent(t *testing.T) {
+	acnt := &Account{ID: "cgrates.org:dan2904"}
+	cdrlog := &Action{
+		ActionType:      CDRLOG,
+		ExtraParameters: `{"Subject":"StatID","Destination":"*acd"}`,
+	}
+	thEv := &ThresholdEvent{Tenant: "cgrates.org", ID: "TH_EV",
+		Fields: map[string]interface{}{
+			utils.EventSource: utils.StatService,
+			utils.StatID:      "Stats1",
+			utils.MetaACD:     90.5,
+		}}
+	err := cdrLogAction(acnt, nil, cdrlog, Actions{
+		&Action{
+			ActionType: DEBIT,
+			Balance:    &BalanceFilter{Value: &utils.ValueFormula{Static: 25}, DestinationIDs: utils.StringMapPointer(utils.NewStringMap("RET")), Weight: utils.Float64Pointer(20)},
+		},
+	}, thEv)
+	if err != nil {
+		t.Error("Error performing cdrlog action: ", err)
+	}
+	cdrs := make([]*CDR, 0)
+	json.Unmarshal([]byte(cdrlog.ExpirationString), &cdrs)
+	if len(cdrs) != 1 ||
+		cdrs[0].Subject != "Stats1" ||
+		cdrs[0].Destination != "90.5" {
+		t.Errorf("Wrong cdrlogs: %+v", cdrs)
+	}
+}
+
+func TestActionPostData(t *testing.T) {
+	acnt := &Account{ID: "cgrates.org:dan2904"}
+	if o := actionPostData(acnt, nil, nil); o != acnt {
+		t.Errorf("expecting: %+v, received: %+v", acnt, o)
+	}
+	thEv := &ThresholdEvent{Tenant: "cgrates.org", ID: "TH_EV",
+		Fields: map[string]interface{}{utils.ResourceID: "RES1"}}
+	eData := &ThresholdActionData{Account: acnt, ThresholdEvent: thEv}
+	if o := actionPostData(acnt, nil, thEv); !reflect.DeepEqual(eData, o) {
+		t.Errorf("expecting: %+v, received: %+v", eData, o)
+	}
+	if o := actionPostData(nil, nil, thEv); o != thEv {
+		t.Errorf("expecting: %+v, received: %+v", thEv, o)
+	}
+}
+
 func TestActionCdrLogParamsWithOverload(t *testing.T) {
 	acnt := &Account{ID: "cgrates.org:dan2904"}
 	cdrlog := &Action{
@@ -1339,7 +1385,7 @@ func TestActionCdrLogParamsWithOverload(t *testing.T) {
 			ActionType: DEBIT_RESET,
 			Balance:    &BalanceFilter{Value: &utils.ValueFormula{Static: 25}, DestinationIDs: utils.StringMapPointer(utils.NewStringMap("RET")), Weight: utils.Float64Pointer(20)},
 		},
-	})
+	}, nil)
 	if err != nil {
 		t.Error("Error performing cdrlog action: ", err)
 	}
@@ -1376,7 +1422,7 @@ func TestActionSetDDestination(t *testing.T) {
 	if !found || len(x1.([]string)) != 1 {
 		t.Error("Error cacheing destination: ", x1)
 	}
-	setddestinations(acc, &CDRStatsQueueTriggered{Metrics: map[string]float64{"333": 1, "666": 1}}, nil, nil)
+	setddestinations(acc, &CDRStatsQueueTriggered{Metrics: map[string]float64{"333": 1, "666": 1}}, nil, nil, nil)
 	d, err := dm.DataDB().GetDestination("*ddc_test", false, utils.NonTransactional)
 	if err != nil ||
 		d.Id != origD.Id ||
@@ -2304,7 +2350,7 @@ func TestCgrRpcAction(t *testing.T) {
 	"Async" :false,
 	"Params": {"Name":"n", "Surname":"s", "Age":10.2}}`,
 	}
-	if err := cgrRPCAction(nil, nil, a, nil); err != nil {
+	if err := cgrRPCAction(nil, nil, a, nil, nil); err != nil {
 		t.Error("error executing cgr action: ", err)
 	}
 	if trpcp.status != utils.OK {
@@ -42,6 +42,7 @@ type BalanceFilter struct {
 	Disabled       *bool
 	Factor         *ValueFactor
 	Blocker        *bool
+	Currency       *string
 }
 
 func (bp *BalanceFilter) CreateBalance() *Balance {
@@ -61,6 +62,7 @@ func (bp *BalanceFilter) CreateBalance() *Balance {
 		Disabled:       bp.GetDisabled(),
 		Factor:         bp.GetFactor(),
 		Blocker:        bp.GetBlocker(),
+		Currency:       bp.GetCurrency(),
 	}
 	return b.Clone()
 }
@@ -103,6 +105,10 @@ func (bf *BalanceFilter) Clone() *BalanceFilter {
 		result.Blocker = new(bool)
 		*result.Blocker = *bf.Blocker
 	}
+	if bf.Currency != nil {
+		result.Currency = new(string)
+		*result.Currency = *bf.Currency
+	}
 	if bf.Factor != nil {
 		result.Factor = new(ValueFactor)
 		*result.Factor = *bf.Factor
@@ -169,6 +175,9 @@ func (bf *BalanceFilter) LoadFromBalance(b *Balance) *BalanceFilter {
 	if b.Blocker {
 		bf.Blocker = &b.Blocker
 	}
+	if b.Currency != "" {
+		bf.Currency = &b.Currency
+	}
 	bf.Timings = b.Timings
 	return bf
 }
@@ -286,6 +295,13 @@ func (bp *BalanceFilter) GetBlocker() bool {
 	return *bp.Blocker
 }
 
+func (bp *BalanceFilter) GetCurrency() string {
+	if bp == nil || bp.Currency == nil {
+		return ""
+	}
+	return *bp.Currency
+}
+
 func (bp *BalanceFilter) GetExpirationDate() time.Time {
 	if bp == nil || bp.ExpirationDate == nil {
 		return time.Time{}
@@ -344,6 +360,9 @@ func (bf *BalanceFilter) ModifyBalance(b *Balance) {
 	if bf.Blocker != nil {
 		b.Blocker = *bf.Blocker
 	}
+	if bf.Currency != nil {
+		b.Currency = *bf.Currency
+	}
 	if bf.Disabled != nil {
 		b.Disabled = *bf.Disabled
 	}
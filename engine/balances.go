@@ -46,6 +46,7 @@ type Balance struct {
 	Disabled       bool
 	Factor         ValueFactor
 	Blocker        bool
+	Currency       string // currency of a *monetary balance, empty for the one of the rates
 	precision      int
 	account        *Account // used to store ub reference for shared balances
 	dirty          bool
@@ -68,7 +69,8 @@ func (b *Balance) Equal(o *Balance) bool {
 		b.Categories.Equal(o.Categories) &&
 		b.SharedGroups.Equal(o.SharedGroups) &&
 		b.Disabled == o.Disabled &&
-		b.Blocker == o.Blocker
+		b.Blocker == o.Blocker &&
+		b.Currency == o.Currency
 }
 
 func (b *Balance) MatchFilter(o *BalanceFilter, skipIds bool) bool {
@@ -90,7 +92,8 @@ func (b *Balance) MatchFilter(o *BalanceFilter, skipIds bool) bool {
 		(o.Categories == nil || b.Categories.Includes(*o.Categories)) &&
 		(o.TimingIDs == nil || b.TimingIDs.Includes(*o.TimingIDs)) &&
 		(o.SharedGroups == nil || b.SharedGroups.Includes(*o.SharedGroups)) &&
-		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject)
+		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject) &&
+		(o.Currency == nil || b.Currency == *o.Currency)
 }
 
 func (b *Balance) HardMatchFilter(o *BalanceFilter, skipIds bool) bool {
@@ -112,7 +115,8 @@ func (b *Balance) HardMatchFilter(o *BalanceFilter, skipIds bool) bool {
 		(o.Categories == nil || b.Categories.Equal(*o.Categories)) &&
 		(o.TimingIDs == nil || b.TimingIDs.Equal(*o.TimingIDs)) &&
 		(o.SharedGroups == nil || b.SharedGroups.Equal(*o.SharedGroups)) &&
-		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject)
+		(o.RatingSubject == nil || b.RatingSubject == *o.RatingSubject) &&
+		(o.Currency == nil || b.Currency == *o.Currency)
 }
 
 // the default balance has standard Id
@@ -181,6 +185,7 @@ func (b *Balance) Clone() *Balance {
 		Timings:        b.Timings, // should not be a problem with aliasing
 		Blocker:        b.Blocker,
 		Disabled:       b.Disabled,
+		Currency:       b.Currency,
 		dirty:          b.dirty,
 	}
 	if b.DestinationIDs != nil {
@@ -388,7 +393,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 	} else {
 		// get the cost from balance
 		//log.Printf("::::::: %+v", cd)
-		var debitedConnectFeeBalance Balance
+		var debitedConnectFee *MonetaryInfo
 		var ok bool
 		cc, err = b.GetCost(cd, true)
 		if err != nil {
@@ -396,7 +401,9 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 		}
 		if debitConnectFee {
 			// this is the first add, debit the connect fee
-			if ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, moneyBalances, count, true); !ok {
+			if ok, debitedConnectFee, err = ub.DebitConnectionFee(cd, cc, moneyBalances, count, true); err != nil {
+				return nil, err
+			} else if !ok {
 				// found blocker balance
 				return nil, nil
 			}
@@ -419,11 +426,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					Duration: 0,
 					Cost:     ts.RateInterval.Rating.ConnectFee,
 					BalanceInfo: &DebitInfo{
-						Monetary: &MonetaryInfo{
-							UUID:  debitedConnectFeeBalance.Uuid,
-							ID:    debitedConnectFeeBalance.ID,
-							Value: debitedConnectFeeBalance.Value,
-						},
+						Monetary:  debitedConnectFee,
 						AccountID: ub.ID,
 					},
 				}
@@ -477,15 +480,23 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					continue
 				}
 				var moneyBal *Balance
+				var moneyAmount, fxRate float64 // cost converted into the currency of moneyBal
 				for _, mb := range moneyBalances {
-					if mb.GetValue() >= cost {
-						moneyBal = mb
+					mbAmount, mbFxRate, err := cd.balanceAmount(mb, cost, ts.RateInterval.Rating.Currency, ts.TimeStart)
+					if err != nil {
+						return nil, err
+					}
+					if mb.GetValue() >= mbAmount {
+						moneyBal, moneyAmount, fxRate = mb, mbAmount, mbFxRate
 						break
 					}
 				}
 				if cost != 0 && moneyBal == nil && (!dryRun || ub.AllowNegative) { // Fix for issue #685
 					utils.Logger.Warning(fmt.Sprintf("<RALs> Going negative on account %s with AllowNegative: false", cd.GetAccountKey()))
 					moneyBal = ub.GetDefaultMoneyBalance()
+					if moneyAmount, fxRate, err = cd.balanceAmount(moneyBal, cost, ts.RateInterval.Rating.Currency, ts.TimeStart); err != nil {
+						return nil, err
+					}
 				}
 				if b.GetValue() >= amount && (moneyBal != nil || cost == 0) {
 					b.SubstractValue(amount)
@@ -500,11 +511,13 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					}
 					inc.BalanceInfo.AccountID = ub.ID
 					if cost != 0 {
-						moneyBal.SubstractValue(cost)
+						moneyBal.SubstractValue(moneyAmount)
 						inc.BalanceInfo.Monetary = &MonetaryInfo{
-							UUID:  moneyBal.Uuid,
-							ID:    moneyBal.ID,
-							Value: moneyBal.Value,
+							UUID:         moneyBal.Uuid,
+							ID:           moneyBal.ID,
+							Value:        moneyBal.Value,
+							Currency:     moneyBal.Currency,
+							ExchangeRate: fxRate,
 						}
 						cd.MaxCostSoFar += cost
 					}
@@ -512,7 +525,7 @@ func (b *Balance) debitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					if count {
 						ub.countUnits(amount, cc.TOR, cc, b)
 						if cost != 0 {
-							ub.countUnits(cost, utils.MONETARY, cc, moneyBal)
+							ub.countUnits(moneyAmount, utils.MONETARY, cc, moneyBal)
 						}
 					}
 				} else {
@@ -547,13 +560,15 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 		return nil, err
 	}
 
-	var debitedConnectFeeBalance Balance
+	var debitedConnectFee *MonetaryInfo
 	var ok bool
 	//log.Print("cc: " + utils.ToJSON(cc))
 	if debitConnectFee {
 
 		// this is the first add, debit the connect fee
-		if ok, debitedConnectFeeBalance = ub.DebitConnectionFee(cc, moneyBalances, count, true); !ok {
+		if ok, debitedConnectFee, err = ub.DebitConnectionFee(cd, cc, moneyBalances, count, true); err != nil {
+			return nil, err
+		} else if !ok {
 			// balance is blocker
 			return nil, nil
 		}
@@ -580,11 +595,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				Duration: 0,
 				Cost:     ts.RateInterval.Rating.ConnectFee,
 				BalanceInfo: &DebitInfo{
-					Monetary: &MonetaryInfo{
-						UUID:  debitedConnectFeeBalance.Uuid,
-						ID:    debitedConnectFeeBalance.ID,
-						Value: debitedConnectFeeBalance.Value,
-					},
+					Monetary:  debitedConnectFee,
 					AccountID: ub.ID,
 				},
 			}
@@ -642,13 +653,19 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 				continue
 			}
 
+			var fxRate float64
+			if amount, fxRate, err = cd.balanceAmount(b, amount, ts.RateInterval.Rating.Currency, ts.TimeStart); err != nil {
+				return nil, err
+			}
 			if b.GetValue() >= amount {
 				b.SubstractValue(amount)
-				cd.MaxCostSoFar += amount
+				cd.MaxCostSoFar += inc.Cost // MaxCost is in the currency of the rates
 				inc.BalanceInfo.Monetary = &MonetaryInfo{
-					UUID:  b.Uuid,
-					ID:    b.ID,
-					Value: b.Value,
+					UUID:         b.Uuid,
+					ID:           b.ID,
+					Value:        b.Value,
+					Currency:     b.Currency,
+					ExchangeRate: fxRate,
 				}
 				inc.BalanceInfo.AccountID = ub.ID
 				if b.RatingSubject != "" {
@@ -684,7 +701,7 @@ func (b *Balance) debitMoney(cd *CallDescriptor, ub *Account, moneyBalances Bala
 
 // Converts the balance towards compressed information to be displayed
 func (b *Balance) AsBalanceSummary(typ string) *BalanceSummary {
-	bd := &BalanceSummary{UUID: b.Uuid, ID: b.ID, Type: typ, Value: b.Value, Disabled: b.Disabled}
+	bd := &BalanceSummary{UUID: b.Uuid, ID: b.ID, Type: typ, Value: b.Value, Currency: b.Currency, Disabled: b.Disabled}
 	if bd.ID == "" {
 		bd.ID = b.Uuid
 	}
@@ -765,42 +782,43 @@ func (bc Balances) HasBalance(balance *Balance) bool {
 	return false
 }
 
-func (bc Balances) SaveDirtyBalances(acc *Account) {
-	savedAccounts := make(map[string]bool)
+// dirtyAccounts returns the accounts other than acc owning balances changed by a debit,
+// stored together with acc so the shared group debits are not lost or applied twice
+func (bc Balances) dirtyAccounts(acc *Account) (accs []*Account) {
+	seen := utils.StringMap{acc.ID: true}
 	for _, b := range bc {
-		if b.dirty {
-			// publish event
-			accountId := ""
-			allowNegative := ""
-			disabled := ""
-			if b.account != nil { // only publish modifications for balances with account set
-				//utils.LogStack()
-				accountId = b.account.ID
-				allowNegative = strconv.FormatBool(b.account.AllowNegative)
-				disabled = strconv.FormatBool(b.account.Disabled)
-				Publish(CgrEvent{
-					"EventName":            utils.EVT_ACCOUNT_BALANCE_MODIFIED,
-					"Uuid":                 b.Uuid,
-					"Id":                   b.ID,
-					"Value":                strconv.FormatFloat(b.Value, 'f', -1, 64),
-					"ExpirationDate":       b.ExpirationDate.String(),
-					"Weight":               strconv.FormatFloat(b.Weight, 'f', -1, 64),
-					"DestinationIDs":       b.DestinationIDs.String(),
-					"Directions":           b.Directions.String(),
-					"RatingSubject":        b.RatingSubject,
-					"Categories":           b.Categories.String(),
-					"SharedGroups":         b.SharedGroups.String(),
-					"TimingIDs":            b.TimingIDs.String(),
-					"Account":              accountId,
-					"AccountAllowNegative": allowNegative,
-					"AccountDisabled":      disabled,
-				})
-			}
+		if !b.dirty || b.account == nil || seen[b.account.ID] {
+			continue
 		}
-		if b.account != nil && b.account != acc && b.dirty && savedAccounts[b.account.ID] == false {
-			dm.DataDB().SetAccount(b.account)
-			savedAccounts[b.account.ID] = true
+		seen[b.account.ID] = true
+		accs = append(accs, b.account)
+	}
+	return
+}
+
+// publishDirtyBalances publishes the changed balances, called once their accounts are stored
+func (bc Balances) publishDirtyBalances() {
+	for _, b := range bc {
+		if !b.dirty || b.account == nil { // only publish modifications for balances with account set
+			continue
 		}
+		Publish(CgrEvent{
+			"EventName":            utils.EVT_ACCOUNT_BALANCE_MODIFIED,
+			"Uuid":                 b.Uuid,
+			"Id":                   b.ID,
+			"Value":                strconv.FormatFloat(b.Value, 'f', -1, 64),
+			"ExpirationDate":       b.ExpirationDate.String(),
+			"Weight":               strconv.FormatFloat(b.Weight, 'f', -1, 64),
+			"DestinationIDs":       b.DestinationIDs.String(),
+			"Directions":           b.Directions.String(),
+			"RatingSubject":        b.RatingSubject,
+			"Categories":           b.Categories.String(),
+			"SharedGroups":         b.SharedGroups.String(),
+			"TimingIDs":            b.TimingIDs.String(),
+			"Account":              b.account.ID,
+			"AccountAllowNegative": strconv.FormatBool(b.account.AllowNegative),
+			"AccountDisabled":      strconv.FormatBool(b.account.Disabled),
+		})
 	}
 }
 
@@ -819,5 +837,6 @@ type BalanceSummary struct {
 	ID       string // Balance ID  if not defined
 	Type     string // *voice, *data, etc
 	Value    float64
+	Currency string
 	Disabled bool
 }
@@ -0,0 +1,77 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
+)
+
+// CacheInvalidationBus is implemented by the DataDBs able to carry the cache invalidations between engines
+type CacheInvalidationBus interface {
+	cache.InvalidationPublisher
+	ListenInvalidations(exitChan chan bool) error // blocks applying the invalidations received, till exitChan is written
+}
+
+// ListenCacheInvalidations applies the invalidations received over bus, till exitChan is written
+// on connection errors it listens again, flushing the cache since invalidations could have been missed meanwhile
+func ListenCacheInvalidations(bus CacheInvalidationBus, exitChan chan bool) {
+	for {
+		err := bus.ListenInvalidations(exitChan)
+		if err == nil {
+			return
+		}
+		utils.Logger.Warning(fmt.Sprintf("<%s> error: %s listening for cache invalidations, retrying",
+			utils.CacheInvalidation, err.Error()))
+		time.Sleep(time.Second)
+		cache.Flush()
+	}
+}
+
+// publishCacheRefresh invalidates on the other engines the items refreshed locally out of DataDB
+func publishCacheRefresh(prfx string, ids []string) {
+	if ids == nil {
+		cache.PublishInvalidation(nil, []string{prfx})
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = prfx + id
+	}
+	cache.PublishInvalidation(keys, nil)
+}
+
+// NewRPCInvalidationPublisher returns the publisher sending the cache invalidations to peer engines over RPC
+func NewRPCInvalidationPublisher(peerConns rpcclient.RpcClientConnection) *RPCInvalidationPublisher {
+	return &RPCInvalidationPublisher{peerConns: peerConns}
+}
+
+// RPCInvalidationPublisher implements cache.InvalidationPublisher, peers applying the invalidations within ApierV1
+type RPCInvalidationPublisher struct {
+	peerConns rpcclient.RpcClientConnection
+}
+
+func (pub *RPCInvalidationPublisher) PublishInvalidation(inv *cache.Invalidation) error {
+	var reply string
+	return pub.peerConns.Call("ApierV1.ApplyCacheInvalidation", inv, &reply)
+}
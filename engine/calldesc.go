@@ -40,6 +40,8 @@ const (
 	MIN_PREFIX_MATCH    = 1
 	FALLBACK_SUBJECT    = utils.ANY
 	DB                  = "map"
+	// attempts to update an account changed in the meantime by other engine
+	ACCOUNT_UPDATE_RETRIES = 5
 )
 
 func init() {
@@ -154,7 +156,18 @@ type CallDescriptor struct {
 	DryRun              bool
 	DenyNegativeAccount bool // prevent account going on negative during debit
 	account             *Account
-	testCallcost        *CallCost // testing purpose only!
+	debitedBalances     Balances                 // balances of the last debit, the dirty ones stored with the account
+	exchangeRates       map[string]*ExchangeRate // queried while debiting, nil if not found
+	dataManager         *DataManager             // rating data source, defaults to the global one
+	testCallcost        *CallCost                // testing purpose only!
+}
+
+// getDataManager returns the DataManager the rating data is read from
+func (cd *CallDescriptor) getDataManager() *DataManager {
+	if cd.dataManager != nil {
+		return cd.dataManager
+	}
+	return dm
 }
 
 func (cd *CallDescriptor) ValidateCallData() error {
@@ -208,7 +221,7 @@ func (cd *CallDescriptor) LoadRatingPlans() (err error) {
 		utils.Logger.Err(fmt.Sprintf("Destination %s not authorized for account: %s, subject: %s", cd.Destination, cd.GetAccountKey(), cd.GetKey(cd.Subject)))
 		return utils.ErrUnauthorizedDestination
 	}
-	return
+	return cd.applyRateTiers()
 }
 
 // FIXME: this method is not exhaustive but will cover 99% of cases just good
@@ -217,7 +230,7 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 	if recursionDepth > RECURSION_MAX_DEPTH {
 		return utils.ErrMaxRecursionDepth, recursionDepth
 	}
-	rpf, err := RatingProfileSubjectPrefixMatching(key)
+	rpf, err := ratingProfileSubjectPrefixMatching(cd.getDataManager(), key)
 	if err != nil || rpf == nil {
 		return utils.ErrNotFound, recursionDepth
 	}
@@ -236,6 +249,7 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 					Direction:   cd.Direction,
 					Tenant:      cd.Tenant,
 					Destination: cd.Destination,
+					dataManager: cd.dataManager,
 				}
 				if index == 0 {
 					tempCD.TimeStart = cd.TimeStart
@@ -604,7 +618,7 @@ func (origCD *CallDescriptor) getMaxSessionDuration(origAcc *Account) (time.Dura
 		for _, incr := range ts.Increments {
 			totalCost += incr.Cost
 			if incr.BalanceInfo.Monetary != nil && incr.BalanceInfo.Monetary.UUID == defaultBalance.Uuid {
-				initialDefaultBalanceValue -= incr.Cost
+				initialDefaultBalanceValue -= incr.BalanceInfo.Monetary.balanceAmount(incr.Cost) // in the currency of the balance
 				if initialDefaultBalanceValue < 0 {
 					// this increment was payed with debt
 					// TODO: improve this check
@@ -670,9 +684,12 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 		cd.TOR = utils.VOICE
 	}
 	//log.Printf("Debit CD: %+v", cd)
+	cd.debitedBalances = nil
+	account.triggersDeferred = !dryRun // not to execute the actions of a debit which might be retried
 	cc, err = account.debitCreditBalance(cd, !dryRun, dryRun, goNegative)
 	//log.Printf("HERE: %+v %v", cc, err)
 	if err != nil {
+		account.triggersDeferred = false
 		utils.Logger.Err(fmt.Sprintf("<Rater> Error getting cost for account key <%s>: %s", cd.GetAccountKey(), err.Error()))
 		return nil, err
 	}
@@ -680,12 +697,19 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 	cc.UpdateRatedUsage()
 	cc.Timespans.Compress()
 	if !dryRun {
-		dm.DataDB().SetAccount(account)
+		if err = storeAccounts(append([]*Account{account}, cd.debitedBalances.dirtyAccounts(account)...)); err != nil {
+			return nil, err
+		}
+		cd.debitedBalances.publishDirtyBalances()
+		if err = cd.updateTierCounters(cc); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<Rater> Error updating tier counters for account key <%s>: %s", cd.GetAccountKey(), err.Error()))
+			err = nil // the account was already debited
+		}
 	}
 	if cd.PerformRounding {
 		cc.Round()
 		roundIncrements := cc.GetRoundIncrements()
-		if len(roundIncrements) != 0 {
+		if len(roundIncrements) != 0 && !dryRun { // dry runs should not touch the stored account
 			rcd := cc.CreateCallDescriptor()
 			rcd.Increments = roundIncrements
 			rcd.refundRounding()
@@ -695,8 +719,31 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 	return
 }
 
+// retryOnRevisionConflict repeats the account update done by f as long as the account is changed concurrently,
+// each attempt starting with the account read again and the call descriptor as it was initially
+func (cd *CallDescriptor) retryOnRevisionConflict(f func() error) (err error) {
+	timeEnd, durationIndex, ratingInfos := cd.TimeEnd, cd.DurationIndex, cd.RatingInfos
+	for i := 0; i < ACCOUNT_UPDATE_RETRIES; i++ {
+		cd.account = nil // make sure it's not cached
+		cd.TimeEnd, cd.DurationIndex, cd.RatingInfos = timeEnd, durationIndex, ratingInfos
+		if err = f(); err != utils.ErrRevisionConflict {
+			return
+		}
+		utils.Logger.Warning(fmt.Sprintf("<Rater> account <%s> changed concurrently, retrying the update", cd.GetAccountKey()))
+	}
+	return
+}
+
 func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
-	cd.account = nil // make sure it's not cached
+	err = cd.retryOnRevisionConflict(func() error {
+		var err error
+		cc, err = cd.debitOnce()
+		return err
+	})
+	return
+}
+
+func (cd *CallDescriptor) debitOnce() (cc *CallCost, err error) {
 	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
 		// lock all group members
 		account, err := cd.getAccount()
@@ -730,7 +777,15 @@ func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
 // This methods combines the Debit and GetMaxSessionDuration and will debit the max available time as returned
 // by the GetMaxSessionDuration method. The amount filed has to be filled in call descriptor.
 func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
-	cd.account = nil // make sure it's not cached
+	err = cd.retryOnRevisionConflict(func() error {
+		var err error
+		cc, err = cd.maxDebitOnce()
+		return err
+	})
+	return
+}
+
+func (cd *CallDescriptor) maxDebitOnce() (cc *CallCost, err error) {
 	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
 		account, err := cd.getAccount()
 		if err != nil {
@@ -794,17 +849,38 @@ func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
 	return cc, err
 }
 
+// storeAccounts saves the accounts changed together, all or none of them,
+// executing their action triggers deferred during the change once stored
+func storeAccounts(accs []*Account) (err error) {
+	if err = dm.DataDB().SetAccounts(accs); err != nil {
+		return
+	}
+	for _, acc := range accs {
+		if acc.triggersDeferred {
+			acc.triggersDeferred = false
+			acc.ExecuteActionTriggers(nil)
+		}
+	}
+	return
+}
+
 // refundIncrements has no locks
 func (cd *CallDescriptor) refundIncrements() (err error) {
 	accountsCache := make(map[string]*Account)
+	var accounts []*Account
+	defer func() { // save the accounts only once at the end of the function
+		if errSet := storeAccounts(accounts); errSet != nil && err == nil {
+			err = errSet
+		}
+	}()
 	for _, increment := range cd.Increments {
 		account, found := accountsCache[increment.BalanceInfo.AccountID]
 		if !found {
 			if acc, err := dm.DataDB().GetAccount(increment.BalanceInfo.AccountID); err == nil && acc != nil {
 				account = acc
+				account.triggersDeferred = true
 				accountsCache[increment.BalanceInfo.AccountID] = account
-				// will save the account only once at the end of the function
-				defer dm.DataDB().SetAccount(account)
+				accounts = append(accounts, account)
 			}
 		}
 		if account == nil {
@@ -827,8 +903,9 @@ func (cd *CallDescriptor) refundIncrements() (err error) {
 			if balance = account.BalanceMap[utils.MONETARY].GetBalance(increment.BalanceInfo.Monetary.UUID); balance == nil {
 				return
 			}
-			balance.AddValue(increment.Cost)
-			account.countUnits(-increment.Cost, utils.MONETARY, cc, balance)
+			amount := increment.BalanceInfo.Monetary.balanceAmount(increment.Cost)
+			balance.AddValue(amount)
+			account.countUnits(-amount, utils.MONETARY, cc, balance)
 		}
 	}
 	return
@@ -848,10 +925,18 @@ func (cd *CallDescriptor) RefundIncrements() (err error) {
 			accMap[utils.ACCOUNT_PREFIX+increment.BalanceInfo.AccountID] = true
 		}
 	}
-	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
-		err = cd.refundIncrements()
-		return
-	}, 0, accMap.Slice()...)
+	err = cd.retryOnRevisionConflict(func() error {
+		_, err := guardian.Guardian.Guard(func() (iface interface{}, err error) {
+			err = cd.refundIncrements()
+			return
+		}, 0, accMap.Slice()...)
+		return err
+	})
+	if err == nil {
+		if errTiers := cd.refundTierCounters(); errTiers != nil {
+			utils.Logger.Warning(fmt.Sprintf("<Rater> Error refunding tier counters for account key <%s>: %s", cd.GetAccountKey(), errTiers.Error()))
+		}
+	}
 	return
 }
 
@@ -859,14 +944,20 @@ func (cd *CallDescriptor) refundRounding() (err error) {
 	// get account list for locking
 	// all must be locked in order to use cache
 	accountsCache := make(map[string]*Account)
+	var accounts []*Account
+	defer func() { // save the accounts only once at the end of the function
+		if errSet := storeAccounts(accounts); errSet != nil && err == nil {
+			err = errSet
+		}
+	}()
 	for _, increment := range cd.Increments {
 		account, found := accountsCache[increment.BalanceInfo.AccountID]
 		if !found {
 			if acc, err := dm.DataDB().GetAccount(increment.BalanceInfo.AccountID); err == nil && acc != nil {
 				account = acc
+				account.triggersDeferred = true
 				accountsCache[increment.BalanceInfo.AccountID] = account
-				// will save the account only once at the end of the function
-				defer dm.DataDB().SetAccount(account)
+				accounts = append(accounts, account)
 			}
 		}
 		if account == nil {
@@ -879,8 +970,9 @@ func (cd *CallDescriptor) refundRounding() (err error) {
 			if balance = account.BalanceMap[utils.MONETARY].GetBalance(increment.BalanceInfo.Monetary.UUID); balance == nil {
 				return
 			}
-			balance.AddValue(-increment.Cost)
-			account.countUnits(increment.Cost, utils.MONETARY, cc, balance)
+			amount := increment.BalanceInfo.Monetary.balanceAmount(increment.Cost)
+			balance.AddValue(-amount)
+			account.countUnits(amount, utils.MONETARY, cc, balance)
 		}
 	}
 	return
@@ -891,10 +983,18 @@ func (cd *CallDescriptor) RefundRounding() (err error) {
 	for _, inc := range cd.Increments {
 		accMap[utils.ACCOUNT_PREFIX+inc.BalanceInfo.AccountID] = true
 	}
-	_, err = guardian.Guardian.Guard(func() (iface interface{}, err error) {
-		err = cd.refundRounding()
-		return
-	}, 0, accMap.Slice()...)
+	err = cd.retryOnRevisionConflict(func() error {
+		_, err := guardian.Guardian.Guard(func() (iface interface{}, err error) {
+			err = cd.refundRounding()
+			return
+		}, 0, accMap.Slice()...)
+		return err
+	})
+	if err == nil {
+		if errTiers := cd.refundTierCounters(); errTiers != nil {
+			utils.Logger.Warning(fmt.Sprintf("<Rater> Error refunding tier counters for account key <%s>: %s", cd.GetAccountKey(), errTiers.Error()))
+		}
+	}
 	return
 }
 
@@ -679,6 +679,27 @@ func (cdr *CDR) String() string {
 	return string(mrsh)
 }
 
+// AsCallDescriptor returns the CallDescriptor used to rate the CDR
+func (cdr *CDR) AsCallDescriptor() *CallDescriptor {
+	timeStart := cdr.AnswerTime
+	if timeStart.IsZero() { // Fix for FreeSWITCH unanswered calls
+		timeStart = cdr.SetupTime
+	}
+	return &CallDescriptor{
+		TOR:             cdr.ToR,
+		Direction:       cdr.Direction,
+		Tenant:          cdr.Tenant,
+		Category:        cdr.Category,
+		Subject:         cdr.Subject,
+		Account:         cdr.Account,
+		Destination:     cdr.Destination,
+		TimeStart:       timeStart,
+		TimeEnd:         timeStart.Add(cdr.Usage),
+		DurationIndex:   cdr.Usage,
+		PerformRounding: true,
+	}
+}
+
 func (cdr *CDR) combimedCdrFieldVal(cfgCdrFld *config.CfgCdrField, groupCDRs []*CDR) (string, error) {
 	var combinedVal string // Will result as combination of the field values, filters must match
 	for _, filterRule := range cfgCdrFld.FieldFilter {
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
@@ -50,14 +51,15 @@ const (
 	META_FORMATCOST    = "*format_cost"
 )
 
-func NewCDRExporter(cdrs []*CDR, exportTemplate *config.CdreConfig, exportFormat, exportPath, fallbackPath, exportID string,
+// maximum number of CDRs posted in parallel, limits the goroutines started by large exports
+const cdreMaxConcurrentPosts = 100
+
+// NewCDRExporter reads the CDRs out of cdrsIter while exporting, the iterator is closed by the caller
+func NewCDRExporter(cdrsIter CDRsIterator, exportTemplate *config.CdreConfig, exportFormat, exportPath, fallbackPath, exportID string,
 	synchronous bool, attempts int, fieldSeparator rune, usageMultiplyFactor utils.FieldMultiplyFactor,
 	costMultiplyFactor float64, roundingDecimals int, httpSkipTlsCheck bool, httpPoster *utils.HTTPPoster) (*CDRExporter, error) {
-	if len(cdrs) == 0 { // Nothing to export
-		return nil, nil
-	}
 	cdre := &CDRExporter{
-		cdrs:                cdrs,
+		cdrsIter:            cdrsIter,
 		exportTemplate:      exportTemplate,
 		exportFormat:        exportFormat,
 		exportPath:          exportPath,
@@ -78,7 +80,8 @@ func NewCDRExporter(cdrs []*CDR, exportTemplate *config.CdreConfig, exportFormat
 
 type CDRExporter struct {
 	sync.RWMutex
-	cdrs                []*CDR
+	cdrsIter            CDRsIterator
+	cdrs                []*CDR // populated only when the template needs all CDRs for grouping
 	exportTemplate      *config.CdreConfig
 	exportFormat        string
 	exportPath          string
@@ -93,8 +96,11 @@ type CDRExporter struct {
 	httpSkipTlsCheck    bool
 	httpPoster          *utils.HTTPPoster
 
-	header, trailer []string   // Header and Trailer fields
-	content         [][]string // Rows of cdr fields
+	header, trailer []string // Header and Trailer fields
+	content         *os.File // Rows of cdr fields, spooled on disk so large exports do not stay in memory
+	contentLen      int
+
+	totalCdrs int // CDRs read out of the iterator
 
 	firstCdrATime, lastCdrATime time.Time
 	numberOfRecords             int
@@ -286,7 +292,7 @@ func (cdre *CDRExporter) processCDR(cdr *CDR) (err error) {
 			return
 		} else {
 			cdre.Lock()
-			cdre.content = append(cdre.content, cdrRow)
+			err = cdre.writeContent(cdrRow)
 			cdre.Unlock()
 		}
 	default: // attempt posting CDR
@@ -332,10 +338,101 @@ func (cdre *CDRExporter) processCDR(cdr *CDR) (err error) {
 	return nil
 }
 
+// isFileExport returns true for the formats writing all CDRs into one file
+func (cdre *CDRExporter) isFileExport() bool {
+	return utils.IsSliceMember([]string{utils.MetaFileCSV, utils.MetaFileFWV}, cdre.exportFormat)
+}
+
+// needsGroupedCDRs returns true if the content template combines fields out of all exported CDRs
+func (cdre *CDRExporter) needsGroupedCDRs() bool {
+	for _, cfgFld := range cdre.exportTemplate.ContentFields {
+		if cfgFld.Type == utils.META_COMBIMED {
+			return true
+		}
+	}
+	return false
+}
+
+// nextCDR returns the next CDR out of the iterator or out of the buffered ones
+func (cdre *CDRExporter) nextCDR(bufIdx *int) (cdr *CDR, err error) {
+	if cdre.cdrs == nil {
+		if cdr, err = cdre.cdrsIter.Next(); err == nil {
+			cdre.totalCdrs++
+		}
+		return
+	}
+	if *bufIdx >= len(cdre.cdrs) {
+		return nil, io.EOF
+	}
+	cdr = cdre.cdrs[*bufIdx]
+	*bufIdx++
+	return
+}
+
+// writeContent spools one content row, cdre should be locked by the caller
+func (cdre *CDRExporter) writeContent(cdrRow []string) (err error) {
+	if cdre.content == nil {
+		if cdre.content, err = ioutil.TempFile("", "cdre_"); err != nil {
+			return
+		}
+	}
+	if cdre.exportFormat == utils.MetaFileCSV {
+		csvWriter := csv.NewWriter(cdre.content)
+		csvWriter.Comma = cdre.fieldSeparator
+		if err = csvWriter.Write(cdrRow); err != nil {
+			return
+		}
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	} else {
+		for _, cdrFld := range append(cdrRow, "\n") {
+			if _, err = io.WriteString(cdre.content, cdrFld); err != nil {
+				return
+			}
+		}
+	}
+	if err == nil {
+		cdre.contentLen++
+	}
+	return
+}
+
+// removeContent drops the spooled content once written out
+func (cdre *CDRExporter) removeContent() {
+	if cdre.content == nil {
+		return
+	}
+	cdre.content.Close()
+	os.Remove(cdre.content.Name())
+	cdre.content = nil
+}
+
 // Builds header, content and trailers
 func (cdre *CDRExporter) processCDRs() (err error) {
+	if cdre.needsGroupedCDRs() { // grouping needs all CDRs in memory
+		cdre.cdrs = make([]*CDR, 0)
+		for {
+			cdr, err := cdre.cdrsIter.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+			cdre.totalCdrs++
+			cdre.cdrs = append(cdre.cdrs, cdr)
+		}
+	}
 	var wg sync.WaitGroup
-	for _, cdr := range cdre.cdrs {
+	postSlots := make(chan struct{}, cdreMaxConcurrentPosts)
+	var bufIdx int
+	for {
+		cdr, errNext := cdre.nextCDR(&bufIdx)
+		if errNext == io.EOF {
+			break
+		} else if errNext != nil {
+			wg.Wait()
+			return errNext
+		}
 		if cdr == nil || len(cdr.CGRID) == 0 { // CDR needs to exist and it's CGRID needs to be populated
 			continue
 		}
@@ -349,22 +446,18 @@ func (cdre *CDRExporter) processCDRs() (err error) {
 		if !passesFilters { // Not passes filters, ignore this CDR
 			continue
 		}
-		if cdre.synchronous ||
-			utils.IsSliceMember([]string{utils.MetaFileCSV, utils.MetaFileFWV}, cdre.exportFormat) {
-			wg.Add(1) // wait for synchronous or file ones since these need to be done before continuing
+		if cdre.isFileExport() { // files are written in the order the CDRs are read
+			cdre.storeExportResult(cdr, cdre.processCDR(cdr))
+			continue
+		}
+		postSlots <- struct{}{}
+		if cdre.synchronous {
+			wg.Add(1) // wait for synchronous ones since these need to be done before continuing
 		}
 		go func(cdr *CDR) {
-			if err := cdre.processCDR(cdr); err != nil {
-				cdre.Lock()
-				cdre.negativeExports[cdr.CGRID] = err.Error()
-				cdre.Unlock()
-			} else {
-				cdre.Lock()
-				cdre.positiveExports = append(cdre.positiveExports, cdr.CGRID)
-				cdre.Unlock()
-			}
-			if cdre.synchronous ||
-				utils.IsSliceMember([]string{utils.MetaFileCSV, utils.MetaFileFWV}, cdre.exportFormat) {
+			cdre.storeExportResult(cdr, cdre.processCDR(cdr))
+			<-postSlots
+			if cdre.synchronous {
 				wg.Done()
 			}
 		}(cdr)
@@ -384,6 +477,29 @@ func (cdre *CDRExporter) processCDRs() (err error) {
 	return
 }
 
+// storeExportResult records the CGRID as positive or negative export
+func (cdre *CDRExporter) storeExportResult(cdr *CDR, err error) {
+	cdre.Lock()
+	if err != nil {
+		cdre.negativeExports[cdr.CGRID] = err.Error()
+	} else {
+		cdre.positiveExports = append(cdre.positiveExports, cdr.CGRID)
+	}
+	cdre.Unlock()
+}
+
+// copyContent writes the spooled content rows into ioWriter
+func (cdre *CDRExporter) copyContent(ioWriter io.Writer) (err error) {
+	if cdre.content == nil {
+		return
+	}
+	if _, err = cdre.content.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	_, err = io.Copy(ioWriter, cdre.content)
+	return
+}
+
 // Simple write method
 func (cdre *CDRExporter) writeOut(ioWriter io.Writer) error {
 	cdre.Lock()
@@ -395,12 +511,8 @@ func (cdre *CDRExporter) writeOut(ioWriter io.Writer) error {
 			}
 		}
 	}
-	for _, cdrContent := range cdre.content {
-		for _, cdrFld := range append(cdrContent, "\n") {
-			if _, err := io.WriteString(ioWriter, cdrFld); err != nil {
-				return err
-			}
-		}
+	if err := cdre.copyContent(ioWriter); err != nil {
+		return err
 	}
 	if len(cdre.trailer) != 0 {
 		for _, fld := range append(cdre.trailer, "\n") {
@@ -413,19 +525,19 @@ func (cdre *CDRExporter) writeOut(ioWriter io.Writer) error {
 }
 
 // csvWriter specific method
-func (cdre *CDRExporter) writeCsv(csvWriter *csv.Writer) error {
+func (cdre *CDRExporter) writeCsv(ioWriter io.Writer) error {
+	csvWriter := csv.NewWriter(ioWriter)
 	csvWriter.Comma = cdre.fieldSeparator
-	cdre.RLock()
-	defer cdre.RUnlock()
+	cdre.Lock()
+	defer cdre.Unlock()
 	if len(cdre.header) != 0 {
 		if err := csvWriter.Write(cdre.header); err != nil {
 			return err
 		}
+		csvWriter.Flush()
 	}
-	for _, cdrContent := range cdre.content {
-		if err := csvWriter.Write(cdrContent); err != nil {
-			return err
-		}
+	if err := cdre.copyContent(ioWriter); err != nil {
+		return err
 	}
 	if len(cdre.trailer) != 0 {
 		if err := csvWriter.Write(cdre.trailer); err != nil {
@@ -433,16 +545,17 @@ func (cdre *CDRExporter) writeCsv(csvWriter *csv.Writer) error {
 		}
 	}
 	csvWriter.Flush()
-	return nil
+	return csvWriter.Error()
 }
 
 func (cdre *CDRExporter) ExportCDRs() (err error) {
+	defer cdre.removeContent()
 	if err = cdre.processCDRs(); err != nil {
 		return
 	}
-	if utils.IsSliceMember([]string{utils.MetaFileCSV, utils.MetaFileFWV}, cdre.exportFormat) { // files are written after processing all CDRs
+	if cdre.isFileExport() { // files are written after processing all CDRs
 		cdre.RLock()
-		contLen := len(cdre.content)
+		contLen := cdre.contentLen
 		cdre.RUnlock()
 		if contLen == 0 {
 			return
@@ -453,7 +566,7 @@ func (cdre *CDRExporter) ExportCDRs() (err error) {
 		}
 		defer fileOut.Close()
 		if cdre.exportFormat == utils.MetaFileCSV {
-			return cdre.writeCsv(csv.NewWriter(fileOut))
+			return cdre.writeCsv(fileOut)
 		}
 		return cdre.writeOut(fileOut)
 	}
@@ -479,6 +592,11 @@ func (cdre *CDRExporter) TotalExportedCdrs() int {
 	return cdre.numberOfRecords
 }
 
+// Return the number of CDRs read out of the iterator, exported or not
+func (cdre *CDRExporter) TotalCdrs() int {
+	return cdre.totalCdrs
+}
+
 // Return successfully exported CGRIDs
 func (cdre *CDRExporter) PositiveExports() []string {
 	cdre.RLock()
@@ -19,7 +19,6 @@ package engine
 
 import (
 	"bytes"
-	"encoding/csv"
 	"strings"
 	"testing"
 	"time"
@@ -38,16 +37,16 @@ func TestCsvCdrWriter(t *testing.T) {
 		Usage: time.Duration(10) * time.Second, RunID: utils.DEFAULT_RUNID,
 		ExtraFields: map[string]string{"extra1": "val_extra1", "extra2": "val_extra2", "extra3": "val_extra3"}, Cost: 1.01,
 	}
-	cdre, err := NewCDRExporter([]*CDR{storedCdr1}, cfg.CdreProfiles["*default"], utils.MetaFileCSV, "", "", "firstexport",
+	cdre, err := NewCDRExporter(NewCDRsSliceIterator([]*CDR{storedCdr1}), cfg.CdreProfiles["*default"], utils.MetaFileCSV, "", "", "firstexport",
 		true, 1, ',', map[string]float64{}, 0.0, cfg.RoundingDecimals, cfg.HttpSkipTlsVerify, nil)
 	if err != nil {
 		t.Error("Unexpected error received: ", err)
 	}
+	defer cdre.removeContent()
 	if err = cdre.processCDRs(); err != nil {
 		t.Error(err)
 	}
-	csvWriter := csv.NewWriter(writer)
-	if err := cdre.writeCsv(csvWriter); err != nil {
+	if err := cdre.writeCsv(writer); err != nil {
 		t.Error("Unexpected error: ", err)
 	}
 	expected := `dbafe9c8614c785a65aabd116dd3959c3c56f7f6,*default,*voice,dsafdsaf,*rated,*out,cgrates.org,call,1001,1001,1002,2013-11-07T08:42:25Z,2013-11-07T08:42:26Z,10,1.01000`
@@ -69,16 +68,16 @@ func TestAlternativeFieldSeparator(t *testing.T) {
 		Usage: time.Duration(10) * time.Second, RunID: utils.DEFAULT_RUNID,
 		ExtraFields: map[string]string{"extra1": "val_extra1", "extra2": "val_extra2", "extra3": "val_extra3"}, Cost: 1.01,
 	}
-	cdre, err := NewCDRExporter([]*CDR{storedCdr1}, cfg.CdreProfiles["*default"], utils.MetaFileCSV, "", "", "firstexport",
+	cdre, err := NewCDRExporter(NewCDRsSliceIterator([]*CDR{storedCdr1}), cfg.CdreProfiles["*default"], utils.MetaFileCSV, "", "", "firstexport",
 		true, 1, '|', map[string]float64{}, 0.0, cfg.RoundingDecimals, cfg.HttpSkipTlsVerify, nil)
 	if err != nil {
 		t.Error("Unexpected error received: ", err)
 	}
+	defer cdre.removeContent()
 	if err = cdre.processCDRs(); err != nil {
 		t.Error(err)
 	}
-	csvWriter := csv.NewWriter(writer)
-	if err := cdre.writeCsv(csvWriter); err != nil {
+	if err := cdre.writeCsv(writer); err != nil {
 		t.Error("Unexpected error: ", err)
 	}
 	expected := `dbafe9c8614c785a65aabd116dd3959c3c56f7f6|*default|*voice|dsafdsaf|*rated|*out|cgrates.org|call|1001|1001|1002|2013-11-07T08:42:25Z|2013-11-07T08:42:26Z|10|1.01000`
@@ -125,11 +125,12 @@ func TestWriteCdr(t *testing.T) {
 		ExtraFields: map[string]string{"field_extr1": "val_extr1", "fieldextr2": "valextr2"},
 	}
 
-	cdre, err := NewCDRExporter([]*CDR{cdr}, cdreCfg, utils.MetaFileFWV, "", "", "fwv_1",
+	cdre, err := NewCDRExporter(NewCDRsSliceIterator([]*CDR{cdr}), cdreCfg, utils.MetaFileFWV, "", "", "fwv_1",
 		true, 1, '|', map[string]float64{}, 0.0, cfg.RoundingDecimals, cfg.HttpSkipTlsVerify, nil)
 	if err != nil {
 		t.Error(err)
 	}
+	defer cdre.removeContent()
 	if err = cdre.processCDRs(); err != nil {
 		t.Error(err)
 	}
@@ -203,11 +204,12 @@ func TestWriteCdrs(t *testing.T) {
 		ExtraFields: map[string]string{"productnumber": "12344", "fieldextr2": "valextr2"},
 	}
 	cfg, _ := config.NewDefaultCGRConfig()
-	cdre, err := NewCDRExporter([]*CDR{cdr1, cdr2, cdr3, cdr4}, cdreCfg, utils.MetaFileFWV, "", "", "fwv_1",
+	cdre, err := NewCDRExporter(NewCDRsSliceIterator([]*CDR{cdr1, cdr2, cdr3, cdr4}), cdreCfg, utils.MetaFileFWV, "", "", "fwv_1",
 		true, 1, ',', map[string]float64{}, 0.0, cfg.RoundingDecimals, cfg.HttpSkipTlsVerify, nil)
 	if err != nil {
 		t.Error(err)
 	}
+	defer cdre.removeContent()
 	if err = cdre.processCDRs(); err != nil {
 		t.Error(err)
 	}
@@ -20,10 +20,12 @@ package engine
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cgrates/cgrates/cache"
@@ -89,13 +91,20 @@ func NewCdrServer(cgrCfg *config.CGRConfig, cdrDb CdrStorage, dm *DataManager, r
 	if stats == nil || reflect.ValueOf(stats).IsNil() {
 		stats = nil
 	}
+	taxProvider, err := NewTaxProvider(cgrCfg.CDRSTaxProvider, dm)
+	if err != nil {
+		return nil, err
+	}
 	return &CdrServer{cgrCfg: cgrCfg, cdrDb: cdrDb, dm: dm,
 		rals: rater, pubsub: pubsub, users: users, aliases: aliases,
 		cdrstats: cdrstats, stats: stats, guard: guardian.Guardian,
-		httpPoster: utils.NewHTTPPoster(cgrCfg.HttpSkipTlsVerify, cgrCfg.ReplyTimeout)}, nil
+		httpPoster:  utils.NewHTTPPoster(cgrCfg.HttpSkipTlsVerify, cgrCfg.ReplyTimeout),
+		taxProvider: taxProvider}, nil
 }
 
 type CdrServer struct {
+	cdrsProcessed uint64 // CDRs processed successfully, updated atomically
+	cdrsFailed    uint64 // CDRs failed processing, updated atomically
 	cgrCfg        *config.CGRConfig
 	cdrDb         CdrStorage
 	dm            *DataManager
@@ -108,6 +117,7 @@ type CdrServer struct {
 	guard         *guardian.GuardianLock
 	responseCache *cache.ResponseCache
 	httpPoster    *utils.HTTPPoster // used for replication
+	taxProvider   TaxProvider       // adds taxes to the rated CDRs, nil if disabled
 }
 
 func (self *CdrServer) Timezone() string {
@@ -118,6 +128,16 @@ func (self *CdrServer) SetTimeToLive(timeToLive time.Duration, out *int) error {
 	return nil
 }
 
+// PrometheusMetrics exports the counters of the CDRs processed
+func (self *CdrServer) PrometheusMetrics() (pms []*utils.PrometheusMetric, err error) {
+	return []*utils.PrometheusMetric{
+		&utils.PrometheusMetric{Name: "cgrates_cdrs_processed_total", Help: "CDRs processed by CDRS",
+			Type: utils.MetricCounter, Value: float64(atomic.LoadUint64(&self.cdrsProcessed))},
+		&utils.PrometheusMetric{Name: "cgrates_cdrs_failed_total", Help: "CDRs failed processing by CDRS",
+			Type: utils.MetricCounter, Value: float64(atomic.LoadUint64(&self.cdrsFailed))},
+	}, nil
+}
+
 func (self *CdrServer) getCache() *cache.ResponseCache {
 	if self.responseCache == nil {
 		self.responseCache = cache.NewResponseCache(0)
@@ -162,6 +182,13 @@ func (self *CdrServer) storeSMCost(smCost *SMCost, checkDuplicate bool) error {
 
 // Returns error if not able to properly store the CDR, mediation is async since we can always recover offline
 func (self *CdrServer) processCdr(cdr *CDR) (err error) {
+	defer func() {
+		if err != nil {
+			atomic.AddUint64(&self.cdrsFailed, 1)
+		} else {
+			atomic.AddUint64(&self.cdrsProcessed, 1)
+		}
+	}()
 	if cdr.Direction == "" {
 		cdr.Direction = utils.OUT
 	}
@@ -250,14 +277,8 @@ func (self *CdrServer) deriveRateStoreStatsReplicate(cdr *CDR, store, cdrstats,
 		}
 		ratedCDRs = append(ratedCDRs, rcvRatedCDRs...)
 	}
-	// Request should be processed by SureTax
 	for _, ratedCDR := range ratedCDRs {
-		if ratedCDR.RunID == utils.META_SURETAX {
-			if err := SureTaxProcessCdr(ratedCDR); err != nil {
-				ratedCDR.Cost = -1.0
-				ratedCDR.ExtraInfo = err.Error() // Something failed, write the error in the ExtraInfo
-			}
-		}
+		self.taxCDR(ratedCDR)
 	}
 	// Store AccountSummary if requested
 	if self.cgrCfg.CDRScdrAccountSummary {
@@ -444,27 +465,26 @@ func (self *CdrServer) rateCDR(cdr *CDR) ([]*CDR, error) {
 	return []*CDR{cdr}, nil
 }
 
+// taxCDR calculates the taxes of a rated CDR, the *sure_tax run being always processed by SureTax
+func (self *CdrServer) taxCDR(cdr *CDR) {
+	if cdr.RunID == utils.META_SURETAX {
+		if err := SureTaxProcessCdr(cdr); err != nil {
+			cdr.Cost = -1.0
+			cdr.ExtraInfo = err.Error() // Something failed, write the error in the ExtraInfo
+		}
+	} else if self.taxProvider != nil && cdr.Cost != -1 {
+		if err := self.taxProvider.ProcessCDR(cdr); err != nil {
+			cdr.Cost = -1.0
+			cdr.ExtraInfo = err.Error()
+		}
+	}
+}
+
 // Retrive the cost from engine
 func (self *CdrServer) getCostFromRater(cdr *CDR) (*CallCost, error) {
 	cc := new(CallCost)
 	var err error
-	timeStart := cdr.AnswerTime
-	if timeStart.IsZero() { // Fix for FreeSWITCH unanswered calls
-		timeStart = cdr.SetupTime
-	}
-	cd := &CallDescriptor{
-		TOR:             cdr.ToR,
-		Direction:       cdr.Direction,
-		Tenant:          cdr.Tenant,
-		Category:        cdr.Category,
-		Subject:         cdr.Subject,
-		Account:         cdr.Account,
-		Destination:     cdr.Destination,
-		TimeStart:       timeStart,
-		TimeEnd:         timeStart.Add(cdr.Usage),
-		DurationIndex:   cdr.Usage,
-		PerformRounding: true,
-	}
+	cd := cdr.AsCallDescriptor()
 	if utils.IsSliceMember([]string{utils.META_PSEUDOPREPAID, utils.META_POSTPAID, utils.META_PREPAID, utils.PSEUDOPREPAID, utils.POSTPAID, utils.PREPAID}, cdr.RequestType) { // Prepaid - Cost can be recalculated in case of missing records from SM
 		err = self.rals.Call("Responder.Debit", cd, cc)
 	} else {
@@ -481,7 +501,7 @@ func (self *CdrServer) replicateCDRs(cdrs []*CDR) (err error) {
 	for _, exportID := range self.cgrCfg.CDRSOnlineCDRExports {
 		expTpl := self.cgrCfg.CdreProfiles[exportID] // not checking for existence of profile since this should be done in a higher layer
 		var cdre *CDRExporter
-		if cdre, err = NewCDRExporter(cdrs, expTpl, expTpl.ExportFormat, expTpl.ExportPath, self.cgrCfg.FailedPostsDir, "CDRSReplication",
+		if cdre, err = NewCDRExporter(NewCDRsSliceIterator(cdrs), expTpl, expTpl.ExportFormat, expTpl.ExportPath, self.cgrCfg.FailedPostsDir, "CDRSReplication",
 			expTpl.Synchronous, expTpl.Attempts, expTpl.FieldSeparator, expTpl.UsageMultiplyFactor,
 			expTpl.CostMultiplyFactor, self.cgrCfg.RoundingDecimals, self.cgrCfg.HttpSkipTlsVerify, self.httpPoster); err != nil {
 			utils.Logger.Err(fmt.Sprintf("<CDRS> Building CDRExporter for online exports got error: <%s>", err.Error()))
@@ -497,18 +517,55 @@ func (self *CdrServer) replicateCDRs(cdrs []*CDR) (err error) {
 
 // Called by rate/re-rate API, FixMe: deprecate it once new APIer structure is operational
 func (self *CdrServer) RateCDRs(cdrFltr *utils.CDRsFilter, sendToStats bool) error {
-	cdrs, _, err := self.cdrDb.GetCDRs(cdrFltr, false)
+	return self.rateCDRs(cdrFltr, self.cgrCfg.CDRSStoreCdrs, sendToStats, len(self.cgrCfg.CDRSOnlineCDRExports) != 0)
+}
+
+// rateCDRs streams the IDs of the matching CDRs out of storDB, processing the CDRs one by one so memory stays low
+// the IDs are collected before processing since storing the rated CDRs writes into the iterated table
+func (self *CdrServer) rateCDRs(cdrFltr *utils.CDRsFilter, storeCDRs, sendToStats, replicate bool) error {
+	cdrIDs, err := self.matchingCDRIDs(cdrFltr)
 	if err != nil {
 		return err
 	}
-	for _, cdr := range cdrs {
-		if err := self.deriveRateStoreStatsReplicate(cdr, self.cgrCfg.CDRSStoreCdrs, sendToStats, len(self.cgrCfg.CDRSOnlineCDRExports) != 0); err != nil {
-			utils.Logger.Err(fmt.Sprintf("<CDRS> Processing CDR %+v, got error: %s", cdr, err.Error()))
+	if len(cdrIDs) == 0 {
+		return utils.ErrNotFound
+	}
+	for _, cdrID := range cdrIDs {
+		cdrs, _, err := self.cdrDb.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{cdrID[0]}, RunIDs: []string{cdrID[1]}}, false)
+		if err != nil {
+			if err == utils.ErrNotFound { // removed in the meantime
+				continue
+			}
+			return err
+		}
+		for _, cdr := range cdrs {
+			if err := self.deriveRateStoreStatsReplicate(cdr, storeCDRs, sendToStats, replicate); err != nil {
+				utils.Logger.Err(fmt.Sprintf("<CDRS> Processing CDR %+v, got error: %s", cdr, err.Error()))
+			}
 		}
 	}
 	return nil
 }
 
+// matchingCDRIDs returns the CGRID and RunID of the CDRs matching the filter
+func (self *CdrServer) matchingCDRIDs(cdrFltr *utils.CDRsFilter) (cdrIDs [][2]string, err error) {
+	cdrsIter, err := self.cdrDb.GetCDRsIterator(cdrFltr)
+	if err != nil {
+		return nil, err
+	}
+	defer cdrsIter.Close()
+	for {
+		cdr, err := cdrsIter.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		cdrIDs = append(cdrIDs, [2]string{cdr.CGRID, cdr.RunID})
+	}
+	return
+}
+
 // Internally used and called from CDRSv1
 // Cached requests for HA setups
 func (self *CdrServer) V1ProcessCDR(cdr *CDR, reply *string) error {
@@ -604,10 +661,6 @@ func (self *CdrServer) V1RateCDRs(attrs utils.AttrRateCDRs, reply *string) error
 	if err != nil {
 		return utils.NewErrServerError(err)
 	}
-	cdrs, _, err := self.cdrDb.GetCDRs(cdrFltr, false)
-	if err != nil {
-		return err
-	}
 	storeCDRs := self.cgrCfg.CDRSStoreCdrs
 	if attrs.StoreCDRs != nil {
 		storeCDRs = *attrs.StoreCDRs
@@ -620,12 +673,7 @@ func (self *CdrServer) V1RateCDRs(attrs utils.AttrRateCDRs, reply *string) error
 	if attrs.ReplicateCDRs != nil {
 		replicate = *attrs.ReplicateCDRs
 	}
-	for _, cdr := range cdrs {
-		if err := self.deriveRateStoreStatsReplicate(cdr, storeCDRs, sendToStats, replicate); err != nil {
-			utils.Logger.Err(fmt.Sprintf("<CDRS> Processing CDR %+v, got error: %s", cdr, err.Error()))
-		}
-	}
-	return nil
+	return self.rateCDRs(cdrFltr, storeCDRs, sendToStats, replicate)
 }
 
 func (cdrsrv *CdrServer) Call(serviceMethod string, args interface{}, reply interface{}) error {
@@ -0,0 +1,285 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// ArgsRunCDRsRetention are the arguments of an on-demand retention run
+type ArgsRunCDRsRetention struct {
+	DryRun bool // only count the CDRs which would be removed
+}
+
+// CDRsRetentionRuleReport is the outcome of applying one retention rule
+type CDRsRetentionRuleReport struct {
+	Tenant      string
+	ToR         string
+	Archive     string
+	Cutoff      time.Time // CDRs with setup time before it were processed
+	Archived    int64     // CDRs moved into the archive table or file
+	Removed     int64     // CDRs removed out of the cdrs table
+	ArchiveFile string
+	Error       string
+}
+
+// CDRsRetentionReport sums up one retention run
+type CDRsRetentionReport struct {
+	StartTime  time.Time
+	Duration   time.Duration
+	DryRun     bool
+	Rules      []*CDRsRetentionRuleReport
+	Partitions []string // partitions created during the run
+	Error      string   // partitioning error
+}
+
+// NewCDRsRetentionService returns the service removing the old CDRs out of cdrDb
+func NewCDRsRetentionService(cfg *config.CGRConfig, cdrDb CdrStorage) *CDRsRetentionService {
+	return &CDRsRetentionService{cfg: cfg, cdrDb: cdrDb}
+}
+
+// CDRsRetentionService applies the retention rules on CDRs, periodically or on demand
+type CDRsRetentionService struct {
+	sync.Mutex // one run at a time
+	cfg        *config.CGRConfig
+	cdrDb      CdrStorage
+}
+
+// ListenAndServe runs the retention at configured interval, till exitChan is written
+func (rs *CDRsRetentionService) ListenAndServe(exitChan chan bool) error {
+	for {
+		select {
+		case e := <-exitChan:
+			exitChan <- e // put back for the others listening for shutdown request
+			return nil
+		case <-time.After(rs.cfg.CdrsRetentionCfg().RunInterval):
+			rpt := rs.Run(false)
+			utils.Logger.Info(fmt.Sprintf("<%s> finished run: %s", utils.CDRsRetention, utils.ToJSON(rpt)))
+		}
+	}
+}
+
+// Run applies the retention rules once, building the report of the actions done
+func (rs *CDRsRetentionService) Run(dryRun bool) (rpt *CDRsRetentionReport) {
+	rs.Lock()
+	defer rs.Unlock()
+	retCfg := rs.cfg.CdrsRetentionCfg()
+	rpt = &CDRsRetentionReport{StartTime: time.Now(), DryRun: dryRun,
+		Rules: make([]*CDRsRetentionRuleReport, len(retCfg.Rules))}
+	if retCfg.PartitionInterval != "" && !dryRun {
+		var err error
+		if partitioner, canPartition := rs.cdrDb.(CDRsPartitioner); !canPartition {
+			err = utils.ErrNotImplemented
+		} else {
+			rpt.Partitions, err = partitioner.CreateCDRsPartitions(retCfg.PartitionInterval,
+				rpt.StartTime, retCfg.PartitionsPremake)
+		}
+		if err != nil {
+			rpt.Error = err.Error()
+			utils.Logger.Warning(fmt.Sprintf("<%s> creating partitions, error: %s",
+				utils.CDRsRetention, err.Error()))
+		}
+	}
+	for i, rule := range retCfg.Rules {
+		rpt.Rules[i] = rs.applyRule(rule, rpt.StartTime, dryRun)
+		if rpt.Rules[i].Error != "" {
+			utils.Logger.Warning(fmt.Sprintf("<%s> applying rule for tenant: %s, tor: %s, error: %s",
+				utils.CDRsRetention, rule.Tenant, rule.ToR, rpt.Rules[i].Error))
+		}
+	}
+	rpt.Duration = time.Now().Sub(rpt.StartTime)
+	return
+}
+
+// ruleCDRsFilter selects the CDRs of rule older than the cutoff
+// CDRs created after the run started are never selected, unless the storage does not record the creation time of the CDRs
+// in which case the archives remove only the CDRs they exported
+func ruleCDRsFilter(rule *config.CdrsRetentionRule, cutoff, runStart time.Time, withCreatedAt bool) *utils.CDRsFilter {
+	fltr := &utils.CDRsFilter{SetupTimeEnd: &cutoff, Unscoped: true}
+	if withCreatedAt {
+		fltr.CreatedAtEnd = &runStart
+	}
+	if rule.Tenant != utils.ANY {
+		fltr.Tenants = []string{rule.Tenant}
+	}
+	if rule.ToR != utils.ANY {
+		fltr.ToRs = []string{rule.ToR}
+	}
+	return fltr
+}
+
+func (rs *CDRsRetentionService) applyRule(rule *config.CdrsRetentionRule, runStart time.Time, dryRun bool) (rRpt *CDRsRetentionRuleReport) {
+	rRpt = &CDRsRetentionRuleReport{Tenant: rule.Tenant, ToR: rule.ToR, Archive: rule.Archive,
+		Cutoff: runStart.AddDate(0, 0, -rule.RetentionDays)}
+	_, isMongo := rs.cdrDb.(*MongoStorage) // CDR documents have no creation time
+	fltr := ruleCDRsFilter(rule, rRpt.Cutoff, runStart, !isMongo)
+	var err error
+	if dryRun {
+		cntFltr := *fltr
+		cntFltr.Count = true
+		_, rRpt.Removed, err = rs.cdrDb.GetCDRs(&cntFltr, false)
+	} else {
+		switch rule.Archive {
+		case utils.MetaTable:
+			rRpt.Archived, err = rs.cdrDb.ArchiveCDRs(fltr)
+			rRpt.Removed = rRpt.Archived
+		case utils.MetaFile:
+			var exported map[string][]string
+			if rRpt.ArchiveFile, exported, err = rs.archiveToFile(rule, fltr, runStart); err == nil {
+				for _, cgrIDs := range exported {
+					rRpt.Archived += int64(len(cgrIDs))
+				}
+				rRpt.Removed, err = rs.removeExportedCDRs(fltr, exported)
+			}
+		default:
+			_, rRpt.Removed, err = rs.cdrDb.GetCDRs(fltr, true)
+		}
+	}
+	if err != nil && err != utils.ErrNotFound {
+		rRpt.Error = err.Error()
+	}
+	return
+}
+
+// removeExportedCDRs removes the CDRs matching fltr out of the exported ones, indexed on RunID
+// CDRs matching fltr only after the export, possible on storages without creation time, are kept
+func (rs *CDRsRetentionService) removeExportedCDRs(fltr *utils.CDRsFilter,
+	exported map[string][]string) (removed int64, err error) {
+	for runID, cgrIDs := range exported {
+		for len(cgrIDs) != 0 {
+			batch := cgrIDs
+			if len(batch) > cdrsRetentionRemoveBatch {
+				batch = batch[:cdrsRetentionRemoveBatch]
+			}
+			cgrIDs = cgrIDs[len(batch):]
+			rmFltr := *fltr
+			rmFltr.RunIDs = []string{runID}
+			rmFltr.CGRIDs = batch
+			var cnt int64
+			if _, cnt, err = rs.cdrDb.GetCDRs(&rmFltr, true); err != nil && err != utils.ErrNotFound {
+				return
+			}
+			removed += cnt
+		}
+	}
+	return removed, nil
+}
+
+// cdrsRetentionRemoveBatch limits the CGRIDs of one removal query
+const cdrsRetentionRemoveBatch = 1000
+
+// exportedCDRsIterator records the IDs of the CDRs read out of the wrapped iterator, indexed on RunID
+type exportedCDRsIterator struct {
+	CDRsIterator
+	ids map[string][]string
+}
+
+func (it *exportedCDRsIterator) Next() (cdr *CDR, err error) {
+	if cdr, err = it.CDRsIterator.Next(); err == nil && cdr != nil {
+		it.ids[cdr.RunID] = append(it.ids[cdr.RunID], cdr.CGRID)
+	}
+	return
+}
+
+// archiveToFile exports the CDRs matching fltr with the CDRE profile of the rule
+// returning the IDs of the exported CDRs, indexed on RunID, only if all of them were exported
+func (rs *CDRsRetentionService) archiveToFile(rule *config.CdrsRetentionRule,
+	fltr *utils.CDRsFilter, runStart time.Time) (fPath string, exported map[string][]string, err error) {
+	expTpl := rs.cfg.CdreProfiles[rule.CdreProfile]
+	var fExt string
+	switch expTpl.ExportFormat {
+	case utils.MetaFileCSV:
+		fExt = utils.CSVSuffix
+	case utils.MetaFileFWV:
+		fExt = utils.FWVSuffix
+	default:
+		return "", nil, fmt.Errorf("unsupported export format for archives: <%s>", expTpl.ExportFormat)
+	}
+	fName := fmt.Sprintf("cdrs_%s_%s_%s", rule.Tenant, rule.ToR, runStart.Format("20060102150405"))
+	fName = strings.Replace(strings.Replace(fName, utils.ANY, "all", -1), utils.MetaPrefix, "", -1) // no * in file names
+	fPath = path.Join(rule.ArchivePath, fName+fExt)
+	dbIter, err := rs.cdrDb.GetCDRsIterator(fltr)
+	if err != nil {
+		return "", nil, err
+	}
+	defer dbIter.Close()
+	cdrsIter := &exportedCDRsIterator{CDRsIterator: dbIter, ids: make(map[string][]string)}
+	cdre, err := NewCDRExporter(cdrsIter, expTpl, expTpl.ExportFormat, fPath, utils.META_NONE, utils.CDRsRetention,
+		true, expTpl.Attempts, expTpl.FieldSeparator, expTpl.UsageMultiplyFactor, expTpl.CostMultiplyFactor,
+		rs.cfg.RoundingDecimals, rs.cfg.HttpSkipTlsVerify, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if err = cdre.ExportCDRs(); err != nil {
+		return "", nil, err
+	}
+	if nrFailed := len(cdre.NegativeExports()); nrFailed != 0 {
+		return "", nil, fmt.Errorf("%d CDRs failed to export, removal skipped", nrFailed)
+	}
+	if cdre.TotalCdrs() != cdre.TotalExportedCdrs() {
+		return "", nil, fmt.Errorf("%d CDRs filtered out by CDRE profile <%s>, removal skipped",
+			cdre.TotalCdrs()-cdre.TotalExportedCdrs(), rule.CdreProfile)
+	}
+	if cdre.TotalExportedCdrs() == 0 { // no file written
+		return "", nil, nil
+	}
+	if rule.Compress {
+		if err = gzipFile(fPath); err != nil {
+			return "", nil, err
+		}
+		fPath += ".gz"
+	}
+	return fPath, cdrsIter.ids, nil
+}
+
+// gzipFile replaces the file at fPath with its compressed version, fPath.gz
+func gzipFile(fPath string) (err error) {
+	fIn, err := os.Open(fPath)
+	if err != nil {
+		return
+	}
+	defer fIn.Close()
+	fOut, err := os.Create(fPath + ".gz")
+	if err != nil {
+		return
+	}
+	gzWriter := gzip.NewWriter(fOut)
+	if _, err = io.Copy(gzWriter, fIn); err != nil {
+		fOut.Close()
+		return
+	}
+	if err = gzWriter.Close(); err != nil {
+		fOut.Close()
+		return
+	}
+	if err = fOut.Close(); err != nil {
+		return
+	}
+	return os.Remove(fPath)
+}
@@ -0,0 +1,166 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestCDRsRetentionRun(t *testing.T) {
+	archivePath, err := ioutil.TempDir("", "cdrs_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(archivePath)
+	cfg, err := config.NewCGRConfigFromJsonStringWithDefaults(fmt.Sprintf(`{
+"cdrs_retention": {
+	"enabled": true,
+	"rules": [
+		{"tenant": "cgrates.org", "tor": "*voice", "retention_days": 30, "archive": "*table"},
+		{"tenant": "cgrates.org", "tor": "*sms", "retention_days": 30, "archive": "*file",
+			"cdre_profile": "*default", "archive_path": "%s", "compress": true},
+		{"tenant": "*any", "tor": "*data", "retention_days": 60},
+	],
+},
+}`, archivePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i, cdr := range []*CDR{
+		&CDR{ToR: utils.VOICE, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -40)},
+		&CDR{ToR: utils.VOICE, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -10)},
+		&CDR{ToR: utils.VOICE, Tenant: "itsyscom.com", SetupTime: now.AddDate(0, 0, -40)},
+		&CDR{ToR: utils.SMS, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -40)},
+		&CDR{ToR: utils.SMS, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -35)},
+		&CDR{ToR: utils.DATA, Tenant: "itsyscom.com", SetupTime: now.AddDate(0, 0, -61)},
+		&CDR{ToR: utils.DATA, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -40)},
+	} {
+		cdr.CGRID = utils.Sha1(strconv.Itoa(i))
+		cdr.RunID = utils.META_DEFAULT
+		cdr.AnswerTime = cdr.SetupTime
+		if err := iDB.SetCDR(cdr, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rs := NewCDRsRetentionService(cfg, iDB)
+	rpt := rs.Run(true)
+	if len(rpt.Rules) != 3 {
+		t.Fatalf("Unexpected report: %s", utils.ToJSON(rpt))
+	}
+	for i, eRemoved := range []int64{1, 2, 1} {
+		if rpt.Rules[i].Removed != eRemoved || rpt.Rules[i].Archived != 0 || rpt.Rules[i].Error != "" {
+			t.Errorf("Rule %d, unexpected dry run report: %s", i, utils.ToJSON(rpt.Rules[i]))
+		}
+	}
+	if _, cnt, err := iDB.GetCDRs(&utils.CDRsFilter{Count: true}, false); err != nil {
+		t.Error(err)
+	} else if cnt != 7 {
+		t.Errorf("Dry run removed CDRs, remaining: %d", cnt)
+	}
+	rpt = rs.Run(false)
+	for i, eRemoved := range []int64{1, 2, 1} {
+		if rpt.Rules[i].Removed != eRemoved || rpt.Rules[i].Error != "" {
+			t.Errorf("Rule %d, unexpected report: %s", i, utils.ToJSON(rpt.Rules[i]))
+		}
+	}
+	if rpt.Rules[0].Archived != 1 || len(iDB.cdrsArchive) != 1 ||
+		iDB.cdrsArchive[0].CDR.CGRID != utils.Sha1("0") {
+		t.Errorf("Unexpected archive table, report: %s", utils.ToJSON(rpt.Rules[0]))
+	}
+	if rpt.Rules[1].Archived != 2 ||
+		rpt.Rules[1].ArchiveFile != path.Join(archivePath,
+			"cdrs_cgrates.org_sms_"+rpt.StartTime.Format("20060102150405")+utils.CSVSuffix+".gz") {
+		t.Errorf("Unexpected file archive report: %s", utils.ToJSON(rpt.Rules[1]))
+	} else if _, err := os.Stat(rpt.Rules[1].ArchiveFile); err != nil {
+		t.Error(err)
+	}
+	if _, cnt, err := iDB.GetCDRs(&utils.CDRsFilter{Count: true}, false); err != nil {
+		t.Error(err)
+	} else if cnt != 3 {
+		t.Errorf("Expecting 3 CDRs kept, received: %d", cnt)
+	}
+	rpt = rs.Run(false) // nothing left to remove
+	for i, rRpt := range rpt.Rules {
+		if rRpt.Removed != 0 || rRpt.Archived != 0 || rRpt.ArchiveFile != "" || rRpt.Error != "" {
+			t.Errorf("Rule %d, unexpected report: %s", i, utils.ToJSON(rRpt))
+		}
+	}
+}
+
+func TestCDRsRetentionRemoveExported(t *testing.T) {
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupTime := time.Now().AddDate(0, 0, -40)
+	for _, cdr := range []*CDR{
+		&CDR{CGRID: utils.Sha1("1"), RunID: utils.META_DEFAULT, ToR: utils.SMS, SetupTime: setupTime},
+		&CDR{CGRID: utils.Sha1("1"), RunID: "run2", ToR: utils.SMS, SetupTime: setupTime},             // arrived after the export
+		&CDR{CGRID: utils.Sha1("2"), RunID: utils.META_DEFAULT, ToR: utils.SMS, SetupTime: setupTime}, // arrived after the export
+		&CDR{CGRID: utils.Sha1("3"), RunID: utils.META_DEFAULT, ToR: utils.SMS, SetupTime: setupTime},
+	} {
+		if err := iDB.SetCDR(cdr, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rs := NewCDRsRetentionService(config.CgrConfig(), iDB)
+	cutoff := time.Now().AddDate(0, 0, -30)
+	if removed, err := rs.removeExportedCDRs(&utils.CDRsFilter{SetupTimeEnd: &cutoff, ToRs: []string{utils.SMS}},
+		map[string][]string{utils.META_DEFAULT: []string{utils.Sha1("1"), utils.Sha1("3")}}); err != nil {
+		t.Error(err)
+	} else if removed != 2 {
+		t.Errorf("Expecting 2 CDRs removed, received: %d", removed)
+	}
+	if cdrs, _, err := iDB.GetCDRs(&utils.CDRsFilter{}, false); err != nil {
+		t.Error(err)
+	} else if len(cdrs) != 2 {
+		t.Errorf("Expecting the 2 CDRs not exported kept, received: %s", utils.ToJSON(cdrs))
+	}
+}
+
+func TestCDRsPartitionName(t *testing.T) {
+	tm := time.Date(2017, 12, 31, 13, 14, 15, 0, time.UTC)
+	if start := cdrsPartitionStart(utils.MetaMonthly, tm); !start.Equal(time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Received: %v", start)
+	} else if end := cdrsPartitionEnd(utils.MetaMonthly, start); !end.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Received: %v", end)
+	} else if name := cdrsPartitionName(utils.MetaMonthly, start); name != "p201712" {
+		t.Errorf("Received: %s", name)
+	}
+	if start := cdrsPartitionStart(utils.MetaDaily, tm); !start.Equal(time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Received: %v", start)
+	} else if end := cdrsPartitionEnd(utils.MetaDaily, start); !end.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Received: %v", end)
+	} else if name := cdrsPartitionName(utils.MetaDaily, start); name != "p20171231" {
+		t.Errorf("Received: %s", name)
+	}
+}
@@ -0,0 +1,559 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+)
+
+const (
+	DataDBDumpFormat  = "cgrates_datadb_dump"
+	DataDBDumpVersion = 1
+)
+
+// DataDBDumpHeader opens a DataDB dump, identifying its format
+type DataDBDumpHeader struct {
+	Format     string
+	Version    int
+	CGRVersion string
+	CreatedAt  time.Time
+}
+
+// DataDBDumpRecord is one item out of DataDB, JSON encoded so the dump does not depend on the DataDB type or marshaler
+type DataDBDumpRecord struct {
+	Prefix string // category of the item, the DataDB key prefix for most of them
+	ID     string // key of the item within its category
+	Value  json.RawMessage
+}
+
+// dataDBDumpPrefixes are the categories listed with GetKeysForPrefix, in the order they are dumped and restored
+// reverse indexes (destinations, aliases) are not dumped, being rebuilt on restore
+var dataDBDumpPrefixes = []string{
+	utils.TimingsPrefix,
+	utils.FilterPrefix,
+	utils.DESTINATION_PREFIX,
+	utils.RATING_PLAN_PREFIX,
+	utils.RATING_PROFILE_PREFIX,
+	utils.LCR_PREFIX,
+	utils.DERIVEDCHARGERS_PREFIX,
+	utils.ACTION_PREFIX,
+	utils.ACTION_TRIGGER_PREFIX,
+	utils.SHARED_GROUP_PREFIX,
+	utils.ACCOUNT_PREFIX,
+	utils.ACTION_PLAN_PREFIX,
+	utils.AccountActionPlansPrefix,
+	utils.ALIASES_PREFIX,
+	utils.ResourceProfilesPrefix,
+	utils.ResourcesPrefix,
+	utils.StatQueueProfilePrefix,
+	utils.StatQueuePrefix,
+	utils.ThresholdProfilePrefix,
+	utils.ThresholdPrefix,
+	utils.TierCounterPrefix,
+	utils.TierEventUsagePrefix,
+	utils.ExchangeRatePrefix,
+	utils.TaxRatesPrefix,
+}
+
+// dataDBDumpIndexes are the filter indexes, stored per tenant
+var dataDBDumpIndexes = map[string]string{ // index prefix: prefix of the indexed profiles
+	utils.ResourceProfilesStringIndex: utils.ResourceProfilesPrefix,
+	utils.StatQueuesStringIndex:       utils.StatQueueProfilePrefix,
+	utils.ThresholdsIndex:             utils.ThresholdProfilePrefix,
+}
+
+type dataDBDumpWriter struct {
+	enc   *json.Encoder
+	stats map[string]int
+}
+
+func (dw *dataDBDumpWriter) write(prefix, id string, val interface{}) (err error) {
+	rec := &DataDBDumpRecord{Prefix: prefix, ID: id}
+	if rec.Value, err = json.Marshal(val); err != nil {
+		return
+	}
+	if err = dw.enc.Encode(rec); err != nil {
+		return
+	}
+	dw.stats[prefix] += 1
+	return
+}
+
+// DataDBDumpReport sums up one dump
+// the dump is not a snapshot: the items are read one by one while the engine keeps changing them,
+// each of them as it was at some moment between StartTime and FinishTime
+// the action plans and the account action plans are read under the action plans lock, consistent with each other
+type DataDBDumpReport struct {
+	StartTime  time.Time
+	FinishTime time.Time
+	Items      map[string]int // number of items dumped per category
+}
+
+// DumpDataDB writes the content of dataDB as gzipped JSON records
+func DumpDataDB(dataDB DataDB, w io.Writer) (rpt *DataDBDumpReport, err error) {
+	rpt = &DataDBDumpReport{StartTime: time.Now()}
+	gzWriter := gzip.NewWriter(w)
+	dw := &dataDBDumpWriter{enc: json.NewEncoder(gzWriter), stats: make(map[string]int)}
+	if err = dw.enc.Encode(&DataDBDumpHeader{Format: DataDBDumpFormat, Version: DataDBDumpVersion,
+		CGRVersion: utils.VERSION, CreatedAt: rpt.StartTime}); err != nil {
+		return nil, err
+	}
+	if err = dumpDataDBItems(dataDB, dw); err != nil {
+		return nil, err
+	}
+	if err = gzWriter.Close(); err != nil {
+		return nil, err
+	}
+	rpt.FinishTime = time.Now()
+	rpt.Items = dw.stats
+	return
+}
+
+func dumpDataDBItems(dataDB DataDB, dw *dataDBDumpWriter) (err error) {
+	tenants := make(map[string]map[string]bool) // tenants of the indexed profiles, per profiles prefix
+	for _, prfx := range dataDBDumpPrefixes {
+		switch prfx {
+		case utils.AccountActionPlansPrefix: // dumped together with the action plans
+		case utils.ACTION_PLAN_PREFIX: // locked as the scheduler and the APIs do when changing them
+			_, err = guardian.Guardian.Guard(func() (interface{}, error) {
+				for _, aplPrfx := range []string{utils.ACTION_PLAN_PREFIX, utils.AccountActionPlansPrefix} {
+					if err := dumpDataDBPrefix(dataDB, dw, aplPrfx, tenants); err != nil {
+						return nil, err
+					}
+				}
+				return nil, nil
+			}, 0, utils.ACTION_PLAN_PREFIX)
+		default:
+			err = dumpDataDBPrefix(dataDB, dw, prfx, tenants)
+		}
+		if err != nil {
+			return
+		}
+	}
+	for idxPrfx, prfPrfx := range dataDBDumpIndexes {
+		for tnt := range tenants[prfPrfx] {
+			if idx, err := dataDB.GetReqFilterIndexes(idxPrfx + tnt); err == utils.ErrNotFound {
+				continue
+			} else if err != nil {
+				return err
+			} else if err = dw.write(idxPrfx, tnt, idx); err != nil {
+				return err
+			}
+		}
+	}
+	if usrs, err := dataDB.GetUsers(); err != nil && err != utils.ErrNotFound {
+		return err
+	} else {
+		for _, usr := range usrs {
+			if err = dw.write(utils.USERS_PREFIX, usr.GetId(), usr); err != nil {
+				return err
+			}
+		}
+	}
+	if subscribers, err := dataDB.GetSubscribers(); err != nil && err != utils.ErrNotFound {
+		return err
+	} else {
+		for key, sub := range subscribers {
+			if err = dw.write(utils.PUBSUB_SUBSCRIBERS_PREFIX, key, sub); err != nil {
+				return err
+			}
+		}
+	}
+	if cdrStats, err := dataDB.GetAllCdrStats(); err != nil && err != utils.ErrNotFound {
+		return err
+	} else {
+		for _, cs := range cdrStats {
+			if err = dw.write(utils.CDR_STATS_PREFIX, cs.Id, cs); err != nil {
+				return err
+			}
+			if sq, err := dataDB.GetCdrStatsQueue(cs.Id); err == utils.ErrNotFound {
+				continue
+			} else if err != nil {
+				return err
+			} else if err = dw.write(utils.CDR_STATS_QUEUE_PREFIX, cs.Id, sq); err != nil {
+				return err
+			}
+		}
+	}
+	if ldHist, err := dataDB.GetLoadHistory(-1, true, utils.NonTransactional); err != nil && err != utils.ErrNotFound {
+		return err
+	} else if len(ldHist) != 0 {
+		if err = dw.write(utils.LOADINST_KEY, "", ldHist); err != nil {
+			return err
+		}
+	}
+	if vrs, err := dataDB.GetVersions(utils.TBLVersions); err != nil && err != utils.ErrNotFound {
+		return err
+	} else if len(vrs) != 0 {
+		if err = dw.write(utils.VERSION_PREFIX, "", vrs); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+// dumpDataDBPrefix writes the items of one category, collecting the tenants of the indexed profiles
+func dumpDataDBPrefix(dataDB DataDB, dw *dataDBDumpWriter, prfx string, tenants map[string]map[string]bool) (err error) {
+	keys, err := dataDB.GetKeysForPrefix(prfx)
+	if err != nil {
+		return fmt.Errorf("listing keys for prefix <%s>, error: %s", prfx, err.Error())
+	}
+	for _, key := range keys {
+		id := key[len(prfx):]
+		val, err := getDataDBDumpItem(dataDB, prfx, id)
+		if err == utils.ErrNotFound { // removed meanwhile
+			continue
+		} else if err != nil {
+			return fmt.Errorf("reading <%s>, error: %s", key, err.Error())
+		}
+		if err = dw.write(prfx, id, val); err != nil {
+			return err
+		}
+		if tntID := utils.NewTenantID(id); tntID.Tenant != "" {
+			if _, has := tenants[prfx]; !has {
+				tenants[prfx] = make(map[string]bool)
+			}
+			tenants[prfx][tntID.Tenant] = true
+		}
+	}
+	return
+}
+
+// getDataDBDumpItem reads the item with id out of its category, bypassing the cache
+func getDataDBDumpItem(dataDB DataDB, prfx, id string) (val interface{}, err error) {
+	tntID := utils.NewTenantID(id)
+	switch prfx {
+	case utils.TimingsPrefix:
+		return dataDB.GetTiming(id, true, utils.NonTransactional)
+	case utils.FilterPrefix:
+		return dataDB.GetFilter(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.DESTINATION_PREFIX:
+		return dataDB.GetDestination(id, true, utils.NonTransactional)
+	case utils.RATING_PLAN_PREFIX:
+		return dataDB.GetRatingPlan(id, true, utils.NonTransactional)
+	case utils.RATING_PROFILE_PREFIX:
+		return dataDB.GetRatingProfile(id, true, utils.NonTransactional)
+	case utils.LCR_PREFIX:
+		return dataDB.GetLCR(id, true, utils.NonTransactional)
+	case utils.DERIVEDCHARGERS_PREFIX:
+		return dataDB.GetDerivedChargers(id, true, utils.NonTransactional)
+	case utils.ACTION_PREFIX:
+		return dataDB.GetActions(id, true, utils.NonTransactional)
+	case utils.ACTION_TRIGGER_PREFIX:
+		return dataDB.GetActionTriggers(id, true, utils.NonTransactional)
+	case utils.SHARED_GROUP_PREFIX:
+		return dataDB.GetSharedGroup(id, true, utils.NonTransactional)
+	case utils.ACCOUNT_PREFIX:
+		return dataDB.GetAccount(id)
+	case utils.ACTION_PLAN_PREFIX:
+		return dataDB.GetActionPlan(id, true, utils.NonTransactional)
+	case utils.AccountActionPlansPrefix:
+		return dataDB.GetAccountActionPlans(id, true, utils.NonTransactional)
+	case utils.ALIASES_PREFIX:
+		return dataDB.GetAlias(id, true, utils.NonTransactional)
+	case utils.ResourceProfilesPrefix:
+		return dataDB.GetResourceProfile(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.ResourcesPrefix:
+		return dataDB.GetResource(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.StatQueueProfilePrefix:
+		return dataDB.GetStatQueueProfile(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.StatQueuePrefix: // metrics are stored marshaled with the DataDB marshaler
+		ssq, err := dataDB.GetStoredStatQueue(tntID.Tenant, tntID.ID)
+		if err != nil {
+			return nil, err
+		}
+		sq, err := ssq.AsStatQueue(dataDB.Marshaler())
+		if err != nil {
+			return nil, err
+		}
+		return NewStoredStatQueue(sq, new(JSONMarshaler))
+	case utils.ThresholdProfilePrefix:
+		return dataDB.GetThresholdProfile(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.ThresholdPrefix:
+		return dataDB.GetThreshold(tntID.Tenant, tntID.ID, true, utils.NonTransactional)
+	case utils.TierCounterPrefix:
+		return dataDB.GetTierCounter(id)
+	case utils.TierEventUsagePrefix:
+		return dataDB.GetTierEventUsage(id)
+	case utils.ExchangeRatePrefix:
+		return dataDB.GetExchangeRate(id)
+	case utils.TaxRatesPrefix:
+		return dataDB.GetTaxRates(id)
+	}
+	return nil, fmt.Errorf("unsupported dump prefix: <%s>", prfx)
+}
+
+// RestoreDataDB writes into dataDB the items out of a dump created with DumpDataDB, returning the number of items restored per category
+// with dryRun the dump is only decoded, checking its consistency
+func RestoreDataDB(dataDB DataDB, r io.Reader, dryRun bool) (stats map[string]int, err error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return
+	}
+	defer gzReader.Close()
+	dec := json.NewDecoder(gzReader)
+	var hdr DataDBDumpHeader
+	if err = dec.Decode(&hdr); err != nil {
+		return nil, fmt.Errorf("decoding dump header, error: %s", err.Error())
+	}
+	if hdr.Format != DataDBDumpFormat || hdr.Version != DataDBDumpVersion {
+		return nil, fmt.Errorf("unsupported dump format: <%s>, version: %d", hdr.Format, hdr.Version)
+	}
+	stats = make(map[string]int)
+	for {
+		var rec DataDBDumpRecord
+		if err = dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decoding dump record, error: %s", err.Error())
+		}
+		if err = restoreDataDBItem(dataDB, &rec, dryRun); err != nil {
+			return nil, fmt.Errorf("restoring <%s%s>, error: %s", rec.Prefix, rec.ID, err.Error())
+		}
+		stats[rec.Prefix] += 1
+	}
+	return stats, nil
+}
+
+func restoreDataDBItem(dataDB DataDB, rec *DataDBDumpRecord, dryRun bool) (err error) {
+	switch rec.Prefix {
+	case utils.TimingsPrefix:
+		var tm *utils.TPTiming
+		if err = json.Unmarshal(rec.Value, &tm); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetTiming(tm, utils.NonTransactional)
+	case utils.FilterPrefix:
+		var fltr *Filter
+		if err = json.Unmarshal(rec.Value, &fltr); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetFilter(fltr)
+	case utils.DESTINATION_PREFIX:
+		var dst *Destination
+		if err = json.Unmarshal(rec.Value, &dst); err != nil || dryRun {
+			return
+		}
+		if err = dataDB.SetDestination(dst, utils.NonTransactional); err != nil {
+			return
+		}
+		return dataDB.SetReverseDestination(dst, utils.NonTransactional)
+	case utils.RATING_PLAN_PREFIX:
+		var rp *RatingPlan
+		if err = json.Unmarshal(rec.Value, &rp); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetRatingPlan(rp, utils.NonTransactional)
+	case utils.RATING_PROFILE_PREFIX:
+		var rpf *RatingProfile
+		if err = json.Unmarshal(rec.Value, &rpf); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetRatingProfile(rpf, utils.NonTransactional)
+	case utils.LCR_PREFIX:
+		var lcr *LCR
+		if err = json.Unmarshal(rec.Value, &lcr); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetLCR(lcr, utils.NonTransactional)
+	case utils.DERIVEDCHARGERS_PREFIX:
+		var dcs *utils.DerivedChargers
+		if err = json.Unmarshal(rec.Value, &dcs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetDerivedChargers(rec.ID, dcs, utils.NonTransactional)
+	case utils.ACTION_PREFIX:
+		var acts Actions
+		if err = json.Unmarshal(rec.Value, &acts); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetActions(rec.ID, acts, utils.NonTransactional)
+	case utils.ACTION_TRIGGER_PREFIX:
+		var atrs ActionTriggers
+		if err = json.Unmarshal(rec.Value, &atrs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetActionTriggers(rec.ID, atrs, utils.NonTransactional)
+	case utils.SHARED_GROUP_PREFIX:
+		var sg *SharedGroup
+		if err = json.Unmarshal(rec.Value, &sg); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetSharedGroup(sg, utils.NonTransactional)
+	case utils.ACCOUNT_PREFIX:
+		var acnt *Account
+		if err = json.Unmarshal(rec.Value, &acnt); err != nil || dryRun {
+			return
+		}
+		acnt.Revision = 0 // the dump overrides the stored account
+		return dataDB.SetAccount(acnt)
+	case utils.ACTION_PLAN_PREFIX:
+		var apl *ActionPlan
+		if err = json.Unmarshal(rec.Value, &apl); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetActionPlan(rec.ID, apl, true, utils.NonTransactional)
+	case utils.AccountActionPlansPrefix:
+		var aplIDs []string
+		if err = json.Unmarshal(rec.Value, &aplIDs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetAccountActionPlans(rec.ID, aplIDs, true)
+	case utils.ALIASES_PREFIX:
+		var al *Alias
+		if err = json.Unmarshal(rec.Value, &al); err != nil || dryRun {
+			return
+		}
+		if err = dataDB.SetAlias(al, utils.NonTransactional); err != nil {
+			return
+		}
+		return dataDB.SetReverseAlias(al, utils.NonTransactional)
+	case utils.ResourceProfilesPrefix:
+		var rsp *ResourceProfile
+		if err = json.Unmarshal(rec.Value, &rsp); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetResourceProfile(rsp)
+	case utils.ResourcesPrefix:
+		var rs *Resource
+		if err = json.Unmarshal(rec.Value, &rs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetResource(rs)
+	case utils.StatQueueProfilePrefix:
+		var sqp *StatQueueProfile
+		if err = json.Unmarshal(rec.Value, &sqp); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetStatQueueProfile(sqp)
+	case utils.StatQueuePrefix:
+		var ssq *StoredStatQueue
+		if err = json.Unmarshal(rec.Value, &ssq); err != nil {
+			return
+		}
+		var sq *StatQueue
+		if sq, err = ssq.AsStatQueue(new(JSONMarshaler)); err != nil || dryRun {
+			return
+		}
+		if ssq, err = NewStoredStatQueue(sq, dataDB.Marshaler()); err != nil {
+			return
+		}
+		return dataDB.SetStoredStatQueue(ssq)
+	case utils.ThresholdProfilePrefix:
+		var thp *ThresholdProfile
+		if err = json.Unmarshal(rec.Value, &thp); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetThresholdProfile(thp)
+	case utils.ThresholdPrefix:
+		var th *Threshold
+		if err = json.Unmarshal(rec.Value, &th); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetThreshold(th)
+	case utils.TierCounterPrefix:
+		var tc *TierCounter
+		if err = json.Unmarshal(rec.Value, &tc); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetTierCounter(tc)
+	case utils.TierEventUsagePrefix:
+		var teu *TierEventUsage
+		if err = json.Unmarshal(rec.Value, &teu); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetTierEventUsage(teu)
+	case utils.ExchangeRatePrefix:
+		var fx *ExchangeRate
+		if err = json.Unmarshal(rec.Value, &fx); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetExchangeRate(fx)
+	case utils.TaxRatesPrefix:
+		var txrs TaxRates
+		if err = json.Unmarshal(rec.Value, &txrs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetTaxRates(rec.ID, txrs)
+	case utils.ResourceProfilesStringIndex, utils.StatQueuesStringIndex, utils.ThresholdsIndex:
+		var idx map[string]map[string]utils.StringMap
+		if err = json.Unmarshal(rec.Value, &idx); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetReqFilterIndexes(rec.Prefix+rec.ID, idx)
+	case utils.USERS_PREFIX:
+		var usr *UserProfile
+		if err = json.Unmarshal(rec.Value, &usr); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetUser(usr)
+	case utils.PUBSUB_SUBSCRIBERS_PREFIX:
+		var sub *SubscriberData
+		if err = json.Unmarshal(rec.Value, &sub); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetSubscriber(rec.ID, sub)
+	case utils.CDR_STATS_PREFIX:
+		var cs *CdrStats
+		if err = json.Unmarshal(rec.Value, &cs); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetCdrStats(cs)
+	case utils.CDR_STATS_QUEUE_PREFIX:
+		var sq *CDRStatsQueue
+		if err = json.Unmarshal(rec.Value, &sq); err != nil || dryRun {
+			return
+		}
+		return dataDB.SetCdrStatsQueue(sq)
+	case utils.LOADINST_KEY:
+		var ldHist []*utils.LoadInstance
+		if err = json.Unmarshal(rec.Value, &ldHist); err != nil || dryRun {
+			return
+		}
+		for i := len(ldHist) - 1; i >= 0; i-- { // newest first in the dump
+			if err = dataDB.AddLoadHistory(ldHist[i], len(ldHist), utils.NonTransactional); err != nil {
+				return
+			}
+		}
+		return
+	case utils.VERSION_PREFIX:
+		var vrs Versions
+		if err = json.Unmarshal(rec.Value, &vrs); err != nil || dryRun {
+			return
+		}
+		dataDBVrs := make(Versions) // StorDB versions might be present when dumping out of mongo
+		for item := range CurrentDataDBVersions() {
+			if v, has := vrs[item]; has {
+				dataDBVrs[item] = v
+			}
+		}
+		if len(dataDBVrs) == 0 {
+			return
+		}
+		return dataDB.SetVersions(dataDBVrs, false)
+	}
+	return fmt.Errorf("unsupported dump prefix: <%s>", rec.Prefix)
+}
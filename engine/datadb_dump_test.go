@@ -0,0 +1,142 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
+)
+
+func TestDataDBDumpRestore(t *testing.T) {
+	defer func(hs rpcclient.RpcClientConnection) { historyScribe = hs }(historyScribe)
+	historyScribe = nil // keep the destinations out of the history checked by other tests
+	srcDB, err := NewMapStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := &Destination{Id: "DST_1001", Prefixes: []string{"1001", "1002"}}
+	acnt := &Account{ID: "cgrates.org:1001",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{ID: "MB1", Value: 10, Weight: 10}}}}
+	rsp := &ResourceProfile{Tenant: "cgrates.org", ID: "RES1", Limit: 2, Stored: true}
+	asr, _ := NewStatMetric(utils.MetaASR, 0)
+	asr.AddEvent(&StatEvent{Tenant: "cgrates.org", ID: "EV1",
+		Fields: map[string]interface{}{utils.ANSWER_TIME: "2017-12-01T14:00:00Z"}})
+	sq := &StatQueue{Tenant: "cgrates.org", ID: "SQ1", SQMetrics: map[string]StatMetric{utils.MetaASR: asr}}
+	ssq, err := NewStoredStatQueue(sq, srcDB.Marshaler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	usr := &UserProfile{Tenant: "cgrates.org", UserName: "dan", Profile: map[string]string{"Account": "1001"}}
+	al := &Alias{Direction: utils.OUT, Tenant: "cgrates.org", Category: "call", Account: "dan", Subject: "dan",
+		Context: utils.ALIAS_CONTEXT_RATING, Values: AliasValues{&AliasValue{DestinationId: utils.ANY,
+			Pairs: AliasPairs{"Account": map[string]string{"dan": "1001"}}, Weight: 10}}}
+	rsIdx := map[string]map[string]utils.StringMap{"Account": map[string]utils.StringMap{"1001": utils.StringMap{"RES1": true}}}
+	for _, err := range []error{
+		srcDB.SetDestination(dst, utils.NonTransactional),
+		srcDB.SetReverseDestination(dst, utils.NonTransactional),
+		srcDB.SetAccount(acnt),
+		srcDB.SetResourceProfile(rsp),
+		srcDB.SetReqFilterIndexes(utils.ResourceProfilesStringIndex+"cgrates.org", rsIdx),
+		srcDB.SetStoredStatQueue(ssq),
+		srcDB.SetUser(usr),
+		srcDB.SetAlias(al, utils.NonTransactional),
+		srcDB.SetVersions(CurrentDataDBVersions(), false),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var dump bytes.Buffer
+	eStats := map[string]int{utils.DESTINATION_PREFIX: 1, utils.ACCOUNT_PREFIX: 1, utils.ResourceProfilesPrefix: 1,
+		utils.ResourceProfilesStringIndex: 1, utils.StatQueuePrefix: 1, utils.USERS_PREFIX: 1,
+		utils.ALIASES_PREFIX: 1, utils.VERSION_PREFIX: 1}
+	if rpt, err := DumpDataDB(srcDB, &dump); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(eStats, rpt.Items) {
+		t.Errorf("Expecting: %+v, received: %+v", eStats, rpt.Items)
+	} else if rpt.StartTime.IsZero() || rpt.FinishTime.Before(rpt.StartTime) {
+		t.Errorf("Unexpected report times: %s", utils.ToJSON(rpt))
+	}
+	dstDB, err := NewMapStorageJson() // different marshaler than the source
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := RestoreDataDB(dstDB, bytes.NewReader(dump.Bytes()), true); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(eStats, stats) {
+		t.Errorf("Expecting: %+v, received: %+v", eStats, stats)
+	} else if empty, _ := dstDB.IsDBEmpty(); !empty {
+		t.Error("Dry run restore should not write")
+	}
+	if stats, err := RestoreDataDB(dstDB, bytes.NewReader(dump.Bytes()), false); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(eStats, stats) {
+		t.Errorf("Expecting: %+v, received: %+v", eStats, stats)
+	}
+	if rcv, err := dstDB.GetDestination(dst.Id, true, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(dst, rcv) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(dst), utils.ToJSON(rcv))
+	}
+	if rcv, err := dstDB.GetReverseDestination("1002", true, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual([]string{dst.Id}, rcv) {
+		t.Errorf("Received: %+v", rcv)
+	}
+	if rcv, err := dstDB.GetAccount(acnt.ID); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 10 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if rcv, err := dstDB.GetResourceProfile(rsp.Tenant, rsp.ID, true, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rsp, rcv) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(rsp), utils.ToJSON(rcv))
+	}
+	if rcv, err := dstDB.GetReqFilterIndexes(utils.ResourceProfilesStringIndex + "cgrates.org"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rsIdx, rcv) {
+		t.Errorf("Expecting: %+v, received: %+v", rsIdx, rcv)
+	}
+	if rcv, err := dstDB.GetStoredStatQueue(sq.Tenant, sq.ID); err != nil {
+		t.Error(err)
+	} else if rcvSQ, err := rcv.AsStatQueue(dstDB.Marshaler()); err != nil {
+		t.Error(err)
+	} else if rcvSQ.SQMetrics[utils.MetaASR].GetValue() != asr.GetValue() {
+		t.Errorf("Expecting: %v, received: %v", asr.GetValue(), rcvSQ.SQMetrics[utils.MetaASR].GetValue())
+	}
+	if rcv, err := dstDB.GetUser(usr.GetId()); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(usr, rcv) {
+		t.Errorf("Expecting: %+v, received: %+v", usr, rcv)
+	}
+	if rcv, err := dstDB.GetReverseAlias(al.ReverseAliasIDs()[0], true, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 1 {
+		t.Errorf("Received: %+v", rcv)
+	}
+	if rcv, err := dstDB.GetVersions(utils.TBLVersions); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(CurrentDataDBVersions(), rcv) {
+		t.Errorf("Expecting: %+v, received: %+v", CurrentDataDBVersions(), rcv)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// ExchangeRate converts amounts from one currency into another, the conversion rate changing in time
+type ExchangeRate struct {
+	ID    string // <FromCurrency>:<ToCurrency>
+	Rates ExchangeRateValues
+}
+
+// ExchangeRateValue is the conversion rate applied starting with ActivationTime
+type ExchangeRateValue struct {
+	ActivationTime time.Time
+	Rate           float64 // units of ToCurrency for one unit of FromCurrency
+}
+
+// ExchangeRateValues are kept sorted on ActivationTime
+type ExchangeRateValues []*ExchangeRateValue
+
+func (fxvs ExchangeRateValues) Len() int {
+	return len(fxvs)
+}
+
+func (fxvs ExchangeRateValues) Swap(i, j int) {
+	fxvs[i], fxvs[j] = fxvs[j], fxvs[i]
+}
+
+func (fxvs ExchangeRateValues) Less(i, j int) bool {
+	return fxvs[i].ActivationTime.Before(fxvs[j].ActivationTime)
+}
+
+func (fxvs ExchangeRateValues) Sort() {
+	sort.Sort(fxvs)
+}
+
+func exchangeRateID(fromCurrency, toCurrency string) string {
+	return utils.ConcatenatedKey(fromCurrency, toCurrency)
+}
+
+// RateAt returns the rate active at t, has is false if none was activated till then
+func (fx *ExchangeRate) RateAt(t time.Time) (rate float64, has bool) {
+	for _, fxv := range fx.Rates {
+		if fxv.ActivationTime.After(t) {
+			break
+		}
+		rate, has = fxv.Rate, true
+	}
+	return
+}
+
+// getExchangeRate returns the ExchangeRate with id, remembering it for the rest of the debit
+func (cd *CallDescriptor) getExchangeRate(id string) (fx *ExchangeRate, err error) {
+	if fx, has := cd.exchangeRates[id]; has {
+		return fx, nil
+	}
+	if fx, err = cd.getDataManager().DataDB().GetExchangeRate(id); err != nil && err != utils.ErrNotFound {
+		return
+	}
+	if cd.exchangeRates == nil {
+		cd.exchangeRates = make(map[string]*ExchangeRate)
+	}
+	cd.exchangeRates[id] = fx
+	return fx, nil
+}
+
+// exchangeRate returns the rate converting fromCurrency into toCurrency at atTime,
+// using the inverse of the rate defined the other way round if there is no direct one
+func (cd *CallDescriptor) exchangeRate(fromCurrency, toCurrency string, atTime time.Time) (rate float64, err error) {
+	var fx *ExchangeRate
+	if fx, err = cd.getExchangeRate(exchangeRateID(fromCurrency, toCurrency)); err != nil {
+		return
+	} else if fx != nil {
+		if rate, has := fx.RateAt(atTime); has {
+			return rate, nil
+		}
+	}
+	if fx, err = cd.getExchangeRate(exchangeRateID(toCurrency, fromCurrency)); err != nil {
+		return
+	} else if fx != nil {
+		if rate, has := fx.RateAt(atTime); has && rate != 0 {
+			return 1 / rate, nil
+		}
+	}
+	return 0, utils.ErrExchangeRateNotFound
+}
+
+// balanceAmount converts cost, expressed in currency, into the currency of balance b
+// fxRate is 0 when no conversion is needed: one of the currencies is not defined or they are the same
+func (cd *CallDescriptor) balanceAmount(b *Balance, cost float64, currency string, atTime time.Time) (amount, fxRate float64, err error) {
+	if cost == 0 || b.Currency == "" || currency == "" || b.Currency == currency {
+		return cost, 0, nil
+	}
+	if fxRate, err = cd.exchangeRate(currency, b.Currency, atTime); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<RALs> converting from %s to %s for account %s, error: %s",
+			currency, b.Currency, cd.GetAccountKey(), err.Error()))
+		return
+	}
+	return utils.Round(cost*fxRate, globalRoundingDecimals, utils.ROUNDING_MIDDLE), fxRate, nil
+}
+
+// balanceAmount returns the amount debited out of the balance for cost
+func (mi *MonetaryInfo) balanceAmount(cost float64) float64 {
+	if mi.ExchangeRate == 0 {
+		return cost
+	}
+	return utils.Round(cost*mi.ExchangeRate, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+}
@@ -0,0 +1,190 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestExchangeRateRateAt(t *testing.T) {
+	fx := &ExchangeRate{
+		ID: "EUR:USD",
+		Rates: ExchangeRateValues{
+			&ExchangeRateValue{ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.05},
+			&ExchangeRateValue{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.1},
+		},
+	}
+	fx.Rates.Sort()
+	if _, has := fx.RateAt(time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)); has {
+		t.Error("Expecting no rate before activation")
+	}
+	if rate, has := fx.RateAt(time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC)); !has || rate != 1.1 {
+		t.Errorf("Expecting 1.1, received: %v", rate)
+	}
+	if rate, has := fx.RateAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)); !has || rate != 1.05 {
+		t.Errorf("Expecting 1.05, received: %v", rate)
+	}
+}
+
+func TestCallDescriptorExchangeRateInverse(t *testing.T) {
+	if err := dm.DataDB().SetExchangeRate(&ExchangeRate{
+		ID: "CHF:RON",
+		Rates: ExchangeRateValues{
+			&ExchangeRateValue{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 4},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cd := new(CallDescriptor)
+	atTime := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	if rate, err := cd.exchangeRate("CHF", "RON", atTime); err != nil {
+		t.Error(err)
+	} else if rate != 4 {
+		t.Errorf("Expecting 4, received: %v", rate)
+	}
+	if rate, err := cd.exchangeRate("RON", "CHF", atTime); err != nil {
+		t.Error(err)
+	} else if rate != 0.25 {
+		t.Errorf("Expecting 0.25, received: %v", rate)
+	}
+	if _, err := cd.exchangeRate("RON", "JPY", atTime); err != utils.ErrExchangeRateNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrExchangeRateNotFound, err)
+	}
+	if err := dm.DataDB().RemoveExchangeRate("CHF:RON"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDebitCreditMoneyExchangeRate(t *testing.T) {
+	if err := dm.DataDB().SetExchangeRate(&ExchangeRate{
+		ID: "CAD:USD",
+		Rates: ExchangeRateValues{
+			&ExchangeRateValue{ActivationTime: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer dm.DataDB().RemoveExchangeRate("CAD:USD")
+	cc := &CallCost{
+		Direction:   utils.OUT,
+		Destination: "0723045326",
+		Timespans: []*TimeSpan{
+			&TimeSpan{
+				TimeStart:     time.Date(2013, 9, 24, 10, 48, 0, 0, time.UTC),
+				TimeEnd:       time.Date(2013, 9, 24, 10, 48, 20, 0, time.UTC),
+				DurationIndex: 0,
+				RateInterval: &RateInterval{Rating: &RIRate{Currency: "CAD",
+					Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 1, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
+			},
+		},
+		TOR: utils.VOICE,
+	}
+	cd := &CallDescriptor{
+		TimeStart:     cc.Timespans[0].TimeStart,
+		TimeEnd:       cc.Timespans[0].TimeEnd,
+		Direction:     cc.Direction,
+		Destination:   cc.Destination,
+		TOR:           cc.TOR,
+		DurationIndex: cc.GetDuration(),
+		testCallcost:  cc,
+	}
+	acnt := &Account{ID: "cgrates.org:fx", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "usd", Value: 100, Currency: "USD"}},
+	}}
+	var err error
+	if cc, err = acnt.debitCreditBalance(cd, false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if acnt.BalanceMap[utils.MONETARY][0].GetValue() != 78 {
+		t.Errorf("Expecting 78 left, received: %v", acnt.BalanceMap[utils.MONETARY][0].GetValue())
+	}
+	mi := cc.Timespans[0].Increments[0].BalanceInfo.Monetary
+	if mi.UUID != "usd" || mi.Currency != "USD" || mi.ExchangeRate != 1.1 {
+		t.Errorf("Unexpected monetary info: %+v", mi)
+	}
+	// a balance in the currency of the rates is debited 1:1
+	acnt.BalanceMap[utils.MONETARY] = Balances{&Balance{Uuid: "cad", Value: 100, Currency: "CAD"}}
+	cd.testCallcost = cc
+	for _, ts := range cc.Timespans {
+		ts.Increments = nil
+	}
+	if _, err = acnt.debitCreditBalance(cd, false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if acnt.BalanceMap[utils.MONETARY][0].GetValue() != 80 {
+		t.Errorf("Expecting 80 left, received: %v", acnt.BalanceMap[utils.MONETARY][0].GetValue())
+	}
+}
+
+func TestMaxSessionDurationExchangeRate(t *testing.T) {
+	if err := dm.DataDB().SetExchangeRate(&ExchangeRate{
+		ID: "CAD:USD",
+		Rates: ExchangeRateValues{
+			&ExchangeRateValue{ActivationTime: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.5},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer dm.DataDB().RemoveExchangeRate("CAD:USD")
+	rpl := &RatingPlan{
+		Id: "RP_FX_MAX",
+		Timings: map[string]*RITiming{
+			"30eab302": &RITiming{StartTime: "00:00:00"},
+		},
+		Ratings: map[string]*RIRate{
+			"b457f863": &RIRate{
+				Rates: RateGroups{
+					&Rate{GroupIntervalStart: 0, Value: 1, RateIncrement: 10 * time.Second, RateUnit: time.Second}},
+				Currency:         "CAD",
+				RoundingMethod:   utils.ROUNDING_MIDDLE,
+				RoundingDecimals: 4,
+			},
+		},
+		DestinationRates: map[string]RPRateList{
+			utils.ANY: []*RPRate{&RPRate{Timing: "30eab302", Rating: "b457f863", Weight: 10}},
+		},
+	}
+	if err := dm.DataDB().SetRatingPlan(rpl, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	if err := dm.DataDB().SetRatingProfile(&RatingProfile{Id: "*out:cgrates.org:call:fx_max",
+		RatingPlanActivations: RatingPlanActivations{&RatingPlanActivation{
+			ActivationTime: time.Date(2015, 01, 01, 8, 0, 0, 0, time.UTC),
+			RatingPlanId:   rpl.Id,
+		}},
+	}, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	acnt := &Account{ID: "cgrates.org:fx_max", BalanceMap: map[string]Balances{
+		utils.MONETARY: Balances{&Balance{Uuid: "usd", ID: utils.META_DEFAULT, Value: 10, Currency: "USD"}},
+	}}
+	cd := &CallDescriptor{Direction: utils.OUT, Category: "call", Tenant: "cgrates.org",
+		Subject: "fx_max", Account: "fx_max", Destination: "0723",
+		TimeStart: time.Date(2017, time.November, 16, 10, 0, 0, 0, time.UTC),
+		TimeEnd:   time.Date(2017, time.November, 16, 10, 0, 20, 0, time.UTC)}
+	// 10 CAD per increment, 5 USD out of the balance, covering the whole session
+	if dur, err := cd.getMaxSessionDuration(acnt); err != nil {
+		t.Error(err)
+	} else if dur != 20*time.Second {
+		t.Errorf("Expecting 20s, received: %v", dur)
+	}
+}
@@ -142,3 +142,158 @@ func TestReqFilterPassDestinations(t *testing.T) {
 		t.Error("Passing")
 	}
 }
+
+func TestReqFilterPassGreaterThan(t *testing.T) {
+	ev := map[string]interface{}{
+		utils.USAGE:       time.Duration(20 * time.Second),
+		utils.COST:        1.5,
+		utils.ANSWER_TIME: "2017-11-08T13:15:00Z",
+	}
+	rf, err := NewFilter(MetaGreaterThan, utils.USAGE, []string{"10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaLessOrEqual, utils.COST, []string{"1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaLessThan, utils.COST, []string{"1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if passes {
+		t.Error("Passing")
+	}
+	if rf, err = NewFilter(MetaGreaterOrEqual, utils.ANSWER_TIME, []string{"2017-11-08T13:15:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaGreaterThan, utils.USAGE, []string{"15"}); err != nil { // seconds
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	for _, fltrType := range []string{MetaGreaterThan, MetaLessThan} { // incomparable values
+		if rf, err = NewFilter(fltrType, utils.USAGE, []string{"cgrates"}); err != nil {
+			t.Fatal(err)
+		}
+		if passes, err := rf.Pass(ev, "", nil); err != nil {
+			t.Error(err)
+		} else if passes {
+			t.Errorf("Passing %s", fltrType)
+		}
+	}
+	if rf, err = NewFilter(MetaGreaterThan, "NonExisting", []string{"10"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if passes {
+		t.Error("Passing")
+	}
+}
+
+func TestReqFilterPassRegexpExistsCIDR(t *testing.T) {
+	ev := map[string]interface{}{
+		utils.ACCOUNT: "1001",
+		"RemoteAddr":  "192.168.56.203",
+	}
+	rf, err := NewFilter(MetaRegexp, utils.ACCOUNT, []string{"^10\\d{2}$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaExists, utils.ACCOUNT, nil); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaCIDR, "RemoteAddr", []string{"192.168.56.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter(MetaCIDR, "RemoteAddr", []string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if passes {
+		t.Error("Passing")
+	}
+	if _, err = NewFilter(MetaCIDR, "RemoteAddr", []string{"10.0.0.0"}); err == nil {
+		t.Error("Expecting error on invalid CIDR")
+	}
+}
+
+func TestReqFilterPassNegative(t *testing.T) {
+	ev := map[string]interface{}{
+		utils.ACCOUNT: "1001",
+	}
+	rf, err := NewFilter(MetaNot+"string", utils.ACCOUNT, []string{"1002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if rf, err = NewFilter("*notexists", utils.ACCOUNT, nil); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if passes {
+		t.Error("Passing")
+	}
+	if rf, err = NewFilter("*notexists", utils.SUBJECT, nil); err != nil {
+		t.Fatal(err)
+	}
+	if passes, err := rf.Pass(ev, "", nil); err != nil {
+		t.Error(err)
+	} else if !passes {
+		t.Error("Not passing")
+	}
+	if _, err = NewFilter("*notsupported", utils.ACCOUNT, nil); err == nil {
+		t.Error("Expecting unsupported filter type")
+	}
+}
+
+func TestFilterStatSMetricWithField(t *testing.T) {
+	rf, err := NewFilter(MetaStatS, "", []string{"Stats1:*min_sum:Usage:60"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := rf.statSThresholds[0]; st.QueueID != "Stats1" ||
+		st.ThresholdType != "*min_sum:Usage" || st.ThresholdValue != 60 {
+		t.Errorf("received: %+v", st)
+	}
+}
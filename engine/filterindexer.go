@@ -38,7 +38,7 @@ func NewReqFilterIndexer(dm *DataManager, dbKey string) (*ReqFilterIndexer, erro
 // retrieves and stores it's data from/to dataDB
 // not thread safe, meant to be used as logic within other code blocks
 type ReqFilterIndexer struct {
-	indexes       map[string]map[string]utils.StringMap // map[fieldName]map[fieldValue]utils.StringMap[resourceID]
+	indexes       map[string]map[string]utils.StringMap // map[fieldName]map[fieldValue]utils.StringMap[resourceID], fieldName can be also filterType or filterType>fieldName
 	dm            *DataManager
 	dbKey         string          // get/store the result from/into this key
 	chngdIndxKeys utils.StringMap // keep record of the changed fieldName:fieldValue pair so we can re-cache wisely
@@ -49,33 +49,51 @@ func (rfi *ReqFilterIndexer) ChangedKeys() utils.StringMap {
 	return rfi.chngdIndxKeys
 }
 
+// prefixIndexFieldName builds the index field name used for the prefix based indexes (*string_prefix, *destinations)
+// ie: *string_prefix>Destination
+func prefixIndexFieldName(fltrType, fldName string) string {
+	return fltrType + utils.HIERARCHY_SEP + fldName
+}
+
+// indexItem adds itemID into indexes[fldName][fldVal], marking the changed key
+func (rfi *ReqFilterIndexer) indexItem(fldName, fldVal, itemID string) {
+	if _, hasIt := rfi.indexes[fldName]; !hasIt {
+		rfi.indexes[fldName] = make(map[string]utils.StringMap)
+	}
+	if _, hasIt := rfi.indexes[fldName][fldVal]; !hasIt {
+		rfi.indexes[fldName][fldVal] = make(utils.StringMap)
+	}
+	rfi.indexes[fldName][fldVal][itemID] = true
+	rfi.chngdIndxKeys[utils.ConcatenatedKey(fldName, fldVal)] = true
+}
+
 // IndexFilters parses reqFltrs, adding itemID in the indexes and marks the changed keys in chngdIndxKeys
+// *string filters are indexed on field value, *string_prefix on prefix and *destinations on destination ID,
+// the last two having also the field name indexed under filter type so the lookups are done only for indexed fields
 func (rfi *ReqFilterIndexer) IndexFilters(itemID string, reqFltrs []*Filter) {
-	var hasMetaString bool
+	var hasIndexed bool
 	for _, fltr := range reqFltrs {
-		if fltr.Type != MetaString {
-			continue
-		}
-		hasMetaString = true // Mark that we found at least one metatring so we don't index globally
-		if _, hastIt := rfi.indexes[fltr.FieldName]; !hastIt {
-			rfi.indexes[fltr.FieldName] = make(map[string]utils.StringMap)
-		}
-		for _, fldVal := range fltr.Values {
-			if _, hasIt := rfi.indexes[fltr.FieldName][fldVal]; !hasIt {
-				rfi.indexes[fltr.FieldName][fldVal] = make(utils.StringMap)
+		switch fltr.Type { // only positive filters can narrow down the matching, negated ones are checked at runtime
+		case MetaString:
+			for _, fldVal := range fltr.Values {
+				rfi.indexItem(fltr.FieldName, fldVal, itemID)
+			}
+		case MetaStringPrefix, MetaDestinations:
+			if utils.IsSliceMember(fltr.Values, "") { // empty prefix matches everything, cannot narrow down
+				continue
+			}
+			idxFldName := prefixIndexFieldName(fltr.Type, fltr.FieldName)
+			for _, fldVal := range fltr.Values {
+				rfi.indexItem(idxFldName, fldVal, itemID)
 			}
-			rfi.indexes[fltr.FieldName][fldVal][itemID] = true
-			rfi.chngdIndxKeys[utils.ConcatenatedKey(fltr.FieldName, fldVal)] = true
+			rfi.indexItem(fltr.Type, fltr.FieldName, itemID)
+		default:
+			continue
 		}
+		hasIndexed = true // Mark that we found at least one indexed filter so we don't index globally
 	}
-	if !hasMetaString {
-		if _, hasIt := rfi.indexes[utils.NOT_AVAILABLE]; !hasIt {
-			rfi.indexes[utils.NOT_AVAILABLE] = make(map[string]utils.StringMap)
-		}
-		if _, hasIt := rfi.indexes[utils.NOT_AVAILABLE][utils.NOT_AVAILABLE]; !hasIt {
-			rfi.indexes[utils.NOT_AVAILABLE][utils.NOT_AVAILABLE] = make(utils.StringMap)
-		}
-		rfi.indexes[utils.NOT_AVAILABLE][utils.NOT_AVAILABLE][itemID] = true // Fields without real field index will be located in map[NOT_AVAILABLE][NOT_AVAILABLE][rl.ID]
+	if !hasIndexed {
+		rfi.indexItem(utils.NOT_AVAILABLE, utils.NOT_AVAILABLE, itemID) // Fields without real field index will be located in map[NOT_AVAILABLE][NOT_AVAILABLE][rl.ID]
 	}
 	return
 }
@@ -0,0 +1,111 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestReqFilterIndexerIndexFilters(t *testing.T) {
+	data, _ := NewMapStorage()
+	rfi, err := NewReqFilterIndexer(NewDataManager(data), "rfi_idx_test_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfi.IndexFilters("ITEM1", []*Filter{
+		&Filter{Type: MetaString, FieldName: utils.ACCOUNT, Values: []string{"1001", "1002"}}})
+	rfi.IndexFilters("ITEM2", []*Filter{
+		&Filter{Type: MetaStringPrefix, FieldName: utils.DESTINATION, Values: []string{"+49", "+40"}}})
+	rfi.IndexFilters("ITEM3", []*Filter{
+		&Filter{Type: MetaDestinations, FieldName: utils.DESTINATION, Values: []string{"DST_RO"}}})
+	rfi.IndexFilters("ITEM4", []*Filter{
+		&Filter{Type: MetaNot + MetaStringPrefix, FieldName: utils.DESTINATION, Values: []string{"+49"}}})
+	eIdxes := map[string]map[string]utils.StringMap{
+		utils.ACCOUNT: map[string]utils.StringMap{
+			"1001": utils.StringMap{"ITEM1": true},
+			"1002": utils.StringMap{"ITEM1": true},
+		},
+		MetaStringPrefix: map[string]utils.StringMap{
+			utils.DESTINATION: utils.StringMap{"ITEM2": true},
+		},
+		"*string_prefix>Destination": map[string]utils.StringMap{
+			"+49": utils.StringMap{"ITEM2": true},
+			"+40": utils.StringMap{"ITEM2": true},
+		},
+		MetaDestinations: map[string]utils.StringMap{
+			utils.DESTINATION: utils.StringMap{"ITEM3": true},
+		},
+		"*destinations>Destination": map[string]utils.StringMap{
+			"DST_RO": utils.StringMap{"ITEM3": true},
+		},
+		utils.NOT_AVAILABLE: map[string]utils.StringMap{
+			utils.NOT_AVAILABLE: utils.StringMap{"ITEM4": true},
+		},
+	}
+	if !reflect.DeepEqual(eIdxes, rfi.indexes) {
+		t.Errorf("expecting: %+v, received: %+v", eIdxes, rfi.indexes)
+	}
+}
+
+func TestReqFilterMatchingItemIDsForEvent(t *testing.T) {
+	data, _ := NewMapStorage()
+	dmIdx := NewDataManager(data)
+	dst := &Destination{Id: "DST_RFI_RO", Prefixes: []string{"+4072", "+4073"}}
+	if err := data.SetReverseDestination(dst, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	dbKey := "rfi_match_test_"
+	rfi, err := NewReqFilterIndexer(dmIdx, dbKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfi.IndexFilters("ITEM_STRING", []*Filter{
+		&Filter{Type: MetaString, FieldName: utils.ACCOUNT, Values: []string{"1001"}}})
+	rfi.IndexFilters("ITEM_PREFIX", []*Filter{
+		&Filter{Type: MetaStringPrefix, FieldName: utils.DESTINATION, Values: []string{"+49"}}})
+	rfi.IndexFilters("ITEM_DST", []*Filter{
+		&Filter{Type: MetaDestinations, FieldName: utils.DESTINATION, Values: []string{"DST_RFI_RO"}}})
+	rfi.IndexFilters("ITEM_NA", []*Filter{
+		&Filter{Type: MetaRSRFields, Values: []string{"Subject(~^1.*1$)"}}})
+	if err := rfi.StoreIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	ev := map[string]interface{}{
+		utils.ACCOUNT:     "1001",
+		utils.DESTINATION: "+4986517174963",
+	}
+	eIDs := utils.StringMap{"ITEM_STRING": true, "ITEM_PREFIX": true, "ITEM_NA": true}
+	if rcv, err := matchingItemIDsForEvent(ev, dmIdx, dbKey); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eIDs, rcv) {
+		t.Errorf("expecting: %+v, received: %+v", eIDs, rcv)
+	}
+	ev = map[string]interface{}{
+		utils.ACCOUNT:     "1002",
+		utils.DESTINATION: "+40723045326",
+	}
+	eIDs = utils.StringMap{"ITEM_DST": true, "ITEM_NA": true}
+	if rcv, err := matchingItemIDsForEvent(ev, dmIdx, dbKey); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eIDs, rcv) {
+		t.Errorf("expecting: %+v, received: %+v", eIDs, rcv)
+	}
+}
@@ -21,7 +21,9 @@ package engine
 import (
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -30,24 +32,57 @@ import (
 )
 
 const (
-	MetaString       = "*string"
-	MetaStringPrefix = "*string_prefix"
-	MetaTimings      = "*timings"
-	MetaRSRFields    = "*rsr_fields"
-	MetaStatS        = "*stats"
-	MetaDestinations = "*destinations"
-	MetaMinCapPrefix = "*min_"
-	MetaMaxCapPrefix = "*max_"
+	MetaString         = "*string"
+	MetaStringPrefix   = "*string_prefix"
+	MetaTimings        = "*timings"
+	MetaRSRFields      = "*rsr_fields"
+	MetaStatS          = "*stats"
+	MetaDestinations   = "*destinations"
+	MetaMinCapPrefix   = "*min_"
+	MetaMaxCapPrefix   = "*max_"
+	MetaGreaterThan    = "*gt"
+	MetaGreaterOrEqual = "*gte"
+	MetaLessThan       = "*lt"
+	MetaLessOrEqual    = "*lte"
+	MetaRegexp         = "*regexp"
+	MetaExists         = "*exists"
+	MetaCIDR           = "*cidr"
+	MetaNot            = "*not" // prefix negating the filter type, ie: *notstring
 )
 
+var (
+	// filter types supported, negated versions are accepted for all of them
+	supportedFilterTypes = []string{MetaString, MetaStringPrefix, MetaTimings, MetaRSRFields,
+		MetaStatS, MetaDestinations, MetaGreaterThan, MetaGreaterOrEqual, MetaLessThan, MetaLessOrEqual,
+		MetaRegexp, MetaExists, MetaCIDR}
+	// filter types which cannot work without FieldName
+	needsFieldName = []string{MetaString, MetaStringPrefix, MetaTimings, MetaDestinations,
+		MetaGreaterThan, MetaGreaterOrEqual, MetaLessThan, MetaLessOrEqual,
+		MetaRegexp, MetaExists, MetaCIDR}
+	// filter types which cannot work without Values
+	needsValues = []string{MetaString, MetaStringPrefix, MetaTimings, MetaRSRFields, MetaStatS,
+		MetaDestinations, MetaGreaterThan, MetaGreaterOrEqual, MetaLessThan, MetaLessOrEqual,
+		MetaRegexp, MetaCIDR}
+)
+
+// filterBaseType strips the negation out of a filter type
+// returns the positive filter type and the negation flag
+func filterBaseType(fltrType string) (baseType string, negative bool) {
+	if strings.HasPrefix(fltrType, MetaNot) {
+		return utils.MetaPrefix + fltrType[len(MetaNot):], true
+	}
+	return fltrType, false
+}
+
 func NewFilter(rfType, fieldName string, vals []string) (*Filter, error) {
-	if !utils.IsSliceMember([]string{MetaStringPrefix, MetaTimings, MetaRSRFields, MetaStatS, MetaDestinations}, rfType) {
+	baseType, _ := filterBaseType(rfType)
+	if !utils.IsSliceMember(supportedFilterTypes, baseType) {
 		return nil, fmt.Errorf("Unsupported filter Type: %s", rfType)
 	}
-	if fieldName == "" && utils.IsSliceMember([]string{MetaStringPrefix, MetaTimings, MetaDestinations}, rfType) {
+	if fieldName == "" && utils.IsSliceMember(needsFieldName, baseType) {
 		return nil, fmt.Errorf("FieldName is mandatory for Type: %s", rfType)
 	}
-	if len(vals) == 0 && utils.IsSliceMember([]string{MetaStringPrefix, MetaTimings, MetaRSRFields, MetaDestinations, MetaDestinations}, rfType) {
+	if len(vals) == 0 && utils.IsSliceMember(needsValues, baseType) {
 		return nil, fmt.Errorf("Values is mandatory for Type: %s", rfType)
 	}
 	rf := &Filter{Type: rfType, FieldName: fieldName, Values: vals}
@@ -73,6 +108,8 @@ type Filter struct {
 	Values          []string            // Filter definition
 	rsrFields       utils.RSRFields     // Cache here the RSRFilter Values
 	statSThresholds []*RFStatSThreshold // Cached compiled RFStatsThreshold out of Values
+	regexps         []*regexp.Regexp    // Cached compiled regexps out of Values
+	ipNets          []*net.IPNet        // Cached compiled CIDRs out of Values
 }
 
 func (flt *Filter) TenantID() string {
@@ -81,24 +118,41 @@ func (flt *Filter) TenantID() string {
 
 // Separate method to compile RSR fields
 func (rf *Filter) CompileValues() (err error) {
-	if rf.Type == MetaRSRFields {
+	switch baseType, _ := filterBaseType(rf.Type); baseType {
+	case MetaRSRFields:
 		if rf.rsrFields, err = utils.ParseRSRFieldsFromSlice(rf.Values); err != nil {
 			return
 		}
-	} else if rf.Type == MetaStatS {
+	case MetaRegexp:
+		rf.regexps = make([]*regexp.Regexp, len(rf.Values))
+		for i, val := range rf.Values {
+			if rf.regexps[i], err = regexp.Compile(val); err != nil {
+				return
+			}
+		}
+	case MetaCIDR:
+		rf.ipNets = make([]*net.IPNet, len(rf.Values))
+		for i, val := range rf.Values {
+			if _, rf.ipNets[i], err = net.ParseCIDR(val); err != nil {
+				return
+			}
+		}
+	case MetaStatS:
 		rf.statSThresholds = make([]*RFStatSThreshold, len(rf.Values))
 		for i, val := range rf.Values {
 			valSplt := strings.Split(val, utils.InInFieldSep)
-			if len(valSplt) != 3 {
+			if len(valSplt) < 3 {
 				return fmt.Errorf("Value %s needs to contain at least 3 items", val)
 			}
-			st := &RFStatSThreshold{QueueID: valSplt[0], ThresholdType: valSplt[1]}
+			// metric IDs can contain the separator themselves, ie: *min_sum:Usage
+			st := &RFStatSThreshold{QueueID: valSplt[0],
+				ThresholdType: strings.Join(valSplt[1:len(valSplt)-1], utils.InInFieldSep)}
 			if len(st.ThresholdType) < len(MetaMinCapPrefix)+1 {
 				return fmt.Errorf("Value %s contains a unsupported ThresholdType format", val)
 			} else if !strings.HasPrefix(st.ThresholdType, MetaMinCapPrefix) && !strings.HasPrefix(st.ThresholdType, MetaMaxCapPrefix) {
 				return fmt.Errorf("Value %s contains unsupported ThresholdType prefix", val)
 			}
-			if tv, err := strconv.ParseFloat(valSplt[2], 64); err != nil {
+			if tv, err := strconv.ParseFloat(valSplt[len(valSplt)-1], 64); err != nil {
 				return err
 			} else {
 				st.ThresholdValue = tv
@@ -110,23 +164,36 @@ func (rf *Filter) CompileValues() (err error) {
 }
 
 // Pass is the method which should be used from outside.
-func (fltr *Filter) Pass(req interface{}, extraFieldsLabel string, rpcClnt rpcclient.RpcClientConnection) (bool, error) {
-	switch fltr.Type {
+func (fltr *Filter) Pass(req interface{}, extraFieldsLabel string, rpcClnt rpcclient.RpcClientConnection) (pass bool, err error) {
+	baseType, negative := filterBaseType(fltr.Type)
+	switch baseType {
 	case MetaString:
-		return fltr.passString(req, extraFieldsLabel)
+		pass, err = fltr.passString(req, extraFieldsLabel)
 	case MetaStringPrefix:
-		return fltr.passStringPrefix(req, extraFieldsLabel)
+		pass, err = fltr.passStringPrefix(req, extraFieldsLabel)
 	case MetaTimings:
-		return fltr.passTimings(req, extraFieldsLabel)
+		pass, err = fltr.passTimings(req, extraFieldsLabel)
 	case MetaDestinations:
-		return fltr.passDestinations(req, extraFieldsLabel)
+		pass, err = fltr.passDestinations(req, extraFieldsLabel)
 	case MetaRSRFields:
-		return fltr.passRSRFields(req, extraFieldsLabel)
+		pass, err = fltr.passRSRFields(req, extraFieldsLabel)
 	case MetaStatS:
-		return fltr.passStatS(req, extraFieldsLabel, rpcClnt)
+		pass, err = fltr.passStatS(req, extraFieldsLabel, rpcClnt)
+	case MetaGreaterThan, MetaGreaterOrEqual, MetaLessThan, MetaLessOrEqual:
+		pass, err = fltr.passGreaterThan(req, extraFieldsLabel, baseType)
+	case MetaRegexp:
+		pass, err = fltr.passRegexp(req, extraFieldsLabel)
+	case MetaExists:
+		pass, err = fltr.passExists(req, extraFieldsLabel)
+	case MetaCIDR:
+		pass, err = fltr.passCIDR(req, extraFieldsLabel)
 	default:
 		return false, utils.ErrNotImplemented
 	}
+	if err != nil {
+		return false, err
+	}
+	return pass != negative, nil
 }
 
 func (fltr *Filter) passString(req interface{}, extraFieldsLabel string) (bool, error) {
@@ -225,3 +292,75 @@ func (fltr *Filter) passStatS(req interface{}, extraFieldsLabel string, stats rp
 	}
 	return false, nil
 }
+
+// passGreaterThan compares the field value with the filter Values
+// numbers, durations and timestamps are supported
+func (fltr *Filter) passGreaterThan(req interface{}, extraFieldsLabel, fltrType string) (bool, error) {
+	fldIf, err := utils.ReflectFieldInterface(req, fltr.FieldName, extraFieldsLabel)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if fldStr, castStr := fldIf.(string); castStr { // attempt converting string since deserialization fails here (ie: time.Time fields)
+		fldIf = utils.StringToInterface(fldStr)
+	}
+	orEqual := fltrType == MetaGreaterOrEqual || fltrType == MetaLessThan // *lt is the opposite of *gte
+	for _, val := range fltr.Values {
+		gte, err := utils.GreaterThan(fldIf, utils.StringToInterface(val), orEqual)
+		if err != nil { // incomparable values do not match
+			continue
+		}
+		if utils.IsSliceMember([]string{MetaGreaterThan, MetaGreaterOrEqual}, fltrType) == gte {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fltr *Filter) passRegexp(req interface{}, extraFieldsLabel string) (bool, error) {
+	strVal, err := utils.ReflectFieldAsString(req, fltr.FieldName, extraFieldsLabel)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, re := range fltr.regexps {
+		if re.MatchString(strVal) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fltr *Filter) passExists(req interface{}, extraFieldsLabel string) (bool, error) {
+	if _, err := utils.ReflectFieldInterface(req, fltr.FieldName, extraFieldsLabel); err != nil {
+		if err == utils.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (fltr *Filter) passCIDR(req interface{}, extraFieldsLabel string) (bool, error) {
+	strVal, err := utils.ReflectFieldAsString(req, fltr.FieldName, extraFieldsLabel)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	ip := net.ParseIP(strVal)
+	if ip == nil {
+		return false, nil
+	}
+	for _, ipNet := range fltr.ipNets {
+		if ipNet.Contains(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
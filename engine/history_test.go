@@ -18,6 +18,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package engine
 
 import (
+	"runtime"
 	"strings"
 	"testing"
 
@@ -33,6 +34,7 @@ func TestHistoryRatinPlans(t *testing.T) {
 }
 
 func TestHistoryDestinations(t *testing.T) {
+	runtime.Gosched() // give the goroutines recording the history a chance to run
 	scribe := historyScribe.(*history.MockScribe)
 	buf := scribe.GetBuffer(history.DESTINATIONS_FN)
 	expected := `{"Id":"ALL","Prefixes":["49","41","43"]},
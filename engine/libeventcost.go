@@ -159,6 +159,7 @@ type BalanceCharge struct {
 	RatingID      string  // special price applied on this balance
 	Units         float64 // number of units charged
 	ExtraChargeID string  // used in cases when paying *voice with *monetary
+	ExchangeRate  float64 // converting the monetary Units into the currency of the balance, 0 if not converted
 }
 
 func (bc *BalanceCharge) Equals(oBC *BalanceCharge) bool {
@@ -166,7 +167,8 @@ func (bc *BalanceCharge) Equals(oBC *BalanceCharge) bool {
 		bc.BalanceUUID == oBC.BalanceUUID &&
 		bc.RatingID == oBC.RatingID &&
 		bc.Units == oBC.Units &&
-		bc.ExtraChargeID == oBC.ExtraChargeID
+		bc.ExtraChargeID == oBC.ExtraChargeID &&
+		bc.ExchangeRate == oBC.ExchangeRate
 }
 
 func (bc *BalanceCharge) Clone() *BalanceCharge {
@@ -227,6 +229,7 @@ type RatingUnit struct {
 	TimingID         string // This RatingUnit is bounded to specific timing profile
 	RatesID          string
 	RatingFiltersID  string
+	Currency         string // currency of the rates, empty if not defined
 }
 
 func (ru *RatingUnit) Equals(oRU *RatingUnit) bool {
@@ -237,7 +240,8 @@ func (ru *RatingUnit) Equals(oRU *RatingUnit) bool {
 		ru.MaxCostStrategy == oRU.MaxCostStrategy &&
 		ru.TimingID == oRU.TimingID &&
 		ru.RatesID == oRU.RatesID &&
-		ru.RatingFiltersID == oRU.RatingFiltersID
+		ru.RatingFiltersID == oRU.RatingFiltersID &&
+		ru.Currency == oRU.Currency
 }
 
 func (ru *RatingUnit) Clone() (cln *RatingUnit) {
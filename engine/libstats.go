@@ -35,9 +35,10 @@ type StatQueueProfile struct {
 	ActivationInterval *utils.ActivationInterval // Activation interval
 	QueueLength        int
 	TTL                time.Duration
-	Metrics            []string // list of metrics to build
-	Thresholds         []string // list of thresholds to be checked after changes
-	Blocker            bool     // blocker flag to stop processing on filters matched
+	BucketSize         time.Duration // aggregate the events into time buckets of this size instead of keeping each of them
+	Metrics            []string      // list of metrics to build
+	Thresholds         []string      // list of thresholds to be checked after changes
+	Blocker            bool          // blocker flag to stop processing on filters matched
 	Stored             bool
 	Weight             float64
 	MinItems           int
@@ -145,6 +146,48 @@ func (se StatEvent) Destination() (ddc string, err error) {
 	return ddcStr, nil
 }
 
+// FieldAsString returns the value of a field as string
+func (se StatEvent) FieldAsString(fldName string) (val string, err error) {
+	fldIf, has := se.Fields[fldName]
+	if !has {
+		return val, utils.ErrNotFound
+	}
+	val, canCast := utils.CastFieldIfToString(fldIf)
+	if !canCast {
+		return val, fmt.Errorf("cannot cast field: %s to string", fldName)
+	}
+	return
+}
+
+// FieldAsFloat64 returns the value of a field as float64
+// durations are converted to seconds so they can be processed together with numbers
+func (se StatEvent) FieldAsFloat64(fldName string) (val float64, err error) {
+	fldIf, has := se.Fields[fldName]
+	if !has {
+		return val, utils.ErrNotFound
+	}
+	switch fldVal := fldIf.(type) {
+	case float64:
+		return fldVal, nil
+	case int:
+		return float64(fldVal), nil
+	case int64:
+		return float64(fldVal), nil
+	case time.Duration:
+		return fldVal.Seconds(), nil
+	case string:
+		if val, err = strconv.ParseFloat(fldVal, 64); err == nil {
+			return
+		}
+		var dur time.Duration
+		if dur, err = utils.ParseDurationWithSecs(fldVal); err != nil {
+			return val, fmt.Errorf("cannot convert field: %s to float64", fldName)
+		}
+		return dur.Seconds(), nil
+	}
+	return val, fmt.Errorf("cannot convert field: %s to float64", fldName)
+}
+
 // NewStoredStatQueue initiates a StoredStatQueue out of StatQueue
 func NewStoredStatQueue(sq *StatQueue, ms Marshaler) (sSQ *StoredStatQueue, err error) {
 	sSQ = &StoredStatQueue{
@@ -219,7 +262,7 @@ type StatQueue struct {
 	Tenant  string
 	ID      string
 	SQItems []struct {
-		EventID    string     // Bounded to the original StatEvent
+		EventID    string     // Bounded to the original StatEvent, BucketID for bucketed queues
 		ExpiryTime *time.Time // Used to auto-expire events
 	}
 	SQMetrics map[string]StatMetric
@@ -275,6 +318,10 @@ func (sq *StatQueue) remOnQueueLength() {
 	if sq.sqPrfl.QueueLength <= 0 { // infinite length
 		return
 	}
+	if sq.sqPrfl.BucketSize > 0 && len(sq.SQItems) != 0 &&
+		sq.SQItems[len(sq.SQItems)-1].EventID == sq.bucketID(time.Now()) { // event goes into the current bucket
+		return
+	}
 	if len(sq.SQItems) == sq.sqPrfl.QueueLength { // reached limit, rem first element
 		itm := sq.SQItems[0]
 		sq.remEventWithID(itm.EventID)
@@ -282,8 +329,48 @@ func (sq *StatQueue) remOnQueueLength() {
 	}
 }
 
+// bucketID returns the ID of the bucket containing time t, out of bucket start time
+func (sq *StatQueue) bucketID(t time.Time) string {
+	return t.Truncate(sq.sqPrfl.BucketSize).UTC().Format(time.RFC3339Nano)
+}
+
+// addBucketEvent aggregates the event into the current bucket, creating it if needed
+// buckets are kept in SQItems so they expire and get limited like the events of unbucketed queues
+func (sq *StatQueue) addBucketEvent(ev *StatEvent) {
+	now := time.Now()
+	bucketID := sq.bucketID(now)
+	if len(sq.SQItems) == 0 || sq.SQItems[len(sq.SQItems)-1].EventID != bucketID {
+		var expTime *time.Time
+		if sq.ttl != nil && *sq.ttl > 0 {
+			expTime = utils.TimePointer(now.Truncate(sq.sqPrfl.BucketSize).Add(*sq.ttl))
+		}
+		sq.SQItems = append(sq.SQItems, struct {
+			EventID    string
+			ExpiryTime *time.Time
+		}{bucketID, expTime})
+	}
+	for metricID, metric := range sq.SQMetrics {
+		if err := metric.AddBucketEvent(bucketID, ev); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<StatQueue> metricID: %s, add eventID: %s to bucket: %s, error: %s",
+				metricID, ev.TenantID(), bucketID, err.Error()))
+		}
+	}
+}
+
 // addStatEvent computes metrics for an event
 func (sq *StatQueue) addStatEvent(ev *StatEvent) {
+	if sq.sqPrfl != nil && sq.sqPrfl.BucketSize > 0 {
+		sq.addBucketEvent(ev)
+		return
+	}
+	var expTime *time.Time
+	if sq.ttl != nil && *sq.ttl > 0 {
+		expTime = utils.TimePointer(time.Now().Add(*sq.ttl))
+	}
+	sq.SQItems = append(sq.SQItems, struct {
+		EventID    string
+		ExpiryTime *time.Time
+	}{ev.TenantID(), expTime})
 	for metricID, metric := range sq.SQMetrics {
 		if err := metric.AddEvent(ev); err != nil {
 			utils.Logger.Warning(fmt.Sprintf("<StatQueue> metricID: %s, add eventID: %s, error: %s",
@@ -292,6 +379,33 @@ func (sq *StatQueue) addStatEvent(ev *StatEvent) {
 	}
 }
 
+// thresholdEvent builds the ThresholdEvent out of the metric values of the queue
+func (sq *StatQueue) thresholdEvent() (thEv *ThresholdEvent) {
+	thEv = &ThresholdEvent{
+		Tenant: sq.Tenant,
+		ID:     utils.GenUUID(),
+		Fields: map[string]interface{}{
+			utils.EventSource: utils.StatService,
+			utils.StatID:      sq.ID,
+		},
+	}
+	if sq.sqPrfl != nil {
+		thEv.ThresholdIDs = sq.sqPrfl.Thresholds
+	}
+	for metricID, metric := range sq.SQMetrics {
+		thEv.Fields[metricID] = metric.GetValue()
+	}
+	return
+}
+
+// StatQueueMetrics is a snapshot of the metric values of a StatQueue, building the metrics history
+type StatQueueMetrics struct {
+	Tenant  string
+	ID      string
+	Time    time.Time
+	Metrics map[string]float64
+}
+
 // StatQueues is a sortable list of StatQueue
 type StatQueues []*StatQueue
 
@@ -205,3 +205,140 @@ func TestStatAddStatEvent(t *testing.T) {
 		t.Errorf("ASR: %v", asrMetric)
 	}
 }
+
+func TestStatQueueThresholdEvent(t *testing.T) {
+	sq := &StatQueue{Tenant: "cgrates.org", ID: "TestStatQueueThresholdEvent",
+		SQMetrics: map[string]StatMetric{
+			utils.MetaASR: &StatASR{
+				Answered: 1,
+				Count:    2,
+				Events: map[string]bool{
+					"cgrates.org:TestStatQueueThresholdEvent_1": true,
+					"cgrates.org:TestStatQueueThresholdEvent_2": false,
+				},
+			},
+		},
+	}
+	eFields := map[string]interface{}{
+		utils.EventSource: utils.StatService,
+		utils.StatID:      "TestStatQueueThresholdEvent",
+		utils.MetaASR:     50.0,
+	}
+	if thEv := sq.thresholdEvent(); thEv.Tenant != sq.Tenant {
+		t.Errorf("unexpected tenant: %s", thEv.Tenant)
+	} else if thEv.ID == "" {
+		t.Error("empty event ID")
+	} else if !reflect.DeepEqual(eFields, thEv.Fields) {
+		t.Errorf("expecting: %+v, received: %+v", eFields, thEv.Fields)
+	}
+}
+
+func TestStatQueueBucketed(t *testing.T) {
+	sq := &StatQueue{Tenant: "cgrates.org", ID: "TestStatQueueBucketed",
+		sqPrfl: &StatQueueProfile{BucketSize: time.Hour},
+		ttl:    utils.DurationPointer(24 * time.Hour),
+	}
+	asr, _ := NewASR(0)
+	sum, _ := NewStatMetric("*sum:Usage", 0)
+	pctl, _ := NewStatMetric("*p99:Usage", 0)
+	sq.SQMetrics = map[string]StatMetric{
+		utils.MetaASR: asr, "*sum:Usage": sum, "*p99:Usage": pctl}
+	for i := 0; i < 10; i++ {
+		ev := &StatEvent{Tenant: "cgrates.org", ID: utils.GenUUID(),
+			Fields: map[string]interface{}{utils.USAGE: time.Duration(i+1) * time.Second}}
+		if i%2 == 0 {
+			ev.Fields[utils.ANSWER_TIME] = time.Now()
+		}
+		if err := sq.ProcessEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sq.SQItems) != 1 {
+		t.Fatalf("unexpected items: %+v", sq.SQItems)
+	}
+	bucketID := sq.SQItems[0].EventID
+	if sq.SQItems[0].ExpiryTime == nil ||
+		!sq.SQItems[0].ExpiryTime.Equal(time.Now().Truncate(time.Hour).Add(24*time.Hour)) {
+		t.Errorf("unexpected expiry time: %+v", sq.SQItems[0].ExpiryTime)
+	}
+	asrMetric := asr.(*StatASR)
+	if len(asrMetric.Events) != 0 {
+		t.Errorf("unexpected events: %+v", asrMetric.Events)
+	} else if eBucket := (&StatBucket{Sum: 5, Count: 10}); !reflect.DeepEqual(eBucket, asrMetric.Buckets[bucketID]) {
+		t.Errorf("expecting: %+v, received: %+v", eBucket, asrMetric.Buckets[bucketID])
+	}
+	if val := asr.GetFloat64Value(); val != 50 {
+		t.Errorf("received ASR: %v", val)
+	}
+	if val := sum.GetFloat64Value(); val != 55 {
+		t.Errorf("received sum: %v", val)
+	}
+	if val := pctl.GetFloat64Value(); val < 8.91 || val > 9.09 { // 9 within 1% accuracy
+		t.Errorf("received percentile: %v", val)
+	}
+	sq.SQItems[0].ExpiryTime = utils.TimePointer(time.Now())
+	sq.remExpired()
+	if len(sq.SQItems) != 0 {
+		t.Errorf("unexpected items: %+v", sq.SQItems)
+	} else if len(asrMetric.Buckets) != 0 {
+		t.Errorf("unexpected buckets: %+v", asrMetric.Buckets)
+	}
+	for metricID, metric := range sq.SQMetrics {
+		if val := metric.GetFloat64Value(); val != STATS_NA {
+			t.Errorf("metric: %s, value: %v", metricID, val)
+		}
+	}
+}
+
+func TestAggregateStatQueueMetrics(t *testing.T) {
+	from := time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC)
+	sqms := []*StatQueueMetrics{
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from,
+			Metrics: map[string]float64{utils.MetaASR: 50, utils.MetaACD: STATS_NA}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from.Add(5 * time.Minute),
+			Metrics: map[string]float64{utils.MetaASR: 100, utils.MetaACD: 60}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from.Add(25 * time.Minute),
+			Metrics: map[string]float64{utils.MetaASR: 20, utils.MetaACD: STATS_NA}},
+	}
+	eSqms := []*StatQueueMetrics{
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from,
+			Metrics: map[string]float64{utils.MetaASR: 75, utils.MetaACD: 60}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from.Add(20 * time.Minute),
+			Metrics: map[string]float64{utils.MetaASR: 20, utils.MetaACD: STATS_NA}},
+	}
+	if rcv := aggregateStatQueueMetrics(sqms, from, 10*time.Minute); !reflect.DeepEqual(eSqms, rcv) {
+		t.Errorf("expecting: %s, received: %s", utils.ToJSON(eSqms), utils.ToJSON(rcv))
+	}
+	if rcv := aggregateStatQueueMetrics(sqms, time.Time{}, 10*time.Minute); !reflect.DeepEqual(eSqms, rcv) {
+		t.Errorf("expecting: %s, received: %s", utils.ToJSON(eSqms), utils.ToJSON(rcv))
+	}
+}
+
+func TestStatServiceMetricsHistoryTTL(t *testing.T) {
+	cdrDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, sqm := range []*StatQueueMetrics{
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ_TTL", Time: now.Add(-2 * time.Hour),
+			Metrics: map[string]float64{utils.MetaASR: 50}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ_TTL", Time: now.Add(-10 * time.Minute),
+			Metrics: map[string]float64{utils.MetaASR: 100}},
+	} {
+		if err := cdrDB.SetStatQueueMetrics(sqm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, _ := NewMapStorage()
+	sS, err := NewStatService(NewDataManager(data), 0, nil, cdrDB, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sS.storeMetricsHistory()
+	if sqms, err := cdrDB.GetStatQueueMetrics("cgrates.org", "SQ_TTL", time.Time{}, time.Time{}); err != nil {
+		t.Fatal(err)
+	} else if len(sqms) != 1 || sqms[0].Metrics[utils.MetaASR] != 100 {
+		t.Errorf("expecting the expired snapshot removed, received: %s", utils.ToJSON(sqms))
+	}
+}
@@ -289,6 +289,18 @@ cgrates.org,FLTR_1,*rsr_fields,,Subject(~^1.*1$);Destination(1002)
 cgrates.org,FLTR_ACNT_dan,*string,Account,dan
 cgrates.org,FLTR_DST_DE,*destinations,Destination,DST_DE
 cgrates.org,FLTR_DST_NL,*destinations,Destination,DST_NL
+`
+	exchangeRates = `
+#FromCurrency[0],ToCurrency[1],ActivationTime[2],Rate[3]
+EUR,USD,2014-01-01T00:00:00Z,1.1
+EUR,USD,2017-01-01T00:00:00Z,1.05
+GBP,EUR,2014-01-01T00:00:00Z,1.15
+`
+	taxRates = `
+#Tenant[0],ID[1],Categories[2],DestinationIDs[3],Accounts[4],Type[5],Value[6],Compound[7],Weight[8]
+cgrates.org,TAX_VAT,call;sms,,,*percent,19,false,20
+cgrates.org,TAX_LOCAL,,DST_DE,,*percent,1.5,true,10
+cgrates.org,TAX_LINE,,,dan;rif,*fixed,0.1,false,0
 `
 )
 
@@ -296,7 +308,7 @@ var csvr *TpReader
 
 func init() {
 	csvr = NewTpReader(dm.dataDB, NewStringCSVStorage(',', destinations, timings, rates, destinationRates, ratingPlans, ratingProfiles,
-		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats, users, aliases, resProfiles, stats, thresholds, filters), testTPID, "")
+		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats, users, aliases, resProfiles, stats, thresholds, filters, exchangeRates, taxRates), testTPID, "")
 
 	if err := csvr.LoadDestinations(); err != nil {
 		log.Print("error in LoadDestinations:", err)
@@ -356,6 +368,12 @@ func init() {
 	if err := csvr.LoadThresholds(); err != nil {
 		log.Print("error in LoadThresholds:", err)
 	}
+	if err := csvr.LoadExchangeRates(); err != nil {
+		log.Print("error in LoadExchangeRates:", err)
+	}
+	if err := csvr.LoadTaxRates(); err != nil {
+		log.Print("error in LoadTaxRates:", err)
+	}
 	csvr.WriteToDatabase(false, false, false)
 	cache.Flush()
 	dm.DataDB().LoadDataDBCache(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
@@ -1506,3 +1524,45 @@ func TestLoadThresholds(t *testing.T) {
 		t.Errorf("Expecting: %+v, received: %+v", eThresholds["cgrates.org"]["Threshold1"], csvr.thProfiles["cgrates.org"]["Threshold1"])
 	}
 }
+
+func TestLoadExchangeRates(t *testing.T) {
+	eFx := &ExchangeRate{
+		ID: "EUR:USD",
+		Rates: ExchangeRateValues{
+			&ExchangeRateValue{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.1},
+			&ExchangeRateValue{ActivationTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.05},
+		},
+	}
+	if len(csvr.exchangeRates) != 2 {
+		t.Error("Failed to load exchange rates: ", len(csvr.exchangeRates))
+	} else if !reflect.DeepEqual(eFx, csvr.exchangeRates["EUR:USD"]) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eFx), utils.ToJSON(csvr.exchangeRates["EUR:USD"]))
+	}
+	if fx, err := dm.DataDB().GetExchangeRate("EUR:USD"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eFx, fx) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eFx), utils.ToJSON(fx))
+	}
+}
+
+func TestLoadTaxRates(t *testing.T) {
+	eTxrs := TaxRates{
+		&TaxRate{ID: "TAX_VAT", Categories: utils.NewStringMap("call", "sms"),
+			DestinationIDs: utils.StringMap{}, Accounts: utils.StringMap{},
+			Type: utils.MetaPercent, Value: 19, Weight: 20},
+		&TaxRate{ID: "TAX_LOCAL", Categories: utils.StringMap{},
+			DestinationIDs: utils.NewStringMap("DST_DE"), Accounts: utils.StringMap{},
+			Type: utils.MetaPercent, Value: 1.5, Compound: true, Weight: 10},
+		&TaxRate{ID: "TAX_LINE", Categories: utils.StringMap{},
+			DestinationIDs: utils.StringMap{}, Accounts: utils.NewStringMap("dan", "rif"),
+			Type: utils.MetaFixed, Value: 0.1},
+	}
+	if !reflect.DeepEqual(eTxrs, csvr.taxRates["cgrates.org"]) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTxrs), utils.ToJSON(csvr.taxRates["cgrates.org"]))
+	}
+	if txrs, err := dm.DataDB().GetTaxRates("cgrates.org"); err != nil {
+		t.Error(err)
+	} else if len(txrs) != len(eTxrs) || txrs[0].ID != "TAX_VAT" {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eTxrs), utils.ToJSON(txrs))
+	}
+}
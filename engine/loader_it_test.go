@@ -108,6 +108,8 @@ func TestLoaderITLoadFromCSV(t *testing.T) {
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.StatsCsv),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ThresholdsCsv),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.FiltersCsv),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ExchangeRatesCsv),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.TaxRatesCsv),
 	), "", "")
 
 	if err = loader.LoadDestinations(); err != nil {
@@ -40,7 +40,13 @@ func csvLoad(s interface{}, values []string) (interface{}, error) {
 		index := field.Tag.Get("index")
 		if index != "" {
 			idx, err := strconv.Atoi(index)
-			if err != nil || len(values) <= idx {
+			if err != nil {
+				return nil, fmt.Errorf("invalid %v.%v index %v", st.Name(), field.Name, index)
+			}
+			if len(values) <= idx {
+				if field.Tag.Get("optional") == "true" { // column missing in files written before it was introduced
+					continue
+				}
 				return nil, fmt.Errorf("invalid %v.%v index %v", st.Name(), field.Name, index)
 			}
 			if re != "" {
@@ -172,6 +178,8 @@ func modelEqual(this interface{}, other interface{}) bool {
 	return true
 }
 
+// getColumnCount returns the number of CSV columns of a model,
+// -1 if some of them are optional so the record length is checked by csvLoad
 func getColumnCount(s interface{}) int {
 	st := reflect.TypeOf(s)
 	numFields := st.NumField()
@@ -180,6 +188,9 @@ func getColumnCount(s interface{}) int {
 		field := st.Field(i)
 		index := field.Tag.Get("index")
 		if index != "" {
+			if field.Tag.Get("optional") == "true" {
+				return -1
+			}
 			count++
 		}
 	}
@@ -411,6 +422,9 @@ func (tps TpDestinationRates) AsMapDestinationRates() (map[string]*utils.TPDesti
 					RoundingDecimals: tp.RoundingDecimals,
 					MaxCost:          tp.MaxCost,
 					MaxCostStrategy:  tp.MaxCostStrategy,
+					TierUsageStart:   tp.TierUsageStart,
+					TierPeriod:       tp.TierPeriod,
+					Currency:         tp.Currency,
 				},
 			},
 		}
@@ -460,6 +474,9 @@ func APItoModelDestinationRate(d *utils.TPDestinationRate) (result TpDestination
 				RoundingDecimals: dr.RoundingDecimals,
 				MaxCost:          dr.MaxCost,
 				MaxCostStrategy:  dr.MaxCostStrategy,
+				TierUsageStart:   dr.TierUsageStart,
+				TierPeriod:       dr.TierPeriod,
+				Currency:         dr.Currency,
 			})
 		}
 		if len(d.DestinationRates) == 0 {
@@ -535,11 +552,17 @@ func GetRateInterval(rpl *utils.TPRatingPlanBinding, dr *utils.DestinationRate)
 			RoundingDecimals: dr.RoundingDecimals,
 			MaxCost:          dr.MaxCost,
 			MaxCostStrategy:  dr.MaxCostStrategy,
+			Currency:         dr.Currency,
 			tag:              dr.Rate.ID,
 		},
 	}
-	for _, rl := range dr.Rate.RateSlots {
-		i.Rating.Rates = append(i.Rating.Rates, &Rate{
+	i.Rating.Rates = rateSlotsToRateGroups(dr.Rate.RateSlots)
+	return
+}
+
+func rateSlotsToRateGroups(rss []*utils.RateSlot) (rgs RateGroups) {
+	for _, rl := range rss {
+		rgs = append(rgs, &Rate{
 			GroupIntervalStart: rl.GroupIntervalStartDuration(),
 			Value:              rl.Rate,
 			RateIncrement:      rl.RateIncrementDuration(),
@@ -549,6 +572,55 @@ func GetRateInterval(rpl *utils.TPRatingPlanBinding, dr *utils.DestinationRate)
 	return
 }
 
+// AddRatingPlanRates adds to rp the RateIntervals out of the DestinationRates bound by rpl,
+// the DestinationRates with TierUsageStart becoming tiers of the one without it, for the same destination
+func AddRatingPlanRates(rp *RatingPlan, rpl *utils.TPRatingPlanBinding, drs []*utils.DestinationRate) (err error) {
+	tiers := make(map[string][]*utils.DestinationRate)
+	for _, dr := range drs {
+		if dr.TierUsageStart != "" {
+			tiers[dr.DestinationId] = append(tiers[dr.DestinationId], dr)
+		}
+	}
+	for _, dr := range drs {
+		if dr.TierUsageStart != "" {
+			continue
+		}
+		ri := GetRateInterval(rpl, dr)
+		if tierDrs, has := tiers[dr.DestinationId]; has {
+			if err = setRateTiers(ri.Rating, dr.TierPeriod, tierDrs); err != nil {
+				return fmt.Errorf("destination %s: %s", dr.DestinationId, err.Error())
+			}
+			delete(tiers, dr.DestinationId)
+		}
+		rp.AddRateInterval(dr.DestinationId, ri)
+	}
+	for dstID := range tiers {
+		return fmt.Errorf("destination %s: tiers without base rate", dstID)
+	}
+	return
+}
+
+func setRateTiers(rating *RIRate, period string, tierDrs []*utils.DestinationRate) (err error) {
+	if _, err = tierPeriodStart(period, time.Now()); err != nil {
+		return
+	}
+	rating.TierPeriod = period
+	rating.Rates.Sort()
+	for _, dr := range tierDrs {
+		if dr.TierPeriod != "" && dr.TierPeriod != period {
+			return fmt.Errorf("tier period %s different than the base one: %s", dr.TierPeriod, period)
+		}
+		tier := &RateTier{Rates: rateSlotsToRateGroups(dr.Rate.RateSlots)}
+		if tier.UsageStart, err = utils.ParseDurationWithSecs(dr.TierUsageStart); err != nil {
+			return
+		}
+		tier.Rates.Sort()
+		rating.Tiers = append(rating.Tiers, tier)
+	}
+	rating.Tiers.Sort()
+	return
+}
+
 func MapTPRatingPlanBindings(s []*utils.TPRatingPlan) map[string][]*utils.TPRatingPlanBinding {
 	result := make(map[string][]*utils.TPRatingPlanBinding)
 	for _, e := range s {
@@ -1992,6 +2064,9 @@ func (tps TpStatsS) AsTPStats() (result []*utils.TPStats) {
 		if tp.TTL != "" {
 			st.TTL = tp.TTL
 		}
+		if tp.BucketSize != "" {
+			st.BucketSize = tp.BucketSize
+		}
 		if tp.Metrics != "" {
 			metrSplt := strings.Split(tp.Metrics, utils.INFIELD_SEP)
 			for _, metr := range metrSplt {
@@ -2047,6 +2122,7 @@ func APItoModelStats(st *utils.TPStats) (mdls TpStatsS) {
 		}
 		if i == 0 {
 			mdl.TTL = st.TTL
+			mdl.BucketSize = st.BucketSize
 			mdl.Blocker = st.Blocker
 			mdl.Stored = st.Stored
 			mdl.Weight = st.Weight
@@ -2102,6 +2178,11 @@ func APItoStats(tpST *utils.TPStats, timezone string) (st *StatQueueProfile, err
 			return nil, err
 		}
 	}
+	if tpST.BucketSize != "" {
+		if st.BucketSize, err = utils.ParseDurationWithSecs(tpST.BucketSize); err != nil {
+			return nil, err
+		}
+	}
 	for _, metr := range tpST.Metrics {
 		st.Metrics = append(st.Metrics, metr)
 	}
@@ -2143,6 +2224,15 @@ func (tps TpThresholdS) AsTPThreshold() (result []*utils.TPThreshold) {
 		if tp.ActionIDs != "" {
 			th.ActionIDs = append(th.ActionIDs, strings.Split(tp.ActionIDs, utils.INFIELD_SEP)...)
 		}
+		if tp.MinHits != 0 {
+			th.MinHits = tp.MinHits
+		}
+		if tp.MaxHits != 0 {
+			th.MaxHits = tp.MaxHits
+		}
+		if tp.RecoverActionIDs != "" {
+			th.RecoverActionIDs = append(th.RecoverActionIDs, strings.Split(tp.RecoverActionIDs, utils.INFIELD_SEP)...)
+		}
 		if tp.Weight != 0 {
 			th.Weight = tp.Weight
 		}
@@ -2188,6 +2278,9 @@ func APItoModelTPThreshold(th *utils.TPThreshold) (mdls TpThresholdS) {
 			mdl.Weight = th.Weight
 			mdl.Recurrent = th.Recurrent
 			mdl.MinSleep = th.MinSleep
+			mdl.MinHits = th.MinHits
+			mdl.MaxHits = th.MaxHits
+			mdl.RecoverActionIDs = strings.Join(th.RecoverActionIDs, utils.INFIELD_SEP)
 			if th.ActivationInterval != nil {
 				if th.ActivationInterval.ActivationTime != "" {
 					mdl.ActivationInterval = th.ActivationInterval.ActivationTime
@@ -2226,6 +2319,8 @@ func APItoThresholdProfile(tpTH *utils.TPThreshold, timezone string) (th *Thresh
 		Recurrent: tpTH.Recurrent,
 		Weight:    tpTH.Weight,
 		Blocker:   tpTH.Blocker,
+		MinHits:   tpTH.MinHits,
+		MaxHits:   tpTH.MaxHits,
 		Filters:   make([]*Filter, len(tpTH.Filters)),
 	}
 	if tpTH.MinSleep != "" {
@@ -2237,6 +2332,9 @@ func APItoThresholdProfile(tpTH *utils.TPThreshold, timezone string) (th *Thresh
 		th.ActionIDs = append(th.ActionIDs, ati)
 
 	}
+	for _, ati := range tpTH.RecoverActionIDs {
+		th.RecoverActionIDs = append(th.RecoverActionIDs, ati)
+	}
 	for i, f := range tpTH.Filters {
 		rf := &Filter{Type: f.Type, FieldName: f.FieldName, Values: f.Values}
 		if err := rf.CompileValues(); err != nil {
@@ -2309,5 +2407,124 @@ func APItoFilter(tpTH *utils.TPFilter) (th *Filter, err error) {
 	for _, ati := range tpTH.FilterFielValues {
 		th.Values = append(th.Values, ati)
 	}
+	if err := th.CompileValues(); err != nil {
+		return nil, err
+	}
 	return th, nil
 }
+
+type TpExchangeRates []*TpExchangeRate
+
+func (tps TpExchangeRates) AsTPExchangeRates() (result []*utils.TPExchangeRate) {
+	mst := make(map[string]*utils.TPExchangeRate)
+	for _, tp := range tps {
+		id := exchangeRateID(tp.FromCurrency, tp.ToCurrency)
+		fx, found := mst[id]
+		if !found {
+			fx = &utils.TPExchangeRate{
+				TPid:         tp.Tpid,
+				FromCurrency: tp.FromCurrency,
+				ToCurrency:   tp.ToCurrency,
+			}
+			mst[id] = fx
+		}
+		fx.Rates = append(fx.Rates, &utils.TPExchangeRateValue{
+			ActivationTime: tp.ActivationTime,
+			Rate:           tp.Rate,
+		})
+	}
+	result = make([]*utils.TPExchangeRate, len(mst))
+	i := 0
+	for _, fx := range mst {
+		result[i] = fx
+		i++
+	}
+	return
+}
+
+func APItoModelTPExchangeRate(fx *utils.TPExchangeRate) (mdls TpExchangeRates) {
+	for _, rate := range fx.Rates {
+		mdls = append(mdls, &TpExchangeRate{
+			Tpid:           fx.TPid,
+			FromCurrency:   fx.FromCurrency,
+			ToCurrency:     fx.ToCurrency,
+			ActivationTime: rate.ActivationTime,
+			Rate:           rate.Rate,
+		})
+	}
+	return
+}
+
+func APItoExchangeRate(tpFx *utils.TPExchangeRate, timezone string) (fx *ExchangeRate, err error) {
+	fx = &ExchangeRate{ID: exchangeRateID(tpFx.FromCurrency, tpFx.ToCurrency)}
+	for _, tpRate := range tpFx.Rates {
+		if tpRate.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %v for exchange rate %s", tpRate.Rate, fx.ID)
+		}
+		rate := &ExchangeRateValue{Rate: tpRate.Rate}
+		if rate.ActivationTime, err = utils.ParseTimeDetectLayout(tpRate.ActivationTime, timezone); err != nil {
+			return nil, err
+		}
+		fx.Rates = append(fx.Rates, rate)
+	}
+	fx.Rates.Sort()
+	return
+}
+
+type TpTaxRates []*TpTaxRate
+
+func (tps TpTaxRates) AsTPTaxRates() (result []*utils.TPTaxRate) {
+	for _, tp := range tps {
+		txr := &utils.TPTaxRate{
+			TPid:     tp.Tpid,
+			Tenant:   tp.Tenant,
+			ID:       tp.ID,
+			Type:     tp.Type,
+			Value:    tp.Value,
+			Compound: tp.Compound,
+			Weight:   tp.Weight,
+		}
+		if tp.Categories != "" {
+			txr.Categories = strings.Split(tp.Categories, utils.INFIELD_SEP)
+		}
+		if tp.DestinationIDs != "" {
+			txr.DestinationIDs = strings.Split(tp.DestinationIDs, utils.INFIELD_SEP)
+		}
+		if tp.Accounts != "" {
+			txr.Accounts = strings.Split(tp.Accounts, utils.INFIELD_SEP)
+		}
+		result = append(result, txr)
+	}
+	return
+}
+
+func APItoModelTPTaxRate(txr *utils.TPTaxRate) *TpTaxRate {
+	return &TpTaxRate{
+		Tpid:           txr.TPid,
+		Tenant:         txr.Tenant,
+		ID:             txr.ID,
+		Categories:     strings.Join(txr.Categories, utils.INFIELD_SEP),
+		DestinationIDs: strings.Join(txr.DestinationIDs, utils.INFIELD_SEP),
+		Accounts:       strings.Join(txr.Accounts, utils.INFIELD_SEP),
+		Type:           txr.Type,
+		Value:          txr.Value,
+		Compound:       txr.Compound,
+		Weight:         txr.Weight,
+	}
+}
+
+func APItoTaxRate(tpTxr *utils.TPTaxRate) (txr *TaxRate, err error) {
+	if tpTxr.Type != utils.MetaPercent && tpTxr.Type != utils.MetaFixed {
+		return nil, fmt.Errorf("unsupported type %s for tax rate %s", tpTxr.Type, tpTxr.ID)
+	}
+	return &TaxRate{
+		ID:             tpTxr.ID,
+		Categories:     utils.NewStringMap(tpTxr.Categories...),
+		DestinationIDs: utils.NewStringMap(tpTxr.DestinationIDs...),
+		Accounts:       utils.NewStringMap(tpTxr.Accounts...),
+		Type:           tpTxr.Type,
+		Value:          tpTxr.Value,
+		Compound:       tpTxr.Compound,
+		Weight:         tpTxr.Weight,
+	}, nil
+}
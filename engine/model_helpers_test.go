@@ -21,6 +21,7 @@ package engine
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cgrates/cgrates/utils"
 )
@@ -41,6 +42,27 @@ func TestModelHelperCsvLoadInt(t *testing.T) {
 	}
 }
 
+func TestModelHelperCsvLoadOptional(t *testing.T) {
+	record := []string{"cgrates.org", "Stats1", "*string", "Account", "1001", "2014-07-29T15:00:00Z", "100", "1s", "*asr", "true", "true", "20", "2", "THRESH1"}
+	l, err := csvLoad(TpStats{}, record)
+	tps, ok := l.(TpStats)
+	if err != nil || !ok || tps.Thresholds != "THRESH1" || tps.BucketSize != "" {
+		t.Errorf("model load failed: %+v, err: %v", tps, err)
+	}
+	if _, err := csvLoad(TpStats{}, record[:13]); err == nil {
+		t.Error("expecting error for missing mandatory column")
+	}
+	if getColumnCount(TpStats{}) != -1 {
+		t.Error("expecting variable column count")
+	}
+	// DestinationRates.csv created before the tiers and currency columns
+	l, err = csvLoad(TpDestinationRate{}, []string{"RT_STANDARD", "GERMANY", "R1", "*middle", "4", "0", ""})
+	if tpdr, ok := l.(TpDestinationRate); err != nil || !ok || tpdr.RatesTag != "R1" ||
+		tpdr.TierUsageStart != "" || tpdr.TierPeriod != "" || tpdr.Currency != "" {
+		t.Errorf("model load failed: %+v, err: %v", tpdr, err)
+	}
+}
+
 func TestModelHelperCsvDump(t *testing.T) {
 	tpd := TpDestination{
 		Tag:    "TEST_DEST",
@@ -144,8 +166,8 @@ func TestTPDestinationRateAsExportSlice(t *testing.T) {
 		},
 	}
 	expectedSlc := [][]string{
-		[]string{"TEST_DSTRATE", "TEST_DEST1", "TEST_RATE1", "*up", "4", "0", ""},
-		[]string{"TEST_DSTRATE", "TEST_DEST2", "TEST_RATE2", "*up", "4", "0", ""},
+		[]string{"TEST_DSTRATE", "TEST_DEST1", "TEST_RATE1", "*up", "4", "0", "", "", "", ""},
+		[]string{"TEST_DSTRATE", "TEST_DEST2", "TEST_RATE2", "*up", "4", "0", "", "", "", ""},
 	}
 	ms := APItoModelDestinationRate(tpDstRate)
 	var slc [][]string
@@ -901,6 +923,7 @@ func TestAPItoTPStats(t *testing.T) {
 		ActivationInterval: &utils.TPActivationInterval{ActivationTime: "2014-07-29T15:00:00Z"},
 		QueueLength:        100,
 		TTL:                "1s",
+		BucketSize:         "1m",
 		Metrics:            []string{"*asr", "*acd", "*acc"},
 		MinItems:           1,
 		Thresholds:         []string{"THRESH1", "THRESH2"},
@@ -918,6 +941,7 @@ func TestAPItoTPStats(t *testing.T) {
 		Blocker:     tps.Blocker,
 		Weight:      20.0,
 		MinItems:    tps.MinItems,
+		BucketSize:  time.Minute,
 	}
 	if eTPs.TTL, err = utils.ParseDurationWithSecs(tps.TTL); err != nil {
 		t.Errorf("Got error: %+v", err)
@@ -949,6 +973,9 @@ func TestAsTPThresholdAsAsTPThreshold(t *testing.T) {
 			Blocker:            false,
 			Weight:             20.0,
 			ActionIDs:          "WARN3",
+			MinHits:            2,
+			MaxHits:            5,
+			RecoverActionIDs:   "CLEAR3",
 		},
 	}
 	eTPs := []*utils.TPThreshold{
@@ -965,11 +992,14 @@ func TestAsTPThresholdAsAsTPThreshold(t *testing.T) {
 			ActivationInterval: &utils.TPActivationInterval{
 				ActivationTime: tps[0].ActivationInterval,
 			},
-			MinSleep:  tps[0].MinSleep,
-			Recurrent: tps[0].Recurrent,
-			Blocker:   tps[0].Blocker,
-			Weight:    tps[0].Weight,
-			ActionIDs: []string{"WARN3"},
+			MinSleep:         tps[0].MinSleep,
+			Recurrent:        tps[0].Recurrent,
+			Blocker:          tps[0].Blocker,
+			Weight:           tps[0].Weight,
+			ActionIDs:        []string{"WARN3"},
+			MinHits:          tps[0].MinHits,
+			MaxHits:          tps[0].MaxHits,
+			RecoverActionIDs: []string{"CLEAR3"},
 		},
 	}
 	rcvTPs := TpThresholdS(tps).AsTPThreshold()
@@ -991,15 +1021,19 @@ func TestAPItoTPThreshold(t *testing.T) {
 		Blocker:            false,
 		Weight:             20.0,
 		ActionIDs:          []string{"WARN3"},
+		MinHits:            2,
+		RecoverActionIDs:   []string{"CLEAR3"},
 	}
 
 	eTPs := &ThresholdProfile{
-		ID:        tps.ID,
-		Filters:   make([]*Filter, len(tps.Filters)),
-		Recurrent: tps.Recurrent,
-		Blocker:   tps.Blocker,
-		Weight:    tps.Weight,
-		ActionIDs: []string{"WARN3"},
+		ID:               tps.ID,
+		Filters:          make([]*Filter, len(tps.Filters)),
+		Recurrent:        tps.Recurrent,
+		Blocker:          tps.Blocker,
+		Weight:           tps.Weight,
+		ActionIDs:        []string{"WARN3"},
+		MinHits:          2,
+		RecoverActionIDs: []string{"CLEAR3"},
 	}
 	if eTPs.MinSleep, err = utils.ParseDurationWithSecs(tps.MinSleep); err != nil {
 		t.Errorf("Got error: %+v", err)
@@ -1064,3 +1098,34 @@ func TestAPItoTPFilter(t *testing.T) {
 		t.Errorf("Expecting: %+v, received: %+v", eTPs, st)
 	}
 }
+
+func TestAddRatingPlanRates(t *testing.T) {
+	rpb := &utils.TPRatingPlanBinding{DestinationRatesId: "DR_TIERED", TimingId: "ALWAYS", Weight: 10}
+	rpb.SetTiming(&utils.TPTiming{ID: "ALWAYS", StartTime: "00:00:00"})
+	newRate := func(id string, value float64) *utils.TPRate {
+		rs, _ := utils.NewRateSlot(0, value, "60s", "60s", "0s")
+		return &utils.TPRate{ID: id, RateSlots: []*utils.RateSlot{rs}}
+	}
+	drs := []*utils.DestinationRate{
+		&utils.DestinationRate{DestinationId: "DST_1002", RateId: "RT_15CNT", Rate: newRate("RT_15CNT", 0.015),
+			TierUsageStart: "600m"},
+		&utils.DestinationRate{DestinationId: "DST_1002", RateId: "RT_20CNT", Rate: newRate("RT_20CNT", 0.02),
+			TierPeriod: utils.MetaMonthly},
+	}
+	rp := &RatingPlan{Id: "RP_TIERED"}
+	if err := AddRatingPlanRates(rp, rpb, drs); err != nil {
+		t.Fatal(err)
+	}
+	ril := rp.RateIntervalList("DST_1002")
+	if len(ril) != 1 {
+		t.Fatalf("Received: %s", utils.ToJSON(ril))
+	}
+	if rating := ril[0].Rating; rating.TierPeriod != utils.MetaMonthly ||
+		len(rating.Tiers) != 1 || rating.Tiers[0].UsageStart != 600*time.Minute ||
+		rating.Tiers[0].Rates[0].Value != 0.015 || rating.Rates[0].Value != 0.02 {
+		t.Errorf("Received: %s", utils.ToJSON(rating))
+	}
+	if err := AddRatingPlanRates(&RatingPlan{Id: "RP_TIERED"}, rpb, drs[:1]); err == nil {
+		t.Error("Expecting error for tiers without base rate")
+	}
+}
@@ -71,6 +71,9 @@ type TpDestinationRate struct {
 	RoundingDecimals int     `index:"4" re:"\d+"`
 	MaxCost          float64 `index:"5" re:"\d+\.*\d*s*"`
 	MaxCostStrategy  string  `index:"6" re:"\*free|\*disconnect"`
+	TierUsageStart   string  `index:"7" re:"[0-9.]*[a-z]*" optional:"true"`
+	TierPeriod       string  `index:"8" re:"([*](daily|weekly|monthly|yearly))?" optional:"true"`
+	Currency         string  `index:"9" re:"" optional:"true"`
 	CreatedAt        time.Time
 }
 
@@ -451,6 +454,18 @@ func (t TBLSMCosts) TableName() string {
 	return utils.TBLSMCosts
 }
 
+type TBLStatMetrics struct {
+	ID           int64
+	Tenant       string
+	QueueID      string
+	Metrics      string
+	SnapshotTime time.Time
+}
+
+func (t TBLStatMetrics) TableName() string {
+	return utils.TBLStatMetrics
+}
+
 type TpResource struct {
 	PK                 uint `gorm:"primary_key"`
 	Tpid               string
@@ -497,6 +512,7 @@ type TpStats struct {
 	Weight             float64 `index:"11" re:"\d+\.?\d*"`
 	MinItems           int     `index:"12" re:""`
 	Thresholds         string  `index:"13" re:""`
+	BucketSize         string  `index:"14" re:"" optional:"true"`
 	CreatedAt          time.Time
 }
 
@@ -514,6 +530,9 @@ type TpThreshold struct {
 	Blocker            bool    `index:"8" re:""`
 	Weight             float64 `index:"9" re:"\d+\.?\d*"`
 	ActionIDs          string  `index:"10" re:""`
+	MinHits            int     `index:"11" re:"" optional:"true"`
+	MaxHits            int     `index:"12" re:"" optional:"true"`
+	RecoverActionIDs   string  `index:"13" re:"" optional:"true"`
 	CreatedAt          time.Time
 }
 
@@ -527,3 +546,28 @@ type TpFilter struct {
 	Values    string `index:"4" re:""`
 	CreatedAt time.Time
 }
+
+type TpExchangeRate struct {
+	PK             uint `gorm:"primary_key"`
+	Tpid           string
+	FromCurrency   string  `index:"0" re:""`
+	ToCurrency     string  `index:"1" re:""`
+	ActivationTime string  `index:"2" re:""`
+	Rate           float64 `index:"3" re:""`
+	CreatedAt      time.Time
+}
+
+type TpTaxRate struct {
+	PK             uint `gorm:"primary_key"`
+	Tpid           string
+	Tenant         string  `index:"0" re:""`
+	ID             string  `index:"1" re:""`
+	Categories     string  `index:"2" re:""`
+	DestinationIDs string  `index:"3" re:""`
+	Accounts       string  `index:"4" re:""`
+	Type           string  `index:"5" re:""`
+	Value          float64 `index:"6" re:""`
+	Compound       bool    `index:"7" re:""`
+	Weight         float64 `index:"8" re:""`
+	CreatedAt      time.Time
+}
@@ -0,0 +1,417 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+const rateDeckVersionLayout = "20060102150405"
+
+// RateDeckTemplate maps the columns of a vendor rate deck, indexes start with 0
+type RateDeckTemplate struct {
+	FieldSeparator     string // defaults to comma
+	HeaderLines        int    // lines skipped at the beginning of the file
+	PrefixIndex        int
+	RateIndex          int
+	ConnectFeeIndex    *int   // no connect fee if missing
+	EffectiveDateIndex *int   // the rates are effective at import if missing
+	RateUnit           string // unit the rates are priced for, defaults to 60s
+	RateIncrement      string // defaults to RateUnit
+	RoundingMethod     string // defaults to *up
+	RoundingDecimals   *int   // defaults to 4
+}
+
+func (tpl *RateDeckTemplate) setDefaults() {
+	if tpl.FieldSeparator == "" {
+		tpl.FieldSeparator = utils.FIELDS_SEP
+	}
+	if tpl.RateUnit == "" {
+		tpl.RateUnit = "60s"
+	}
+	if tpl.RateIncrement == "" {
+		tpl.RateIncrement = tpl.RateUnit
+	}
+	if tpl.RoundingMethod == "" {
+		tpl.RoundingMethod = utils.ROUNDING_UP
+	}
+	if tpl.RoundingDecimals == nil {
+		tpl.RoundingDecimals = utils.IntPointer(4)
+	}
+}
+
+// rateDeckRate is one line of the rate deck
+type rateDeckRate struct {
+	Prefix        string
+	ConnectFee    float64
+	Rate          float64
+	EffectiveDate time.Time
+}
+
+// rateID returns the ID of the TP rate built out of the deck rate
+func (rdr *rateDeckRate) rateID(deckID string) string {
+	id := fmt.Sprintf("RT_%s_%s", deckID, strconv.FormatFloat(rdr.Rate, 'f', -1, 64))
+	if rdr.ConnectFee != 0 {
+		id += "_" + strconv.FormatFloat(rdr.ConnectFee, 'f', -1, 64)
+	}
+	return id
+}
+
+// RateDeckChange is the rate of a prefix in the deck compared with the previous one
+type RateDeckChange struct {
+	Prefix  string
+	OldRate float64 // 0 for new prefixes
+	NewRate float64 // 0 for removed prefixes
+}
+
+// RateDeckVersion is the RatingPlan created for one effective date of the deck,
+// with the changes against the previous version or against the active plan for the first one
+type RateDeckVersion struct {
+	RatingPlanID   string
+	ActivationTime time.Time
+	Prefixes       int
+	Increases      []*RateDeckChange
+	Decreases      []*RateDeckChange
+	New            []*RateDeckChange
+	Removed        []*RateDeckChange
+}
+
+// RateDeckReport is the result of importing a rate deck
+type RateDeckReport struct {
+	TPid     string
+	DeckID   string
+	Versions []*RateDeckVersion
+}
+
+// RateDeckImporter converts vendor rate decks into Destinations, Rates, DestinationRates and RatingPlans in StorDB
+type RateDeckImporter struct {
+	TPid      string
+	DeckID    string // identifies the deck inside the IDs of the TP data created
+	StorDb    LoadWriter
+	DataDB    DataDB // rating data the deck is compared with, nil to skip comparing
+	Template  *RateDeckTemplate
+	Direction string // the RatingProfile activating the versions is written only if Tenant is not empty
+	Tenant    string
+	Category  string
+	Subject   string
+	Timezone  string
+	Verbose   bool
+}
+
+// Run imports the rate deck, replying with the changes of its versions
+func (rdi *RateDeckImporter) Run(deck io.Reader) (rpt *RateDeckReport, err error) {
+	if rdi.TPid == "" || rdi.DeckID == "" || rdi.Template == nil {
+		return nil, utils.ErrMandatoryIeMissing
+	}
+	rdi.Template.setDefaults()
+	if rdi.Direction == "" {
+		rdi.Direction = utils.OUT
+	}
+	if rdi.Category == "" {
+		rdi.Category = config.CgrConfig().DefaultCategory
+	}
+	if rdi.Subject == "" {
+		rdi.Subject = utils.ANY
+	}
+	rates, err := rdi.parseDeck(deck)
+	if err != nil {
+		return
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no rates in deck %s", rdi.DeckID)
+	}
+	rpt = &RateDeckReport{TPid: rdi.TPid, DeckID: rdi.DeckID}
+	versions := rateDeckVersions(rates)
+	actTimes := make([]time.Time, 0, len(versions))
+	for actTime := range versions {
+		actTimes = append(actTimes, actTime)
+	}
+	sort.Slice(actTimes, func(i, j int) bool { return actTimes[i].Before(actTimes[j]) })
+	prevRates, err := rdi.activeRates(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err = rdi.writeDestinationsAndRates(rates); err != nil {
+		return nil, err
+	}
+	var tpActivations []*utils.TPRatingActivation
+	for _, actTime := range actTimes {
+		rpID := fmt.Sprintf("RP_%s_%s", rdi.DeckID, actTime.UTC().Format(rateDeckVersionLayout))
+		if err = rdi.writeRatingPlan(rpID, versions[actTime]); err != nil {
+			return nil, err
+		}
+		tpActivations = append(tpActivations, &utils.TPRatingActivation{
+			ActivationTime: actTime.UTC().Format(time.RFC3339), RatingPlanId: rpID})
+		verRates := make(map[string]float64, len(versions[actTime]))
+		for prefix, rdr := range versions[actTime] {
+			verRates[prefix] = rdr.Rate
+		}
+		rpt.Versions = append(rpt.Versions, newRateDeckVersion(rpID, actTime, prevRates, verRates))
+		prevRates = verRates
+	}
+	if rdi.Tenant != "" {
+		if err = rdi.StorDb.SetTPRatingProfiles([]*utils.TPRatingProfile{{TPid: rdi.TPid, LoadId: rdi.DeckID,
+			Direction: rdi.Direction, Tenant: rdi.Tenant, Category: rdi.Category, Subject: rdi.Subject,
+			RatingPlanActivations: tpActivations}}); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// parseDeck reads the rates out of the deck, the last line wins for the same prefix and effective date
+func (rdi *RateDeckImporter) parseDeck(deck io.Reader) (rates []*rateDeckRate, err error) {
+	tpl := rdi.Template
+	csvReader := csv.NewReader(deck)
+	csvReader.Comma = rune(tpl.FieldSeparator[0])
+	csvReader.Comment = '#'
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	now := time.Now().Truncate(time.Second)
+	idxs := make(map[string]int) // prefix:effective date: index in rates
+	for lineNr := 1; ; lineNr++ {
+		record, errRead := csvReader.Read()
+		if errRead == io.EOF {
+			break
+		} else if errRead != nil {
+			return nil, errRead
+		}
+		if lineNr <= tpl.HeaderLines {
+			continue
+		}
+		rdr := &rateDeckRate{EffectiveDate: now}
+		if rdr.Prefix, err = rateDeckField(record, tpl.PrefixIndex); err != nil {
+			return nil, fmt.Errorf("line %d, %s", lineNr, err.Error())
+		}
+		var fldVal string
+		if fldVal, err = rateDeckField(record, tpl.RateIndex); err != nil {
+			return nil, fmt.Errorf("line %d, %s", lineNr, err.Error())
+		}
+		if rdr.Rate, err = strconv.ParseFloat(fldVal, 64); err != nil {
+			return nil, fmt.Errorf("line %d, rate: %s", lineNr, err.Error())
+		}
+		if tpl.ConnectFeeIndex != nil {
+			if fldVal, err = rateDeckField(record, *tpl.ConnectFeeIndex); err != nil {
+				return nil, fmt.Errorf("line %d, %s", lineNr, err.Error())
+			}
+			if rdr.ConnectFee, err = strconv.ParseFloat(fldVal, 64); err != nil {
+				return nil, fmt.Errorf("line %d, connect fee: %s", lineNr, err.Error())
+			}
+		}
+		if tpl.EffectiveDateIndex != nil {
+			if fldVal, err = rateDeckField(record, *tpl.EffectiveDateIndex); err != nil {
+				return nil, fmt.Errorf("line %d, %s", lineNr, err.Error())
+			}
+			if rdr.EffectiveDate, err = utils.ParseTimeDetectLayout(fldVal, rdi.Timezone); err != nil {
+				return nil, fmt.Errorf("line %d, effective date: %s", lineNr, err.Error())
+			}
+		}
+		idxKey := utils.ConcatenatedKey(rdr.Prefix, rdr.EffectiveDate.String())
+		if idx, has := idxs[idxKey]; has {
+			rates[idx] = rdr
+			continue
+		}
+		idxs[idxKey] = len(rates)
+		rates = append(rates, rdr)
+	}
+	return rates, nil
+}
+
+func rateDeckField(record []string, idx int) (string, error) {
+	if idx < 0 || idx >= len(record) || record[idx] == "" {
+		return "", fmt.Errorf("missing field with index %d", idx)
+	}
+	return record[idx], nil
+}
+
+// rateDeckVersions groups the deck rates on effective dates, each version having
+// for every prefix the rate with the latest effective date not after its own
+func rateDeckVersions(rates []*rateDeckRate) (versions map[time.Time]map[string]*rateDeckRate) {
+	versions = make(map[time.Time]map[string]*rateDeckRate)
+	for _, rdr := range rates {
+		versions[rdr.EffectiveDate] = make(map[string]*rateDeckRate)
+	}
+	for actTime, verRates := range versions {
+		for _, rdr := range rates {
+			if rdr.EffectiveDate.After(actTime) {
+				continue
+			}
+			if prev, has := verRates[rdr.Prefix]; has && prev.EffectiveDate.After(rdr.EffectiveDate) {
+				continue
+			}
+			verRates[rdr.Prefix] = rdr
+		}
+	}
+	return
+}
+
+// writeDestinationsAndRates writes one Destination per prefix and one Rate per distinct price
+func (rdi *RateDeckImporter) writeDestinationsAndRates(rates []*rateDeckRate) (err error) {
+	tpDsts := make(map[string]*utils.TPDestination)
+	tpRates := make(map[string]*utils.TPRate)
+	for _, rdr := range rates {
+		dstID := fmt.Sprintf("DST_%s_%s", rdi.DeckID, rdr.Prefix)
+		if _, has := tpDsts[dstID]; !has {
+			tpDsts[dstID] = &utils.TPDestination{TPid: rdi.TPid, ID: dstID, Prefixes: []string{rdr.Prefix}}
+		}
+		rtID := rdr.rateID(rdi.DeckID)
+		if _, has := tpRates[rtID]; has {
+			continue
+		}
+		rs, err := utils.NewRateSlot(rdr.ConnectFee, rdr.Rate, rdi.Template.RateUnit, rdi.Template.RateIncrement, "0s")
+		if err != nil {
+			return err
+		}
+		tpRates[rtID] = &utils.TPRate{TPid: rdi.TPid, ID: rtID, RateSlots: []*utils.RateSlot{rs}}
+	}
+	dsts := make([]*utils.TPDestination, 0, len(tpDsts))
+	for _, tpDst := range tpDsts {
+		dsts = append(dsts, tpDst)
+	}
+	if err = rdi.StorDb.SetTPDestinations(dsts); err != nil {
+		return
+	}
+	rts := make([]*utils.TPRate, 0, len(tpRates))
+	for _, tpRate := range tpRates {
+		rts = append(rts, tpRate)
+	}
+	return rdi.StorDb.SetTPRates(rts)
+}
+
+// writeRatingPlan writes the DestinationRates of a version and the RatingPlan using them
+func (rdi *RateDeckImporter) writeRatingPlan(rpID string, verRates map[string]*rateDeckRate) (err error) {
+	drID := "DR" + strings.TrimPrefix(rpID, "RP")
+	tpDr := &utils.TPDestinationRate{TPid: rdi.TPid, ID: drID}
+	for prefix, rdr := range verRates {
+		tpDr.DestinationRates = append(tpDr.DestinationRates, &utils.DestinationRate{
+			DestinationId:    fmt.Sprintf("DST_%s_%s", rdi.DeckID, prefix),
+			RateId:           rdr.rateID(rdi.DeckID),
+			RoundingMethod:   rdi.Template.RoundingMethod,
+			RoundingDecimals: *rdi.Template.RoundingDecimals,
+		})
+	}
+	if err = rdi.StorDb.SetTPDestinationRates([]*utils.TPDestinationRate{tpDr}); err != nil {
+		return
+	}
+	if rdi.Verbose {
+		utils.Logger.Info(fmt.Sprintf("<RateDeck> deck: %s, rating plan: %s, prefixes: %d", rdi.DeckID, rpID, len(verRates)))
+	}
+	return rdi.StorDb.SetTPRatingPlans([]*utils.TPRatingPlan{{TPid: rdi.TPid, ID: rpID,
+		RatingPlanBindings: []*utils.TPRatingPlanBinding{{DestinationRatesId: drID, TimingId: utils.ANY, Weight: 10}}}})
+}
+
+// activeRates returns the rates per prefix of the RatingPlan active at the time for the RatingProfile of the deck,
+// priced for the RateUnit of the deck
+func (rdi *RateDeckImporter) activeRates(t time.Time) (rates map[string]float64, err error) {
+	rates = make(map[string]float64)
+	if rdi.DataDB == nil || rdi.Tenant == "" {
+		return
+	}
+	rpf, err := rdi.DataDB.GetRatingProfile(utils.ConcatenatedKey(rdi.Direction, rdi.Tenant, rdi.Category, rdi.Subject),
+		false, utils.NonTransactional)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	var rpa *RatingPlanActivation
+	for _, a := range rpf.RatingPlanActivations {
+		if !a.ActivationTime.After(t) && (rpa == nil || a.ActivationTime.After(rpa.ActivationTime)) {
+			rpa = a
+		}
+	}
+	if rpa == nil {
+		return
+	}
+	rpl, err := rdi.DataDB.GetRatingPlan(rpa.RatingPlanId, false, utils.NonTransactional)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	rateUnit, err := utils.ParseDurationWithSecs(rdi.Template.RateUnit)
+	if err != nil {
+		return
+	}
+	for dstID := range rpl.DestinationRates {
+		if dstID == utils.ANY {
+			continue
+		}
+		dst, errDst := rdi.DataDB.GetDestination(dstID, false, utils.NonTransactional)
+		if errDst != nil {
+			continue
+		}
+		var ri *RateInterval
+		for _, rIntvl := range rpl.RateIntervalList(dstID) {
+			if rIntvl.Rating == nil || len(rIntvl.Rating.Rates) == 0 ||
+				(rIntvl.Timing != nil && !rIntvl.Timing.IsActiveAt(t)) {
+				continue
+			}
+			if ri == nil || rIntvl.Weight > ri.Weight {
+				ri = rIntvl
+			}
+		}
+		if ri == nil || ri.Rating.Rates[0].RateUnit == 0 {
+			continue
+		}
+		rate := ri.Rating.Rates[0].Value * float64(rateUnit) / float64(ri.Rating.Rates[0].RateUnit)
+		for _, prefix := range dst.Prefixes {
+			if _, has := rates[prefix]; !has {
+				rates[prefix] = utils.Round(rate, *rdi.Template.RoundingDecimals, utils.ROUNDING_MIDDLE)
+			}
+		}
+	}
+	return
+}
+
+// newRateDeckVersion compares the rates of the version with the previous ones
+func newRateDeckVersion(rpID string, actTime time.Time, prevRates, verRates map[string]float64) (ver *RateDeckVersion) {
+	ver = &RateDeckVersion{RatingPlanID: rpID, ActivationTime: actTime, Prefixes: len(verRates)}
+	for prefix, rate := range verRates {
+		prevRate, has := prevRates[prefix]
+		switch {
+		case !has:
+			ver.New = append(ver.New, &RateDeckChange{Prefix: prefix, NewRate: rate})
+		case rate > prevRate:
+			ver.Increases = append(ver.Increases, &RateDeckChange{Prefix: prefix, OldRate: prevRate, NewRate: rate})
+		case rate < prevRate:
+			ver.Decreases = append(ver.Decreases, &RateDeckChange{Prefix: prefix, OldRate: prevRate, NewRate: rate})
+		}
+	}
+	for prefix, prevRate := range prevRates {
+		if _, has := verRates[prefix]; !has {
+			ver.Removed = append(ver.Removed, &RateDeckChange{Prefix: prefix, OldRate: prevRate})
+		}
+	}
+	for _, chngs := range [][]*RateDeckChange{ver.Increases, ver.Decreases, ver.New, ver.Removed} {
+		sort.Slice(chngs, func(i, j int) bool { return chngs[i].Prefix < chngs[j].Prefix })
+	}
+	return
+}
@@ -0,0 +1,108 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestRateDeckImport(t *testing.T) {
+	activeDB, err := NewMapStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpr := NewTpReader(activeDB, NewStringCSVStorage(',',
+		`DST_DECK_49,+49
+DST_DECK_33,+33
+DST_DECK_44,+44
+DST_DECK_1,+1`,
+		``,
+		`RT_DECK_2CNT,0,0.02,60s,60s,0s
+RT_DECK_5CNT,0,0.05,60s,60s,0s
+RT_DECK_10CNT,0,0.1,1s,1s,0s`,
+		`DR_DECK,DST_DECK_49,RT_DECK_2CNT,*up,4,0,,,,
+DR_DECK,DST_DECK_33,RT_DECK_5CNT,*up,4,0,,,,
+DR_DECK,DST_DECK_44,RT_DECK_10CNT,*up,4,0,,,,
+DR_DECK,DST_DECK_1,RT_DECK_5CNT,*up,4,0,,,,`,
+		`RP_DECK,DR_DECK,*any,10`,
+		`*out,deck.org,call,*any,2014-01-01T00:00:00Z,RP_DECK,,`,
+		"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", ""), "", "UTC")
+	if err := tpr.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tpr.WriteToDatabase(false, false, false); err != nil {
+		t.Fatal(err)
+	}
+	storDb, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdi := &RateDeckImporter{TPid: "TP_DECK", DeckID: "CARRIER", StorDb: storDb, DataDB: activeDB,
+		Template: &RateDeckTemplate{HeaderLines: 1, PrefixIndex: 0, RateIndex: 1,
+			EffectiveDateIndex: utils.IntPointer(2)},
+		Tenant: "deck.org", Timezone: "UTC"}
+	rpt, err := rdi.Run(strings.NewReader(`Prefix,Rate,EffectiveDate
++49,0.03,2014-01-01T00:00:00Z
++33,0.04,2014-01-01T00:00:00Z
++44,6,2014-01-01T00:00:00Z
++40,0.2,2014-01-01T00:00:00Z
++49,0.025,2030-01-01T00:00:00Z
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eRpt := &RateDeckReport{TPid: "TP_DECK", DeckID: "CARRIER", Versions: []*RateDeckVersion{
+		&RateDeckVersion{RatingPlanID: "RP_CARRIER_20140101000000",
+			ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Prefixes: 4,
+			Increases: []*RateDeckChange{&RateDeckChange{Prefix: "+49", OldRate: 0.02, NewRate: 0.03}},
+			Decreases: []*RateDeckChange{&RateDeckChange{Prefix: "+33", OldRate: 0.05, NewRate: 0.04}},
+			New:       []*RateDeckChange{&RateDeckChange{Prefix: "+40", NewRate: 0.2}},
+			Removed:   []*RateDeckChange{&RateDeckChange{Prefix: "+1", OldRate: 0.05}}},
+		&RateDeckVersion{RatingPlanID: "RP_CARRIER_20300101000000",
+			ActivationTime: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Prefixes: 4,
+			Decreases: []*RateDeckChange{&RateDeckChange{Prefix: "+49", OldRate: 0.03, NewRate: 0.025}}},
+	}}
+	if !reflect.DeepEqual(eRpt, rpt) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eRpt), utils.ToJSON(rpt))
+	}
+	if tpDrs, err := storDb.GetTPDestinationRates("TP_DECK", "DR_CARRIER_20300101000000", nil); err != nil {
+		t.Error(err)
+	} else if len(tpDrs) != 1 || len(tpDrs[0].DestinationRates) != 4 {
+		t.Errorf("Received: %s", utils.ToJSON(tpDrs))
+	}
+	// the versions are activated on the RatingProfile and load as any other tariff plan
+	tpr = NewTpReader(nil, storDb, "TP_DECK", "UTC")
+	for _, load := range []func() error{tpr.LoadDestinations, tpr.LoadTimings, tpr.LoadRates,
+		tpr.LoadDestinationRates, tpr.LoadRatingPlans, tpr.LoadRatingProfiles} {
+		if err := load(); err != nil && err != utils.ErrNotFound {
+			t.Fatal(err)
+		}
+	}
+	if rpf, has := tpr.ratingProfiles["*out:deck.org:call:*any"]; !has {
+		t.Errorf("Missing rating profile in: %s", utils.ToJSON(tpr.ratingProfiles))
+	} else if len(rpf.RatingPlanActivations) != 2 ||
+		rpf.RatingPlanActivations[1].RatingPlanId != "RP_CARRIER_20300101000000" {
+		t.Errorf("Received: %s", utils.ToJSON(rpf))
+	}
+}
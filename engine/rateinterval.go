@@ -215,7 +215,10 @@ type RIRate struct {
 	MaxCost          float64
 	MaxCostStrategy  string
 	Rates            RateGroups // GroupRateInterval (start time): Rate
-	tag              string     // loading validation only
+	Tiers            RateTiers  // replace Rates based on the usage cumulated within TierPeriod
+	TierPeriod       string
+	Currency         string // currency of the rates, empty if the same as the one of the balances
+	tag              string // loading validation only
 }
 
 func (rir *RIRate) Stringify() string {
@@ -223,6 +226,18 @@ func (rir *RIRate) Stringify() string {
 	for _, r := range rir.Rates {
 		str += r.Stringify()
 	}
+	if rir.Currency != "" {
+		str += rir.Currency
+	}
+	if len(rir.Tiers) != 0 {
+		str += rir.TierPeriod
+		for _, rt := range rir.Tiers {
+			str += rt.UsageStart.String()
+			for _, r := range rt.Rates {
+				str += r.Stringify()
+			}
+		}
+	}
 	return utils.Sha1(str)[:8]
 }
 
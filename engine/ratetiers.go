@@ -0,0 +1,415 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/guardian"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// RateTier replaces the Rates of a RIRate once the usage within the tier period reaches UsageStart
+type RateTier struct {
+	UsageStart time.Duration
+	Rates      RateGroups
+}
+
+// RateTiers are kept sorted on UsageStart
+type RateTiers []*RateTier
+
+func (rts RateTiers) Len() int {
+	return len(rts)
+}
+
+func (rts RateTiers) Swap(i, j int) {
+	rts[i], rts[j] = rts[j], rts[i]
+}
+
+func (rts RateTiers) Less(i, j int) bool {
+	return rts[i].UsageStart < rts[j].UsageStart
+}
+
+func (rts RateTiers) Sort() {
+	sort.Sort(rts)
+}
+
+// TierCounter cumulates the usage rated with tiered rates for one account and rating subject within a tier period
+type TierCounter struct {
+	ID          string    // <tenant:account>:<matched rating subject>:<tier period>
+	PeriodStart time.Time // usage belonging to older periods is discarded
+	Usage       time.Duration
+}
+
+func tierCounterID(acntKey, ratingSubject, period string) string {
+	return utils.ConcatenatedKey(acntKey, ratingSubject, period)
+}
+
+// TierEventUsage is the usage one event counted into the tier counters, refunded or replaced when the event is rated again
+// kept apart from the counters so these do not grow with the events, it expires together with the periods it was counted into
+type TierEventUsage struct {
+	ID         string                // <CGRID>:<RunID>
+	Counters   map[string]*TierUsage // usage counted per tier counter ID
+	ExpiryTime time.Time             // end of the latest tier period counted into, tierEventUsageMinTTL after counting at the earliest
+}
+
+// tierEventUsageMinTTL keeps the usage counted into past periods, out of late events, for refunds and rating again
+const tierEventUsageMinTTL = 24 * time.Hour
+
+// TierUsage is the usage counted into one tier counter within the period starting at PeriodStart
+type TierUsage struct {
+	PeriodStart time.Time
+	Usage       time.Duration
+}
+
+// tierEventKey identifies the event rated by cd within the tier counters, empty for the events without CGRID
+func (cd *CallDescriptor) tierEventKey() string {
+	if cd.CgrID == "" {
+		return ""
+	}
+	return utils.ConcatenatedKey(cd.CgrID, cd.RunID)
+}
+
+// addUsage records the usage counted into the counter with tcID within the period starting at pStart, ending at pEnd
+func (teu *TierEventUsage) addUsage(tcID string, pStart, pEnd time.Time, usage time.Duration) {
+	if teu.Counters == nil {
+		teu.Counters = make(map[string]*TierUsage)
+	}
+	if tu, has := teu.Counters[tcID]; has && tu.PeriodStart.Equal(pStart) {
+		tu.Usage += usage
+	} else { // usage of older periods cannot be refunded anymore
+		teu.Counters[tcID] = &TierUsage{PeriodStart: pStart, Usage: usage}
+	}
+	if minExpiry := time.Now().Add(tierEventUsageMinTTL); pEnd.Before(minExpiry) {
+		pEnd = minExpiry
+	}
+	if pEnd.After(teu.ExpiryTime) {
+		teu.ExpiryTime = pEnd
+	}
+}
+
+// countUsage adds the usage within the period starting at pStart, restarting the counter on a new period
+// returns false for the late usage, its period being over
+func (tc *TierCounter) countUsage(pStart time.Time, usage time.Duration) bool {
+	if pStart.Before(tc.PeriodStart) {
+		return false
+	}
+	if tc.PeriodStart.Before(pStart) { // new period
+		tc.PeriodStart, tc.Usage = pStart, 0
+	}
+	tc.Usage += usage
+	return true
+}
+
+// discountUsage takes out usage counted within the period starting at pStart
+// returns false if there is nothing to discount, the counter being in another period
+func (tc *TierCounter) discountUsage(pStart time.Time, usage time.Duration) bool {
+	if !tc.PeriodStart.Equal(pStart) || tc.Usage == 0 {
+		return false
+	}
+	if tc.Usage -= usage; tc.Usage < 0 {
+		tc.Usage = 0
+	}
+	return true
+}
+
+// tierPeriodStart returns the start of the tier period containing t, weeks starting on Monday
+func tierPeriodStart(period string, t time.Time) (time.Time, error) {
+	switch period {
+	case utils.MetaDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case utils.MetaWeekly:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location()), nil
+	case utils.MetaMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	case utils.MetaYearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported tier period: <%s>", period)
+}
+
+// tierPeriodEnd returns the start of the tier period following the one starting at pStart
+func tierPeriodEnd(period string, pStart time.Time) time.Time {
+	switch period {
+	case utils.MetaDaily:
+		return pStart.AddDate(0, 0, 1)
+	case utils.MetaWeekly:
+		return pStart.AddDate(0, 0, 7)
+	case utils.MetaMonthly:
+		return pStart.AddDate(0, 1, 0)
+	}
+	return pStart.AddDate(1, 0, 0)
+}
+
+// rateAt returns the Rate applying at grpStart, RateGroups being sorted
+func (pg RateGroups) rateAt(grpStart time.Duration) (rt *Rate) {
+	for _, r := range pg {
+		if r.GroupIntervalStart > grpStart {
+			break
+		}
+		rt = r
+	}
+	return
+}
+
+// tierRates returns the Rates applying once usage was cumulated within the tier period
+func (rir *RIRate) tierRates(usage time.Duration) (rts RateGroups) {
+	rts = rir.Rates
+	for _, tier := range rir.Tiers {
+		if tier.UsageStart > usage {
+			break
+		}
+		rts = tier.Rates
+	}
+	return
+}
+
+// tieredRates flattens the tiers into RateGroups equivalent for one call,
+// periodUsage being cumulated before the call part starting at callStart
+func (rir *RIRate) tieredRates(periodUsage, callStart time.Duration) (rts RateGroups) {
+	grpStarts := []time.Duration{0}
+	for _, r := range rir.Rates {
+		grpStarts = append(grpStarts, r.GroupIntervalStart)
+	}
+	for _, tier := range rir.Tiers {
+		for _, r := range tier.Rates {
+			grpStarts = append(grpStarts, r.GroupIntervalStart)
+		}
+		if tierStart := callStart + tier.UsageStart - periodUsage; tierStart > 0 { // tier reached during the call
+			grpStarts = append(grpStarts, tierStart)
+		}
+	}
+	sort.Slice(grpStarts, func(i, j int) bool { return grpStarts[i] < grpStarts[j] })
+	for _, grpStart := range grpStarts {
+		usage := periodUsage
+		if grpStart > callStart {
+			usage += grpStart - callStart
+		}
+		r := rir.tierRates(usage).rateAt(grpStart)
+		if r == nil {
+			continue
+		}
+		if len(rts) != 0 && rts[len(rts)-1].Value == r.Value &&
+			rts[len(rts)-1].RateIncrement == r.RateIncrement &&
+			rts[len(rts)-1].RateUnit == r.RateUnit {
+			continue // same rate as previous group
+		}
+		rts = append(rts, &Rate{GroupIntervalStart: grpStart, Value: r.Value,
+			RateIncrement: r.RateIncrement, RateUnit: r.RateUnit})
+	}
+	return
+}
+
+// applyRateTiers replaces the tiered RateIntervals with their equivalent for the usage cumulated so far within the tier period
+func (cd *CallDescriptor) applyRateTiers() (err error) {
+	callStart := cd.DurationIndex - cd.GetDuration()
+	if callStart < 0 {
+		callStart = 0
+	}
+	for _, ri := range cd.RatingInfos {
+		for i, rIntvl := range ri.RateIntervals {
+			if rIntvl.Rating == nil || len(rIntvl.Rating.Tiers) == 0 {
+				continue
+			}
+			var periodUsage time.Duration
+			if periodUsage, err = cd.tierPeriodUsage(ri.MatchedSubject, rIntvl.Rating.TierPeriod); err != nil {
+				return
+			}
+			rating := *rIntvl.Rating // shared with the cached RatingPlan, work on a copy
+			rating.Rates = rIntvl.Rating.tieredRates(periodUsage, callStart)
+			rating.Tiers = nil // TierPeriod is kept so the usage is counted on debit
+			ri.RateIntervals[i] = &RateInterval{Timing: rIntvl.Timing, Rating: &rating, Weight: rIntvl.Weight}
+		}
+	}
+	return
+}
+
+// tierPeriodUsage returns the usage cumulated in the tier period containing the start of the call
+func (cd *CallDescriptor) tierPeriodUsage(ratingSubject, period string) (usage time.Duration, err error) {
+	pStart, err := tierPeriodStart(period, cd.TimeStart)
+	if err != nil {
+		return
+	}
+	tc, err := cd.getDataManager().DataDB().GetTierCounter(tierCounterID(cd.GetAccountKey(), ratingSubject, period))
+	if err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	if !tc.PeriodStart.Before(pStart) {
+		usage = tc.Usage
+	}
+	return
+}
+
+// tierUsages returns the usage rated with tiered rates per tier counter ID, together with the tier period of each counter
+func (cd *CallDescriptor) tierUsages(cc *CallCost) (usages map[string]time.Duration, periods map[string]string) {
+	usages = make(map[string]time.Duration)
+	periods = make(map[string]string)
+	for _, ts := range cc.Timespans {
+		if ts.RateInterval == nil || ts.RateInterval.Rating == nil || ts.RateInterval.Rating.TierPeriod == "" {
+			continue
+		}
+		tcID := tierCounterID(cd.GetAccountKey(), ts.MatchedSubject, ts.RateInterval.Rating.TierPeriod)
+		usages[tcID] += ts.GetDuration()
+		periods[tcID] = ts.RateInterval.Rating.TierPeriod
+	}
+	return
+}
+
+// updateTierCounter applies update on the counter with tcID under its lock, storing the counter if update returns true
+func (cd *CallDescriptor) updateTierCounter(tcID string, pStart time.Time, update func(tc *TierCounter) bool) (err error) {
+	dataDB := cd.getDataManager().DataDB()
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) {
+		tc, err := dataDB.GetTierCounter(tcID)
+		if err == utils.ErrNotFound {
+			tc, err = &TierCounter{ID: tcID, PeriodStart: pStart}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !update(tc) {
+			return nil, nil
+		}
+		return nil, dataDB.SetTierCounter(tc)
+	}, 0, utils.TierCounterPrefix+tcID)
+	return
+}
+
+// countTierUsages adds the usages to their tier counters, recording them into teu if not nil
+func (cd *CallDescriptor) countTierUsages(usages map[string]time.Duration, periods map[string]string, teu *TierEventUsage) (err error) {
+	for tcID, usage := range usages {
+		pStart, err := tierPeriodStart(periods[tcID], cd.TimeStart)
+		if err != nil {
+			return err
+		}
+		var counted bool
+		if err = cd.updateTierCounter(tcID, pStart, func(tc *TierCounter) bool {
+			counted = tc.countUsage(pStart, usage)
+			return counted
+		}); err != nil {
+			return err
+		}
+		if counted && teu != nil {
+			teu.addUsage(tcID, pStart, tierPeriodEnd(periods[tcID], pStart), usage)
+		}
+	}
+	return
+}
+
+// discountTierUsages takes out of the tier counters up to usage counted by the event, all of it for negative usage
+// the counters are processed sorted on ID
+func (cd *CallDescriptor) discountTierUsages(teu *TierEventUsage, usage time.Duration) (err error) {
+	tcIDs := make([]string, 0, len(teu.Counters))
+	for tcID := range teu.Counters {
+		tcIDs = append(tcIDs, tcID)
+	}
+	sort.Strings(tcIDs)
+	for _, tcID := range tcIDs {
+		if usage == 0 {
+			break
+		}
+		tu := teu.Counters[tcID]
+		discount := tu.Usage
+		if usage > 0 && usage < discount {
+			discount = usage
+		}
+		if err = cd.updateTierCounter(tcID, tu.PeriodStart, func(tc *TierCounter) bool {
+			return tc.discountUsage(tu.PeriodStart, discount)
+		}); err != nil {
+			return
+		}
+		if usage > 0 {
+			usage -= discount
+		}
+		if tu.Usage -= discount; tu.Usage == 0 {
+			delete(teu.Counters, tcID)
+		}
+	}
+	return
+}
+
+// updateTierEventUsage applies update on the usage counted by the event with evKey under its lock,
+// storing it afterwards or removing it once empty
+func (cd *CallDescriptor) updateTierEventUsage(evKey string, update func(teu *TierEventUsage) error) (err error) {
+	dataDB := cd.getDataManager().DataDB()
+	_, err = guardian.Guardian.Guard(func() (interface{}, error) {
+		teu, err := dataDB.GetTierEventUsage(evKey)
+		stored := err == nil
+		if err == utils.ErrNotFound {
+			teu, err = &TierEventUsage{ID: evKey}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if err = update(teu); err != nil {
+			return nil, err
+		}
+		if len(teu.Counters) == 0 {
+			if !stored {
+				return nil, nil
+			}
+			return nil, dataDB.RemoveTierEventUsage(evKey)
+		}
+		return nil, dataDB.SetTierEventUsage(teu)
+	}, 0, utils.TierEventUsagePrefix+evKey)
+	return
+}
+
+// updateTierCounters adds the usage rated with tiered rates to the counters of their tier periods
+// the usage counted before by the event is replaced on its first debit, as when rating a CDR again
+func (cd *CallDescriptor) updateTierCounters(cc *CallCost) (err error) {
+	usages, periods := cd.tierUsages(cc)
+	evKey := cd.tierEventKey()
+	if evKey == "" { // cannot be recognized when refunded or rated again
+		return cd.countTierUsages(usages, periods, nil)
+	}
+	return cd.updateTierEventUsage(evKey, func(teu *TierEventUsage) (err error) {
+		if cd.LoopIndex == 0 { // first debit of a session or the CDR rated again
+			if err = cd.discountTierUsages(teu, -1); err != nil {
+				return
+			}
+		}
+		return cd.countTierUsages(usages, periods, teu)
+	})
+}
+
+// refundTierCounters discounts the usage of the refunded increments out of the tier counters the event contributed to
+func (cd *CallDescriptor) refundTierCounters() (err error) {
+	evKey := cd.tierEventKey()
+	if evKey == "" {
+		return
+	}
+	var usage time.Duration
+	for _, inc := range cd.Increments {
+		cmpFactor := inc.CompressFactor
+		if cmpFactor == 0 {
+			cmpFactor = 1
+		}
+		usage += inc.Duration * time.Duration(cmpFactor)
+	}
+	if usage == 0 { // rounding refunds cost only
+		return
+	}
+	return cd.updateTierEventUsage(evKey, func(teu *TierEventUsage) error {
+		return cd.discountTierUsages(teu, usage)
+	})
+}
@@ -0,0 +1,209 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestRIRateTieredRates(t *testing.T) {
+	rir := &RIRate{
+		Rates: RateGroups{
+			&Rate{GroupIntervalStart: 0, Value: 0.02, RateIncrement: time.Minute, RateUnit: time.Minute}},
+		Tiers: RateTiers{
+			&RateTier{UsageStart: 100 * time.Minute, Rates: RateGroups{
+				&Rate{GroupIntervalStart: 0, Value: 0.015, RateIncrement: time.Minute, RateUnit: time.Minute}}},
+			&RateTier{UsageStart: 200 * time.Minute, Rates: RateGroups{
+				&Rate{GroupIntervalStart: 0, Value: 0.01, RateIncrement: time.Minute, RateUnit: time.Minute}}},
+		},
+		TierPeriod: utils.MetaMonthly,
+	}
+	eRates := RateGroups{
+		&Rate{GroupIntervalStart: 0, Value: 0.02, RateIncrement: time.Minute, RateUnit: time.Minute},
+		&Rate{GroupIntervalStart: 2 * time.Minute, Value: 0.015, RateIncrement: time.Minute, RateUnit: time.Minute},
+		&Rate{GroupIntervalStart: 102 * time.Minute, Value: 0.01, RateIncrement: time.Minute, RateUnit: time.Minute},
+	}
+	if rts := rir.tieredRates(98*time.Minute, 0); !reflect.DeepEqual(eRates, rts) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eRates), utils.ToJSON(rts))
+	}
+	// second part of the same call, the first one being already counted
+	eRates = RateGroups{
+		&Rate{GroupIntervalStart: 0, Value: 0.015, RateIncrement: time.Minute, RateUnit: time.Minute},
+		&Rate{GroupIntervalStart: 102 * time.Minute, Value: 0.01, RateIncrement: time.Minute, RateUnit: time.Minute},
+	}
+	if rts := rir.tieredRates(108*time.Minute, 10*time.Minute); !reflect.DeepEqual(eRates, rts) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eRates), utils.ToJSON(rts))
+	}
+}
+
+func TestTierPeriodStart(t *testing.T) {
+	tm := time.Date(2017, time.November, 16, 10, 30, 0, 0, time.UTC) // Thursday
+	for period, eStart := range map[string]time.Time{
+		utils.MetaDaily:   time.Date(2017, time.November, 16, 0, 0, 0, 0, time.UTC),
+		utils.MetaWeekly:  time.Date(2017, time.November, 13, 0, 0, 0, 0, time.UTC),
+		utils.MetaMonthly: time.Date(2017, time.November, 1, 0, 0, 0, 0, time.UTC),
+		utils.MetaYearly:  time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if start, err := tierPeriodStart(period, tm); err != nil {
+			t.Error(err)
+		} else if !start.Equal(eStart) {
+			t.Errorf("Period: %s, expecting: %v, received: %v", period, eStart, start)
+		}
+	}
+	if _, err := tierPeriodStart("*hourly", tm); err == nil {
+		t.Error("Expecting error for unsupported period")
+	}
+}
+
+func TestCallDescriptorRateTiers(t *testing.T) {
+	rpl := &RatingPlan{
+		Id: "RP_TIERED",
+		Timings: map[string]*RITiming{
+			"30eab302": &RITiming{StartTime: "00:00:00"},
+		},
+		Ratings: map[string]*RIRate{
+			"b457f862": &RIRate{
+				Rates: RateGroups{
+					&Rate{GroupIntervalStart: 0, Value: 0.02, RateIncrement: time.Minute, RateUnit: time.Minute}},
+				Tiers: RateTiers{
+					&RateTier{UsageStart: 10 * time.Minute, Rates: RateGroups{
+						&Rate{GroupIntervalStart: 0, Value: 0.015, RateIncrement: time.Minute, RateUnit: time.Minute}}},
+				},
+				TierPeriod:       utils.MetaMonthly,
+				RoundingMethod:   utils.ROUNDING_MIDDLE,
+				RoundingDecimals: 4,
+			},
+		},
+		DestinationRates: map[string]RPRateList{
+			utils.ANY: []*RPRate{&RPRate{Timing: "30eab302", Rating: "b457f862", Weight: 10}},
+		},
+	}
+	if err := dm.DataDB().SetRatingPlan(rpl, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	rpf := &RatingProfile{Id: "*out:cgrates.org:call:tiered",
+		RatingPlanActivations: RatingPlanActivations{&RatingPlanActivation{
+			ActivationTime: time.Date(2015, 01, 01, 8, 0, 0, 0, time.UTC),
+			RatingPlanId:   rpl.Id,
+		}},
+	}
+	if err := dm.DataDB().SetRatingProfile(rpf, utils.NonTransactional); err != nil {
+		t.Fatal(err)
+	}
+	if err := dm.DataDB().SetAccount(&Account{ID: "cgrates.org:tiered",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 10}}}}); err != nil {
+		t.Fatal(err)
+	}
+	tcID := tierCounterID("cgrates.org:tiered", rpf.Id, utils.MetaMonthly)
+	if err := dm.DataDB().SetTierCounter(&TierCounter{ID: tcID,
+		PeriodStart: time.Date(2017, time.November, 1, 0, 0, 0, 0, time.UTC), Usage: 9 * time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	newCD := func() *CallDescriptor {
+		return &CallDescriptor{Direction: utils.OUT, Category: "call", Tenant: "cgrates.org",
+			Subject: "tiered", Account: "tiered", Destination: "0723",
+			TimeStart: time.Date(2017, time.November, 16, 10, 0, 0, 0, time.UTC),
+			TimeEnd:   time.Date(2017, time.November, 16, 10, 2, 0, 0, time.UTC)}
+	}
+	if cc, err := newCD().GetCost(); err != nil {
+		t.Error(err)
+	} else if cc.Cost != 0.035 { // one minute out of each tier
+		t.Errorf("Received cost: %v", cc.Cost)
+	}
+	if cc, err := newCD().Debit(); err != nil {
+		t.Error(err)
+	} else if cc.Cost != 0.035 {
+		t.Errorf("Received cost: %v", cc.Cost)
+	}
+	if tc, err := dm.DataDB().GetTierCounter(tcID); err != nil {
+		t.Error(err)
+	} else if tc.Usage != 11*time.Minute {
+		t.Errorf("Received: %s", utils.ToJSON(tc))
+	}
+	if cc, err := newCD().GetCost(); err != nil {
+		t.Error(err)
+	} else if cc.Cost != 0.03 {
+		t.Errorf("Received cost: %v", cc.Cost)
+	}
+	// the counter of the previous month does not apply
+	cd := newCD()
+	cd.TimeStart, cd.TimeEnd = cd.TimeStart.AddDate(0, 1, 0), cd.TimeEnd.AddDate(0, 1, 0)
+	if cc, err := cd.GetCost(); err != nil {
+		t.Error(err)
+	} else if cc.Cost != 0.04 {
+		t.Errorf("Received cost: %v", cc.Cost)
+	}
+}
+
+func TestTierCounterRatedAgainAndRefunded(t *testing.T) {
+	evKey := utils.ConcatenatedKey("cgrid1", utils.META_DEFAULT)
+	tcID := tierCounterID("cgrates.org:tier_refund", "*out:cgrates.org:call:tiered", utils.MetaMonthly)
+	newCD := func(cgrID string, loopIndex float64, usage time.Duration) (*CallDescriptor, *CallCost) {
+		cd := &CallDescriptor{CgrID: cgrID, RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "tier_refund",
+			TimeStart: time.Date(2017, time.November, 16, 10, 0, 0, 0, time.UTC), LoopIndex: loopIndex}
+		cc := &CallCost{Timespans: TimeSpans{&TimeSpan{TimeStart: cd.TimeStart, TimeEnd: cd.TimeStart.Add(usage),
+			MatchedSubject: "*out:cgrates.org:call:tiered",
+			RateInterval:   &RateInterval{Rating: &RIRate{TierPeriod: utils.MetaMonthly}}}}}
+		return cd, cc
+	}
+	checkUsage := func(eCounted, eEvent time.Duration) {
+		if tc, err := dm.DataDB().GetTierCounter(tcID); err != nil {
+			t.Error(err)
+		} else if tc.Usage != eCounted {
+			t.Errorf("Expecting counted: %v, received: %s", eCounted, utils.ToJSON(tc))
+		}
+		if teu, err := dm.DataDB().GetTierEventUsage(evKey); eEvent == 0 {
+			if err != utils.ErrNotFound {
+				t.Errorf("Expecting the event usage removed, received: %s, err: %v", utils.ToJSON(teu), err)
+			}
+		} else if err != nil {
+			t.Error(err)
+		} else if teu.Counters[tcID].Usage != eEvent || !teu.ExpiryTime.After(time.Now()) {
+			t.Errorf("Expecting event usage: %v, received: %s", eEvent, utils.ToJSON(teu))
+		}
+	}
+	for _, ev := range []struct {
+		cgrID     string
+		loopIndex float64
+		usage     time.Duration
+	}{
+		{"cgrid1", 0, 2 * time.Minute},
+		{"cgrid1", 1, time.Minute}, // session update
+		{"", 0, time.Minute},       // cannot be recognized when rated again
+	} {
+		cd, cc := newCD(ev.cgrID, ev.loopIndex, ev.usage)
+		if err := cd.updateTierCounters(cc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkUsage(4*time.Minute, 3*time.Minute)
+	cd, cc := newCD("cgrid1", 0, 2*time.Minute) // CDR rated again
+	if err := cd.updateTierCounters(cc); err != nil {
+		t.Fatal(err)
+	}
+	checkUsage(3*time.Minute, 2*time.Minute)
+	cd.Increments = Increments{&Increment{Duration: time.Minute, CompressFactor: 3}}
+	if err := cd.refundTierCounters(); err != nil {
+		t.Error(err)
+	}
+	checkUsage(time.Minute, 0)
+}
@@ -154,6 +154,7 @@ func (ris RatingInfos) String() string {
 
 func (rpf *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error) {
 	var ris RatingInfos
+	dm := cd.getDataManager()
 	for index, rpa := range rpf.RatingPlanActivations.GetActiveForCall(cd) {
 		rpl, err := dm.DataDB().GetRatingPlan(rpa.RatingPlanId, false, utils.NonTransactional)
 		if err != nil || rpl == nil {
@@ -256,6 +257,10 @@ type TenantRatingSubject struct {
 }
 
 func RatingProfileSubjectPrefixMatching(key string) (rp *RatingProfile, err error) {
+	return ratingProfileSubjectPrefixMatching(dm, key)
+}
+
+func ratingProfileSubjectPrefixMatching(dm *DataManager, key string) (rp *RatingProfile, err error) {
 	if !rpSubjectPrefixMatching || strings.HasSuffix(key, utils.ANY) {
 		return dm.DataDB().GetRatingProfile(key, false, utils.NonTransactional)
 	}
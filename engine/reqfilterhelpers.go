@@ -24,9 +24,38 @@ import (
 	"github.com/cgrates/cgrates/utils"
 )
 
+// addMatchingItemIDs queries the index for fldName/fldVal and adds the results to itemIDs
+func addMatchingItemIDs(itemIDs utils.StringMap, dm *DataManager, dbIdxKey, fldName, fldVal string) (err error) {
+	dbItemIDs, err := dm.DataDB().MatchReqFilterIndex(dbIdxKey, fldName, fldVal)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			return nil
+		}
+		return
+	}
+	for itemID := range dbItemIDs {
+		if _, hasIt := itemIDs[itemID]; !hasIt { // Add it to list if not already there
+			itemIDs[itemID] = dbItemIDs[itemID]
+		}
+	}
+	return
+}
+
+// hasPrefixIndex checks if the field was indexed for one of the prefix based filter types (*string_prefix, *destinations)
+func hasPrefixIndex(dm *DataManager, dbIdxKey, fltrType, fldName string) (has bool, err error) {
+	if _, err = dm.DataDB().MatchReqFilterIndex(dbIdxKey, fltrType, fldName); err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	return true, nil
+}
+
 // matchingItemIDsForEvent returns the list of item IDs matching fieldName/fieldValue for an event
 // helper on top of dataDB.MatchReqFilterIndex, adding utils.NOT_AVAILABLE to list of fields queried
 // executes a number of $(len(fields) + 1) queries to dataDB so the size of event influences the speed of return
+// fields indexed by *string_prefix or *destinations filters add one query per prefix of the field value
 func matchingItemIDsForEvent(ev map[string]interface{}, dm *DataManager, dbIdxKey string) (itemIDs utils.StringMap, err error) {
 	itemIDs = make(utils.StringMap)
 	for fldName, fieldValIf := range ev {
@@ -34,30 +63,43 @@ func matchingItemIDsForEvent(ev map[string]interface{}, dm *DataManager, dbIdxKe
 		if !canCast {
 			return nil, fmt.Errorf("Cannot cast field: %s into string", fldName)
 		}
-		dbItemIDs, err := dm.DataDB().MatchReqFilterIndex(dbIdxKey, fldName, fldVal)
-		if err != nil {
-			if err == utils.ErrNotFound {
-				continue
-			}
+		if err = addMatchingItemIDs(itemIDs, dm, dbIdxKey, fldName, fldVal); err != nil {
 			return nil, err
 		}
-		for itemID := range dbItemIDs {
-			if _, hasIt := itemIDs[itemID]; !hasIt { // Add it to list if not already there
-				itemIDs[itemID] = dbItemIDs[itemID]
+		var hasIt bool
+		if hasIt, err = hasPrefixIndex(dm, dbIdxKey, MetaStringPrefix, fldName); err != nil {
+			return nil, err
+		} else if hasIt {
+			idxFldName := prefixIndexFieldName(MetaStringPrefix, fldName)
+			for _, p := range utils.SplitPrefix(fldVal, MIN_PREFIX_MATCH) {
+				if err = addMatchingItemIDs(itemIDs, dm, dbIdxKey, idxFldName, p); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-	dbItemIDs, err := dm.DataDB().MatchReqFilterIndex(dbIdxKey, utils.NOT_AVAILABLE, utils.NOT_AVAILABLE) // add unindexed itemIDs to be checked
-	if err != nil {
-		if err != utils.ErrNotFound {
+		if hasIt, err = hasPrefixIndex(dm, dbIdxKey, MetaDestinations, fldName); err != nil {
 			return nil, err
+		} else if hasIt {
+			idxFldName := prefixIndexFieldName(MetaDestinations, fldName)
+			for _, p := range utils.SplitPrefix(fldVal, MIN_PREFIX_MATCH) {
+				destIDs, err := dm.DataDB().GetReverseDestination(p, false, utils.NonTransactional)
+				if err != nil {
+					if err == utils.ErrNotFound {
+						continue
+					}
+					return nil, err
+				}
+				for _, dID := range destIDs {
+					if err = addMatchingItemIDs(itemIDs, dm, dbIdxKey, idxFldName, dID); err != nil {
+						return nil, err
+					}
+				}
+			}
 		}
-		err = nil // not found is ignored
 	}
-	for itemID := range dbItemIDs {
-		if _, hasIt := itemIDs[itemID]; !hasIt {
-			itemIDs[itemID] = dbItemIDs[itemID]
-		}
+	// add unindexed itemIDs to be checked
+	if err = addMatchingItemIDs(itemIDs, dm, dbIdxKey, utils.NOT_AVAILABLE, utils.NOT_AVAILABLE); err != nil {
+		return nil, err
 	}
 	return
 }
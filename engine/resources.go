@@ -249,7 +249,9 @@ func (rs Resources) allocateResource(ru *ResourceUsage, dryRun bool) (alcMessage
 		return "", utils.ErrResourceUnavailable
 	}
 	lockIDs := utils.PrefixSliceItems(rs.tenatIDsStr(), utils.ResourcesPrefix)
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...)
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...); err != nil {
+		return
+	}
 	defer guardian.Guardian.UnguardIDs(lockIDs...)
 	// Simulate resource usage
 	for _, r := range rs {
@@ -276,11 +278,14 @@ func (rs Resources) allocateResource(ru *ResourceUsage, dryRun bool) (alcMessage
 
 // Pas the config as a whole so we can ask access concurrently
 func NewResourceService(dm *DataManager, storeInterval time.Duration,
-	statS rpcclient.RpcClientConnection) (*ResourceService, error) {
+	statS, thdS rpcclient.RpcClientConnection) (*ResourceService, error) {
 	if statS != nil && reflect.ValueOf(statS).IsNil() {
 		statS = nil
 	}
-	return &ResourceService{dm: dm, statS: statS,
+	if thdS != nil && reflect.ValueOf(thdS).IsNil() {
+		thdS = nil
+	}
+	return &ResourceService{dm: dm, statS: statS, thdS: thdS,
 		lcEventResources: make(map[string][]*utils.TenantID),
 		storedResources:  make(utils.StringMap),
 		storeInterval:    storeInterval, stopBackup: make(chan struct{})}, nil
@@ -290,6 +295,7 @@ func NewResourceService(dm *DataManager, storeInterval time.Duration,
 type ResourceService struct {
 	dm               *DataManager                  // So we can load the data in cache and index it
 	statS            rpcclient.RpcClientConnection // allows applying filters based on stats
+	thdS             rpcclient.RpcClientConnection // notifies ThresholdS on usage changes
 	lcEventResources map[string][]*utils.TenantID  // cache recording resources for events in alocation phase
 	lcERMux          sync.RWMutex                  // protects the lcEventResources
 	storedResources  utils.StringMap               // keep a record of resources which need saving, map[resID]bool
@@ -377,6 +383,35 @@ func (rS *ResourceService) runBackup() {
 	}
 }
 
+// processThresholds will pass the resource usages to ThresholdS
+func (rS *ResourceService) processThresholds(rs Resources) (err error) {
+	if rS.thdS == nil {
+		return
+	}
+	for _, r := range rs {
+		thEv := &ThresholdEvent{
+			Tenant: r.Tenant,
+			ID:     utils.GenUUID(),
+			Fields: map[string]interface{}{
+				utils.EventSource: utils.ResourceS,
+				utils.ResourceID:  r.ID,
+				utils.USAGE:       r.totalUsage(),
+			},
+		}
+		if r.rPrf != nil {
+			thEv.ThresholdIDs = r.rPrf.Thresholds
+		}
+		var reply string
+		if errTh := rS.thdS.Call("ThresholdSV1.ProcessEvent", thEv, &reply); errTh != nil &&
+			errTh.Error() != utils.ErrNotFound.Error() {
+			utils.Logger.Warning(
+				fmt.Sprintf("<ResourceS> error: %s processing event %+v with ThresholdS.", errTh.Error(), thEv))
+			err = utils.ErrPartiallyExecuted
+		}
+	}
+	return
+}
+
 // cachedResourcesForEvent attempts to retrieve cached resources for an event
 // returns nil if event not cached or errors occur
 // returns []Resource if negative reply was cached
@@ -401,7 +436,12 @@ func (rS *ResourceService) cachedResourcesForEvent(evUUID string) (rs Resources)
 	for i, rTid := range rIDs {
 		lockIDs[i] = utils.ResourcesPrefix + rTid.TenantID()
 	}
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...)
+	if err := guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...); err != nil {
+		utils.Logger.Warning(
+			fmt.Sprintf("<ResourceS> failed locking resources for evUUID: <%s>, error: <%s>",
+				evUUID, err.Error()))
+		return nil
+	}
 	defer guardian.Guardian.UnguardIDs(lockIDs...)
 	for i, rTid := range rIDs {
 		if r, err := rS.dm.DataDB().GetResource(rTid.Tenant, rTid.ID, false, ""); err != nil {
@@ -432,7 +472,9 @@ func (rS *ResourceService) matchingResourcesForEvent(tenant string, ev map[strin
 		return nil, err
 	}
 	lockIDs := utils.PrefixSliceItems(rIDs.Slice(), utils.ResourceProfilesStringIndex)
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...)
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...); err != nil {
+		return nil, err
+	}
 	defer guardian.Guardian.UnguardIDs(lockIDs...)
 	for resName := range rIDs {
 		rPrf, err := rS.dm.DataDB().GetResourceProfile(tenant, resName, false, utils.NonTransactional)
@@ -586,6 +628,10 @@ func (rS *ResourceService) V1AllocateResource(args utils.ArgRSv1ResourceUsage, r
 			rS.srMux.Unlock()
 		}
 	}
+	if errTh := rS.processThresholds(mtcRLs); errTh != nil {
+		utils.Logger.Warning(
+			fmt.Sprintf("<ResourceS> allocating usage: %s, failed notifying ThresholdS: %s", args.UsageID, errTh.Error()))
+	}
 	*reply = alcMsg
 	return
 }
@@ -621,6 +667,10 @@ func (rS *ResourceService) V1ReleaseResource(args utils.ArgRSv1ResourceUsage, re
 	if rS.storeInterval != -1 {
 		rS.srMux.Unlock()
 	}
+	if errTh := rS.processThresholds(mtcRLs); errTh != nil {
+		utils.Logger.Warning(
+			fmt.Sprintf("<ResourceS> releasing usage: %s, failed notifying ThresholdS: %s", args.UsageID, errTh.Error()))
+	}
 	*reply = utils.OK
 	return nil
 }
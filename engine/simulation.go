@@ -0,0 +1,213 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// simulationDataDB serves the rating data of a tariff plan out of memory, isolated from the live DataDB and its cache
+type simulationDataDB struct {
+	*MapStorage    // empty, completes the DataDB interface
+	destinations   map[string]*Destination
+	revDests       map[string][]string
+	ratingPlans    map[string]*RatingPlan
+	ratingProfiles map[string]*RatingProfile
+	tierCounters   map[string]*TierCounter // cumulate the usage of the simulated CDRs only
+}
+
+func (sdb *simulationDataDB) GetDestination(key string, skipCache bool, transactionID string) (*Destination, error) {
+	if dst, has := sdb.destinations[key]; has {
+		return dst, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (sdb *simulationDataDB) GetReverseDestination(prefix string, skipCache bool, transactionID string) ([]string, error) {
+	if ids, has := sdb.revDests[prefix]; has {
+		return ids, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (sdb *simulationDataDB) GetRatingPlan(key string, skipCache bool, transactionID string) (*RatingPlan, error) {
+	if rp, has := sdb.ratingPlans[key]; has {
+		return rp, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (sdb *simulationDataDB) GetRatingProfile(key string, skipCache bool, transactionID string) (*RatingProfile, error) {
+	if rpf, has := sdb.ratingProfiles[key]; has {
+		return rpf, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (sdb *simulationDataDB) GetTierCounter(id string) (*TierCounter, error) {
+	if tc, has := sdb.tierCounters[id]; has {
+		return tc, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+// countTierUsage advances the simulated tier counters with the usage of a rated CDR
+func (sdb *simulationDataDB) countTierUsage(cd *CallDescriptor, cc *CallCost) error {
+	usages, periods := cd.tierUsages(cc)
+	for tcID, usage := range usages {
+		pStart, err := tierPeriodStart(periods[tcID], cd.TimeStart)
+		if err != nil {
+			return err
+		}
+		tc, has := sdb.tierCounters[tcID]
+		if !has {
+			tc = &TierCounter{ID: tcID, PeriodStart: pStart}
+			sdb.tierCounters[tcID] = tc
+		}
+		tc.countUsage(pStart, usage) // simulated CDRs are rated once, no need to track their usage
+	}
+	return nil
+}
+
+// SimulatedCost cumulates the costs of a group of re-rated CDRs
+type SimulatedCost struct {
+	CDRs          int
+	Usage         time.Duration
+	StoredCost    float64 // cost the CDRs were stored with
+	SimulatedCost float64 // cost out of the simulated tariff plan
+	Delta         float64 // SimulatedCost - StoredCost
+}
+
+func (sc *SimulatedCost) add(usage time.Duration, storedCost, simulatedCost float64) {
+	sc.CDRs++
+	sc.Usage += usage
+	sc.StoredCost += storedCost
+	sc.SimulatedCost += simulatedCost
+	sc.Delta = sc.SimulatedCost - sc.StoredCost
+}
+
+// RatingSimulation is the report of re-rating stored CDRs with a tariff plan out of StorDB
+type RatingSimulation struct {
+	TPid         string
+	Total        *SimulatedCost
+	Accounts     map[string]*SimulatedCost // tenant:account: costs
+	Destinations map[string]*SimulatedCost // matched destination ID: costs
+	Errors       map[string]string         // cgrid:runid: rating error
+}
+
+func NewRatingSimulation(tpid string) *RatingSimulation {
+	return &RatingSimulation{TPid: tpid, Total: new(SimulatedCost),
+		Accounts: make(map[string]*SimulatedCost), Destinations: make(map[string]*SimulatedCost),
+		Errors: make(map[string]string)}
+}
+
+func (rs *RatingSimulation) addCost(cdr *CDR, cc *CallCost) {
+	storedCost := cdr.Cost
+	if cdr.CostDetails != nil { // cost debited, without the taxes added by SureTax
+		storedCost = cdr.CostDetails.Cost
+	}
+	if storedCost < 0 { // not rated before
+		storedCost = 0
+	}
+	dstID := utils.META_NONE
+	if len(cc.Timespans) != 0 && cc.Timespans[0].MatchedDestId != "" {
+		dstID = cc.Timespans[0].MatchedDestId
+	}
+	acntID := utils.ConcatenatedKey(cdr.Tenant, cdr.Account)
+	if _, has := rs.Accounts[acntID]; !has {
+		rs.Accounts[acntID] = new(SimulatedCost)
+	}
+	if _, has := rs.Destinations[dstID]; !has {
+		rs.Destinations[dstID] = new(SimulatedCost)
+	}
+	for _, sc := range []*SimulatedCost{rs.Total, rs.Accounts[acntID], rs.Destinations[dstID]} {
+		sc.add(cdr.Usage, storedCost, cc.Cost)
+	}
+}
+
+// SimulateRating re-rates the CDRs matching the filter with the rating data of the tariff plan tpid
+// the tariff plan is kept in memory so neither the live rating data nor the accounts or the stored CDRs are changed
+// the *raw CDRs are skipped unless their RunID is requested, the tiered rates using the usage of the simulated CDRs only
+func SimulateRating(lr LoadReader, cdrDB CdrStorage, tpid, timezone string, cdrsFltr *utils.CDRsFilter) (rs *RatingSimulation, err error) {
+	sdb, err := newSimulationDataDB(lr, tpid, timezone)
+	if err != nil {
+		return
+	}
+	fltr := *cdrsFltr // the caller's filter is not changed
+	if !utils.IsSliceMember(fltr.RunIDs, utils.MetaRaw) {
+		fltr.NotRunIDs = append(append([]string{}, fltr.NotRunIDs...), utils.MetaRaw)
+	}
+	fltr.OrderBy = utils.SETUP_TIME // the tier counters advance as they did live
+	cdrsIter, err := cdrDB.GetCDRsIterator(&fltr)
+	if err != nil {
+		return
+	}
+	defer cdrsIter.Close()
+	simDM := NewDataManager(sdb)
+	rs = NewRatingSimulation(tpid)
+	for {
+		cdr, errNext := cdrsIter.Next()
+		if errNext == io.EOF {
+			break
+		} else if errNext != nil {
+			return nil, errNext
+		}
+		if cdr.RequestType == utils.META_NONE {
+			continue
+		}
+		cd := cdr.AsCallDescriptor()
+		cd.CgrID, cd.RunID = cdr.CGRID, cdr.RunID
+		cd.dataManager = simDM
+		cc, errCost := cd.GetCost()
+		if errCost != nil {
+			rs.Errors[utils.ConcatenatedKey(cdr.CGRID, cdr.RunID)] = errCost.Error()
+			continue
+		}
+		if err = sdb.countTierUsage(cd, cc); err != nil {
+			return nil, err
+		}
+		rs.addCost(cdr, cc)
+	}
+	return
+}
+
+// newSimulationDataDB loads the rating data of the tariff plan out of StorDB
+func newSimulationDataDB(lr LoadReader, tpid, timezone string) (sdb *simulationDataDB, err error) {
+	ms, err := NewMapStorage()
+	if err != nil {
+		return
+	}
+	tpr := NewTpReader(ms, lr, tpid, timezone)
+	for _, load := range []func() error{tpr.LoadDestinations, tpr.LoadTimings, tpr.LoadRates,
+		tpr.LoadDestinationRates, tpr.LoadRatingPlans, tpr.LoadRatingProfiles} {
+		if err = load(); err != nil && err != utils.ErrNotFound {
+			return
+		}
+	}
+	if len(tpr.ratingProfiles) == 0 {
+		return nil, fmt.Errorf("no rating profiles in tariff plan %s", tpid)
+	}
+	return &simulationDataDB{MapStorage: ms, destinations: tpr.destinations, revDests: tpr.revDests,
+		ratingPlans: tpr.ratingPlans, ratingProfiles: tpr.ratingProfiles,
+		tierCounters: make(map[string]*TierCounter)}, nil
+}
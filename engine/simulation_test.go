@@ -0,0 +1,153 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSimulateRating(t *testing.T) {
+	lr := NewStringCSVStorage(',',
+		`DST_SIM_49,+49`,
+		`ALWAYS_SIM,*any,*any,*any,*any,00:00:00`,
+		`RT_SIM_1CNT,0,0.01,1s,1s,0s`,
+		`DR_SIM_49,DST_SIM_49,RT_SIM_1CNT,*up,4,0,,,,`,
+		`RP_SIM,DR_SIM_49,ALWAYS_SIM,10`,
+		`*out,sim.org,call,*any,2014-01-01T00:00:00Z,RP_SIM,,`,
+		"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "")
+	cdrDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupTime := time.Date(2017, 12, 1, 14, 0, 0, 0, time.UTC)
+	for i, cdr := range []*CDR{
+		&CDR{CGRID: "CGRID1", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim.org", Category: "call", Account: "1001", Subject: "1001",
+			Destination: "+4986517174963", SetupTime: setupTime, AnswerTime: setupTime, Usage: time.Minute, Cost: 0.5},
+		&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim.org", Category: "call", Account: "1001", Subject: "1001",
+			Destination: "+4915117174963", SetupTime: setupTime, AnswerTime: setupTime, Usage: time.Minute, Cost: 0.5},
+		&CDR{CGRID: "CGRID3", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim.org", Category: "call", Account: "1002", Subject: "1002",
+			Destination: "+33123", SetupTime: setupTime, AnswerTime: setupTime, Usage: time.Minute, Cost: 0.5},
+	} {
+		if err := cdrDB.SetCDR(cdr, false); err != nil {
+			t.Fatalf("CDR %d, error: %v", i, err)
+		}
+	}
+	rs, err := SimulateRating(lr, cdrDB, "", "UTC", &utils.CDRsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eCost := &SimulatedCost{CDRs: 2, Usage: 2 * time.Minute, StoredCost: 1, SimulatedCost: 1.2}
+	for _, sc := range []*SimulatedCost{rs.Total, rs.Accounts["sim.org:1001"], rs.Destinations["DST_SIM_49"]} {
+		if sc == nil {
+			t.Fatalf("Missing costs in: %s", utils.ToJSON(rs))
+		}
+		sc.SimulatedCost = utils.Round(sc.SimulatedCost, 4, utils.ROUNDING_MIDDLE)
+		if sc.CDRs != eCost.CDRs || sc.Usage != eCost.Usage || sc.StoredCost != eCost.StoredCost ||
+			sc.SimulatedCost != eCost.SimulatedCost || utils.Round(sc.Delta, 4, utils.ROUNDING_MIDDLE) != 0.2 {
+			t.Errorf("Expecting: %+v, received: %+v", eCost, sc)
+		}
+	}
+	if _, has := rs.Accounts["sim.org:1002"]; has {
+		t.Errorf("Unexpected costs for unrated account: %s", utils.ToJSON(rs.Accounts))
+	}
+	if _, has := rs.Errors["CGRID3:*default"]; !has || len(rs.Errors) != 1 {
+		t.Errorf("Unexpected errors: %+v", rs.Errors)
+	}
+	// the live rating data is left untouched
+	if _, err := dm.DataDB().GetRatingPlan("RP_SIM", true, utils.NonTransactional); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if _, err := dm.DataDB().GetRatingProfile("*out:sim.org:call:*any", false, utils.NonTransactional); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if cdrs, _, err := cdrDB.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{"CGRID1"}}, false); err != nil {
+		t.Error(err)
+	} else if cdrs[0].Cost != 0.5 {
+		t.Errorf("Stored CDR changed: %s", utils.ToJSON(cdrs[0]))
+	}
+}
+
+func TestSimulateRatingTiers(t *testing.T) {
+	lr := NewStringCSVStorage(',',
+		`DST_SIM_33,+33
+DST_SIM_44,+44`,
+		`ALWAYS_SIM,*any,*any,*any,*any,00:00:00`,
+		`RT_SIM_1CNT,0,0.01,1s,1s,0s
+RT_SIM_FREE,0,0,1s,1s,0s`,
+		`DR_SIM_TIER,DST_SIM_33,RT_SIM_1CNT,*up,4,0,,,*daily,
+DR_SIM_TIER,DST_SIM_33,RT_SIM_FREE,*up,4,0,,1m,*daily,
+DR_SIM_TIER,DST_SIM_44,RT_SIM_1CNT,*up,4,0,,,*daily,
+DR_SIM_TIER,DST_SIM_44,RT_SIM_FREE,*up,4,0,,1m,*daily,`,
+		`RP_SIM_TIER,DR_SIM_TIER,ALWAYS_SIM,10`,
+		`*out,sim_tier.org,call,*any,2014-01-01T00:00:00Z,RP_SIM_TIER,,`,
+		"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "")
+	cdrDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupTime := time.Date(2017, 12, 1, 14, 0, 0, 0, time.UTC)
+	for i, cdr := range []*CDR{
+		&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim_tier.org", Category: "call", Account: "1001", Subject: "1001",
+			Destination: "+44123", SetupTime: setupTime.Add(time.Hour), AnswerTime: setupTime.Add(time.Hour),
+			Usage: time.Minute, Cost: 0},
+		&CDR{CGRID: "CGRID1", RunID: utils.META_DEFAULT, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim_tier.org", Category: "call", Account: "1001", Subject: "1001",
+			Destination: "+33123", SetupTime: setupTime, AnswerTime: setupTime, Usage: time.Minute, Cost: 0.72,
+			CostDetails: &CallCost{Direction: utils.OUT, Destination: "+33123", Cost: 0.6}}, // taxed by SureTax
+		&CDR{CGRID: "CGRID1", RunID: utils.MetaRaw, ToR: utils.VOICE, RequestType: utils.META_POSTPAID,
+			Direction: utils.OUT, Tenant: "sim_tier.org", Category: "call", Account: "1001", Subject: "1001",
+			Destination: "+33123", SetupTime: setupTime, AnswerTime: setupTime, Usage: time.Minute, Cost: -1},
+	} {
+		if err := cdrDB.SetCDR(cdr, false); err != nil {
+			t.Fatalf("CDR %d, error: %v", i, err)
+		}
+	}
+	cdrsFltr := &utils.CDRsFilter{}
+	rs, err := SimulateRating(lr, cdrDB, "", "UTC", cdrsFltr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cdrsFltr.NotRunIDs) != 0 || cdrsFltr.OrderBy != "" {
+		t.Errorf("Filter changed: %+v", cdrsFltr)
+	}
+	if len(rs.Errors) != 0 {
+		t.Errorf("Unexpected errors: %+v", rs.Errors)
+	}
+	rs.Total.SimulatedCost = utils.Round(rs.Total.SimulatedCost, 4, utils.ROUNDING_MIDDLE)
+	if rs.Total.CDRs != 2 || rs.Total.StoredCost != 0.6 || rs.Total.SimulatedCost != 0.6 {
+		t.Errorf("Unexpected costs: %+v", rs.Total)
+	}
+	// the tier is reached by the earlier call, the later one being free
+	if sc := rs.Destinations["DST_SIM_33"]; sc == nil || utils.Round(sc.SimulatedCost, 4, utils.ROUNDING_MIDDLE) != 0.6 {
+		t.Errorf("Unexpected costs: %s", utils.ToJSON(rs.Destinations))
+	} else if sc := rs.Destinations["DST_SIM_44"]; sc == nil || sc.SimulatedCost != 0 {
+		t.Errorf("Unexpected costs: %s", utils.ToJSON(rs.Destinations))
+	}
+	// the live tier counters are left untouched
+	if _, err := dm.DataDB().GetTierCounter(tierCounterID("sim_tier.org:1001", "*out:sim_tier.org:call:*any", utils.MetaDaily)); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+}
@@ -23,11 +23,16 @@ import (
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/utils"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// percentileAccuracy is the relative accuracy of the sketch used by percentile metrics
+const percentileAccuracy = 0.01
+
 // NewStatMetric instantiates the StatMetric
 // cfg serves as general purpose container to pass config options to metric
+// parameterised metrics receive the field name after the metric type, ie: *sum:Usage or *p95:PDD
 func NewStatMetric(metricID string, minItems int) (sm StatMetric, err error) {
 	metrics := map[string]func(int) (StatMetric, error){
 		utils.MetaASR: NewASR,
@@ -38,10 +43,55 @@ func NewStatMetric(metricID string, minItems int) (sm StatMetric, err error) {
 		utils.MetaPDD: NewPDD,
 		utils.MetaDDC: NewDCC,
 	}
-	if _, has := metrics[metricID]; !has {
+	if _, has := metrics[metricID]; has {
+		return metrics[metricID](minItems)
+	}
+	fieldMetrics := map[string]func(int, string) (StatMetric, error){
+		utils.MetaSum:      NewStatSum,
+		utils.MetaAverage:  NewStatAverage,
+		utils.MetaDistinct: NewStatDistinct,
+	}
+	metricSplt := strings.SplitN(metricID, utils.CONCATENATED_KEY_SEP, 2)
+	if len(metricSplt) != 2 || metricSplt[1] == "" {
 		return nil, fmt.Errorf("unsupported metric: %s", metricID)
 	}
-	return metrics[metricID](minItems)
+	if _, has := fieldMetrics[metricSplt[0]]; has {
+		return fieldMetrics[metricSplt[0]](minItems, metricSplt[1])
+	}
+	if strings.HasPrefix(metricSplt[0], utils.MetaPercentilePrefix) {
+		if pctl, err := strconv.ParseFloat(metricSplt[0][len(utils.MetaPercentilePrefix):], 64); err == nil {
+			return NewStatPercentile(minItems, metricSplt[1], pctl)
+		}
+	}
+	return nil, fmt.Errorf("unsupported metric: %s", metricID)
+}
+
+// StatBucket holds the values aggregated by a metric within one time bucket of a StatQueue
+type StatBucket struct {
+	Sum    float64
+	Count  float64
+	Values utils.StringMap       // distinct values seen within the bucket
+	Sketch *utils.QuantileSketch // values sketch for percentile metrics
+}
+
+// getStatBucket returns the bucket with bucketID, creating it if not already there
+func getStatBucket(buckets *map[string]*StatBucket, bucketID string) (sb *StatBucket) {
+	if *buckets == nil {
+		*buckets = make(map[string]*StatBucket)
+	}
+	if sb = (*buckets)[bucketID]; sb == nil {
+		sb = new(StatBucket)
+		(*buckets)[bucketID] = sb
+	}
+	return
+}
+
+// bucketsCount returns the number of events aggregated in buckets
+func bucketsCount(buckets map[string]*StatBucket) (cnt int) {
+	for _, sb := range buckets {
+		cnt += int(sb.Count)
+	}
+	return
 }
 
 // StatMetric is the interface which a metric should implement
@@ -50,6 +100,7 @@ type StatMetric interface {
 	GetStringValue(fmtOpts string) (val string)
 	GetFloat64Value() (val float64)
 	AddEvent(ev *StatEvent) error
+	AddBucketEvent(bucketID string, ev *StatEvent) error // aggregates the event into bucketID, RemEvent(bucketID) removes the whole bucket
 	RemEvent(evTenantID string) error
 	Marshal(ms Marshaler) (marshaled []byte, err error)
 	LoadMarshaled(ms Marshaler, marshaled []byte) (err error)
@@ -63,7 +114,8 @@ func NewASR(minItems int) (StatMetric, error) {
 type StatASR struct {
 	Answered float64
 	Count    float64
-	Events   map[string]bool // map[EventTenantID]Answered
+	Events   map[string]bool        // map[EventTenantID]Answered
+	Buckets  map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *float64 // cached ASR value
 }
@@ -71,7 +123,7 @@ type StatASR struct {
 // getValue returns asr.val
 func (asr *StatASR) getValue() float64 {
 	if asr.val == nil {
-		if (asr.MinItems > 0 && len(asr.Events) < asr.MinItems) || (asr.Count == 0) {
+		if (asr.MinItems > 0 && asr.Count < float64(asr.MinItems)) || (asr.Count == 0) {
 			asr.val = utils.Float64Pointer(STATS_NA)
 		} else {
 			asr.val = utils.Float64Pointer(utils.Round((asr.Answered / asr.Count * 100),
@@ -118,7 +170,28 @@ func (asr *StatASR) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+// AddBucketEvent is part of StatMetric interface
+func (asr *StatASR) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = asr.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&asr.Buckets, bucketID)
+	if asr.Events[ev.TenantID()] {
+		sb.Sum += 1
+	}
+	sb.Count += 1
+	delete(asr.Events, ev.TenantID())
+	return
+}
+
 func (asr *StatASR) RemEvent(evTenantID string) (err error) {
+	if sb, has := asr.Buckets[evTenantID]; has {
+		asr.Answered -= sb.Sum
+		asr.Count -= sb.Count
+		delete(asr.Buckets, evTenantID)
+		asr.val = nil
+		return
+	}
 	answered, has := asr.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -151,6 +224,7 @@ type StatACD struct {
 	Sum      time.Duration
 	Count    int64
 	Events   map[string]time.Duration // map[EventTenantID]Duration
+	Buckets  map[string]*StatBucket   // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *time.Duration // cached ACD value
 }
@@ -158,7 +232,7 @@ type StatACD struct {
 // getValue returns acr.val
 func (acd *StatACD) getValue() time.Duration {
 	if acd.val == nil {
-		if (acd.MinItems > 0 && len(acd.Events) < acd.MinItems) || (acd.Count == 0) {
+		if (acd.MinItems > 0 && acd.Count < int64(acd.MinItems)) || (acd.Count == 0) {
 			acd.val = utils.DurationPointer(time.Duration((-1) * time.Nanosecond))
 		} else {
 			acd.val = utils.DurationPointer(time.Duration(acd.Sum.Nanoseconds() / acd.Count))
@@ -208,7 +282,25 @@ func (acd *StatACD) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (acd *StatACD) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = acd.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&acd.Buckets, bucketID)
+	sb.Sum += float64(acd.Events[ev.TenantID()])
+	sb.Count += 1
+	delete(acd.Events, ev.TenantID())
+	return
+}
+
 func (acd *StatACD) RemEvent(evTenantID string) (err error) {
+	if sb, has := acd.Buckets[evTenantID]; has {
+		acd.Sum -= time.Duration(sb.Sum)
+		acd.Count -= int64(sb.Count)
+		delete(acd.Buckets, evTenantID)
+		acd.val = nil
+		return
+	}
 	duration, has := acd.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -238,6 +330,7 @@ type StatTCD struct {
 	Sum      time.Duration
 	Count    int64
 	Events   map[string]time.Duration // map[EventTenantID]Duration
+	Buckets  map[string]*StatBucket   // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *time.Duration // cached TCD value
 }
@@ -245,7 +338,7 @@ type StatTCD struct {
 // getValue returns tcd.val
 func (tcd *StatTCD) getValue() time.Duration {
 	if tcd.val == nil {
-		if (tcd.MinItems > 0 && len(tcd.Events) < tcd.MinItems) || (tcd.Count == 0) {
+		if (tcd.MinItems > 0 && tcd.Count < int64(tcd.MinItems)) || (tcd.Count == 0) {
 			tcd.val = utils.DurationPointer(time.Duration((-1) * time.Nanosecond))
 		} else {
 			tcd.val = utils.DurationPointer(time.Duration(tcd.Sum.Nanoseconds()))
@@ -296,7 +389,25 @@ func (tcd *StatTCD) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (tcd *StatTCD) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = tcd.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&tcd.Buckets, bucketID)
+	sb.Sum += float64(tcd.Events[ev.TenantID()])
+	sb.Count += 1
+	delete(tcd.Events, ev.TenantID())
+	return
+}
+
 func (tcd *StatTCD) RemEvent(evTenantID string) (err error) {
+	if sb, has := tcd.Buckets[evTenantID]; has {
+		tcd.Sum -= time.Duration(sb.Sum)
+		tcd.Count -= int64(sb.Count)
+		delete(tcd.Buckets, evTenantID)
+		tcd.val = nil
+		return
+	}
 	duration, has := tcd.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -326,7 +437,8 @@ func NewACC(minItems int) (StatMetric, error) {
 type StatACC struct {
 	Sum      float64
 	Count    float64
-	Events   map[string]float64 // map[EventTenantID]Cost
+	Events   map[string]float64     // map[EventTenantID]Cost
+	Buckets  map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *float64 // cached ACC value
 }
@@ -334,7 +446,7 @@ type StatACC struct {
 // getValue returns tcd.val
 func (acc *StatACC) getValue() float64 {
 	if acc.val == nil {
-		if (acc.MinItems > 0 && len(acc.Events) < acc.MinItems) || (acc.Count == 0) {
+		if (acc.MinItems > 0 && acc.Count < float64(acc.MinItems)) || (acc.Count == 0) {
 			acc.val = utils.Float64Pointer(STATS_NA)
 		} else {
 			acc.val = utils.Float64Pointer(utils.Round((acc.Sum / acc.Count),
@@ -381,7 +493,25 @@ func (acc *StatACC) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (acc *StatACC) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = acc.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&acc.Buckets, bucketID)
+	sb.Sum += acc.Events[ev.TenantID()]
+	sb.Count += 1
+	delete(acc.Events, ev.TenantID())
+	return
+}
+
 func (acc *StatACC) RemEvent(evTenantID string) (err error) {
+	if sb, has := acc.Buckets[evTenantID]; has {
+		acc.Sum -= sb.Sum
+		acc.Count -= sb.Count
+		delete(acc.Buckets, evTenantID)
+		acc.val = nil
+		return
+	}
 	cost, has := acc.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -411,7 +541,8 @@ func NewTCC(minItems int) (StatMetric, error) {
 type StatTCC struct {
 	Sum      float64
 	Count    float64
-	Events   map[string]float64 // map[EventTenantID]Cost
+	Events   map[string]float64     // map[EventTenantID]Cost
+	Buckets  map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *float64 // cached TCC value
 }
@@ -419,7 +550,7 @@ type StatTCC struct {
 // getValue returns tcd.val
 func (tcc *StatTCC) getValue() float64 {
 	if tcc.val == nil {
-		if (tcc.MinItems > 0 && len(tcc.Events) < tcc.MinItems) || (tcc.Count == 0) {
+		if (tcc.MinItems > 0 && tcc.Count < float64(tcc.MinItems)) || (tcc.Count == 0) {
 			tcc.val = utils.Float64Pointer(STATS_NA)
 		} else {
 			tcc.val = utils.Float64Pointer(utils.Round(tcc.Sum,
@@ -465,7 +596,25 @@ func (tcc *StatTCC) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (tcc *StatTCC) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = tcc.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&tcc.Buckets, bucketID)
+	sb.Sum += tcc.Events[ev.TenantID()]
+	sb.Count += 1
+	delete(tcc.Events, ev.TenantID())
+	return
+}
+
 func (tcc *StatTCC) RemEvent(evTenantID string) (err error) {
+	if sb, has := tcc.Buckets[evTenantID]; has {
+		tcc.Sum -= sb.Sum
+		tcc.Count -= sb.Count
+		delete(tcc.Buckets, evTenantID)
+		tcc.val = nil
+		return
+	}
 	cost, has := tcc.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -496,6 +645,7 @@ type StatPDD struct {
 	Sum      time.Duration
 	Count    int64
 	Events   map[string]time.Duration // map[EventTenantID]Duration
+	Buckets  map[string]*StatBucket   // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems int
 	val      *time.Duration // cached PDD value
 }
@@ -503,7 +653,7 @@ type StatPDD struct {
 // getValue returns pdd.val
 func (pdd *StatPDD) getValue() time.Duration {
 	if pdd.val == nil {
-		if (pdd.MinItems > 0 && len(pdd.Events) < pdd.MinItems) || (pdd.Count == 0) {
+		if (pdd.MinItems > 0 && pdd.Count < int64(pdd.MinItems)) || (pdd.Count == 0) {
 			pdd.val = utils.DurationPointer(time.Duration((-1) * time.Nanosecond))
 		} else {
 			pdd.val = utils.DurationPointer(time.Duration(pdd.Sum.Nanoseconds() / pdd.Count))
@@ -554,7 +704,25 @@ func (pdd *StatPDD) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (pdd *StatPDD) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = pdd.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&pdd.Buckets, bucketID)
+	sb.Sum += float64(pdd.Events[ev.TenantID()])
+	sb.Count += 1
+	delete(pdd.Events, ev.TenantID())
+	return
+}
+
 func (pdd *StatPDD) RemEvent(evTenantID string) (err error) {
+	if sb, has := pdd.Buckets[evTenantID]; has {
+		pdd.Sum -= time.Duration(sb.Sum)
+		pdd.Count -= int64(sb.Count)
+		delete(pdd.Buckets, evTenantID)
+		pdd.val = nil
+		return
+	}
 	duration, has := pdd.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -581,13 +749,14 @@ func NewDCC(minItems int) (StatMetric, error) {
 
 // DDC implements Destination Distinct Count metric
 type StatDDC struct {
-	Destinations map[string]utils.StringMap
-	Events       map[string]string // map[EventTenantID]Destination
+	Destinations map[string]utils.StringMap // map[Destination]map[EventTenantID or BucketID]bool
+	Events       map[string]string          // map[EventTenantID]Destination
+	Buckets      map[string]*StatBucket     // map[BucketID]*StatBucket, populated in bucketed queues
 	MinItems     int
 }
 
 func (ddc *StatDDC) GetStringValue(fmtOpts string) (valStr string) {
-	if val := len(ddc.Destinations); (val == 0) || (ddc.MinItems > 0 && len(ddc.Events) < ddc.MinItems) {
+	if val := len(ddc.Destinations); (val == 0) || (ddc.MinItems > 0 && len(ddc.Events)+bucketsCount(ddc.Buckets) < ddc.MinItems) {
 		valStr = utils.NOT_AVAILABLE
 	} else {
 		valStr = fmt.Sprintf("%+v", len(ddc.Destinations))
@@ -600,7 +769,7 @@ func (ddc *StatDDC) GetValue() (v interface{}) {
 }
 
 func (ddc *StatDDC) GetFloat64Value() (v float64) {
-	if val := len(ddc.Destinations); (val == 0) || (ddc.MinItems > 0 && len(ddc.Events) < ddc.MinItems) {
+	if val := len(ddc.Destinations); (val == 0) || (ddc.MinItems > 0 && len(ddc.Events)+bucketsCount(ddc.Buckets) < ddc.MinItems) {
 		v = -1.0
 	} else {
 		v = float64(len(ddc.Destinations))
@@ -621,7 +790,35 @@ func (ddc *StatDDC) AddEvent(ev *StatEvent) (err error) {
 	return
 }
 
+func (ddc *StatDDC) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	var dest string
+	if dest, err = ev.Destination(); err != nil {
+		return err
+	}
+	if _, has := ddc.Destinations[dest]; !has {
+		ddc.Destinations[dest] = make(map[string]bool)
+	}
+	ddc.Destinations[dest][bucketID] = true
+	sb := getStatBucket(&ddc.Buckets, bucketID)
+	if sb.Values == nil {
+		sb.Values = make(utils.StringMap)
+	}
+	sb.Values[dest] = true
+	sb.Count += 1
+	return
+}
+
 func (ddc *StatDDC) RemEvent(evTenantID string) (err error) {
+	if sb, has := ddc.Buckets[evTenantID]; has {
+		for dest := range sb.Values {
+			delete(ddc.Destinations[dest], evTenantID)
+			if len(ddc.Destinations[dest]) == 0 {
+				delete(ddc.Destinations, dest)
+			}
+		}
+		delete(ddc.Buckets, evTenantID)
+		return
+	}
 	destination, has := ddc.Events[evTenantID]
 	if !has {
 		return utils.ErrNotFound
@@ -636,8 +833,406 @@ func (ddc *StatDDC) RemEvent(evTenantID string) (err error) {
 }
 
 func (ddc *StatDDC) Marshal(ms Marshaler) (marshaled []byte, err error) {
-	return ms.Marshal(DDC)
+	return ms.Marshal(ddc)
 }
 func (ddc *StatDDC) LoadMarshaled(ms Marshaler, marshaled []byte) (err error) {
 	return ms.Unmarshal(marshaled, ddc)
 }
+
+func NewStatSum(minItems int, fieldName string) (StatMetric, error) {
+	return &StatSum{Events: make(map[string]float64), MinItems: minItems, FieldName: fieldName}, nil
+}
+
+// StatSum implements the *sum:FieldName metric
+type StatSum struct {
+	Sum       float64
+	Events    map[string]float64     // map[EventTenantID]Value
+	Buckets   map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
+	MinItems  int
+	FieldName string
+	val       *float64 // cached sum value
+}
+
+// getValue returns sum.val
+func (sum *StatSum) getValue() float64 {
+	if sum.val == nil {
+		if nrItems := len(sum.Events) + bucketsCount(sum.Buckets); nrItems == 0 ||
+			(sum.MinItems > 0 && nrItems < sum.MinItems) {
+			sum.val = utils.Float64Pointer(STATS_NA)
+		} else {
+			sum.val = utils.Float64Pointer(utils.Round(sum.Sum,
+				config.CgrConfig().RoundingDecimals, utils.ROUNDING_MIDDLE))
+		}
+	}
+	return *sum.val
+}
+
+func (sum *StatSum) GetStringValue(fmtOpts string) (valStr string) {
+	if val := sum.getValue(); val == STATS_NA {
+		valStr = utils.NOT_AVAILABLE
+	} else {
+		valStr = strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return
+}
+
+func (sum *StatSum) GetValue() (v interface{}) {
+	return sum.getValue()
+}
+
+func (sum *StatSum) GetFloat64Value() (v float64) {
+	return sum.getValue()
+}
+
+func (sum *StatSum) AddEvent(ev *StatEvent) (err error) {
+	var val float64
+	if val, err = ev.FieldAsFloat64(sum.FieldName); err != nil {
+		return
+	}
+	sum.Sum += val
+	sum.Events[ev.TenantID()] = val
+	sum.val = nil
+	return
+}
+
+func (sum *StatSum) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = sum.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&sum.Buckets, bucketID)
+	sb.Sum += sum.Events[ev.TenantID()]
+	sb.Count += 1
+	delete(sum.Events, ev.TenantID())
+	return
+}
+
+func (sum *StatSum) RemEvent(evTenantID string) (err error) {
+	if sb, has := sum.Buckets[evTenantID]; has {
+		sum.Sum -= sb.Sum
+		delete(sum.Buckets, evTenantID)
+		sum.val = nil
+		return
+	}
+	val, has := sum.Events[evTenantID]
+	if !has {
+		return utils.ErrNotFound
+	}
+	sum.Sum -= val
+	delete(sum.Events, evTenantID)
+	sum.val = nil
+	return
+}
+
+func (sum *StatSum) Marshal(ms Marshaler) (marshaled []byte, err error) {
+	return ms.Marshal(sum)
+}
+
+func (sum *StatSum) LoadMarshaled(ms Marshaler, marshaled []byte) (err error) {
+	return ms.Unmarshal(marshaled, sum)
+}
+
+func NewStatAverage(minItems int, fieldName string) (StatMetric, error) {
+	return &StatAverage{Events: make(map[string]float64), MinItems: minItems, FieldName: fieldName}, nil
+}
+
+// StatAverage implements the *average:FieldName metric
+type StatAverage struct {
+	Sum       float64
+	Count     float64
+	Events    map[string]float64     // map[EventTenantID]Value
+	Buckets   map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
+	MinItems  int
+	FieldName string
+	val       *float64 // cached average value
+}
+
+// getValue returns avg.val
+func (avg *StatAverage) getValue() float64 {
+	if avg.val == nil {
+		if (avg.MinItems > 0 && avg.Count < float64(avg.MinItems)) || (avg.Count == 0) {
+			avg.val = utils.Float64Pointer(STATS_NA)
+		} else {
+			avg.val = utils.Float64Pointer(utils.Round((avg.Sum / avg.Count),
+				config.CgrConfig().RoundingDecimals, utils.ROUNDING_MIDDLE))
+		}
+	}
+	return *avg.val
+}
+
+func (avg *StatAverage) GetStringValue(fmtOpts string) (valStr string) {
+	if val := avg.getValue(); val == STATS_NA {
+		valStr = utils.NOT_AVAILABLE
+	} else {
+		valStr = strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return
+}
+
+func (avg *StatAverage) GetValue() (v interface{}) {
+	return avg.getValue()
+}
+
+func (avg *StatAverage) GetFloat64Value() (v float64) {
+	return avg.getValue()
+}
+
+func (avg *StatAverage) AddEvent(ev *StatEvent) (err error) {
+	var val float64
+	if val, err = ev.FieldAsFloat64(avg.FieldName); err != nil {
+		return
+	}
+	avg.Sum += val
+	avg.Count += 1
+	avg.Events[ev.TenantID()] = val
+	avg.val = nil
+	return
+}
+
+func (avg *StatAverage) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	if err = avg.AddEvent(ev); err != nil {
+		return
+	}
+	sb := getStatBucket(&avg.Buckets, bucketID)
+	sb.Sum += avg.Events[ev.TenantID()]
+	sb.Count += 1
+	delete(avg.Events, ev.TenantID())
+	return
+}
+
+func (avg *StatAverage) RemEvent(evTenantID string) (err error) {
+	if sb, has := avg.Buckets[evTenantID]; has {
+		avg.Sum -= sb.Sum
+		avg.Count -= sb.Count
+		delete(avg.Buckets, evTenantID)
+		avg.val = nil
+		return
+	}
+	val, has := avg.Events[evTenantID]
+	if !has {
+		return utils.ErrNotFound
+	}
+	avg.Sum -= val
+	avg.Count -= 1
+	delete(avg.Events, evTenantID)
+	avg.val = nil
+	return
+}
+
+func (avg *StatAverage) Marshal(ms Marshaler) (marshaled []byte, err error) {
+	return ms.Marshal(avg)
+}
+
+func (avg *StatAverage) LoadMarshaled(ms Marshaler, marshaled []byte) (err error) {
+	return ms.Unmarshal(marshaled, avg)
+}
+
+func NewStatDistinct(minItems int, fieldName string) (StatMetric, error) {
+	return &StatDistinct{Values: make(map[string]utils.StringMap), Events: make(map[string]string),
+		MinItems: minItems, FieldName: fieldName}, nil
+}
+
+// StatDistinct implements the *distinct:FieldName metric, counting the distinct values of a field
+type StatDistinct struct {
+	Values    map[string]utils.StringMap // map[FieldValue]map[EventTenantID or BucketID]bool
+	Events    map[string]string          // map[EventTenantID]FieldValue
+	Buckets   map[string]*StatBucket     // map[BucketID]*StatBucket, populated in bucketed queues
+	MinItems  int
+	FieldName string
+}
+
+func (dst *StatDistinct) GetStringValue(fmtOpts string) (valStr string) {
+	if val := len(dst.Values); (val == 0) || (dst.MinItems > 0 && len(dst.Events)+bucketsCount(dst.Buckets) < dst.MinItems) {
+		valStr = utils.NOT_AVAILABLE
+	} else {
+		valStr = fmt.Sprintf("%+v", len(dst.Values))
+	}
+	return
+}
+
+func (dst *StatDistinct) GetValue() (v interface{}) {
+	return dst.GetFloat64Value()
+}
+
+func (dst *StatDistinct) GetFloat64Value() (v float64) {
+	if val := len(dst.Values); (val == 0) || (dst.MinItems > 0 && len(dst.Events)+bucketsCount(dst.Buckets) < dst.MinItems) {
+		v = -1.0
+	} else {
+		v = float64(len(dst.Values))
+	}
+	return
+}
+
+func (dst *StatDistinct) AddEvent(ev *StatEvent) (err error) {
+	var val string
+	if val, err = ev.FieldAsString(dst.FieldName); err != nil {
+		return
+	}
+	if _, has := dst.Values[val]; !has {
+		dst.Values[val] = make(utils.StringMap)
+	}
+	dst.Values[val][ev.TenantID()] = true
+	dst.Events[ev.TenantID()] = val
+	return
+}
+
+func (dst *StatDistinct) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	var val string
+	if val, err = ev.FieldAsString(dst.FieldName); err != nil {
+		return
+	}
+	if _, has := dst.Values[val]; !has {
+		dst.Values[val] = make(utils.StringMap)
+	}
+	dst.Values[val][bucketID] = true
+	sb := getStatBucket(&dst.Buckets, bucketID)
+	if sb.Values == nil {
+		sb.Values = make(utils.StringMap)
+	}
+	sb.Values[val] = true
+	sb.Count += 1
+	return
+}
+
+func (dst *StatDistinct) RemEvent(evTenantID string) (err error) {
+	if sb, has := dst.Buckets[evTenantID]; has {
+		for val := range sb.Values {
+			delete(dst.Values[val], evTenantID)
+			if len(dst.Values[val]) == 0 {
+				delete(dst.Values, val)
+			}
+		}
+		delete(dst.Buckets, evTenantID)
+		return
+	}
+	val, has := dst.Events[evTenantID]
+	if !has {
+		return utils.ErrNotFound
+	}
+	delete(dst.Events, evTenantID)
+	if len(dst.Values[val]) == 1 {
+		delete(dst.Values, val)
+		return
+	}
+	delete(dst.Values[val], evTenantID)
+	return
+}
+
+func (dst *StatDistinct) Marshal(ms Marshaler) (marshaled []byte, err error) {
+	return ms.Marshal(dst)
+}
+
+func (dst *StatDistinct) LoadMarshaled(ms Marshaler, marshaled []byte) (err error) {
+	return ms.Unmarshal(marshaled, dst)
+}
+
+func NewStatPercentile(minItems int, fieldName string, percentile float64) (StatMetric, error) {
+	if percentile <= 0 || percentile >= 100 {
+		return nil, fmt.Errorf("unsupported percentile: %v", percentile)
+	}
+	sketch, err := utils.NewQuantileSketch(percentileAccuracy)
+	if err != nil {
+		return nil, err
+	}
+	return &StatPercentile{Sketch: sketch, Events: make(map[string]float64),
+		MinItems: minItems, FieldName: fieldName, Percentile: percentile}, nil
+}
+
+// StatPercentile implements the *pNN:FieldName metric (ie: *p95:PDD)
+// values are kept in a mergeable sketch so the percentile is computed without sorting all values
+type StatPercentile struct {
+	Sketch     *utils.QuantileSketch
+	Events     map[string]float64     // map[EventTenantID]Value
+	Buckets    map[string]*StatBucket // map[BucketID]*StatBucket, populated in bucketed queues
+	MinItems   int
+	FieldName  string
+	Percentile float64
+	val        *float64 // cached percentile value
+}
+
+// getValue returns pctl.val
+func (pctl *StatPercentile) getValue() float64 {
+	if pctl.val == nil {
+		if pctl.MinItems > 0 && pctl.Sketch.Count < float64(pctl.MinItems) {
+			pctl.val = utils.Float64Pointer(STATS_NA)
+		} else if val, err := pctl.Sketch.Quantile(pctl.Percentile / 100); err != nil {
+			pctl.val = utils.Float64Pointer(STATS_NA)
+		} else {
+			pctl.val = utils.Float64Pointer(utils.Round(val,
+				config.CgrConfig().RoundingDecimals, utils.ROUNDING_MIDDLE))
+		}
+	}
+	return *pctl.val
+}
+
+func (pctl *StatPercentile) GetStringValue(fmtOpts string) (valStr string) {
+	if val := pctl.getValue(); val == STATS_NA {
+		valStr = utils.NOT_AVAILABLE
+	} else {
+		valStr = strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return
+}
+
+func (pctl *StatPercentile) GetValue() (v interface{}) {
+	return pctl.getValue()
+}
+
+func (pctl *StatPercentile) GetFloat64Value() (v float64) {
+	return pctl.getValue()
+}
+
+func (pctl *StatPercentile) AddEvent(ev *StatEvent) (err error) {
+	var val float64
+	if val, err = ev.FieldAsFloat64(pctl.FieldName); err != nil {
+		return
+	}
+	pctl.Sketch.Add(val)
+	pctl.Events[ev.TenantID()] = val
+	pctl.val = nil
+	return
+}
+
+func (pctl *StatPercentile) AddBucketEvent(bucketID string, ev *StatEvent) (err error) {
+	var val float64
+	if val, err = ev.FieldAsFloat64(pctl.FieldName); err != nil {
+		return
+	}
+	sb := getStatBucket(&pctl.Buckets, bucketID)
+	if sb.Sketch == nil {
+		if sb.Sketch, err = utils.NewQuantileSketch(percentileAccuracy); err != nil {
+			return
+		}
+	}
+	sb.Sketch.Add(val)
+	sb.Count += 1
+	pctl.Sketch.Add(val)
+	pctl.val = nil
+	return
+}
+
+func (pctl *StatPercentile) RemEvent(evTenantID string) (err error) {
+	if sb, has := pctl.Buckets[evTenantID]; has {
+		if err = pctl.Sketch.Subtract(sb.Sketch); err != nil {
+			return
+		}
+		delete(pctl.Buckets, evTenantID)
+		pctl.val = nil
+		return
+	}
+	val, has := pctl.Events[evTenantID]
+	if !has {
+		return utils.ErrNotFound
+	}
+	pctl.Sketch.Remove(val)
+	delete(pctl.Events, evTenantID)
+	pctl.val = nil
+	return
+}
+
+func (pctl *StatPercentile) Marshal(ms Marshaler) (marshaled []byte, err error) {
+	return ms.Marshal(pctl)
+}
+
+func (pctl *StatPercentile) LoadMarshaled(ms Marshaler, marshaled []byte) (err error) {
+	return ms.Unmarshal(marshaled, pctl)
+}
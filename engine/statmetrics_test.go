@@ -18,7 +18,9 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package engine
 
 import (
+	"fmt"
 	"github.com/cgrates/cgrates/utils"
+	"math"
 	"testing"
 	"time"
 )
@@ -937,3 +939,117 @@ func TestDDCGetFloat64Value(t *testing.T) {
 		t.Errorf("wrong ddc value: %v", strVal)
 	}
 }
+
+func TestNewStatMetricParameterised(t *testing.T) {
+	if sm, err := NewStatMetric("*sum:Usage", 0); err != nil {
+		t.Error(err)
+	} else if sum, canCast := sm.(*StatSum); !canCast || sum.FieldName != utils.USAGE {
+		t.Errorf("received: %+v", sm)
+	}
+	if sm, err := NewStatMetric("*p95:PDD", 0); err != nil {
+		t.Error(err)
+	} else if pctl, canCast := sm.(*StatPercentile); !canCast ||
+		pctl.FieldName != utils.PDD || pctl.Percentile != 95 {
+		t.Errorf("received: %+v", sm)
+	}
+	for _, metricID := range []string{"*sum", "*sum:", "*unknown:Usage", "*p100:Usage", "*pxx:Usage"} {
+		if _, err := NewStatMetric(metricID, 0); err == nil {
+			t.Errorf("expecting error for metric: %s", metricID)
+		}
+	}
+}
+
+func TestStatSumAverage(t *testing.T) {
+	sum, _ := NewStatSum(2, utils.USAGE)
+	avg, _ := NewStatAverage(2, utils.USAGE)
+	ev1 := &StatEvent{Tenant: "cgrates.org", ID: "EVENT_1",
+		Fields: map[string]interface{}{utils.USAGE: time.Duration(10 * time.Second)}}
+	ev2 := &StatEvent{Tenant: "cgrates.org", ID: "EVENT_2",
+		Fields: map[string]interface{}{utils.USAGE: "20"}}
+	ev3 := &StatEvent{Tenant: "cgrates.org", ID: "EVENT_3",
+		Fields: map[string]interface{}{utils.USAGE: "1m"}}
+	for _, sm := range []StatMetric{sum, avg} {
+		sm.AddEvent(ev1)
+		if strVal := sm.GetStringValue(""); strVal != utils.NOT_AVAILABLE {
+			t.Errorf("wrong value: %s", strVal)
+		}
+		sm.AddEvent(ev2)
+		sm.AddEvent(ev3)
+	}
+	if val := sum.GetFloat64Value(); val != 90 {
+		t.Errorf("wrong sum value: %v", val)
+	}
+	if val := avg.GetFloat64Value(); val != 30 {
+		t.Errorf("wrong average value: %v", val)
+	}
+	sum.RemEvent(ev3.TenantID())
+	avg.RemEvent(ev3.TenantID())
+	if strVal := sum.GetStringValue(""); strVal != "30" {
+		t.Errorf("wrong sum value: %s", strVal)
+	}
+	if strVal := avg.GetStringValue(""); strVal != "15" {
+		t.Errorf("wrong average value: %s", strVal)
+	}
+	if err := sum.AddEvent(&StatEvent{Tenant: "cgrates.org", ID: "EVENT_4"}); err != utils.ErrNotFound {
+		t.Errorf("expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+}
+
+func TestStatDistinct(t *testing.T) {
+	dst, _ := NewStatDistinct(0, utils.ACCOUNT)
+	if strVal := dst.GetStringValue(""); strVal != utils.NOT_AVAILABLE {
+		t.Errorf("wrong distinct value: %s", strVal)
+	}
+	for i, acnt := range []string{"1001", "1002", "1001"} {
+		dst.AddEvent(&StatEvent{Tenant: "cgrates.org", ID: fmt.Sprintf("EVENT_%d", i),
+			Fields: map[string]interface{}{utils.ACCOUNT: acnt}})
+	}
+	if val := dst.GetValue(); val != 2.0 {
+		t.Errorf("wrong distinct value: %v", val)
+	}
+	dst.RemEvent(utils.ConcatenatedKey("cgrates.org", "EVENT_1"))
+	if val := dst.GetFloat64Value(); val != 1 {
+		t.Errorf("wrong distinct value: %v", val)
+	}
+	dst, _ = NewStatDistinct(3, utils.ACCOUNT)
+	dst.AddEvent(&StatEvent{Tenant: "cgrates.org", ID: "EVENT_1",
+		Fields: map[string]interface{}{utils.ACCOUNT: "1001"}})
+	if val := dst.GetValue(); val != STATS_NA {
+		t.Errorf("wrong distinct value: %v", val)
+	}
+}
+
+func TestStatPercentile(t *testing.T) {
+	sm, err := NewStatMetric("*p95:PDD", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strVal := sm.GetStringValue(""); strVal != utils.NOT_AVAILABLE {
+		t.Errorf("wrong percentile value: %s", strVal)
+	}
+	for i := 1; i <= 100; i++ {
+		sm.AddEvent(&StatEvent{Tenant: "cgrates.org", ID: fmt.Sprintf("EVENT_%d", i),
+			Fields: map[string]interface{}{utils.PDD: float64(i)}})
+	}
+	if val := sm.GetFloat64Value(); math.Abs(val-95)/95 > 0.01 {
+		t.Errorf("wrong percentile value: %v", val)
+	}
+	ms := NewCodecMsgpackMarshaler()
+	marshaled, err := sm.Marshal(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcv, _ := NewStatMetric("*p95:PDD", 0)
+	if err := rcv.LoadMarshaled(ms, marshaled); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.GetFloat64Value() != sm.GetFloat64Value() {
+		t.Errorf("expecting: %v, received: %v", sm.GetFloat64Value(), rcv.GetFloat64Value())
+	}
+	for i := 51; i <= 100; i++ {
+		rcv.RemEvent(utils.ConcatenatedKey("cgrates.org", fmt.Sprintf("EVENT_%d", i)))
+	}
+	if val := rcv.GetFloat64Value(); math.Abs(val-47)/47 > 0.01 {
+		t.Errorf("wrong percentile value: %v", val)
+	}
+}
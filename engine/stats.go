@@ -21,6 +21,7 @@ package engine
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sync"
 	"time"
 
@@ -28,12 +29,21 @@ import (
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/guardian"
 	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/rpcclient"
 )
 
 // NewStatService initializes a StatService
-func NewStatService(dm *DataManager, storeInterval time.Duration) (ss *StatService, err error) {
+func NewStatService(dm *DataManager, storeInterval time.Duration,
+	thdS rpcclient.RpcClientConnection, cdrDb CdrStorage, historyInterval, historyTTL time.Duration) (ss *StatService, err error) {
+	if thdS != nil && reflect.ValueOf(thdS).IsNil() {
+		thdS = nil
+	}
 	return &StatService{dm: dm,
 		storeInterval:    storeInterval,
+		thdS:             thdS,
+		cdrDb:            cdrDb,
+		historyInterval:  historyInterval,
+		historyTTL:       historyTTL,
 		storedStatQueues: make(utils.StringMap),
 		stopBackup:       make(chan struct{})}, nil
 }
@@ -42,6 +52,10 @@ func NewStatService(dm *DataManager, storeInterval time.Duration) (ss *StatServi
 type StatService struct {
 	dm               *DataManager
 	storeInterval    time.Duration
+	thdS             rpcclient.RpcClientConnection // rpc connection towards ThresholdS
+	cdrDb            CdrStorage                    // storDB keeping the metrics history
+	historyInterval  time.Duration                 // snapshot the metrics into storDB at this interval
+	historyTTL       time.Duration                 // remove the snapshots older than this, 0 to keep them forever
 	stopBackup       chan struct{}
 	storedStatQueues utils.StringMap // keep a record of stats which need saving, map[statsTenantID]bool
 	ssqMux           sync.RWMutex    // protects storedStatQueues
@@ -49,7 +63,8 @@ type StatService struct {
 
 // ListenAndServe loops keeps the service alive
 func (sS *StatService) ListenAndServe(exitChan chan bool) error {
-	go sS.runBackup() // start backup loop
+	go sS.runBackup()  // start backup loop
+	go sS.runHistory() // start metrics history loop
 	e := <-exitChan
 	exitChan <- e // put back for the others listening for shutdown request
 	return nil
@@ -80,6 +95,94 @@ func (sS *StatService) runBackup() {
 	}
 }
 
+// runHistory will regularly snapshot the metrics of the queues into storDB
+func (sS *StatService) runHistory() {
+	if sS.historyInterval <= 0 || sS.cdrDb == nil {
+		return
+	}
+	for {
+		select {
+		case <-sS.stopBackup:
+			return
+		case <-time.After(sS.historyInterval):
+		}
+		sS.storeMetricsHistory()
+	}
+}
+
+// storeMetricsHistory snapshots the float metrics of all queues into storDB, removing the expired snapshots
+func (sS *StatService) storeMetricsHistory() {
+	now := time.Now()
+	if sS.historyTTL > 0 {
+		if err := sS.cdrDb.RemStatQueueMetrics(now.Add(-sS.historyTTL)); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<StatS> failed removing expired metrics history, error: %s", err.Error()))
+		}
+	}
+	sqms, err := sS.queuesMetrics(now)
+	if err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<StatS> failed retrieving stat queue keys, error: %s", err.Error()))
+		return
+	}
+	for _, sqm := range sqms {
+		if err := sS.cdrDb.SetStatQueueMetrics(sqm); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<StatS> failed storing metrics history for stat queue with ID: %s, error: %s",
+				utils.ConcatenatedKey(sqm.Tenant, sqm.ID), err.Error()))
+		}
+	}
+}
+
+// queuesMetrics returns a snapshot of the metric values for all the StatQueues in dataDB
+func (sS *StatService) queuesMetrics(now time.Time) (sqms []*StatQueueMetrics, err error) {
+	keys, err := sS.dm.DataDB().GetKeysForPrefix(utils.StatQueuePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		tntID := utils.NewTenantID(key[len(utils.StatQueuePrefix):])
+		lockID := utils.StatQueuesStringIndex + tntID.ID
+		if err := guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockID); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<StatS> failed locking stat queue with ID: %s, error: %s",
+				tntID.TenantID(), err.Error()))
+			continue
+		}
+		sq, err := sS.dm.GetStatQueue(tntID.Tenant, tntID.ID, false, "")
+		if err != nil {
+			guardian.Guardian.UnguardIDs(lockID)
+			utils.Logger.Warning(fmt.Sprintf("<StatS> failed retrieving stat queue with ID: %s, error: %s",
+				tntID.TenantID(), err.Error()))
+			continue
+		}
+		sqm := &StatQueueMetrics{Tenant: sq.Tenant, ID: sq.ID, Time: now,
+			Metrics: make(map[string]float64, len(sq.SQMetrics))}
+		for metricID, metric := range sq.SQMetrics {
+			sqm.Metrics[metricID] = metric.GetFloat64Value()
+		}
+		guardian.Guardian.UnguardIDs(lockID)
+		sqms = append(sqms, sqm)
+	}
+	return
+}
+
+// PrometheusMetrics exports the StatQueue metrics as gauges, metrics not available are skipped
+func (sS *StatService) PrometheusMetrics() (pms []*utils.PrometheusMetric, err error) {
+	sqms, err := sS.queuesMetrics(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, sqm := range sqms {
+		for metricID, val := range sqm.Metrics {
+			if val == STATS_NA {
+				continue
+			}
+			pms = append(pms, &utils.PrometheusMetric{Name: "cgrates_stats_metric",
+				Help: "StatS queue metric values", Type: utils.MetricGauge,
+				Labels: map[string]string{"tenant": sqm.Tenant, "queue": sqm.ID, "metric": metricID},
+				Value:  val})
+		}
+	}
+	return
+}
+
 // storeResources represents one task of complete backup
 func (sS *StatService) storeStats() {
 	var failedSqIDs []string
@@ -133,7 +236,9 @@ func (sS *StatService) matchingStatQueuesForEvent(ev *StatEvent) (sqs StatQueues
 		return nil, err
 	}
 	lockIDs := utils.PrefixSliceItems(sqIDs.Slice(), utils.StatQueuesStringIndex)
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...)
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...); err != nil {
+		return nil, err
+	}
 	defer guardian.Guardian.UnguardIDs(lockIDs...)
 	for sqID := range sqIDs {
 		sqPrfl, err := sS.dm.DataDB().GetStatQueueProfile(ev.Tenant, sqID, false, utils.NonTransactional)
@@ -213,6 +318,16 @@ func (sS *StatService) processEvent(ev *StatEvent) (err error) {
 					sq.TenantID(), ev.TenantID(), err.Error()))
 			withErrors = true
 		}
+		if sS.thdS != nil && err == nil { // metrics not updated on errors, nothing to notify
+			thEv := sq.thresholdEvent()
+			var reply string
+			if err := sS.thdS.Call("ThresholdSV1.ProcessEvent", thEv, &reply); err != nil &&
+				err.Error() != utils.ErrNotFound.Error() {
+				utils.Logger.Warning(
+					fmt.Sprintf("<StatS> error: %s processing event %+v with ThresholdS.", err.Error(), thEv))
+				withErrors = true
+			}
+		}
 		if sS.storeInterval == 0 || sq.dirty == nil { // don't save
 			continue
 		}
@@ -294,6 +409,90 @@ func (sS *StatService) V1GetQueueFloatMetrics(args *utils.TenantID, reply *map[s
 	return
 }
 
+// ArgsGetQueueMetricsHistory filters the metrics history of a StatQueue
+type ArgsGetQueueMetricsHistory struct {
+	Tenant string
+	ID     string
+	From   string // start time, inclusive
+	To     string // end time, exclusive
+	Step   string // aggregate the snapshots into intervals of this duration, empty for raw snapshots
+}
+
+// V1GetQueueMetricsHistory returns the metrics history of a Queue as time series
+func (sS *StatService) V1GetQueueMetricsHistory(args *ArgsGetQueueMetricsHistory, reply *[]*StatQueueMetrics) (err error) {
+	if missing := utils.MissingStructFields(args, []string{"Tenant", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	if sS.cdrDb == nil {
+		return utils.NewErrNotConnected(utils.StorDB)
+	}
+	var from, to time.Time
+	if args.From != "" {
+		if from, err = utils.ParseTimeDetectLayout(args.From, config.CgrConfig().DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	if args.To != "" {
+		if to, err = utils.ParseTimeDetectLayout(args.To, config.CgrConfig().DefaultTimezone); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	var step time.Duration
+	if args.Step != "" {
+		if step, err = utils.ParseDurationWithSecs(args.Step); err != nil {
+			return utils.NewErrServerError(err)
+		}
+	}
+	sqms, err := sS.cdrDb.GetStatQueueMetrics(args.Tenant, args.ID, from, to)
+	if err != nil {
+		if err != utils.ErrNotFound {
+			err = utils.NewErrServerError(err)
+		}
+		return err
+	}
+	if step > 0 {
+		sqms = aggregateStatQueueMetrics(sqms, from, step)
+	}
+	*reply = sqms
+	return
+}
+
+// aggregateStatQueueMetrics averages the snapshots (sorted by time) within each step, starting with from
+// metrics which are not available (STATS_NA) are not considered in the average
+func aggregateStatQueueMetrics(sqms []*StatQueueMetrics, from time.Time, step time.Duration) (aggrSqms []*StatQueueMetrics) {
+	if len(sqms) == 0 {
+		return
+	}
+	if from.IsZero() {
+		from = sqms[0].Time.Truncate(step)
+	}
+	var aggrSqm *StatQueueMetrics
+	var sums, counts map[string]float64
+	for _, sqm := range sqms {
+		stepTime := from.Add(sqm.Time.Sub(from) / step * step)
+		if aggrSqm == nil || !aggrSqm.Time.Equal(stepTime) {
+			aggrSqm = &StatQueueMetrics{Tenant: sqm.Tenant, ID: sqm.ID, Time: stepTime,
+				Metrics: make(map[string]float64)}
+			aggrSqms = append(aggrSqms, aggrSqm)
+			sums = make(map[string]float64)
+			counts = make(map[string]float64)
+		}
+		for metricID, val := range sqm.Metrics {
+			if val == STATS_NA {
+				if _, has := counts[metricID]; !has {
+					aggrSqm.Metrics[metricID] = STATS_NA
+				}
+				continue
+			}
+			sums[metricID] += val
+			counts[metricID] += 1
+			aggrSqm.Metrics[metricID] = utils.Round(sums[metricID]/counts[metricID],
+				config.CgrConfig().RoundingDecimals, utils.ROUNDING_MIDDLE)
+		}
+	}
+	return
+}
+
 // V1GetQueueIDs returns list of queueIDs registered for a tenant
 func (sS *StatService) V1GetQueueIDs(tenant string, qIDs *[]string) (err error) {
 	prfx := utils.StatQueuePrefix + tenant + ":"
@@ -63,6 +63,22 @@ func TestITCDRsPSQL(t *testing.T) {
 	}
 }
 
+func TestITCDRsSQLite(t *testing.T) {
+	cfg, err := config.NewCGRConfigFromFolder(path.Join(*dataDir, "conf", "samples", "storage", "sqlite"))
+	if err != nil {
+		t.Error(err)
+	}
+	if err := testGetCDRs(cfg); err != nil {
+		t.Error(err)
+	}
+	if err := testSetCDR(cfg); err != nil {
+		t.Error(err)
+	}
+	if err := testSMCosts(cfg); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestITCDRsMongo(t *testing.T) {
 	cfg, err := config.NewCGRConfigFromFolder(path.Join(*dataDir, "conf", "samples", "storage", "mongo"))
 	if err != nil {
@@ -77,6 +93,9 @@ func TestITCDRsMongo(t *testing.T) {
 	if err := testSMCosts(cfg); err != nil {
 		t.Error(err)
 	}
+	if err := testCDRsRetention(cfg); err != nil {
+		t.Error(err)
+	}
 }
 
 // helper function to populate CDRs and check if they were stored in storDb
@@ -821,3 +840,61 @@ func testGetCDRs(cfg *config.CGRConfig) error {
 
 	return nil
 }
+
+// testCDRsRetention checks that the retention rules remove and archive the old CDRs out of storDb
+func testCDRsRetention(cfg *config.CGRConfig) error {
+	if err := InitStorDb(cfg); err != nil {
+		return fmt.Errorf("testCDRsRetention #1: %v", err)
+	}
+	cdrStorage, err := ConfigureCdrStorage(cfg.StorDBType, cfg.StorDBHost, cfg.StorDBPort, cfg.StorDBName, cfg.StorDBUser, cfg.StorDBPass,
+		cfg.StorDBMaxOpenConns, cfg.StorDBMaxIdleConns, cfg.StorDBConnMaxLifetime, cfg.StorDBCDRSIndexes)
+	if err != nil {
+		return fmt.Errorf("testCDRsRetention #2: %v", err)
+	}
+	retCfg, err := config.NewCGRConfigFromJsonStringWithDefaults(`{
+"cdrs_retention": {
+	"enabled": true,
+	"rules": [
+		{"tenant": "cgrates.org", "tor": "*voice", "retention_days": 30, "archive": "*table"},
+		{"tenant": "*any", "tor": "*data", "retention_days": 30},
+	],
+},
+}`)
+	if err != nil {
+		return fmt.Errorf("testCDRsRetention #3: %v", err)
+	}
+	now := time.Now()
+	for i, cdr := range []*CDR{
+		&CDR{ToR: utils.VOICE, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -40)},
+		&CDR{ToR: utils.VOICE, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -10)},
+		&CDR{ToR: utils.DATA, Tenant: "itsyscom.com", SetupTime: now.AddDate(0, 0, -40)},
+		&CDR{ToR: utils.DATA, Tenant: "cgrates.org", SetupTime: now.AddDate(0, 0, -10)},
+	} {
+		cdr.CGRID = utils.Sha1("testCDRsRetention", strconv.Itoa(i))
+		cdr.RunID = utils.META_DEFAULT
+		cdr.OriginID = strconv.Itoa(i)
+		cdr.AnswerTime = cdr.SetupTime
+		if err := cdrStorage.SetCDR(cdr, false); err != nil {
+			return fmt.Errorf("testCDRsRetention #4, CDR: %d, err: %v", i, err)
+		}
+	}
+	rpt := NewCDRsRetentionService(retCfg, cdrStorage).Run(false)
+	if len(rpt.Rules) != 2 {
+		return fmt.Errorf("testCDRsRetention #5, unexpected report: %s", utils.ToJSON(rpt))
+	}
+	for i, rRpt := range rpt.Rules {
+		if rRpt.Removed != 1 || rRpt.Error != "" {
+			return fmt.Errorf("testCDRsRetention #6, rule: %d, unexpected report: %s", i, utils.ToJSON(rRpt))
+		}
+	}
+	if rpt.Rules[0].Archived != 1 {
+		return fmt.Errorf("testCDRsRetention #7, unexpected report: %s", utils.ToJSON(rpt.Rules[0]))
+	}
+	if cdrs, _, err := cdrStorage.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{utils.Sha1("testCDRsRetention", "1"),
+		utils.Sha1("testCDRsRetention", "3")}}, false); err != nil {
+		return fmt.Errorf("testCDRsRetention #8, err: %v", err)
+	} else if len(cdrs) != 2 {
+		return fmt.Errorf("testCDRsRetention #9, unexpected CDRs kept: %s", utils.ToJSON(cdrs))
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/cgrates/cgrates/utils"
 	"github.com/ugorji/go/codec"
@@ -85,6 +86,7 @@ type DataDB interface {
 	PopTask() (*Task, error)
 	GetAccount(string) (*Account, error)
 	SetAccount(*Account) error
+	SetAccounts([]*Account) error
 	RemoveAccount(string) error
 	GetCdrStatsQueue(string) (*CDRStatsQueue, error)
 	SetCdrStatsQueue(*CDRStatsQueue) error
@@ -129,6 +131,17 @@ type DataDB interface {
 	GetFilter(string, string, bool, string) (*Filter, error)
 	SetFilter(*Filter) error
 	RemoveFilter(string, string, string) error
+	GetTierCounter(string) (*TierCounter, error)
+	SetTierCounter(*TierCounter) error
+	GetTierEventUsage(string) (*TierEventUsage, error)
+	SetTierEventUsage(*TierEventUsage) error
+	RemoveTierEventUsage(string) error
+	GetExchangeRate(string) (*ExchangeRate, error)
+	SetExchangeRate(*ExchangeRate) error
+	RemoveExchangeRate(string) error
+	GetTaxRates(string) (TaxRates, error)
+	SetTaxRates(string, TaxRates) error
+	RemoveTaxRates(string) error
 	// CacheDataFromDB loads data to cache, prefix represents the cache prefix, IDs should be nil if all available data should be loaded
 	CacheDataFromDB(prefix string, IDs []string, mustBeCached bool) error // ToDo: Move this to dataManager
 }
@@ -146,6 +159,24 @@ type CdrStorage interface {
 	GetSMCosts(cgrid, runid, originHost, originIDPrfx string) ([]*SMCost, error)
 	RemoveSMCost(*SMCost) error
 	GetCDRs(*utils.CDRsFilter, bool) ([]*CDR, int64, error)
+	GetCDRsIterator(*utils.CDRsFilter) (CDRsIterator, error)
+	ArchiveCDRs(*utils.CDRsFilter) (int64, error)
+	SetStatQueueMetrics(sqm *StatQueueMetrics) error
+	GetStatQueueMetrics(tenant, id string, from, to time.Time) ([]*StatQueueMetrics, error)
+	RemStatQueueMetrics(before time.Time) error
+}
+
+// CDRsIterator streams the result of a CDRs query, one CDR at a time
+// Next returns io.EOF once all CDRs were read, Close releases the resources of the query
+type CDRsIterator interface {
+	Next() (*CDR, error)
+	Close() error
+}
+
+// CDRsPartitioner is implemented by the StorDBs able to split the cdrs table in time based partitions
+// CreateCDRsPartitions makes sure the partitions covering the period of now and the next premake ones exist
+type CDRsPartitioner interface {
+	CreateCDRsPartitions(interval string, now time.Time, premake int) (created []string, err error)
 }
 
 type LoadStorage interface {
@@ -178,6 +209,8 @@ type LoadReader interface {
 	GetTPStats(string, string) ([]*utils.TPStats, error)
 	GetTPThreshold(string, string) ([]*utils.TPThreshold, error)
 	GetTPFilter(string, string) ([]*utils.TPFilter, error)
+	GetTPExchangeRates(string, string, string) ([]*utils.TPExchangeRate, error)
+	GetTPTaxRates(string, string) ([]*utils.TPTaxRate, error)
 }
 
 type LoadWriter interface {
@@ -202,6 +235,8 @@ type LoadWriter interface {
 	SetTPStats([]*utils.TPStats) error
 	SetTPThreshold([]*utils.TPThreshold) error
 	SetTPFilter([]*utils.TPFilter) error
+	SetTPExchangeRates([]*utils.TPExchangeRate) error
+	SetTPTaxRates([]*utils.TPTaxRate) error
 }
 
 // NewMarshaler returns the marshaler type selected by mrshlerStr
@@ -0,0 +1,1353 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// tpTables lists the tariff plan tables known by InternalStorDB
+var tpTables = []string{utils.TBLTPTimings, utils.TBLTPDestinations, utils.TBLTPRates,
+	utils.TBLTPDestinationRates, utils.TBLTPRatingPlans, utils.TBLTPRateProfiles,
+	utils.TBLTPSharedGroups, utils.TBLTPCdrStats, utils.TBLTPLcrs, utils.TBLTPActions,
+	utils.TBLTPActionPlans, utils.TBLTPActionTriggers, utils.TBLTPAccountActions,
+	utils.TBLTPDerivedChargers, utils.TBLTPAliases, utils.TBLTPUsers, utils.TBLTPResources,
+	utils.TBLTPStats, utils.TBLTPThresholds, utils.TBLTPFilters, utils.TBLTPExchangeRates, utils.TBLTPTaxRates}
+
+// internalTPColumns translates the SQL column names used in APIs into TP item fields
+var internalTPColumns = map[string]string{"tag": "id", "user_name": "username",
+	"from_currency": "fromcurrency", "to_currency": "tocurrency"}
+
+func internalTPColumn(col string) string {
+	if fld, has := internalTPColumns[col]; has {
+		return fld
+	}
+	return col
+}
+
+// newInternalTPItem marshals the TP object, indexing its string fields for queries
+func newInternalTPItem(tp interface{}, ms Marshaler) (itm *InternalTPItem, err error) {
+	itm = &InternalTPItem{Fields: make(map[string]string)}
+	v := reflect.ValueOf(tp)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if fld := v.Field(i); fld.Kind() == reflect.String {
+			itm.Fields[strings.ToLower(v.Type().Field(i).Name)] = fld.String()
+		}
+	}
+	if itm.Value, err = ms.Marshal(tp); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// InternalTPItem is one TP object stored by InternalStorDB
+type InternalTPItem struct {
+	Fields map[string]string // lowercase field names to their values, used in queries
+	Value  []byte            // marshaled TP object
+}
+
+// matches returns true if all the filter columns have the same value in item
+func (itm *InternalTPItem) matches(filter map[string]string) bool {
+	for col, val := range filter {
+		if itm.Fields[internalTPColumn(col)] != val {
+			return false
+		}
+	}
+	return true
+}
+
+// InternalCDR stores the CDR together with the data kept in SQL columns
+type InternalCDR struct {
+	CDR       *CDR
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// internalStorDBDump is the content of InternalStorDB saved on disk
+type internalStorDBDump struct {
+	TPTables    map[string][]*InternalTPItem
+	CDRs        []*InternalCDR
+	CDRsArchive []*InternalCDR
+	OrderID     int64
+	SMCosts     []*SMCost
+	StatMetrics []*StatQueueMetrics
+	Versions    Versions
+}
+
+// NewInternalStorDB returns a StorDB keeping its data in memory
+// with non empty dumpPath, the data is saved on Close and loaded back here
+func NewInternalStorDB(dumpPath string) (iDB *InternalStorDB, err error) {
+	iDB = &InternalStorDB{dumpPath: dumpPath, ms: NewCodecMsgpackMarshaler()}
+	iDB.flush()
+	if dumpPath == "" {
+		return
+	}
+	if err = iDB.loadDump(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return iDB, nil
+}
+
+// InternalStorDB is a StorDB implemented in memory, used mostly in tests and demo setups
+type InternalStorDB struct {
+	mux         sync.RWMutex
+	dumpPath    string
+	ms          Marshaler
+	tpTables    map[string][]*InternalTPItem
+	cdrs        []*InternalCDR // ordered on OrderID
+	cdrsArchive []*InternalCDR // CDRs moved out by ArchiveCDRs
+	orderID     int64          // last OrderID given to a CDR
+	smCosts     []*SMCost
+	statMetrics []*StatQueueMetrics
+	versions    Versions
+}
+
+// flush resets the data, iDB.mux should be locked by the caller
+func (iDB *InternalStorDB) flush() {
+	iDB.tpTables = make(map[string][]*InternalTPItem)
+	iDB.cdrs = make([]*InternalCDR, 0)
+	iDB.cdrsArchive = make([]*InternalCDR, 0)
+	iDB.smCosts = make([]*SMCost, 0)
+	iDB.statMetrics = make([]*StatQueueMetrics, 0)
+	iDB.versions = nil
+}
+
+func (iDB *InternalStorDB) loadDump() (err error) {
+	f, err := os.Open(iDB.dumpPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var dump internalStorDBDump
+	if err = gob.NewDecoder(f).Decode(&dump); err != nil {
+		return fmt.Errorf("dump: %s, error: %s", iDB.dumpPath, err.Error())
+	}
+	if dump.TPTables != nil {
+		iDB.tpTables = dump.TPTables
+	}
+	iDB.cdrs = append(iDB.cdrs, dump.CDRs...)
+	iDB.cdrsArchive = append(iDB.cdrsArchive, dump.CDRsArchive...)
+	iDB.orderID = dump.OrderID
+	iDB.smCosts = append(iDB.smCosts, dump.SMCosts...)
+	iDB.statMetrics = append(iDB.statMetrics, dump.StatMetrics...)
+	iDB.versions = dump.Versions
+	return
+}
+
+// saveDump writes the data into dumpPath, replacing the previous dump only on success
+func (iDB *InternalStorDB) saveDump() (err error) {
+	tmpPath := iDB.dumpPath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return
+	}
+	if err = gob.NewEncoder(f).Encode(&internalStorDBDump{TPTables: iDB.tpTables,
+		CDRs: iDB.cdrs, CDRsArchive: iDB.cdrsArchive, OrderID: iDB.orderID, SMCosts: iDB.smCosts,
+		StatMetrics: iDB.statMetrics, Versions: iDB.versions}); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(tmpPath, iDB.dumpPath)
+}
+
+func (iDB *InternalStorDB) Close() {
+	if iDB.dumpPath == "" {
+		return
+	}
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	if err := iDB.saveDump(); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<%s> failed saving StorDB dump: %s, error: %s",
+			utils.MetaInternal, iDB.dumpPath, err.Error()))
+	}
+}
+
+func (iDB *InternalStorDB) Flush(ignore string) error {
+	iDB.mux.Lock()
+	iDB.flush()
+	iDB.mux.Unlock()
+	return SetDBVersions(iDB)
+}
+
+func (iDB *InternalStorDB) GetKeysForPrefix(prefix string) ([]string, error) {
+	return nil, utils.ErrNotImplemented
+}
+
+func (iDB *InternalStorDB) RebuildReverseForPrefix(prefix string) error {
+	return utils.ErrNotImplemented
+}
+
+func (iDB *InternalStorDB) SelectDatabase(dbName string) (err error) {
+	return
+}
+
+func (iDB *InternalStorDB) GetStorageType() string {
+	return utils.MetaInternal
+}
+
+func (iDB *InternalStorDB) IsDBEmpty() (resp bool, err error) {
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	for _, itms := range iDB.tpTables {
+		if len(itms) != 0 {
+			return false, nil
+		}
+	}
+	return len(iDB.cdrs) == 0 && len(iDB.smCosts) == 0, nil
+}
+
+// GetVersions returns all versions or a specific one if itm is specified
+func (iDB *InternalStorDB) GetVersions(itm string) (vrs Versions, err error) {
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	vrs = make(Versions)
+	for key, ver := range iDB.versions {
+		if itm == utils.TBLVersions || itm == "" || itm == key {
+			vrs[key] = ver
+		}
+	}
+	if len(vrs) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetVersions(vrs Versions, overwrite bool) (err error) {
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	if overwrite || iDB.versions == nil {
+		iDB.versions = make(Versions)
+	}
+	for key, ver := range vrs {
+		iDB.versions[key] = ver
+	}
+	return
+}
+
+// RemoveVersions removes the versions specified, all of them if vrs is empty
+func (iDB *InternalStorDB) RemoveVersions(vrs Versions) (err error) {
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	if len(vrs) == 0 {
+		iDB.versions = nil
+		return
+	}
+	for key := range vrs {
+		delete(iDB.versions, key)
+	}
+	return
+}
+
+// getTPValues returns the marshaled items of table with tpid, matching the non-empty filter values
+func (iDB *InternalStorDB) getTPValues(table, tpid string, filter map[string]string, pag *utils.Paginator) (vals [][]byte) {
+	fltr := map[string]string{"tpid": tpid}
+	for col, val := range filter {
+		if val != "" {
+			fltr[col] = val
+		}
+	}
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	for _, itm := range iDB.tpTables[table] {
+		if itm.matches(fltr) {
+			vals = append(vals, itm.Value)
+		}
+	}
+	if pag != nil {
+		vals = paginateInternalTPValues(vals, pag)
+	}
+	return
+}
+
+func paginateInternalTPValues(vals [][]byte, pag *utils.Paginator) [][]byte {
+	if pag.Offset != nil {
+		if *pag.Offset >= len(vals) {
+			return nil
+		}
+		vals = vals[*pag.Offset:]
+	}
+	if pag.Limit != nil && *pag.Limit < len(vals) {
+		vals = vals[:*pag.Limit]
+	}
+	return vals
+}
+
+// setTPItem stores tp in table, replacing the item with the same tpid and keyCols values
+func (iDB *InternalStorDB) setTPItem(table string, tp interface{}, keyCols ...string) (err error) {
+	itm, err := newInternalTPItem(tp, iDB.ms)
+	if err != nil {
+		return
+	}
+	keyFltr := map[string]string{"tpid": itm.Fields["tpid"]}
+	for _, col := range keyCols {
+		keyFltr[col] = itm.Fields[col]
+	}
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	itms := iDB.tpTables[table]
+	for i, oldItm := range itms {
+		if oldItm.matches(keyFltr) {
+			itms[i] = itm
+			return
+		}
+	}
+	iDB.tpTables[table] = append(itms, itm)
+	return
+}
+
+// GetTpIds returns the TPids present in any of the TP tables
+func (iDB *InternalStorDB) GetTpIds() (tpids []string, err error) {
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	tpidMap := make(utils.StringMap)
+	for _, itms := range iDB.tpTables {
+		for _, itm := range itms {
+			tpidMap[itm.Fields["tpid"]] = true
+		}
+	}
+	return tpidMap.Slice(), nil
+}
+
+func (iDB *InternalStorDB) GetTpTableIds(tpid, table string, distinct utils.TPDistinctIds,
+	filters map[string]string, pag *utils.Paginator) (ids []string, err error) {
+	fltr := make(map[string]string)
+	if tpid != "" {
+		fltr["tpid"] = tpid
+	}
+	for col, val := range filters {
+		if col != "" && val != "" {
+			fltr[col] = val
+		}
+	}
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	idMap := make(utils.StringMap)
+	for _, itm := range iDB.tpTables[table] {
+		if !itm.matches(fltr) {
+			continue
+		}
+		vals := make([]string, len(distinct))
+		var searchMatched bool
+		for i, col := range distinct {
+			vals[i] = itm.Fields[internalTPColumn(col)]
+			if pag != nil && strings.Contains(vals[i], pag.SearchTerm) {
+				searchMatched = true
+			}
+		}
+		if pag != nil && pag.SearchTerm != "" && !searchMatched {
+			continue
+		}
+		id := strings.Join(vals, utils.CONCATENATED_KEY_SEP)
+		if _, has := idMap[id]; has {
+			continue
+		}
+		idMap[id] = true
+		ids = append(ids, id)
+	}
+	if pag != nil {
+		if pag.Offset != nil {
+			if *pag.Offset >= len(ids) {
+				return nil, nil
+			}
+			ids = ids[*pag.Offset:]
+		}
+		if pag.Limit != nil && *pag.Limit < len(ids) {
+			ids = ids[:*pag.Limit]
+		}
+	}
+	return
+}
+
+// RemTpData removes the TP items of table matching tpid and args, all TP data of tpid if table is empty
+func (iDB *InternalStorDB) RemTpData(table, tpid string, args map[string]string) error {
+	tables := []string{table}
+	if len(table) == 0 {
+		tables = tpTables
+		args = nil
+	}
+	fltr := map[string]string{"tpid": tpid}
+	for col, val := range args {
+		fltr[col] = val
+	}
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	for _, tbl := range tables {
+		var remaining []*InternalTPItem
+		for _, itm := range iDB.tpTables[tbl] {
+			if !itm.matches(fltr) {
+				remaining = append(remaining, itm)
+			}
+		}
+		iDB.tpTables[tbl] = remaining
+	}
+	return nil
+}
+
+func (iDB *InternalStorDB) GetTPTimings(tpid, id string) (tps []*utils.ApierTPTiming, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPTimings, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.ApierTPTiming)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPDestinations(tpid, id string) (tps []*utils.TPDestination, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPDestinations, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPDestination)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPRates(tpid, id string) (tps []*utils.TPRate, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPRates, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPRate)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		for _, rs := range tp.RateSlots {
+			rs.SetDurations()
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPDestinationRates(tpid, id string, pag *utils.Paginator) (tps []*utils.TPDestinationRate, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPDestinationRates, tpid, map[string]string{"id": id}, pag) {
+		tp := new(utils.TPDestinationRate)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPRatingPlans(tpid, id string, pag *utils.Paginator) (tps []*utils.TPRatingPlan, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPRatingPlans, tpid, map[string]string{"id": id}, pag) {
+		tp := new(utils.TPRatingPlan)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPRatingProfiles(fltr *utils.TPRatingProfile) (tps []*utils.TPRatingProfile, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPRateProfiles, fltr.TPid, map[string]string{
+		"loadid": fltr.LoadId, "direction": fltr.Direction, "tenant": fltr.Tenant,
+		"category": fltr.Category, "subject": fltr.Subject}, nil) {
+		tp := new(utils.TPRatingProfile)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPSharedGroups(tpid, id string) (tps []*utils.TPSharedGroups, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPSharedGroups, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPSharedGroups)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPCdrStats(tpid, id string) (tps []*utils.TPCdrStats, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPCdrStats, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPCdrStats)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPLCRs(fltr *utils.TPLcrRules) (tps []*utils.TPLcrRules, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPLcrs, fltr.TPid, map[string]string{
+		"direction": fltr.Direction, "tenant": fltr.Tenant, "category": fltr.Category,
+		"account": fltr.Account, "subject": fltr.Subject}, nil) {
+		tp := new(utils.TPLcrRules)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPUsers(fltr *utils.TPUsers) (tps []*utils.TPUsers, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPUsers, fltr.TPid, map[string]string{
+		"tenant": fltr.Tenant, "username": fltr.UserName}, nil) {
+		tp := new(utils.TPUsers)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPAliases(fltr *utils.TPAliases) (tps []*utils.TPAliases, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPAliases, fltr.TPid, map[string]string{
+		"direction": fltr.Direction, "tenant": fltr.Tenant, "category": fltr.Category,
+		"account": fltr.Account, "subject": fltr.Subject, "context": fltr.Context}, nil) {
+		tp := new(utils.TPAliases)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPDerivedChargers(fltr *utils.TPDerivedChargers) (tps []*utils.TPDerivedChargers, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPDerivedChargers, fltr.TPid, map[string]string{
+		"loadid": fltr.LoadId, "direction": fltr.Direction, "tenant": fltr.Tenant,
+		"category": fltr.Category, "account": fltr.Account, "subject": fltr.Subject}, nil) {
+		tp := new(utils.TPDerivedChargers)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPActions(tpid, id string) (tps []*utils.TPActions, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPActions, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPActions)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPActionPlans(tpid, id string) (tps []*utils.TPActionPlan, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPActionPlans, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPActionPlan)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPActionTriggers(tpid, id string) (tps []*utils.TPActionTriggers, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPActionTriggers, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPActionTriggers)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPAccountActions(fltr *utils.TPAccountActions) (tps []*utils.TPAccountActions, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPAccountActions, fltr.TPid, map[string]string{
+		"loadid": fltr.LoadId, "tenant": fltr.Tenant, "account": fltr.Account}, nil) {
+		tp := new(utils.TPAccountActions)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPResources(tpid, id string) (tps []*utils.TPResource, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPResources, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPResource)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPStats(tpid, id string) (tps []*utils.TPStats, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPStats, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPStats)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPThreshold(tpid, id string) (tps []*utils.TPThreshold, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPThresholds, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPThreshold)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPFilter(tpid, id string) (tps []*utils.TPFilter, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPFilters, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPFilter)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPExchangeRates(tpid, fromCurrency, toCurrency string) (tps []*utils.TPExchangeRate, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPExchangeRates, tpid,
+		map[string]string{"fromcurrency": fromCurrency, "tocurrency": toCurrency}, nil) {
+		tp := new(utils.TPExchangeRate)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) GetTPTaxRates(tpid, id string) (tps []*utils.TPTaxRate, err error) {
+	for _, val := range iDB.getTPValues(utils.TBLTPTaxRates, tpid, map[string]string{"id": id}, nil) {
+		tp := new(utils.TPTaxRate)
+		if err = iDB.ms.Unmarshal(val, tp); err != nil {
+			return nil, err
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		return tps, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPTimings(tps []*utils.ApierTPTiming) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPTimings, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPDestinations(tps []*utils.TPDestination) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPDestinations, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPRates(tps []*utils.TPRate) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPRates, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPDestinationRates(tps []*utils.TPDestinationRate) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPDestinationRates, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPRatingPlans(tps []*utils.TPRatingPlan) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPRatingPlans, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPRatingProfiles(tps []*utils.TPRatingProfile) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPRateProfiles, tp,
+			"loadid", "direction", "tenant", "category", "subject"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPSharedGroups(tps []*utils.TPSharedGroups) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPSharedGroups, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPCdrStats(tps []*utils.TPCdrStats) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPCdrStats, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPUsers(tps []*utils.TPUsers) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPUsers, tp, "tenant", "username"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPAliases(tps []*utils.TPAliases) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPAliases, tp,
+			"direction", "tenant", "category", "account", "subject", "context"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPDerivedChargers(tps []*utils.TPDerivedChargers) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPDerivedChargers, tp,
+			"loadid", "direction", "tenant", "category", "account", "subject"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPLCRs(tps []*utils.TPLcrRules) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPLcrs, tp,
+			"direction", "tenant", "category", "account", "subject"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPActions(tps []*utils.TPActions) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPActions, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPActionPlans(tps []*utils.TPActionPlan) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPActionPlans, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPActionTriggers(tps []*utils.TPActionTriggers) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPActionTriggers, tp, "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPAccountActions(tps []*utils.TPAccountActions) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPAccountActions, tp,
+			"loadid", "tenant", "account"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPResources(tps []*utils.TPResource) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPResources, tp, "tenant", "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPStats(tps []*utils.TPStats) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPStats, tp, "tenant", "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPThreshold(tps []*utils.TPThreshold) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPThresholds, tp, "tenant", "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPFilter(tps []*utils.TPFilter) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPFilters, tp, "tenant", "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPExchangeRates(tps []*utils.TPExchangeRate) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPExchangeRates, tp, "fromcurrency", "tocurrency"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetTPTaxRates(tps []*utils.TPTaxRate) (err error) {
+	for _, tp := range tps {
+		if err = iDB.setTPItem(utils.TBLTPTaxRates, tp, "tenant", "id"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetSMCost(smc *SMCost) (err error) {
+	if smc.CostDetails == nil {
+		return
+	}
+	var clnSMC SMCost
+	if err = utils.Clone(smc, &clnSMC); err != nil {
+		return
+	}
+	iDB.mux.Lock()
+	iDB.smCosts = append(iDB.smCosts, &clnSMC)
+	iDB.mux.Unlock()
+	return
+}
+
+func (iDB *InternalStorDB) RemoveSMCost(smc *SMCost) (err error) {
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	var remaining []*SMCost
+	for _, stored := range iDB.smCosts {
+		if stored.CGRID != smc.CGRID || stored.RunID != smc.RunID {
+			remaining = append(remaining, stored)
+		}
+	}
+	iDB.smCosts = remaining
+	return
+}
+
+// GetSMCosts is used to retrieve one or multiple SMCosts based on filter
+func (iDB *InternalStorDB) GetSMCosts(cgrid, runid, originHost, originIDPrefix string) (smcs []*SMCost, err error) {
+	iDB.mux.RLock()
+	defer iDB.mux.RUnlock()
+	for _, smc := range iDB.smCosts {
+		if (cgrid != "" && smc.CGRID != cgrid) ||
+			(runid != "" && smc.RunID != runid) ||
+			(originHost != "" && smc.OriginHost != originHost) ||
+			(originIDPrefix != "" && !strings.HasPrefix(smc.OriginID, originIDPrefix)) {
+			continue
+		}
+		var clnSMC SMCost
+		if err = utils.Clone(smc, &clnSMC); err != nil {
+			return nil, err
+		}
+		smcs = append(smcs, &clnSMC)
+	}
+	if len(smcs) == 0 {
+		return smcs, utils.ErrNotFound
+	}
+	return
+}
+
+func (iDB *InternalStorDB) SetStatQueueMetrics(sqm *StatQueueMetrics) (err error) {
+	clnSQM := &StatQueueMetrics{Tenant: sqm.Tenant, ID: sqm.ID, Time: sqm.Time,
+		Metrics: make(map[string]float64)}
+	for metricID, val := range sqm.Metrics {
+		clnSQM.Metrics[metricID] = val
+	}
+	iDB.mux.Lock()
+	iDB.statMetrics = append(iDB.statMetrics, clnSQM)
+	iDB.mux.Unlock()
+	return
+}
+
+// GetStatQueueMetrics returns the metrics history of a StatQueue, ordered by time
+// from is inclusive, to exclusive, zero values are not limiting the query
+func (iDB *InternalStorDB) GetStatQueueMetrics(tenant, id string, from, to time.Time) (sqms []*StatQueueMetrics, err error) {
+	iDB.mux.RLock()
+	for _, sqm := range iDB.statMetrics {
+		if sqm.Tenant != tenant || sqm.ID != id ||
+			(!from.IsZero() && sqm.Time.Before(from)) ||
+			(!to.IsZero() && !sqm.Time.Before(to)) {
+			continue
+		}
+		clnSQM := &StatQueueMetrics{Tenant: sqm.Tenant, ID: sqm.ID, Time: sqm.Time,
+			Metrics: make(map[string]float64)}
+		for metricID, val := range sqm.Metrics {
+			clnSQM.Metrics[metricID] = val
+		}
+		sqms = append(sqms, clnSQM)
+	}
+	iDB.mux.RUnlock()
+	if len(sqms) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	sort.SliceStable(sqms, func(i, j int) bool {
+		return sqms[i].Time.Before(sqms[j].Time)
+	})
+	return
+}
+
+// RemStatQueueMetrics removes the metrics history older than before
+func (iDB *InternalStorDB) RemStatQueueMetrics(before time.Time) (err error) {
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	kept := iDB.statMetrics[:0]
+	for _, sqm := range iDB.statMetrics {
+		if !sqm.Time.Before(before) {
+			kept = append(kept, sqm)
+		}
+	}
+	for i := len(kept); i < len(iDB.statMetrics); i++ {
+		iDB.statMetrics[i] = nil // release the removed snapshots
+	}
+	iDB.statMetrics = kept
+	return
+}
+
+// SetCDR stores a copy of the CDR, with allowUpdate the CDR having the same CGRID and RunID is replaced
+func (iDB *InternalStorDB) SetCDR(cdr *CDR, allowUpdate bool) (err error) {
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	for _, iCDR := range iDB.cdrs {
+		if iCDR.CDR.CGRID != cdr.CGRID || iCDR.CDR.RunID != cdr.RunID {
+			continue
+		}
+		if !allowUpdate {
+			return utils.ErrExists
+		}
+		orderID := iCDR.CDR.OrderID
+		iCDR.CDR = cdr.Clone()
+		iCDR.CDR.OrderID = orderID
+		iCDR.UpdatedAt = time.Now()
+		return
+	}
+	iDB.orderID++
+	clnCDR := cdr.Clone()
+	clnCDR.OrderID = iDB.orderID
+	iDB.cdrs = append(iDB.cdrs, &InternalCDR{CDR: clnCDR, CreatedAt: time.Now()})
+	return
+}
+
+// GetCDRs has ability to remove the selected CDRs, count them or simply return them
+func (iDB *InternalStorDB) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) (cdrs []*CDR, cnt int64, err error) {
+	fltr, err := newInternalCDRFilter(qryFltr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if remove {
+		iDB.mux.Lock()
+		defer iDB.mux.Unlock()
+	} else {
+		iDB.mux.RLock()
+		defer iDB.mux.RUnlock()
+	}
+	matched := iDB.matchCDRs(fltr)
+	if remove {
+		rmvd := make(map[*InternalCDR]bool)
+		for _, iCDR := range matched {
+			rmvd[iCDR] = true
+		}
+		var remaining []*InternalCDR
+		for _, iCDR := range iDB.cdrs {
+			if !rmvd[iCDR] {
+				remaining = append(remaining, iCDR)
+			}
+		}
+		iDB.cdrs = remaining
+		return nil, int64(len(matched)), nil
+	}
+	if qryFltr.Count {
+		return nil, int64(len(matched)), nil
+	}
+	for _, iCDR := range matched {
+		cdrs = append(cdrs, iCDR.CDR.Clone())
+	}
+	if len(cdrs) == 0 {
+		return cdrs, 0, utils.ErrNotFound
+	}
+	return
+}
+
+// ArchiveCDRs moves the CDRs matching the filter into the archive, the Paginator is ignored
+func (iDB *InternalStorDB) ArchiveCDRs(qryFltr *utils.CDRsFilter) (archived int64, err error) {
+	noPagFltr := *qryFltr
+	noPagFltr.Paginator = utils.Paginator{}
+	fltr, err := newInternalCDRFilter(&noPagFltr)
+	if err != nil {
+		return 0, err
+	}
+	iDB.mux.Lock()
+	defer iDB.mux.Unlock()
+	remaining := make([]*InternalCDR, 0, len(iDB.cdrs))
+	for _, iCDR := range iDB.cdrs {
+		if fltr.match(iCDR) {
+			iDB.cdrsArchive = append(iDB.cdrsArchive, iCDR)
+			archived++
+		} else {
+			remaining = append(remaining, iCDR)
+		}
+	}
+	iDB.cdrs = remaining
+	return
+}
+
+// GetCDRsIterator returns the CDRs matching the filter, cloning each of them only when read
+func (iDB *InternalStorDB) GetCDRsIterator(qryFltr *utils.CDRsFilter) (CDRsIterator, error) {
+	fltr, err := newInternalCDRFilter(qryFltr)
+	if err != nil {
+		return nil, err
+	}
+	iDB.mux.RLock()
+	matched := iDB.matchCDRs(fltr)
+	iDB.mux.RUnlock()
+	return &internalCDRsIterator{iDB: iDB, cdrs: matched}, nil
+}
+
+// matchCDRs returns the sorted and paginated CDRs matching fltr, iDB.mux should be locked by the caller
+func (iDB *InternalStorDB) matchCDRs(fltr *internalCDRFilter) (matched []*InternalCDR) {
+	for _, iCDR := range iDB.cdrs {
+		if fltr.match(iCDR) {
+			matched = append(matched, iCDR)
+		}
+	}
+	if fltr.OrderBy == utils.SETUP_TIME {
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].CDR.SetupTime.Before(matched[j].CDR.SetupTime) })
+	}
+	if fltr.Paginator.Offset != nil {
+		if *fltr.Paginator.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[*fltr.Paginator.Offset:]
+		}
+	}
+	if fltr.Paginator.Limit != nil && *fltr.Paginator.Limit < len(matched) {
+		matched = matched[:*fltr.Paginator.Limit]
+	}
+	return
+}
+
+type internalCDRsIterator struct {
+	iDB  *InternalStorDB
+	cdrs []*InternalCDR
+	idx  int
+}
+
+func (it *internalCDRsIterator) Next() (cdr *CDR, err error) {
+	if it.idx >= len(it.cdrs) {
+		return nil, io.EOF
+	}
+	it.iDB.mux.RLock()
+	cdr = it.cdrs[it.idx].CDR.Clone()
+	it.iDB.mux.RUnlock()
+	it.idx++
+	return
+}
+
+func (it *internalCDRsIterator) Close() error {
+	it.cdrs = nil
+	return nil
+}
+
+func newInternalCDRFilter(qryFltr *utils.CDRsFilter) (fltr *internalCDRFilter, err error) {
+	if err = checkCDRsOrder(qryFltr.OrderBy); err != nil {
+		return
+	}
+	fltr = &internalCDRFilter{CDRsFilter: qryFltr}
+	for _, durFltr := range []struct {
+		strVal string
+		dur    **time.Duration
+	}{
+		{qryFltr.MinUsage, &fltr.minUsage},
+		{qryFltr.MaxUsage, &fltr.maxUsage},
+		{qryFltr.MinPDD, &fltr.minPDD},
+		{qryFltr.MaxPDD, &fltr.maxPDD},
+	} {
+		if len(durFltr.strVal) == 0 {
+			continue
+		}
+		dur, err := utils.ParseDurationWithSecs(durFltr.strVal)
+		if err != nil {
+			return nil, err
+		}
+		*durFltr.dur = &dur
+	}
+	return
+}
+
+// internalCDRFilter matches CDRs in memory, with the same semantics as the SQL queries
+type internalCDRFilter struct {
+	*utils.CDRsFilter
+	minUsage, maxUsage *time.Duration
+	minPDD, maxPDD     *time.Duration
+}
+
+// matchInternalCDRStrings checks val against the list of accepted and rejected values
+func matchInternalCDRStrings(val string, in, notIn []string) bool {
+	if len(in) != 0 && !utils.IsSliceMember(in, val) {
+		return false
+	}
+	return len(notIn) == 0 || !utils.IsSliceMember(notIn, val)
+}
+
+// matchInternalCDRTime checks start <= t < end, nil or zero limits are ignored when skipZero
+func matchInternalCDRTime(t time.Time, start, end *time.Time, skipZero bool) bool {
+	if start != nil && !(skipZero && start.IsZero()) && t.Before(*start) {
+		return false
+	}
+	if end != nil && !(skipZero && end.IsZero()) && !t.Before(*end) {
+		return false
+	}
+	return true
+}
+
+func hasInternalCDRCost(costs []float64, cost float64) bool {
+	for _, c := range costs {
+		if c == cost {
+			return true
+		}
+	}
+	return false
+}
+
+func (fltr *internalCDRFilter) match(iCDR *InternalCDR) bool {
+	cdr := iCDR.CDR
+	for _, strFltr := range []struct {
+		val       string
+		in, notIn []string
+	}{
+		{cdr.CGRID, fltr.CGRIDs, fltr.NotCGRIDs},
+		{cdr.RunID, fltr.RunIDs, fltr.NotRunIDs},
+		{cdr.ToR, fltr.ToRs, fltr.NotToRs},
+		{cdr.OriginHost, fltr.OriginHosts, fltr.NotOriginHosts},
+		{cdr.Source, fltr.Sources, fltr.NotSources},
+		{cdr.RequestType, fltr.RequestTypes, fltr.NotRequestTypes},
+		{cdr.Direction, fltr.Directions, fltr.NotDirections},
+		{cdr.Tenant, fltr.Tenants, fltr.NotTenants},
+		{cdr.Category, fltr.Categories, fltr.NotCategories},
+		{cdr.Account, fltr.Accounts, fltr.NotAccounts},
+		{cdr.Subject, fltr.Subjects, fltr.NotSubjects},
+		{cdr.Supplier, fltr.Suppliers, fltr.NotSuppliers},
+		{cdr.DisconnectCause, fltr.DisconnectCauses, fltr.NotDisconnectCauses},
+	} {
+		if !matchInternalCDRStrings(strFltr.val, strFltr.in, strFltr.notIn) {
+			return false
+		}
+	}
+	if len(fltr.DestinationPrefixes) != 0 {
+		var hasPrefix bool
+		for _, prfx := range fltr.DestinationPrefixes {
+			if strings.HasPrefix(cdr.Destination, prfx) {
+				hasPrefix = true
+				break
+			}
+		}
+		if !hasPrefix {
+			return false
+		}
+	}
+	for _, prfx := range fltr.NotDestinationPrefixes {
+		if strings.HasPrefix(cdr.Destination, prfx) {
+			return false
+		}
+	}
+	if (len(fltr.Costs) != 0 && !hasInternalCDRCost(fltr.Costs, cdr.Cost)) ||
+		hasInternalCDRCost(fltr.NotCosts, cdr.Cost) {
+		return false
+	}
+	if len(fltr.ExtraFields) != 0 { // any of the extra fields matching is enough
+		var efMatched bool
+		for fldName, fldVal := range fltr.ExtraFields {
+			if cdrVal, has := cdr.ExtraFields[fldName]; has &&
+				(fldVal == utils.MetaExists || cdrVal == fldVal) {
+				efMatched = true
+				break
+			}
+		}
+		if !efMatched {
+			return false
+		}
+	}
+	for fldName, fldVal := range fltr.NotExtraFields {
+		if cdrVal, has := cdr.ExtraFields[fldName]; has &&
+			(fldVal == utils.MetaExists || cdrVal == fldVal) {
+			return false
+		}
+	}
+	if (fltr.OrderIDStart != nil && cdr.OrderID < *fltr.OrderIDStart) ||
+		(fltr.OrderIDEnd != nil && cdr.OrderID >= *fltr.OrderIDEnd) {
+		return false
+	}
+	if !matchInternalCDRTime(cdr.SetupTime, fltr.SetupTimeStart, fltr.SetupTimeEnd, false) ||
+		!matchInternalCDRTime(cdr.AnswerTime, fltr.AnswerTimeStart, fltr.AnswerTimeEnd, true) ||
+		!matchInternalCDRTime(iCDR.CreatedAt, fltr.CreatedAtStart, fltr.CreatedAtEnd, true) ||
+		!matchInternalCDRTime(iCDR.UpdatedAt, fltr.UpdatedAtStart, fltr.UpdatedAtEnd, true) {
+		return false
+	}
+	if (fltr.minUsage != nil && cdr.Usage < *fltr.minUsage) ||
+		(fltr.maxUsage != nil && cdr.Usage >= *fltr.maxUsage) ||
+		(fltr.minPDD != nil && cdr.PDD < *fltr.minPDD) ||
+		(fltr.maxPDD != nil && cdr.PDD >= *fltr.maxPDD) {
+		return false
+	}
+	return fltr.matchCost(cdr.Cost)
+}
+
+// matchCost applies MinCost and MaxCost, negative cost is considered not rated
+func (fltr *internalCDRFilter) matchCost(cost float64) bool {
+	if fltr.MinCost != nil {
+		if fltr.MaxCost == nil {
+			return cost >= *fltr.MinCost
+		} else if *fltr.MinCost == 0.0 && *fltr.MaxCost == -1.0 { // Special case when we want to skip errors
+			return cost >= 0.0
+		}
+		return cost >= *fltr.MinCost && cost < *fltr.MaxCost
+	} else if fltr.MaxCost != nil {
+		if *fltr.MaxCost == -1.0 { // Non-rated CDRs
+			return cost == -1.0
+		}
+		return cost == -1.0 || cost < *fltr.MaxCost
+	}
+	return true
+}
@@ -0,0 +1,280 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestInternalStorDBCDRs(t *testing.T) {
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupTime := time.Date(2017, 12, 1, 14, 0, 0, 0, time.UTC)
+	for i, cdr := range []*CDR{
+		&CDR{CGRID: "CGRID1", RunID: utils.MetaRaw, Tenant: "cgrates.org", Account: "1001",
+			Destination: "+4986517174963", SetupTime: setupTime, Usage: time.Duration(10 * time.Second),
+			Cost: -1, ExtraFields: map[string]string{"Service": "voice"}},
+		&CDR{CGRID: "CGRID1", RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "1001",
+			Destination: "+4986517174963", SetupTime: setupTime, Usage: time.Duration(10 * time.Second),
+			Cost: 0.1, ExtraFields: map[string]string{"Service": "voice"}},
+		&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT, Tenant: "cgrates.org", Account: "1002",
+			Destination: "+4915117174963", SetupTime: setupTime.Add(time.Hour), Usage: time.Duration(2 * time.Minute),
+			Cost: 1.2},
+	} {
+		if err := iDB.SetCDR(cdr, false); err != nil {
+			t.Fatalf("CDR %d, error: %v", i, err)
+		}
+	}
+	if err := iDB.SetCDR(&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT}, false); err != utils.ErrExists {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrExists, err)
+	}
+	if err := iDB.SetCDR(&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT, Tenant: "cgrates.org",
+		Account: "1002", Destination: "+4915117174963", SetupTime: setupTime.Add(time.Hour),
+		Usage: time.Duration(2 * time.Minute), Cost: 2.4}, true); err != nil {
+		t.Error(err)
+	}
+	if cdrs, _, err := iDB.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{"CGRID2"}}, false); err != nil {
+		t.Error(err)
+	} else if len(cdrs) != 1 || cdrs[0].Cost != 2.4 || cdrs[0].OrderID != 3 {
+		t.Errorf("Received: %s", utils.ToJSON(cdrs))
+	}
+	for i, tc := range []struct {
+		fltr *utils.CDRsFilter
+		eCnt int64
+	}{
+		{&utils.CDRsFilter{}, 3},
+		{&utils.CDRsFilter{RunIDs: []string{utils.META_DEFAULT}}, 2},
+		{&utils.CDRsFilter{NotRunIDs: []string{utils.META_DEFAULT}}, 1},
+		{&utils.CDRsFilter{DestinationPrefixes: []string{"+49865"}}, 2},
+		{&utils.CDRsFilter{NotDestinationPrefixes: []string{"+49865"}}, 1},
+		{&utils.CDRsFilter{ExtraFields: map[string]string{"Service": utils.MetaExists}}, 2},
+		{&utils.CDRsFilter{NotExtraFields: map[string]string{"Service": "voice"}}, 1},
+		{&utils.CDRsFilter{SetupTimeStart: utils.TimePointer(setupTime.Add(time.Minute))}, 1},
+		{&utils.CDRsFilter{SetupTimeEnd: utils.TimePointer(setupTime.Add(time.Minute))}, 2},
+		{&utils.CDRsFilter{MinUsage: "1m"}, 1},
+		{&utils.CDRsFilter{MaxUsage: "60"}, 2},
+		{&utils.CDRsFilter{MaxCost: utils.Float64Pointer(-1.0)}, 1},
+		{&utils.CDRsFilter{MinCost: utils.Float64Pointer(0.0), MaxCost: utils.Float64Pointer(1.0)}, 1},
+		{&utils.CDRsFilter{MinCost: utils.Float64Pointer(0.0), MaxCost: utils.Float64Pointer(-1.0)}, 2},
+		{&utils.CDRsFilter{Costs: []float64{0.1, 2.4}}, 2},
+		{&utils.CDRsFilter{OrderIDStart: utils.Int64Pointer(2)}, 2},
+		{&utils.CDRsFilter{Paginator: utils.Paginator{Offset: utils.IntPointer(1), Limit: utils.IntPointer(1)}}, 1},
+		{&utils.CDRsFilter{Paginator: utils.Paginator{Offset: utils.IntPointer(5)}}, 0},
+	} {
+		tc.fltr.Count = true
+		if _, cnt, err := iDB.GetCDRs(tc.fltr, false); err != nil {
+			t.Errorf("Case %d, error: %v", i, err)
+		} else if cnt != tc.eCnt {
+			t.Errorf("Case %d, expecting: %d, received: %d", i, tc.eCnt, cnt)
+		}
+	}
+	if _, _, err := iDB.GetCDRs(&utils.CDRsFilter{Accounts: []string{"1003"}}, false); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if _, cnt, err := iDB.GetCDRs(&utils.CDRsFilter{CGRIDs: []string{"CGRID1"}}, true); err != nil {
+		t.Error(err)
+	} else if cnt != 2 {
+		t.Errorf("Expecting 2 removed CDRs, received: %d", cnt)
+	}
+	if cdrs, _, err := iDB.GetCDRs(&utils.CDRsFilter{}, false); err != nil {
+		t.Error(err)
+	} else if len(cdrs) != 1 || cdrs[0].CGRID != "CGRID2" {
+		t.Errorf("Received: %s", utils.ToJSON(cdrs))
+	}
+}
+
+func TestInternalStorDBCDRsIterator(t *testing.T) {
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := iDB.SetCDR(&CDR{CGRID: utils.Sha1(strconv.Itoa(i)), RunID: utils.META_DEFAULT,
+			Tenant: "cgrates.org", Account: "1001", Cost: float64(i)}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cdrsIter, err := iDB.GetCDRsIterator(&utils.CDRsFilter{MinCost: utils.Float64Pointer(1),
+		Paginator: utils.Paginator{Offset: utils.IntPointer(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cdrsIter.Close()
+	var costs []float64
+	for {
+		cdr, err := cdrsIter.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		costs = append(costs, cdr.Cost)
+		cdr.Cost = 0 // clones should not alter the stored CDRs
+	}
+	if eCosts := []float64{2, 3, 4}; !reflect.DeepEqual(eCosts, costs) {
+		t.Errorf("Expecting: %v, received: %v", eCosts, costs)
+	}
+	if _, cnt, err := iDB.GetCDRs(&utils.CDRsFilter{MinCost: utils.Float64Pointer(1), Count: true}, false); err != nil {
+		t.Error(err)
+	} else if cnt != 4 {
+		t.Errorf("Expecting 4 CDRs, received: %d", cnt)
+	}
+	if cdr, err := cdrsIter.Next(); err != io.EOF {
+		t.Errorf("Expecting: %v, received: %v, %+v", io.EOF, err, cdr)
+	}
+}
+
+func TestInternalStorDBTPData(t *testing.T) {
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpDsts := []*utils.TPDestination{
+		&utils.TPDestination{TPid: "TP1", ID: "DST_1001", Prefixes: []string{"1001"}},
+		&utils.TPDestination{TPid: "TP1", ID: "DST_1002", Prefixes: []string{"1002"}},
+		&utils.TPDestination{TPid: "TP2", ID: "DST_1001", Prefixes: []string{"1001"}},
+	}
+	if err := iDB.SetTPDestinations(tpDsts); err != nil {
+		t.Fatal(err)
+	}
+	// update on the same TPid and ID
+	if err := iDB.SetTPDestinations([]*utils.TPDestination{
+		&utils.TPDestination{TPid: "TP1", ID: "DST_1002", Prefixes: []string{"1002", "1003"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if rcv, err := iDB.GetTPDestinations("TP1", "DST_1002"); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 1 || !reflect.DeepEqual(rcv[0].Prefixes, []string{"1002", "1003"}) {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if rcv, err := iDB.GetTPDestinations("TP1", ""); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 2 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if ids, err := iDB.GetTpTableIds("TP1", utils.TBLTPDestinations, utils.TPDistinctIds{"tag"},
+		nil, &utils.Paginator{SearchTerm: "1002"}); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(ids, []string{"DST_1002"}) {
+		t.Errorf("Received: %+v", ids)
+	}
+	if tpids, err := iDB.GetTpIds(); err != nil {
+		t.Error(err)
+	} else if len(tpids) != 2 {
+		t.Errorf("Received: %+v", tpids)
+	}
+	tpRPrfs := []*utils.TPRatingProfile{
+		&utils.TPRatingProfile{TPid: "TP1", LoadId: "LOAD1", Direction: utils.OUT, Tenant: "cgrates.org",
+			Category: "call", Subject: "1001", RatingPlanActivations: []*utils.TPRatingActivation{
+				&utils.TPRatingActivation{ActivationTime: "2017-01-01T00:00:00Z", RatingPlanId: "RP_1"}}},
+		&utils.TPRatingProfile{TPid: "TP1", LoadId: "LOAD1", Direction: utils.OUT, Tenant: "cgrates.org",
+			Category: "call", Subject: "1002", RatingPlanActivations: []*utils.TPRatingActivation{
+				&utils.TPRatingActivation{ActivationTime: "2017-01-01T00:00:00Z", RatingPlanId: "RP_1"}}},
+	}
+	if err := iDB.SetTPRatingProfiles(tpRPrfs); err != nil {
+		t.Fatal(err)
+	}
+	if rcv, err := iDB.GetTPRatingProfiles(&utils.TPRatingProfile{TPid: "TP1", Subject: "1002"}); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rcv, tpRPrfs[1:]) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(tpRPrfs[1:]), utils.ToJSON(rcv))
+	}
+	if err := iDB.RemTpData(utils.TBLTPDestinations, "TP1", map[string]string{"tag": "DST_1001"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := iDB.GetTPDestinations("TP1", "DST_1001"); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if err := iDB.RemTpData("", "TP1", nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := iDB.GetTPRatingProfiles(&utils.TPRatingProfile{TPid: "TP1"}); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	if rcv, err := iDB.GetTPDestinations("TP2", ""); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rcv, tpDsts[2:]) {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+}
+
+func TestInternalStorDBDump(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "internal_stordb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+	dumpPath := path.Join(dirPath, "stordb.dump")
+	iDB, err := NewInternalStorDB(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smc := &SMCost{CGRID: "CGRID1", RunID: utils.META_DEFAULT, OriginHost: "127.0.0.1",
+		OriginID: "call1", CostSource: utils.SESSION_MANAGER_SOURCE, Usage: 10,
+		CostDetails: &CallCost{Direction: utils.OUT, Destination: "1002", Cost: 0.1}}
+	if err := iDB.SetSMCost(smc); err != nil {
+		t.Fatal(err)
+	}
+	if err := iDB.SetCDR(&CDR{CGRID: "CGRID1", RunID: utils.META_DEFAULT, Cost: 0.1}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := iDB.SetTPTimings([]*utils.ApierTPTiming{
+		&utils.ApierTPTiming{TPid: "TP1", ID: "ALWAYS", Time: "00:00:00"}}); err != nil {
+		t.Fatal(err)
+	}
+	iDB.Close()
+	iDB2, err := NewInternalStorDB(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcv, err := iDB2.GetSMCosts("", "", "", "call"); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 1 || rcv[0].CostDetails.Cost != 0.1 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if err := iDB2.SetCDR(&CDR{CGRID: "CGRID2", RunID: utils.META_DEFAULT}, false); err != nil {
+		t.Error(err)
+	}
+	if cdrs, _, err := iDB2.GetCDRs(&utils.CDRsFilter{}, false); err != nil {
+		t.Error(err)
+	} else if len(cdrs) != 2 || cdrs[1].OrderID != 2 {
+		t.Errorf("Received: %s", utils.ToJSON(cdrs))
+	}
+	if rcv, err := iDB2.GetTPTimings("TP1", "ALWAYS"); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 1 || rcv[0].Time != "00:00:00" {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if err := iDB2.RemoveSMCost(smc); err != nil {
+		t.Error(err)
+	}
+	if _, err := iDB2.GetSMCosts("CGRID1", "", "", ""); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+}
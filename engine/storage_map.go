@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cgrates/cgrates/cache"
 	"github.com/cgrates/cgrates/config"
@@ -38,6 +39,7 @@ type MapStorage struct {
 	ms       Marshaler
 	mu       sync.RWMutex
 	cacheCfg config.CacheConfig
+	dbLog    *mapStorageLog // persists the changes on disk, nil for pure in-memory storage
 }
 
 type storage map[string][]byte
@@ -71,6 +73,28 @@ func (s storage) smembers(key string, ms Marshaler) (idMap utils.StringMap, ok b
 	return
 }
 
+// setKey writes the value of key, ms.mu should be locked by the caller
+func (ms *MapStorage) setKey(key string, value []byte) {
+	ms.dict[key] = value
+	ms.logWrite(mapStorageOpSet, key, value)
+}
+
+// removeKey deletes the key, ms.mu should be locked by the caller
+func (ms *MapStorage) removeKey(key string) {
+	delete(ms.dict, key)
+	ms.logWrite(mapStorageOpRemove, key, nil)
+}
+
+func (ms *MapStorage) sadd(key, value string) {
+	ms.dict.sadd(key, value, ms.ms)
+	ms.logWrite(mapStorageOpSet, key, ms.dict[key])
+}
+
+func (ms *MapStorage) srem(key, value string) {
+	ms.dict.srem(key, value, ms.ms)
+	ms.logWrite(mapStorageOpSet, key, ms.dict[key])
+}
+
 func NewMapStorage() (*MapStorage, error) {
 	return &MapStorage{dict: make(map[string][]byte), ms: NewCodecMsgpackMarshaler(),
 		cacheCfg: config.CgrConfig().CacheConfig}, nil
@@ -82,12 +106,23 @@ func NewMapStorageJson() (mpStorage *MapStorage, err error) {
 	return
 }
 
-func (ms *MapStorage) Close() {}
+func (ms *MapStorage) Close() {
+	if ms.dbLog == nil {
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.dbLog.close(ms.dict); err != nil {
+		utils.Logger.Warning(fmt.Sprintf("<%s> failed closing the database, error: %s",
+			utils.MetaInternalPersistent, err.Error()))
+	}
+}
 
 func (ms *MapStorage) Flush(ignore string) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	ms.dict = make(map[string][]byte)
+	ms.logWrite(mapStorageOpFlush, "", nil)
 	return nil
 }
 
@@ -107,7 +142,7 @@ func (ms *MapStorage) RebuildReverseForPrefix(prefix string) error {
 	}
 	for _, key := range keys {
 		ms.mu.Lock()
-		delete(ms.dict, key)
+		ms.removeKey(key)
 		ms.mu.Unlock()
 	}
 	switch prefix {
@@ -222,6 +257,9 @@ func (ms *MapStorage) CacheDataFromDB(prefix string, IDs []string, mustBeCached
 			utils.UnsupportedCachePrefix,
 			fmt.Sprintf("prefix <%s> is not a supported cache prefix", prefix))
 	}
+	if mustBeCached { // refreshing after changes in DataDB, the other engines should drop their copies
+		defer publishCacheRefresh(prefix, IDs)
+	}
 	if IDs == nil {
 		keyIDs, err := ms.GetKeysForPrefix(prefix)
 		if err != nil {
@@ -366,7 +404,7 @@ func (ms *MapStorage) SetRatingPlan(rp *RatingPlan, transactionID string) (err e
 	w := zlib.NewWriter(&b)
 	w.Write(result)
 	w.Close()
-	ms.dict[utils.RATING_PLAN_PREFIX+rp.Id] = b.Bytes()
+	ms.setKey(utils.RATING_PLAN_PREFIX+rp.Id, b.Bytes())
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Call("HistoryV1.Record", rp.GetHistoryRecord(), &response)
@@ -405,7 +443,7 @@ func (ms *MapStorage) SetRatingProfile(rpf *RatingProfile, transactionID string)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(rpf)
-	ms.dict[utils.RATING_PROFILE_PREFIX+rpf.Id] = result
+	ms.setKey(utils.RATING_PROFILE_PREFIX+rpf.Id, result)
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Call("HistoryV1.Record", rpf.GetHistoryRecord(false), &response)
@@ -419,7 +457,7 @@ func (ms *MapStorage) RemoveRatingProfile(key string, transactionID string) (err
 	defer ms.mu.Unlock()
 	for k := range ms.dict {
 		if strings.HasPrefix(k, key) {
-			delete(ms.dict, key)
+			ms.removeKey(key)
 			cache.RemKey(k, cacheCommit(transactionID), transactionID)
 			response := 0
 			rpf := &RatingProfile{Id: key}
@@ -458,7 +496,7 @@ func (ms *MapStorage) SetLCR(lcr *LCR, transactionID string) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(lcr)
-	ms.dict[utils.LCR_PREFIX+lcr.GetId()] = result
+	ms.setKey(utils.LCR_PREFIX+lcr.GetId(), result)
 	cache.RemKey(utils.LCR_PREFIX+lcr.GetId(), cacheCommit(transactionID), transactionID)
 	return
 }
@@ -512,7 +550,7 @@ func (ms *MapStorage) SetDestination(dest *Destination, transactionID string) (e
 	w.Write(result)
 	w.Close()
 	key := utils.DESTINATION_PREFIX + dest.Id
-	ms.dict[key] = b.Bytes()
+	ms.setKey(key, b.Bytes())
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Call("HistoryV1.Record", dest.GetHistoryRecord(false), &response)
@@ -547,7 +585,7 @@ func (ms *MapStorage) SetReverseDestination(dest *Destination, transactionID str
 	for _, p := range dest.Prefixes {
 		key := utils.REVERSE_DESTINATION_PREFIX + p
 		ms.mu.Lock()
-		ms.dict.sadd(key, dest.Id, ms.ms)
+		ms.sadd(key, dest.Id)
 		ms.mu.Unlock()
 		cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	}
@@ -563,13 +601,13 @@ func (ms *MapStorage) RemoveDestination(destID string, transactionID string) (er
 	}
 
 	ms.mu.Lock()
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	ms.mu.Unlock()
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 
 	for _, prefix := range d.Prefixes {
 		ms.mu.Lock()
-		ms.dict.srem(utils.REVERSE_DESTINATION_PREFIX+prefix, destID, ms.ms)
+		ms.srem(utils.REVERSE_DESTINATION_PREFIX+prefix, destID)
 		ms.mu.Unlock()
 		ms.GetReverseDestination(prefix, true, transactionID) // it will recache the destination
 	}
@@ -614,7 +652,7 @@ func (ms *MapStorage) UpdateReverseDestination(oldDest, newDest *Destination, tr
 	var err error
 	for _, obsoletePrefix := range obsoletePrefixes {
 		ms.mu.Lock()
-		ms.dict.srem(utils.REVERSE_DESTINATION_PREFIX+obsoletePrefix, oldDest.Id, ms.ms)
+		ms.srem(utils.REVERSE_DESTINATION_PREFIX+obsoletePrefix, oldDest.Id)
 		ms.mu.Unlock()
 		cache.RemKey(utils.REVERSE_DESTINATION_PREFIX+obsoletePrefix, cCommit, transactionID)
 	}
@@ -622,7 +660,7 @@ func (ms *MapStorage) UpdateReverseDestination(oldDest, newDest *Destination, tr
 	// add the id to all new prefixes
 	for _, addedPrefix := range addedPrefixes {
 		ms.mu.Lock()
-		ms.dict.sadd(utils.REVERSE_DESTINATION_PREFIX+addedPrefix, newDest.Id, ms.ms)
+		ms.sadd(utils.REVERSE_DESTINATION_PREFIX+addedPrefix, newDest.Id)
 		ms.mu.Unlock()
 		cache.RemKey(utils.REVERSE_DESTINATION_PREFIX+addedPrefix, cCommit, transactionID)
 	}
@@ -661,7 +699,7 @@ func (ms *MapStorage) SetActions(key string, as Actions, transactionID string) (
 	cCommit := cacheCommit(transactionID)
 	cachekey := utils.ACTION_PREFIX + key
 	result, err := ms.ms.Marshal(&as)
-	ms.dict[cachekey] = result
+	ms.setKey(cachekey, result)
 	cache.RemKey(cachekey, cCommit, transactionID)
 	return
 }
@@ -669,7 +707,7 @@ func (ms *MapStorage) SetActions(key string, as Actions, transactionID string) (
 func (ms *MapStorage) RemoveActions(key string, transactionID string) (err error) {
 	cachekey := utils.ACTION_PREFIX + key
 	ms.mu.Lock()
-	delete(ms.dict, cachekey)
+	ms.removeKey(cachekey)
 	ms.mu.Unlock()
 	cache.RemKey(cachekey, cacheCommit(transactionID), transactionID)
 	return
@@ -704,7 +742,7 @@ func (ms *MapStorage) SetSharedGroup(sg *SharedGroup, transactionID string) (err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(sg)
-	ms.dict[utils.SHARED_GROUP_PREFIX+sg.Id] = result
+	ms.setKey(utils.SHARED_GROUP_PREFIX+sg.Id, result)
 	cache.RemKey(utils.SHARED_GROUP_PREFIX+sg.Id, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -728,30 +766,45 @@ func (ms *MapStorage) GetAccount(key string) (ub *Account, err error) {
 	return
 }
 
+// SetAccount stores the account only if its revision did not change since it was read
 func (ms *MapStorage) SetAccount(ub *Account) (err error) {
-	// never override existing account with an empty one
-	// UPDATE: if all balances expired and were cleaned it makes
-	// sense to write empty balance map
-	if len(ub.BalanceMap) == 0 {
-		if ac, err := ms.GetAccount(ub.ID); err == nil && !ac.allBalancesExpired() {
-			ac.ActionTriggers = ub.ActionTriggers
-			ac.UnitCounters = ub.UnitCounters
-			ac.AllowNegative = ub.AllowNegative
-			ac.Disabled = ub.Disabled
-			ub = ac
-		}
-	}
+	return ms.SetAccounts([]*Account{ub})
+}
+
+// SetAccounts stores all the accounts only if none of their revisions changed since they were read
+func (ms *MapStorage) SetAccounts(accs []*Account) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	result, err := ms.ms.Marshal(ub)
-	ms.dict[utils.ACCOUNT_PREFIX+ub.ID] = result
+	toStore := make([]*Account, len(accs))
+	for i, acc := range accs {
+		var stored *Account
+		if values, has := ms.dict[utils.ACCOUNT_PREFIX+acc.ID]; has {
+			stored = &Account{ID: acc.ID}
+			if err = ms.ms.Unmarshal(values, stored); err != nil {
+				return
+			}
+		}
+		if toStore[i], err = accountToStore(acc, stored); err != nil {
+			return
+		}
+	}
+	results := make([][]byte, len(accs))
+	for i := range toStore {
+		if results[i], err = ms.ms.Marshal(toStore[i]); err != nil {
+			return
+		}
+	}
+	for i, acc := range accs {
+		ms.setKey(utils.ACCOUNT_PREFIX+acc.ID, results[i])
+		acc.Revision = toStore[i].Revision
+	}
 	return
 }
 
 func (ms *MapStorage) RemoveAccount(key string) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.ACCOUNT_PREFIX+key)
+	ms.removeKey(utils.ACCOUNT_PREFIX + key)
 	return
 }
 
@@ -771,7 +824,7 @@ func (ms *MapStorage) SetCdrStatsQueue(sq *CDRStatsQueue) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(sq)
-	ms.dict[utils.CDR_STATS_QUEUE_PREFIX+sq.GetId()] = result
+	ms.setKey(utils.CDR_STATS_QUEUE_PREFIX+sq.GetId(), result)
 	return
 }
 
@@ -793,14 +846,14 @@ func (ms *MapStorage) SetSubscriber(key string, sub *SubscriberData) (err error)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(sub)
-	ms.dict[utils.PUBSUB_SUBSCRIBERS_PREFIX+key] = result
+	ms.setKey(utils.PUBSUB_SUBSCRIBERS_PREFIX+key, result)
 	return
 }
 
 func (ms *MapStorage) RemoveSubscriber(key string) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.PUBSUB_SUBSCRIBERS_PREFIX+key)
+	ms.removeKey(utils.PUBSUB_SUBSCRIBERS_PREFIX + key)
 	return
 }
 
@@ -811,7 +864,7 @@ func (ms *MapStorage) SetUser(up *UserProfile) error {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.USERS_PREFIX+up.GetId()] = result
+	ms.setKey(utils.USERS_PREFIX+up.GetId(), result)
 	return nil
 }
 func (ms *MapStorage) GetUser(key string) (up *UserProfile, err error) {
@@ -843,7 +896,7 @@ func (ms *MapStorage) GetUsers() (result []*UserProfile, err error) {
 func (ms *MapStorage) RemoveUser(key string) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.USERS_PREFIX+key)
+	ms.removeKey(utils.USERS_PREFIX + key)
 	return nil
 }
 
@@ -884,7 +937,7 @@ func (ms *MapStorage) SetAlias(al *Alias, transactionID string) error {
 	key := utils.ALIASES_PREFIX + al.GetId()
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	ms.dict[key] = result
+	ms.setKey(key, result)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return nil
 }
@@ -921,7 +974,7 @@ func (ms *MapStorage) SetReverseAlias(al *Alias, transactionID string) (err erro
 				rKey := strings.Join([]string{utils.REVERSE_ALIASES_PREFIX, alias, target, al.Context}, "")
 				id := utils.ConcatenatedKey(al.GetId(), value.DestinationId)
 				ms.mu.Lock()
-				ms.dict.sadd(rKey, id, ms.ms)
+				ms.sadd(rKey, id)
 				ms.mu.Unlock()
 
 				cache.RemKey(rKey, cCommit, transactionID)
@@ -946,7 +999,7 @@ func (ms *MapStorage) RemoveAlias(key string, transactionID string) error {
 	if values, ok := ms.dict[key]; ok {
 		ms.ms.Unmarshal(values, &aliasValues)
 	}
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cCommit := cacheCommit(transactionID)
 	cache.RemKey(key, cCommit, transactionID)
 	for _, value := range al.Values {
@@ -954,7 +1007,7 @@ func (ms *MapStorage) RemoveAlias(key string, transactionID string) error {
 		for target, pairs := range value.Pairs {
 			for _, alias := range pairs {
 				rKey := utils.REVERSE_ALIASES_PREFIX + alias + target + al.Context
-				ms.dict.srem(rKey, tmpKey, ms.ms)
+				ms.srem(rKey, tmpKey)
 				cache.RemKey(rKey, cCommit, transactionID)
 				/*_, err = ms.GetReverseAlias(rKey, true) // recache
 				if err != nil {
@@ -1006,11 +1059,11 @@ func (ms *MapStorage) SetActionTriggers(key string, atrs ActionTriggers, transac
 	defer ms.mu.Unlock()
 	if len(atrs) == 0 {
 		// delete the key
-		delete(ms.dict, utils.ACTION_TRIGGER_PREFIX+key)
+		ms.removeKey(utils.ACTION_TRIGGER_PREFIX + key)
 		return
 	}
 	result, err := ms.ms.Marshal(&atrs)
-	ms.dict[utils.ACTION_TRIGGER_PREFIX+key] = result
+	ms.setKey(utils.ACTION_TRIGGER_PREFIX+key, result)
 	cache.RemKey(utils.ACTION_TRIGGER_PREFIX+key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1018,7 +1071,7 @@ func (ms *MapStorage) SetActionTriggers(key string, atrs ActionTriggers, transac
 func (ms *MapStorage) RemoveActionTriggers(key string, transactionID string) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.ACTION_TRIGGER_PREFIX+key)
+	ms.removeKey(utils.ACTION_TRIGGER_PREFIX + key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1051,7 +1104,7 @@ func (ms *MapStorage) SetActionPlan(key string, ats *ActionPlan, overwrite bool,
 		ms.mu.Lock()
 		defer ms.mu.Unlock()
 		// delete the key
-		delete(ms.dict, utils.ACTION_PLAN_PREFIX+key)
+		ms.removeKey(utils.ACTION_PLAN_PREFIX + key)
 		cache.RemKey(utils.ACTION_PLAN_PREFIX+key, cCommit, transactionID)
 		return
 	}
@@ -1069,7 +1122,7 @@ func (ms *MapStorage) SetActionPlan(key string, ats *ActionPlan, overwrite bool,
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(&ats)
-	ms.dict[utils.ACTION_PLAN_PREFIX+key] = result
+	ms.setKey(utils.ACTION_PLAN_PREFIX+key, result)
 	cache.RemKey(utils.ACTION_PLAN_PREFIX+key, cCommit, transactionID)
 	return
 }
@@ -1133,7 +1186,7 @@ func (ms *MapStorage) SetAccountActionPlans(acntID string, apIDs []string, overw
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.AccountActionPlansPrefix+acntID] = result
+	ms.setKey(utils.AccountActionPlansPrefix+acntID, result)
 
 	return
 }
@@ -1141,7 +1194,7 @@ func (ms *MapStorage) SetAccountActionPlans(acntID string, apIDs []string, overw
 func (ms *MapStorage) RemAccountActionPlans(acntID string, apIDs []string) (err error) {
 	key := utils.AccountActionPlansPrefix + acntID
 	if len(apIDs) == 0 {
-		delete(ms.dict, key)
+		ms.removeKey(key)
 		return
 	}
 	oldaPlIDs, err := ms.GetAccountActionPlans(acntID, true, utils.NonTransactional)
@@ -1158,14 +1211,14 @@ func (ms *MapStorage) RemAccountActionPlans(acntID string, apIDs []string) (err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	if len(oldaPlIDs) == 0 {
-		delete(ms.dict, key)
+		ms.removeKey(key)
 		return
 	}
 	var result []byte
 	if result, err = ms.ms.Marshal(oldaPlIDs); err != nil {
 		return err
 	}
-	ms.dict[key] = result
+	ms.setKey(key, result)
 	return
 }
 
@@ -1223,12 +1276,12 @@ func (ms *MapStorage) SetDerivedChargers(key string, dcs *utils.DerivedChargers,
 	cCommit := cacheCommit(transactionID)
 	key = utils.DERIVEDCHARGERS_PREFIX + key
 	if dcs == nil || len(dcs.Chargers) == 0 {
-		delete(ms.dict, key)
+		ms.removeKey(key)
 		cache.RemKey(key, cCommit, transactionID)
 		return nil
 	}
 	result, err := ms.ms.Marshal(dcs)
-	ms.dict[key] = result
+	ms.setKey(key, result)
 	cache.RemKey(key, cCommit, transactionID)
 	return err
 }
@@ -1237,7 +1290,7 @@ func (ms *MapStorage) SetCdrStats(cs *CdrStats) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(cs)
-	ms.dict[utils.CDR_STATS_PREFIX+cs.Id] = result
+	ms.setKey(utils.CDR_STATS_PREFIX+cs.Id, result)
 	return err
 }
 
@@ -1270,7 +1323,7 @@ func (ms *MapStorage) SetSMCost(smCost *SMCost) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	result, err := ms.ms.Marshal(smCost)
-	ms.dict[utils.LOG_CALL_COST_PREFIX+smCost.CostSource+smCost.RunID+"_"+smCost.CGRID] = result
+	ms.setKey(utils.LOG_CALL_COST_PREFIX+smCost.CostSource+smCost.RunID+"_"+smCost.CGRID, result)
 	return err
 }
 
@@ -1322,7 +1375,7 @@ func (ms *MapStorage) SetResourceProfile(r *ResourceProfile) error {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.ResourceProfilesPrefix+r.TenantID()] = result
+	ms.setKey(utils.ResourceProfilesPrefix+r.TenantID(), result)
 	return nil
 }
 
@@ -1330,7 +1383,7 @@ func (ms *MapStorage) RemoveResourceProfile(tenant, id string, transactionID str
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.ResourceProfilesPrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return nil
 }
@@ -1367,7 +1420,7 @@ func (ms *MapStorage) SetResource(r *Resource) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.ResourcesPrefix+r.TenantID()] = result
+	ms.setKey(utils.ResourcesPrefix+r.TenantID(), result)
 	return
 }
 
@@ -1375,7 +1428,7 @@ func (ms *MapStorage) RemoveResource(tenant, id string, transactionID string) (e
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.ResourcesPrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1415,7 +1468,7 @@ func (ms *MapStorage) SetTiming(t *utils.TPTiming, transactionID string) error {
 		return err
 	}
 	key := utils.TimingsPrefix + t.ID
-	ms.dict[key] = result
+	ms.setKey(key, result)
 	return nil
 }
 
@@ -1423,7 +1476,7 @@ func (ms *MapStorage) RemoveTiming(id string, transactionID string) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.TimingsPrefix + id
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return nil
 }
@@ -1448,7 +1501,7 @@ func (ms *MapStorage) SetReqFilterIndexes(dbKey string, indexes map[string]map[s
 	if err != nil {
 		return err
 	}
-	ms.dict[dbKey] = result
+	ms.setKey(dbKey, result)
 	return
 }
 func (ms *MapStorage) MatchReqFilterIndex(dbKey, fldName, fldVal string) (itemIDs utils.StringMap, err error) {
@@ -1523,7 +1576,7 @@ func (ms *MapStorage) SetStatQueueProfile(sqp *StatQueueProfile) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.StatQueueProfilePrefix+utils.ConcatenatedKey(sqp.Tenant, sqp.ID)] = result
+	ms.setKey(utils.StatQueueProfilePrefix+utils.ConcatenatedKey(sqp.Tenant, sqp.ID), result)
 	return
 }
 
@@ -1532,7 +1585,7 @@ func (ms *MapStorage) RemStatQueueProfile(tenant, id, transactionID string) (err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.StatQueueProfilePrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1558,7 +1611,7 @@ func (ms *MapStorage) SetStoredStatQueue(sq *StoredStatQueue) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.StatQueuePrefix+sq.SqID()] = result
+	ms.setKey(utils.StatQueuePrefix+sq.SqID(), result)
 	return
 }
 
@@ -1566,7 +1619,7 @@ func (ms *MapStorage) SetStoredStatQueue(sq *StoredStatQueue) (err error) {
 func (ms *MapStorage) RemStoredStatQueue(tenant, id string) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.StatQueuePrefix+utils.ConcatenatedKey(tenant, id))
+	ms.removeKey(utils.StatQueuePrefix + utils.ConcatenatedKey(tenant, id))
 	return
 }
 
@@ -1610,7 +1663,7 @@ func (ms *MapStorage) SetThresholdProfile(tp *ThresholdProfile) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.ThresholdProfilePrefix+tp.TenantID()] = result
+	ms.setKey(utils.ThresholdProfilePrefix+tp.TenantID(), result)
 	return
 }
 
@@ -1619,7 +1672,7 @@ func (ms *MapStorage) RemThresholdProfile(tenant, id, transactionID string) (err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.ThresholdProfilePrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1641,7 +1694,7 @@ func (ms *MapStorage) GetThreshold(tenant, id string, skipCache bool, transactio
 		cache.Set(key, nil, cacheCommit(transactionID), transactionID)
 		return nil, utils.ErrNotFound
 	}
-	err = ms.ms.Unmarshal(values, r)
+	err = ms.ms.Unmarshal(values, &r)
 	if err != nil {
 		return nil, err
 	}
@@ -1656,7 +1709,7 @@ func (ms *MapStorage) SetThreshold(r *Threshold) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.ThresholdPrefix+utils.ConcatenatedKey(r.Tenant, r.ID)] = result
+	ms.setKey(utils.ThresholdPrefix+utils.ConcatenatedKey(r.Tenant, r.ID), result)
 	return
 }
 
@@ -1664,7 +1717,7 @@ func (ms *MapStorage) RemoveThreshold(tenant, id string, transactionID string) (
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.ThresholdPrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1701,7 +1754,7 @@ func (ms *MapStorage) SetFilter(r *Filter) (err error) {
 	if err != nil {
 		return err
 	}
-	ms.dict[utils.FilterPrefix+utils.ConcatenatedKey(r.Tenant, r.ID)] = result
+	ms.setKey(utils.FilterPrefix+utils.ConcatenatedKey(r.Tenant, r.ID), result)
 	return
 }
 
@@ -1709,7 +1762,7 @@ func (ms *MapStorage) RemoveFilter(tenant, id string, transactionID string) (err
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	key := utils.FilterPrefix + utils.ConcatenatedKey(tenant, id)
-	delete(ms.dict, key)
+	ms.removeKey(key)
 	cache.RemKey(key, cacheCommit(transactionID), transactionID)
 	return
 }
@@ -1731,44 +1784,145 @@ func (ms *MapStorage) GetVersions(itm string) (vrs Versions, err error) {
 func (ms *MapStorage) SetVersions(vrs Versions, overwrite bool) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	var result []byte
-	var x Versions
-	if !overwrite {
-		x, err = ms.GetVersions(utils.TBLVersions)
-		if err != nil {
-			return err
-		}
-		for key, _ := range vrs {
-			if x[key] != vrs[key] {
-				x[key] = vrs[key]
-			}
-		}
-		result, err = ms.ms.Marshal(x)
-		if err != nil {
-			return err
-		}
-		ms.dict[utils.TBLVersions] = result
-		return
-	} else {
-		result, err = ms.ms.Marshal(vrs)
-		if err != nil {
-			return err
-		}
-		if ms.RemoveVersions(vrs); err != nil {
-			return err
+	x := make(Versions)
+	if values, has := ms.dict[utils.TBLVersions]; has && !overwrite { // merge with the existing versions
+		if err = ms.ms.Unmarshal(values, &x); err != nil {
+			return
 		}
-		ms.dict[utils.TBLVersions] = result
+	}
+	for key, val := range vrs {
+		x[key] = val
+	}
+	result, err := ms.ms.Marshal(x)
+	if err != nil {
 		return
 	}
+	ms.setKey(utils.TBLVersions, result)
+	return
 }
 
 func (ms *MapStorage) RemoveVersions(vrs Versions) (err error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	delete(ms.dict, utils.TBLVersions)
+	ms.removeKey(utils.TBLVersions)
 	return
 }
 
 func (ms *MapStorage) GetStorageType() string {
 	return utils.MAPSTOR
 }
+
+func (ms *MapStorage) GetTierCounter(id string) (tc *TierCounter, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.TierCounterPrefix+id]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	err = ms.ms.Unmarshal(values, &tc)
+	return
+}
+
+func (ms *MapStorage) SetTierCounter(tc *TierCounter) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	result, err := ms.ms.Marshal(tc)
+	if err != nil {
+		return err
+	}
+	ms.setKey(utils.TierCounterPrefix+tc.ID, result)
+	return
+}
+
+// GetTierEventUsage reports the expired usages as not found, these staying in memory till set again or removed
+func (ms *MapStorage) GetTierEventUsage(id string) (teu *TierEventUsage, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.TierEventUsagePrefix+id]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	if err = ms.ms.Unmarshal(values, &teu); err != nil {
+		return nil, err
+	}
+	if !teu.ExpiryTime.After(time.Now()) {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+func (ms *MapStorage) SetTierEventUsage(teu *TierEventUsage) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	result, err := ms.ms.Marshal(teu)
+	if err != nil {
+		return err
+	}
+	ms.setKey(utils.TierEventUsagePrefix+teu.ID, result)
+	return
+}
+
+func (ms *MapStorage) RemoveTierEventUsage(id string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.removeKey(utils.TierEventUsagePrefix + id)
+	return
+}
+
+func (ms *MapStorage) GetExchangeRate(id string) (fx *ExchangeRate, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.ExchangeRatePrefix+id]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	err = ms.ms.Unmarshal(values, &fx)
+	return
+}
+
+func (ms *MapStorage) SetExchangeRate(fx *ExchangeRate) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	result, err := ms.ms.Marshal(fx)
+	if err != nil {
+		return err
+	}
+	ms.setKey(utils.ExchangeRatePrefix+fx.ID, result)
+	return
+}
+
+func (ms *MapStorage) RemoveExchangeRate(id string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.removeKey(utils.ExchangeRatePrefix + id)
+	return
+}
+
+func (ms *MapStorage) GetTaxRates(tenant string) (txrs TaxRates, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values, ok := ms.dict[utils.TaxRatesPrefix+tenant]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	err = ms.ms.Unmarshal(values, &txrs)
+	return
+}
+
+func (ms *MapStorage) SetTaxRates(tenant string, txrs TaxRates) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	result, err := ms.ms.Marshal(txrs)
+	if err != nil {
+		return err
+	}
+	ms.setKey(utils.TaxRatesPrefix+tenant, result)
+	return
+}
+
+func (ms *MapStorage) RemoveTaxRates(tenant string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.removeKey(utils.TaxRatesPrefix + tenant)
+	return
+}
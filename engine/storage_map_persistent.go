@@ -0,0 +1,401 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+const (
+	mapStorageOpSet byte = iota + 1
+	mapStorageOpRemove
+	mapStorageOpFlush
+)
+
+const (
+	mapStorageSnapshotFile = "snapshot.db"
+	mapStorageLogFile      = "changes.log"
+	mapStorageMaxFieldLen  = 1 << 30 // protects against allocating on corrupted lengths
+)
+
+var errMapStorageCorruptRecord = errors.New("CORRUPT_RECORD")
+
+// NewMapStoragePersistent returns a MapStorage which persists all its changes inside dirPath
+// Changes are appended to a log, compacted periodically into a snapshot and replayed at start
+// the log is synced to disk after every change for 0 syncInterval, at syncInterval if positive or left to the OS if negative
+func NewMapStoragePersistent(dirPath, marshaler string, snapshotInterval, syncInterval time.Duration) (ms *MapStorage, err error) {
+	var mrshler Marshaler
+	switch marshaler {
+	case utils.MSGPACK:
+		mrshler = NewCodecMsgpackMarshaler()
+	case utils.JSON:
+		mrshler = new(JSONBufMarshaler)
+	default:
+		return nil, fmt.Errorf("Unsupported marshaler: %v", marshaler)
+	}
+	if ms, err = NewMapStorage(); err != nil {
+		return
+	}
+	ms.ms = mrshler
+	if err = os.MkdirAll(dirPath, 0755); err != nil {
+		return nil, err
+	}
+	if ms.dbLog, err = openMapStorageLog(dirPath, ms.dict); err != nil {
+		return nil, err
+	}
+	ms.dbLog.syncInterval = syncInterval
+	if snapshotInterval > 0 {
+		go ms.snapshotLoop(snapshotInterval, ms.dbLog.stopLoops)
+	}
+	if syncInterval > 0 {
+		go ms.syncLoop(syncInterval, ms.dbLog.stopLoops)
+	}
+	return
+}
+
+// logWrite persists one change, ms.mu should be locked by the caller
+func (ms *MapStorage) logWrite(op byte, key string, value []byte) {
+	if ms.dbLog == nil {
+		return
+	}
+	if err := ms.dbLog.append(op, key, value); err != nil {
+		utils.Logger.Err(fmt.Sprintf("<%s> failed persisting key: %s, error: %s",
+			utils.MetaInternalPersistent, key, err.Error()))
+	}
+}
+
+// snapshotLoop compacts the changes log at interval, till stopChan is closed
+func (ms *MapStorage) snapshotLoop(interval time.Duration, stopChan chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(interval):
+			ms.mu.Lock()
+			err := ms.dbLog.snapshot(ms.dict)
+			ms.mu.Unlock()
+			if err != nil {
+				utils.Logger.Warning(fmt.Sprintf("<%s> failed creating snapshot, error: %s",
+					utils.MetaInternalPersistent, err.Error()))
+			}
+		}
+	}
+}
+
+// syncLoop syncs the changes log to disk at interval, till stopChan is closed
+func (ms *MapStorage) syncLoop(interval time.Duration, stopChan chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(interval):
+			ms.mu.Lock()
+			err := ms.dbLog.sync()
+			ms.mu.Unlock()
+			if err != nil {
+				utils.Logger.Warning(fmt.Sprintf("<%s> failed syncing changes log, error: %s",
+					utils.MetaInternalPersistent, err.Error()))
+			}
+		}
+	}
+}
+
+// openMapStorageLog loads the snapshot and replays the changes log from dirPath into dict
+func openMapStorageLog(dirPath string, dict storage) (dbLog *mapStorageLog, err error) {
+	if err = loadMapStorageSnapshot(path.Join(dirPath, mapStorageSnapshotFile), dict); err != nil {
+		return
+	}
+	logPath := path.Join(dirPath, mapStorageLogFile)
+	validLen, err := replayMapStorageLog(logPath, dict)
+	if err != nil {
+		return
+	}
+	if err = os.Truncate(logPath, validLen); err != nil && !os.IsNotExist(err) { // drop the torn records
+		return
+	}
+	dbLog = &mapStorageLog{dirPath: dirPath, stopLoops: make(chan struct{})}
+	if dbLog.logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+	if err = dbLog.snapshot(dict); err != nil { // start with a compacted log
+		dbLog.logFile.Close()
+		return nil, err
+	}
+	return
+}
+
+// loadMapStorageSnapshot reads a snapshot file into dict, a missing snapshot is not an error
+func loadMapStorageSnapshot(fPath string, dict storage) (err error) {
+	f, err := os.Open(fPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return
+	}
+	defer f.Close()
+	rr := newMapStorageRecordReader(f)
+	for {
+		op, key, value, err := rr.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("snapshot: %s, offset: %d, error: %s", fPath, rr.n, err.Error())
+		}
+		dict.apply(op, key, value)
+	}
+}
+
+// replayMapStorageLog applies the changes log over dict
+// returns the length of the valid log since a crash can leave the last record incomplete
+func replayMapStorageLog(fPath string, dict storage) (validLen int64, err error) {
+	f, err := os.Open(fPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return
+	}
+	defer f.Close()
+	rr := newMapStorageRecordReader(f)
+	for {
+		op, key, value, errRead := rr.next()
+		if errRead == io.EOF {
+			return
+		} else if errRead == io.ErrUnexpectedEOF || errRead == errMapStorageCorruptRecord {
+			utils.Logger.Warning(fmt.Sprintf("<%s> discarding changes log: %s after offset: %d, error: %s",
+				utils.MetaInternalPersistent, fPath, validLen, errRead.Error()))
+			return
+		} else if errRead != nil {
+			return validLen, errRead
+		}
+		dict.apply(op, key, value)
+		validLen = rr.n
+	}
+}
+
+// apply executes one logged change on storage
+func (s storage) apply(op byte, key string, value []byte) {
+	switch op {
+	case mapStorageOpSet:
+		s[key] = value
+	case mapStorageOpRemove:
+		delete(s, key)
+	case mapStorageOpFlush:
+		for k := range s {
+			delete(s, k)
+		}
+	}
+}
+
+// mapStorageLog persists the changes of a MapStorage on disk
+type mapStorageLog struct {
+	dirPath      string
+	logFile      *os.File
+	syncInterval time.Duration // 0 to sync every change, negative to leave syncing to the OS
+	unsynced     bool          // changes written since the last sync
+	stopLoops    chan struct{}
+}
+
+// append writes one change at the end of the log
+// the write is not buffered so the change survives a crash of the process,
+// surviving an OS crash or a power loss only once synced, immediately with 0 syncInterval
+func (dbLog *mapStorageLog) append(op byte, key string, value []byte) (err error) {
+	if dbLog.logFile == nil {
+		return errors.New("CLOSED")
+	}
+	if _, err = dbLog.logFile.Write(encodeMapStorageRecord(op, key, value)); err != nil {
+		return
+	}
+	dbLog.unsynced = true
+	if dbLog.syncInterval == 0 {
+		return dbLog.sync()
+	}
+	return
+}
+
+// sync flushes the changes written since the last sync to disk
+func (dbLog *mapStorageLog) sync() (err error) {
+	if dbLog.logFile == nil || !dbLog.unsynced {
+		return
+	}
+	if err = dbLog.logFile.Sync(); err != nil {
+		return
+	}
+	dbLog.unsynced = false
+	return
+}
+
+// snapshot writes dict into a new snapshot file and restarts the changes log
+// the snapshot is replaced atomically so a crash leaves either the old or the new one
+func (dbLog *mapStorageLog) snapshot(dict storage) (err error) {
+	if dbLog.logFile == nil {
+		return errors.New("CLOSED")
+	}
+	snapPath := path.Join(dbLog.dirPath, mapStorageSnapshotFile)
+	tmpPath := snapPath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return
+	}
+	w := bufio.NewWriter(f)
+	for key, value := range dict {
+		if _, err = w.Write(encodeMapStorageRecord(mapStorageOpSet, key, value)); err != nil {
+			f.Close()
+			return
+		}
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	if err = os.Rename(tmpPath, snapPath); err != nil {
+		return
+	}
+	// replaying the old log over the new snapshot gives the same data so a crash here is safe
+	if err = dbLog.logFile.Truncate(0); err != nil {
+		return
+	}
+	if err = dbLog.logFile.Sync(); err != nil {
+		return
+	}
+	dbLog.unsynced = false
+	return
+}
+
+// close stops the snapshots, compacting the changes one last time
+func (dbLog *mapStorageLog) close(dict storage) (err error) {
+	if dbLog.logFile == nil {
+		return
+	}
+	close(dbLog.stopLoops)
+	err = dbLog.snapshot(dict)
+	if errClose := dbLog.logFile.Close(); err == nil {
+		err = errClose
+	}
+	dbLog.logFile = nil
+	return
+}
+
+// encodeMapStorageRecord returns the on-disk format of one change:
+// op(1 byte) | key length(uvarint) | key | value length(uvarint) | value | CRC32 of previous fields(4 bytes)
+func encodeMapStorageRecord(op byte, key string, value []byte) []byte {
+	rec := make([]byte, 1, 1+2*binary.MaxVarintLen64+len(key)+len(value)+4)
+	rec[0] = op
+	lenBuf := make([]byte, binary.MaxVarintLen64)
+	rec = append(rec, lenBuf[:binary.PutUvarint(lenBuf, uint64(len(key)))]...)
+	rec = append(rec, key...)
+	rec = append(rec, lenBuf[:binary.PutUvarint(lenBuf, uint64(len(value)))]...)
+	rec = append(rec, value...)
+	crcBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(crcBuf, crc32.ChecksumIEEE(rec))
+	return append(rec, crcBuf...)
+}
+
+func newMapStorageRecordReader(r io.Reader) *mapStorageRecordReader {
+	return &mapStorageRecordReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+}
+
+// mapStorageRecordReader decodes the records written by encodeMapStorageRecord
+type mapStorageRecordReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64 // bytes consumed by the complete records
+}
+
+// ReadByte implements io.ByteReader, needed by binary.ReadUvarint
+func (rr *mapStorageRecordReader) ReadByte() (b byte, err error) {
+	if b, err = rr.r.ReadByte(); err != nil {
+		return
+	}
+	rr.crc.Write([]byte{b})
+	return
+}
+
+func (rr *mapStorageRecordReader) readField() (fld []byte, err error) {
+	fldLen, err := binary.ReadUvarint(rr)
+	if err != nil {
+		return
+	}
+	if fldLen > mapStorageMaxFieldLen {
+		return nil, errMapStorageCorruptRecord
+	}
+	fld = make([]byte, fldLen)
+	if _, err = io.ReadFull(rr.r, fld); err != nil {
+		return
+	}
+	rr.crc.Write(fld)
+	return
+}
+
+// next returns the following record, io.EOF if the reader ended at the record boundary
+func (rr *mapStorageRecordReader) next() (op byte, key string, value []byte, err error) {
+	rr.crc.Reset()
+	if op, err = rr.ReadByte(); err != nil {
+		return
+	}
+	if op < mapStorageOpSet || op > mapStorageOpFlush {
+		return 0, "", nil, errMapStorageCorruptRecord
+	}
+	defer func() {
+		if err == io.EOF { // record started but did not end
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	keyFld, err := rr.readField()
+	if err != nil {
+		return
+	}
+	if value, err = rr.readField(); err != nil {
+		return
+	}
+	crcBuf := make([]byte, 4)
+	if _, err = io.ReadFull(rr.r, crcBuf); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(crcBuf) != rr.crc.Sum32() {
+		return 0, "", nil, errMapStorageCorruptRecord
+	}
+	rr.n += int64(1+uvarintLen(uint64(len(keyFld)))+len(keyFld)+
+		uvarintLen(uint64(len(value)))+len(value)) + 4
+	return op, string(keyFld), value, nil
+}
+
+func uvarintLen(x uint64) int {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return binary.PutUvarint(buf, x)
+}
@@ -0,0 +1,146 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestMapStoragePersistentReplay(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "internal_persistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+	ms, err := NewMapStoragePersistent(dirPath, utils.MSGPACK, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acnt := &Account{ID: "cgrates.org:1001",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 10}}}}
+	if err := ms.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.SetAccount(&Account{ID: "cgrates.org:1002",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 5}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.RemoveAccount("cgrates.org:1002"); err != nil {
+		t.Fatal(err)
+	}
+	// reopen without closing, simulating a crash
+	ms2, err := NewMapStoragePersistent(dirPath, utils.MSGPACK, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcv, err := ms2.GetAccount("cgrates.org:1001"); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 10 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if _, err := ms2.GetAccount("cgrates.org:1002"); err != utils.ErrNotFound {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+	acnt.BalanceMap[utils.MONETARY][0].Value = 7
+	if err := ms2.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	ms2.Close()
+	// torn write at the end of the log should be discarded
+	f, err := os.OpenFile(path.Join(dirPath, mapStorageLogFile), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := encodeMapStorageRecord(mapStorageOpRemove, utils.ACCOUNT_PREFIX+"cgrates.org:1001", nil)
+	f.Write(rec[:len(rec)-2])
+	f.Close()
+	ms3, err := NewMapStoragePersistent(dirPath, utils.MSGPACK, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms3.Close()
+	if rcv, err := ms3.GetAccount("cgrates.org:1001"); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 7 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if err := ms3.Flush(""); err != nil {
+		t.Error(err)
+	}
+	if empty, err := ms3.IsDBEmpty(); err != nil {
+		t.Error(err)
+	} else if !empty {
+		t.Error("Expecting empty database after flush")
+	}
+}
+
+func TestMapStoragePersistentSyncInterval(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "internal_persistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+	ms, err := NewMapStoragePersistent(dirPath, utils.MSGPACK, 0, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+	if err := ms.SetAccount(&Account{ID: "cgrates.org:1001"}); err != nil {
+		t.Fatal(err)
+	}
+	ms.mu.RLock()
+	unsynced := ms.dbLog.unsynced
+	ms.mu.RUnlock()
+	if !unsynced {
+		t.Error("Expecting the change not synced yet")
+	}
+	time.Sleep(50 * time.Millisecond)
+	ms.mu.RLock()
+	unsynced = ms.dbLog.unsynced
+	ms.mu.RUnlock()
+	if unsynced {
+		t.Error("Expecting the change synced by the sync loop")
+	}
+}
+
+func TestMapStorageRecordReader(t *testing.T) {
+	rec := encodeMapStorageRecord(mapStorageOpSet, "key1", []byte("value1"))
+	rec[len(rec)-5] = 'X' // alter the value
+	f, err := ioutil.TempFile("", "changes_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(encodeMapStorageRecord(mapStorageOpSet, "key0", []byte("value0")))
+	f.Write(rec)
+	f.Close()
+	dict := make(storage)
+	if validLen, err := replayMapStorageLog(f.Name(), dict); err != nil {
+		t.Error(err)
+	} else if validLen != int64(len(rec)) { // key0 record has the same length
+		t.Errorf("Expecting: %d, received: %d", len(rec), validLen)
+	} else if len(dict) != 1 || string(dict["key0"]) != "value0" {
+		t.Errorf("Received: %+v", dict)
+	}
+}
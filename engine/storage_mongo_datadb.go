@@ -64,6 +64,11 @@ const (
 	colTps   = "threshold_profiles"
 	colThs   = "thresholds"
 	colFlt   = "filters"
+	colLck   = "locks"
+	colTcn   = "tier_counters"
+	colTeu   = "tier_event_usages"
+	colFxr   = "exchange_rates"
+	colTxr   = "tax_rates"
 )
 
 var (
@@ -168,13 +173,32 @@ func (ms *MongoStorage) EnsureIndexes() (err error) {
 		Sparse:     false,
 	}
 	if ms.storageType == utils.DataDB {
-		colectNames = []string{colRpf, colShg, colCrs, colAcc}
+		colectNames = []string{colRpf, colShg, colCrs, colAcc, colLck, colTcn, colTeu, colFxr}
 	}
 	for _, col := range colectNames {
 		if err = db.C(col).EnsureIndex(idx); err != nil {
 			return
 		}
 	}
+	if ms.storageType == utils.DataDB {
+		idx = mgo.Index{
+			Key:        []string{"tenant"},
+			Unique:     true,
+			DropDups:   false,
+			Background: false,
+			Sparse:     false,
+		}
+		if err = db.C(colTxr).EnsureIndex(idx); err != nil {
+			return
+		}
+		idx = mgo.Index{
+			Key:         []string{"expirytime"},
+			ExpireAfter: time.Second, // removed by mongo once expired
+		}
+		if err = db.C(colTeu).EnsureIndex(idx); err != nil {
+			return
+		}
+	}
 	if ms.storageType == utils.StorDB {
 		idx = mgo.Index{
 			Key:        []string{"tpid", "id"},
@@ -301,6 +325,16 @@ func (ms *MongoStorage) EnsureIndexes() (err error) {
 		if err = db.C(utils.TBLSMCosts).EnsureIndex(idx); err != nil {
 			return
 		}
+		idx = mgo.Index{
+			Key:        []string{"tenant", "id", "time"},
+			Unique:     false,
+			DropDups:   false,
+			Background: false,
+			Sparse:     false,
+		}
+		if err = db.C(utils.TBLStatMetrics).EnsureIndex(idx); err != nil {
+			return
+		}
 	}
 	return
 }
@@ -473,6 +507,9 @@ func (ms *MongoStorage) CacheDataFromDB(prfx string, ids []string, mustBeCached
 			utils.UnsupportedCachePrefix,
 			fmt.Sprintf("prefix <%s> is not a supported cache prefix", prfx))
 	}
+	if mustBeCached { // refreshing after changes in DataDB, the other engines should drop their copies
+		defer publishCacheRefresh(prfx, ids)
+	}
 	if ids == nil {
 		keyIDs, err := ms.GetKeysForPrefix(prfx)
 		if err != nil {
@@ -676,6 +713,11 @@ func (ms *MongoStorage) GetKeysForPrefix(prefix string) (result []string, err er
 		for iter.Next(&idResult) {
 			result = append(result, utils.StatQueueProfilePrefix+utils.ConcatenatedKey(idResult.Tenant, idResult.Id))
 		}
+	case utils.ThresholdProfilePrefix:
+		iter := db.C(colTps).Find(bson.M{"id": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"tenant": 1, "id": 1}).Iter()
+		for iter.Next(&idResult) {
+			result = append(result, utils.ThresholdProfilePrefix+utils.ConcatenatedKey(idResult.Tenant, idResult.Id))
+		}
 	case utils.AccountActionPlansPrefix:
 		iter := db.C(colAAp).Find(bson.M{"id": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"id": 1}).Iter()
 		for iter.Next(&idResult) {
@@ -703,6 +745,26 @@ func (ms *MongoStorage) GetKeysForPrefix(prefix string) (result []string, err er
 		for iter.Next(&idResult) {
 			result = append(result, utils.ThresholdPrefix+utils.ConcatenatedKey(idResult.Tenant, idResult.Id))
 		}
+	case utils.TierCounterPrefix:
+		iter := db.C(colTcn).Find(bson.M{"id": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"id": 1}).Iter()
+		for iter.Next(&idResult) {
+			result = append(result, utils.TierCounterPrefix+idResult.Id)
+		}
+	case utils.TierEventUsagePrefix:
+		iter := db.C(colTeu).Find(bson.M{"id": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"id": 1}).Iter()
+		for iter.Next(&idResult) {
+			result = append(result, utils.TierEventUsagePrefix+idResult.Id)
+		}
+	case utils.ExchangeRatePrefix:
+		iter := db.C(colFxr).Find(bson.M{"id": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"id": 1}).Iter()
+		for iter.Next(&idResult) {
+			result = append(result, utils.ExchangeRatePrefix+idResult.Id)
+		}
+	case utils.TaxRatesPrefix:
+		iter := db.C(colTxr).Find(bson.M{"tenant": bson.M{"$regex": bson.RegEx{Pattern: subject}}}).Select(bson.M{"tenant": 1}).Iter()
+		for iter.Next(&idResult) {
+			result = append(result, utils.TaxRatesPrefix+idResult.Tenant)
+		}
 	default:
 		err = fmt.Errorf("unsupported prefix in GetKeysForPrefix: %s", prefix)
 	}
@@ -1197,23 +1259,77 @@ func (ms *MongoStorage) GetAccount(key string) (result *Account, err error) {
 	return
 }
 
-func (ms *MongoStorage) SetAccount(acc *Account) error {
-	// never override existing account with an empty one
-	// UPDATE: if all balances expired and were cleaned it makes
-	// sense to write empty balance map
-	if len(acc.BalanceMap) == 0 {
-		if ac, err := ms.GetAccount(acc.ID); err == nil && !ac.allBalancesExpired() {
-			ac.ActionTriggers = acc.ActionTriggers
-			ac.UnitCounters = acc.UnitCounters
-			ac.AllowNegative = acc.AllowNegative
-			ac.Disabled = acc.Disabled
-			acc = ac
+// SetAccount stores the account only if its revision did not change since it was read,
+// the update being conditioned by the revision the account was read with
+func (ms *MongoStorage) SetAccount(acc *Account) (err error) {
+	if err = ms.checkLockFence(utils.ACCOUNT_PREFIX + acc.ID); err != nil {
+		return
+	}
+	stored, err := ms.GetAccount(acc.ID)
+	if err != nil && err != utils.ErrNotFound {
+		return
+	}
+	toStore, err := accountToStore(acc, stored)
+	if err != nil {
+		return
+	}
+	session, col := ms.conn(colAcc)
+	defer session.Close()
+	if acc.Revision == 0 {
+		_, err = col.Upsert(bson.M{"id": acc.ID}, toStore)
+	} else if err = col.Update(bson.M{"id": acc.ID, "revision": acc.Revision}, toStore); err == mgo.ErrNotFound {
+		err = utils.ErrRevisionConflict
+	}
+	if err != nil {
+		return
+	}
+	acc.Revision = toStore.Revision
+	return
+}
+
+// SetAccounts stores all the accounts only if none of their revisions changed since they were read,
+// restoring the ones already updated when a later one conflicts
+func (ms *MongoStorage) SetAccounts(accs []*Account) (err error) {
+	for _, acc := range accs { // lost locks known before writing anything
+		if err = ms.checkLockFence(utils.ACCOUNT_PREFIX + acc.ID); err != nil {
+			return
 		}
 	}
+	storedAccs := make([]*Account, 0, len(accs))
+	for _, acc := range accs {
+		stored, errGet := ms.GetAccount(acc.ID)
+		if errGet != nil && errGet != utils.ErrNotFound {
+			err = errGet
+			break
+		}
+		if err = ms.SetAccount(acc); err != nil {
+			break
+		}
+		storedAccs = append(storedAccs, stored)
+	}
+	if err == nil {
+		return
+	}
 	session, col := ms.conn(colAcc)
 	defer session.Close()
-	_, err := col.Upsert(bson.M{"id": acc.ID}, acc)
-	return err
+	for i, stored := range storedAccs { // undo in the limits of the revisions written here
+		var errUndo error
+		if stored == nil {
+			errUndo = col.Remove(bson.M{"id": accs[i].ID, "revision": accs[i].Revision})
+		} else {
+			errUndo = col.Update(bson.M{"id": accs[i].ID, "revision": accs[i].Revision}, stored)
+		}
+		if errUndo != nil {
+			utils.Logger.Warning(fmt.Sprintf("<MongoStorage> error: %s restoring account: %s", errUndo.Error(), accs[i].ID))
+			continue
+		}
+		if stored == nil {
+			accs[i].Revision = 0
+		} else {
+			accs[i].Revision = stored.Revision
+		}
+	}
+	return
 }
 
 func (ms *MongoStorage) RemoveAccount(key string) error {
@@ -2294,3 +2410,177 @@ func (ms *MongoStorage) RemoveFilter(tenant, id string, transactionID string) (e
 		cacheCommit(transactionID), transactionID)
 	return nil
 }
+
+// TryLockID implements guardian.LockBackend, the lock document being inserted or taken over once expired
+// the unique index on id makes the insert fail while the lock is held by other owner
+func (ms *MongoStorage) TryLockID(lockID string, ttl time.Duration) (token int64, acquired bool, err error) {
+	session, col := ms.conn(colLck)
+	defer session.Close()
+	var tkn struct{ Token int64 }
+	if _, err = col.Find(bson.M{"id": utils.LockFencingTokenKey}).Apply(
+		mgo.Change{Update: bson.M{"$inc": bson.M{"token": 1}}, Upsert: true, ReturnNew: true}, &tkn); err != nil {
+		return
+	}
+	now := time.Now()
+	if _, err = col.Upsert(bson.M{"id": utils.LockPrefix + lockID, "expiry": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"token": tkn.Token, "expiry": now.Add(ttl)}}); err != nil {
+		if mgo.IsDup(err) { // not expired yet
+			err = nil
+		}
+		return
+	}
+	return tkn.Token, true, nil
+}
+
+// RenewLockID implements guardian.LockBackend
+func (ms *MongoStorage) RenewLockID(lockID string, token int64, ttl time.Duration) (renewed bool, err error) {
+	session, col := ms.conn(colLck)
+	defer session.Close()
+	if err = col.Update(bson.M{"id": utils.LockPrefix + lockID, "token": token},
+		bson.M{"$set": bson.M{"expiry": time.Now().Add(ttl)}}); err == mgo.ErrNotFound { // taken over
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkLockFence fails with guardian.ErrLockLost if the backend lock held on lockID expired or was taken over,
+// rejecting the writes of an owner which lost its lock
+func (ms *MongoStorage) checkLockFence(lockID string) (err error) {
+	token, has := guardian.Guardian.FencingToken(lockID)
+	if !has {
+		return
+	}
+	session, col := ms.conn(colLck)
+	defer session.Close()
+	cnt, err := col.Find(bson.M{"id": utils.LockPrefix + lockID, "token": token, "expiry": bson.M{"$gt": time.Now()}}).Count()
+	if err != nil {
+		return
+	}
+	if cnt == 0 {
+		return guardian.ErrLockLost
+	}
+	return
+}
+
+// UnlockID implements guardian.LockBackend
+func (ms *MongoStorage) UnlockID(lockID string, token int64) (err error) {
+	session, col := ms.conn(colLck)
+	defer session.Close()
+	if err = col.Remove(bson.M{"id": utils.LockPrefix + lockID, "token": token}); err == mgo.ErrNotFound { // expired and taken over
+		err = nil
+	}
+	return
+}
+
+func (ms *MongoStorage) GetTierCounter(id string) (tc *TierCounter, err error) {
+	session, col := ms.conn(colTcn)
+	defer session.Close()
+	tc = new(TierCounter)
+	if err = col.Find(bson.M{"id": id}).One(tc); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+func (ms *MongoStorage) SetTierCounter(tc *TierCounter) (err error) {
+	session, col := ms.conn(colTcn)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": tc.ID}, tc)
+	return
+}
+
+func (ms *MongoStorage) GetTierEventUsage(id string) (teu *TierEventUsage, err error) {
+	session, col := ms.conn(colTeu)
+	defer session.Close()
+	teu = new(TierEventUsage)
+	if err = col.Find(bson.M{"id": id}).One(teu); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+func (ms *MongoStorage) SetTierEventUsage(teu *TierEventUsage) (err error) {
+	session, col := ms.conn(colTeu)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": teu.ID}, teu)
+	return
+}
+
+func (ms *MongoStorage) RemoveTierEventUsage(id string) (err error) {
+	session, col := ms.conn(colTeu)
+	defer session.Close()
+	if err = col.Remove(bson.M{"id": id}); err == mgo.ErrNotFound {
+		err = utils.ErrNotFound
+	}
+	return
+}
+
+func (ms *MongoStorage) GetExchangeRate(id string) (fx *ExchangeRate, err error) {
+	session, col := ms.conn(colFxr)
+	defer session.Close()
+	fx = new(ExchangeRate)
+	if err = col.Find(bson.M{"id": id}).One(fx); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+func (ms *MongoStorage) SetExchangeRate(fx *ExchangeRate) (err error) {
+	session, col := ms.conn(colFxr)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": fx.ID}, fx)
+	return
+}
+
+func (ms *MongoStorage) RemoveExchangeRate(id string) (err error) {
+	session, col := ms.conn(colFxr)
+	defer session.Close()
+	if err = col.Remove(bson.M{"id": id}); err == mgo.ErrNotFound {
+		err = utils.ErrNotFound
+	}
+	return
+}
+
+func (ms *MongoStorage) GetTaxRates(tenant string) (txrs TaxRates, err error) {
+	session, col := ms.conn(colTxr)
+	defer session.Close()
+	var result struct {
+		Tenant string
+		Rates  TaxRates
+	}
+	if err = col.Find(bson.M{"tenant": tenant}).One(&result); err != nil {
+		if err == mgo.ErrNotFound {
+			err = utils.ErrNotFound
+		}
+		return nil, err
+	}
+	return result.Rates, nil
+}
+
+func (ms *MongoStorage) SetTaxRates(tenant string, txrs TaxRates) (err error) {
+	session, col := ms.conn(colTxr)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"tenant": tenant}, &struct {
+		Tenant string
+		Rates  TaxRates
+	}{Tenant: tenant, Rates: txrs})
+	return
+}
+
+func (ms *MongoStorage) RemoveTaxRates(tenant string) (err error) {
+	session, col := ms.conn(colTxr)
+	defer session.Close()
+	if err = col.Remove(bson.M{"tenant": tenant}); err == mgo.ErrNotFound {
+		err = utils.ErrNotFound
+	}
+	return
+}
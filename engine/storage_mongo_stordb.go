@@ -23,11 +23,14 @@ import (
 	"github.com/cgrates/cgrates/utils"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	"io"
 	"regexp"
 	"strings"
 	"time"
 )
 
+const mongoArchiveBatchSize = 1000 // CDRs inserted at once into the archive
+
 func (ms *MongoStorage) GetTpIds() ([]string, error) {
 	tpidMap := make(map[string]bool)
 	session := ms.session.Copy()
@@ -530,6 +533,10 @@ func (ms *MongoStorage) RemTpData(table, tpid string, args map[string]string) er
 			delete(args, arg)
 			args["username"] = val
 		}
+		if arg == "from_currency" || arg == "to_currency" { // fix for TPExchangeRates
+			delete(args, arg)
+			args[strings.Replace(arg, "_", "", 1)] = val
+		}
 	}
 
 	if _, has := args["tag"]; has { // API uses tag to be compatible with SQL models, fix it here
@@ -934,6 +941,45 @@ func (ms *MongoStorage) GetSMCosts(cgrid, runid, originHost, originIDPrefix stri
 	return smcs, nil
 }
 
+func (ms *MongoStorage) SetStatQueueMetrics(sqm *StatQueueMetrics) error {
+	session, col := ms.conn(utils.TBLStatMetrics)
+	defer session.Close()
+	return col.Insert(sqm)
+}
+
+// GetStatQueueMetrics returns the metrics history of a StatQueue, ordered by time
+// from is inclusive, to exclusive, zero values are not limiting the query
+func (ms *MongoStorage) GetStatQueueMetrics(tenant, id string, from, to time.Time) (sqms []*StatQueueMetrics, err error) {
+	filter := bson.M{"tenant": tenant, "id": id}
+	timeFltr := bson.M{}
+	if !from.IsZero() {
+		timeFltr["$gte"] = from
+	}
+	if !to.IsZero() {
+		timeFltr["$lt"] = to
+	}
+	if len(timeFltr) != 0 {
+		filter["time"] = timeFltr
+	}
+	session, col := ms.conn(utils.TBLStatMetrics)
+	defer session.Close()
+	if err = col.Find(filter).Sort("time").All(&sqms); err != nil {
+		return nil, err
+	}
+	if len(sqms) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+// RemStatQueueMetrics removes the metrics history older than before
+func (ms *MongoStorage) RemStatQueueMetrics(before time.Time) (err error) {
+	session, col := ms.conn(utils.TBLStatMetrics)
+	defer session.Close()
+	_, err = col.RemoveAll(bson.M{"time": bson.M{"$lt": before}})
+	return
+}
+
 func (ms *MongoStorage) SetCDR(cdr *CDR, allowUpdate bool) (err error) {
 	if cdr.OrderID == 0 {
 		cdr.OrderID = ms.cnter.Next()
@@ -981,32 +1027,36 @@ func (ms *MongoStorage) cleanEmptyFilters(filters bson.M) {
 }
 
 //  _, err := col(utils.TBLCDRs).UpdateAll(bson.M{CGRIDLow: bson.M{"$in": cgrIds}}, bson.M{"$set": bson.M{"deleted_at": time.Now()}})
-func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+// cdrsFilter converts the CDRs filter into a mongo query
+func (ms *MongoStorage) cdrsFilter(qryFltr *utils.CDRsFilter) (bson.M, error) {
+	if err := checkCDRsOrder(qryFltr.OrderBy); err != nil {
+		return nil, err
+	}
 	var minPDD, maxPDD, minUsage, maxUsage *time.Duration
 	if len(qryFltr.MinPDD) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MinPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			minPDD = &parsed
 		}
 	}
 	if len(qryFltr.MaxPDD) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MaxPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			maxPDD = &parsed
 		}
 	}
 	if len(qryFltr.MinUsage) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MinUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			minUsage = &parsed
 		}
 	}
 	if len(qryFltr.MaxUsage) != 0 {
 		if parsed, err := utils.ParseDurationWithSecs(qryFltr.MaxUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			maxUsage = &parsed
 		}
@@ -1111,6 +1161,14 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	//file.WriteString(fmt.Sprintf("AFTER: %v\n", utils.ToIJSON(filters)))
 	//file.Close()
+	return filters, nil
+}
+
+func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+	filters, err := ms.cdrsFilter(qryFltr)
+	if err != nil {
+		return nil, 0, err
+	}
 	session, col := ms.conn(utils.TBLCDRs)
 	defer session.Close()
 	if remove {
@@ -1121,6 +1179,9 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 		}
 	}
 	q := col.Find(filters)
+	if qryFltr.OrderBy == utils.SETUP_TIME {
+		q = q.Sort(SetupTimeLow)
+	}
 	if qryFltr.Paginator.Limit != nil {
 		q = q.Limit(*qryFltr.Paginator.Limit)
 	}
@@ -1148,6 +1209,94 @@ func (ms *MongoStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	return cdrs, 0, nil
 }
 
+// ArchiveCDRs copies the CDRs matching the filter into the archive collection and removes them afterwards
+// the Paginator is ignored
+func (ms *MongoStorage) ArchiveCDRs(qryFltr *utils.CDRsFilter) (archived int64, err error) {
+	filters, err := ms.cdrsFilter(qryFltr)
+	if err != nil {
+		return 0, err
+	}
+	session, col := ms.conn(utils.TBLCDRs)
+	defer session.Close()
+	archCol := session.DB(ms.db).C(utils.TBLCDRsArchive)
+	iter := col.Find(filters).Iter()
+	var doc bson.M
+	var batch, batchIDs []interface{}
+	// moves the batch, removing out of cdrs only the archived documents so the ones matching later are kept
+	moveBatch := func() (err error) {
+		if err = archCol.Insert(batch...); err != nil {
+			return
+		}
+		if _, err = col.RemoveAll(bson.M{"_id": bson.M{"$in": batchIDs}}); err != nil {
+			return
+		}
+		archived += int64(len(batch))
+		batch, batchIDs = batch[:0], batchIDs[:0]
+		return
+	}
+	for iter.Next(&doc) {
+		batch = append(batch, doc)
+		batchIDs = append(batchIDs, doc["_id"])
+		doc = nil
+		if len(batch) == mongoArchiveBatchSize {
+			if err = moveBatch(); err != nil {
+				iter.Close()
+				return
+			}
+		}
+	}
+	if err = iter.Close(); err != nil {
+		return
+	}
+	if len(batch) != 0 {
+		err = moveBatch()
+	}
+	return
+}
+
+// GetCDRsIterator streams the CDRs matching the filter out of a mongo cursor
+func (ms *MongoStorage) GetCDRsIterator(qryFltr *utils.CDRsFilter) (CDRsIterator, error) {
+	filters, err := ms.cdrsFilter(qryFltr)
+	if err != nil {
+		return nil, err
+	}
+	session, col := ms.conn(utils.TBLCDRs)
+	q := col.Find(filters)
+	if qryFltr.OrderBy == utils.SETUP_TIME {
+		q = q.Sort(SetupTimeLow)
+	}
+	if qryFltr.Paginator.Limit != nil {
+		q = q.Limit(*qryFltr.Paginator.Limit)
+	}
+	if qryFltr.Paginator.Offset != nil {
+		q = q.Skip(*qryFltr.Paginator.Offset)
+	}
+	return &mongoCDRsIterator{session: session, iter: q.Iter()}, nil
+}
+
+// mongoCDRsIterator keeps the session open till the iterator is closed
+type mongoCDRsIterator struct {
+	session *mgo.Session
+	iter    *mgo.Iter
+}
+
+func (it *mongoCDRsIterator) Next() (*CDR, error) {
+	cdr := new(CDR)
+	if !it.iter.Next(cdr) {
+		if err := it.iter.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return cdr, nil
+}
+
+func (it *mongoCDRsIterator) Close() (err error) {
+	err = it.iter.Close()
+	it.session.Close()
+	return
+}
+
 func (ms *MongoStorage) GetTPStat(tpid, id string) ([]*utils.TPStats, error) {
 	filter := bson.M{
 		"tpid": tpid,
@@ -1241,6 +1390,71 @@ func (ms *MongoStorage) SetTPFilter(tpTHs []*utils.TPFilter) (err error) {
 	return
 }
 
+func (ms *MongoStorage) GetTPExchangeRates(tpid, fromCurrency, toCurrency string) ([]*utils.TPExchangeRate, error) {
+	filter := bson.M{
+		"tpid": tpid,
+	}
+	if fromCurrency != "" {
+		filter["fromcurrency"] = fromCurrency
+	}
+	if toCurrency != "" {
+		filter["tocurrency"] = toCurrency
+	}
+	var results []*utils.TPExchangeRate
+	session, col := ms.conn(utils.TBLTPExchangeRates)
+	defer session.Close()
+	err := col.Find(filter).All(&results)
+	if len(results) == 0 {
+		return results, utils.ErrNotFound
+	}
+	return results, err
+}
+
+func (ms *MongoStorage) SetTPExchangeRates(tpFxs []*utils.TPExchangeRate) (err error) {
+	if len(tpFxs) == 0 {
+		return
+	}
+	session, col := ms.conn(utils.TBLTPExchangeRates)
+	defer session.Close()
+	tx := col.Bulk()
+	for _, tp := range tpFxs {
+		tx.Upsert(bson.M{"tpid": tp.TPid, "fromcurrency": tp.FromCurrency, "tocurrency": tp.ToCurrency}, tp)
+	}
+	_, err = tx.Run()
+	return
+}
+
+func (ms *MongoStorage) GetTPTaxRates(tpid, id string) ([]*utils.TPTaxRate, error) {
+	filter := bson.M{
+		"tpid": tpid,
+	}
+	if id != "" {
+		filter["id"] = id
+	}
+	var results []*utils.TPTaxRate
+	session, col := ms.conn(utils.TBLTPTaxRates)
+	defer session.Close()
+	err := col.Find(filter).All(&results)
+	if len(results) == 0 {
+		return results, utils.ErrNotFound
+	}
+	return results, err
+}
+
+func (ms *MongoStorage) SetTPTaxRates(tpTxrs []*utils.TPTaxRate) (err error) {
+	if len(tpTxrs) == 0 {
+		return
+	}
+	session, col := ms.conn(utils.TBLTPTaxRates)
+	defer session.Close()
+	tx := col.Bulk()
+	for _, tp := range tpTxrs {
+		tx.Upsert(bson.M{"tpid": tp.TPid, "tenant": tp.Tenant, "id": tp.ID}, tp)
+	}
+	_, err = tx.Run()
+	return
+}
+
 func (ms *MongoStorage) GetVersions(itm string) (vrs Versions, err error) {
 	session, col := ms.conn(colVer)
 	defer session.Close()
@@ -89,3 +89,29 @@ func (self *MySQLStorage) notExtraFieldsValueQry(field, value string) string {
 func (self *MySQLStorage) GetStorageType() string {
 	return utils.MYSQL
 }
+
+// cdrsPartitions returns the partitions of the cdrs table out of information_schema
+func (self *MySQLStorage) cdrsPartitions() (partitions map[string]bool, err error) {
+	rows, err := self.Db.Query(`SELECT partition_name FROM information_schema.partitions
+		WHERE table_schema = DATABASE() AND table_name = ? AND partition_name IS NOT NULL`, utils.TBLCDRs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	partitions = make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		partitions[name] = true
+	}
+	return partitions, rows.Err()
+}
+
+// createCDRsPartition splits the new partition out of pmax, the catch-all partition of the cdrs table
+func (self *MySQLStorage) createCDRsPartition(name string, start, end time.Time) (err error) {
+	_, err = self.Db.Exec(fmt.Sprintf("ALTER TABLE %s REORGANIZE PARTITION pmax INTO (PARTITION %s VALUES LESS THAN ('%s'), PARTITION pmax VALUES LESS THAN (MAXVALUE))",
+		utils.TBLCDRs, name, end.Format("2006-01-02 15:04:05")))
+	return
+}
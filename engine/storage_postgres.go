@@ -20,6 +20,7 @@ package engine
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cgrates/cgrates/utils"
@@ -93,3 +94,47 @@ func (self *PostgresStorage) notExtraFieldsValueQry(field, value string) string
 func (self *PostgresStorage) GetStorageType() string {
 	return utils.POSTGRES
 }
+
+// cdrsPartitions returns the partitions attached to the cdrs table, without the table prefix
+func (self *PostgresStorage) cdrsPartitions() (partitions map[string]bool, err error) {
+	rows, err := self.Db.Query(`SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = $1`, utils.TBLCDRs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	partitions = make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		partitions[strings.TrimPrefix(name, utils.TBLCDRs+"_")] = true
+	}
+	return partitions, rows.Err()
+}
+
+// createCDRsPartition needs the cdrs table created with PARTITION BY RANGE (setup_time)
+// the CDRs of the period already in cdrs_default are moved into the new partition before attaching it
+func (self *PostgresStorage) createCDRsPartition(name string, start, end time.Time) (err error) {
+	tx, err := self.Db.Begin()
+	if err != nil {
+		return
+	}
+	partTbl := utils.TBLCDRs + "_" + name
+	from, to := start.Format(time.RFC3339), end.Format(time.RFC3339)
+	for _, qry := range []string{
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", partTbl, utils.TBLCDRs),
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s_default WHERE setup_time >= '%s' AND setup_time < '%s'",
+			partTbl, utils.TBLCDRs, from, to),
+		fmt.Sprintf("DELETE FROM %s_default WHERE setup_time >= '%s' AND setup_time < '%s'", utils.TBLCDRs, from, to),
+		fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')", utils.TBLCDRs, partTbl, from, to),
+	} {
+		if _, err = tx.Exec(qry); err != nil {
+			tx.Rollback()
+			return
+		}
+	}
+	return tx.Commit()
+}
@@ -21,16 +21,19 @@ package engine
 import (
 	"bytes"
 	"compress/zlib"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/cgrates/cgrates/cache"
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/guardian"
 	"github.com/cgrates/cgrates/utils"
 	"github.com/mediocregopher/radix.v2/pool"
+	"github.com/mediocregopher/radix.v2/pubsub"
 	"github.com/mediocregopher/radix.v2/redis"
 )
 
@@ -242,6 +245,9 @@ func (rs *RedisStorage) CacheDataFromDB(prfx string, ids []string, mustBeCached
 			utils.UnsupportedCachePrefix,
 			fmt.Sprintf("prefix <%s> is not a supported cache prefix", prfx))
 	}
+	if mustBeCached { // refreshing after changes in DataDB, the other engines should drop their copies
+		defer publishCacheRefresh(prfx, ids)
+	}
 	if ids == nil {
 		keyIDs, err := rs.GetKeysForPrefix(prfx)
 		if err != nil {
@@ -748,21 +754,95 @@ func (rs *RedisStorage) GetAccount(key string) (*Account, error) {
 	return ub, nil
 }
 
+// SetAccount stores the account only if its revision did not change since it was read,
+// WATCH making the write fail if the account is changed by other engine between the read and the write
 func (rs *RedisStorage) SetAccount(ub *Account) (err error) {
-	// never override existing account with an empty one
-	// UPDATE: if all balances expired and were cleaned it makes
-	// sense to write empty balance map
-	if len(ub.BalanceMap) == 0 {
-		if ac, err := rs.GetAccount(ub.ID); err == nil && !ac.allBalancesExpired() {
-			ac.ActionTriggers = ub.ActionTriggers
-			ac.UnitCounters = ub.UnitCounters
-			ac.AllowNegative = ub.AllowNegative
-			ac.Disabled = ub.Disabled
-			ub = ac
+	return rs.SetAccounts([]*Account{ub})
+}
+
+// SetAccounts stores all the accounts in one transaction, aborted if any of them
+// was changed since read, either by revision or by other engine after the WATCH
+// the transaction is fenced by the tokens of the backend locks held on the accounts
+func (rs *RedisStorage) SetAccounts(accs []*Account) (err error) {
+	if len(accs) == 0 {
+		return
+	}
+	conn, err := rs.dbPool.Get()
+	if err != nil {
+		return
+	}
+	defer rs.dbPool.Put(conn)
+	keys := make([]string, len(accs))
+	watchKeys := make([]string, 0, len(accs))
+	fences := make(map[string]int64)
+	for i, acc := range accs {
+		keys[i] = utils.ACCOUNT_PREFIX + acc.ID
+		watchKeys = append(watchKeys, keys[i])
+		if token, has := guardian.Guardian.FencingToken(keys[i]); has {
+			fences[utils.LockPrefix+keys[i]] = token
+			watchKeys = append(watchKeys, utils.LockPrefix+keys[i])
 		}
 	}
-	result, err := rs.ms.Marshal(ub)
-	err = rs.Cmd("SET", utils.ACCOUNT_PREFIX+ub.ID, result).Err
+	if err = conn.Cmd("WATCH", watchKeys).Err; err != nil {
+		return
+	}
+	for lockKey, token := range fences {
+		rpl := conn.Cmd("GET", lockKey)
+		if rpl.Err != nil {
+			conn.Cmd("UNWATCH")
+			return rpl.Err
+		}
+		if owner, errOwner := rpl.Int64(); errOwner != nil || owner != token { // expired or taken over
+			conn.Cmd("UNWATCH")
+			return guardian.ErrLockLost
+		}
+	}
+	toStore := make([]*Account, len(accs))
+	results := make([][]byte, len(accs))
+	for i, acc := range accs {
+		var stored *Account
+		rpl := conn.Cmd("GET", keys[i])
+		if rpl.Err != nil {
+			conn.Cmd("UNWATCH")
+			return rpl.Err
+		} else if !rpl.IsType(redis.Nil) {
+			values, err := rpl.Bytes()
+			if err != nil {
+				conn.Cmd("UNWATCH")
+				return err
+			}
+			stored = &Account{ID: acc.ID}
+			if err = rs.ms.Unmarshal(values, stored); err != nil {
+				conn.Cmd("UNWATCH")
+				return err
+			}
+		}
+		if toStore[i], err = accountToStore(acc, stored); err != nil {
+			conn.Cmd("UNWATCH")
+			return
+		}
+		if results[i], err = rs.ms.Marshal(toStore[i]); err != nil {
+			conn.Cmd("UNWATCH")
+			return
+		}
+	}
+	if err = conn.Cmd("MULTI").Err; err != nil {
+		return
+	}
+	for i := range accs {
+		if err = conn.Cmd("SET", keys[i], results[i]).Err; err != nil {
+			conn.Cmd("DISCARD")
+			return
+		}
+	}
+	if rpl := conn.Cmd("EXEC"); rpl.Err != nil {
+		return rpl.Err
+	} else if rpl.IsType(redis.Nil) { // aborted, one of the keys was modified after WATCH
+		return utils.ErrRevisionConflict
+	}
+	for i, acc := range accs {
+		acc.Revision = toStore[i].Revision
+	}
 	return
 }
 
@@ -1821,3 +1901,185 @@ func (rs *RedisStorage) RemoveFilter(tenant, id string, transactionID string) (e
 func (rs *RedisStorage) GetStorageType() string {
 	return utils.REDIS
 }
+
+// redisUnlockScript removes the lock only if still owned by the token, atomically
+const redisUnlockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// TryLockID implements guardian.LockBackend with SET NX PX, the fencing tokens being generated with INCR
+func (rs *RedisStorage) TryLockID(lockID string, ttl time.Duration) (token int64, acquired bool, err error) {
+	if token, err = rs.Cmd("INCR", utils.LockFencingTokenKey).Int64(); err != nil {
+		return
+	}
+	rpl := rs.Cmd("SET", utils.LockPrefix+lockID, token, "NX", "PX", int64(ttl/time.Millisecond))
+	if rpl.Err != nil {
+		return 0, false, rpl.Err
+	}
+	return token, !rpl.IsType(redis.Nil), nil
+}
+
+// redisRenewLockScript extends the lock only if still owned by the token, atomically
+const redisRenewLockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+
+// RenewLockID implements guardian.LockBackend
+func (rs *RedisStorage) RenewLockID(lockID string, token int64, ttl time.Duration) (renewed bool, err error) {
+	renewedInt, err := rs.Cmd("EVAL", redisRenewLockScript, 1, utils.LockPrefix+lockID, token, int64(ttl/time.Millisecond)).Int()
+	return renewedInt == 1, err
+}
+
+// UnlockID implements guardian.LockBackend
+func (rs *RedisStorage) UnlockID(lockID string, token int64) error {
+	return rs.Cmd("EVAL", redisUnlockScript, 1, utils.LockPrefix+lockID, token).Err
+}
+
+// PublishInvalidation implements cache.InvalidationPublisher over redis pub/sub
+func (rs *RedisStorage) PublishInvalidation(inv *cache.Invalidation) (err error) {
+	msg, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	return rs.Cmd("PUBLISH", utils.CacheInvalidationChannel, msg).Err
+}
+
+// ListenInvalidations applies the invalidations published by the other engines, till exitChan is written
+// the subscribed connection is dedicated, never returning to the pool
+func (rs *RedisStorage) ListenInvalidations(exitChan chan bool) (err error) {
+	conn, err := rs.dbPool.Get()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	subClnt := pubsub.NewSubClient(conn)
+	if sr := subClnt.Subscribe(utils.CacheInvalidationChannel); sr.Err != nil {
+		return sr.Err
+	}
+	exiting := make(chan struct{})
+	listenDone := make(chan struct{})
+	defer close(listenDone)
+	go func() {
+		select {
+		case e := <-exitChan:
+			exitChan <- e // put back for the others listening for shutdown request
+			close(exiting)
+			conn.Close() // unblocks Receive
+		case <-listenDone: // connection error, the caller listening again on a new one
+		}
+	}()
+	for {
+		sr := subClnt.Receive()
+		if sr.Err != nil {
+			select {
+			case <-exiting:
+				return nil
+			default:
+				return sr.Err
+			}
+		}
+		if sr.Type != pubsub.Message {
+			continue
+		}
+		var inv cache.Invalidation
+		if err := json.Unmarshal([]byte(sr.Message), &inv); err != nil {
+			utils.Logger.Warning(fmt.Sprintf("<%s> error: %s decoding invalidation: %s",
+				utils.CacheInvalidation, err.Error(), sr.Message))
+			continue
+		}
+		cache.ApplyInvalidation(&inv)
+	}
+}
+
+func (rs *RedisStorage) GetTierCounter(id string) (tc *TierCounter, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.TierCounterPrefix+id).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &tc)
+	return
+}
+
+func (rs *RedisStorage) SetTierCounter(tc *TierCounter) (err error) {
+	result, err := rs.ms.Marshal(tc)
+	if err != nil {
+		return err
+	}
+	return rs.Cmd("SET", utils.TierCounterPrefix+tc.ID, result).Err
+}
+
+func (rs *RedisStorage) GetTierEventUsage(id string) (teu *TierEventUsage, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.TierEventUsagePrefix+id).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &teu)
+	return
+}
+
+// SetTierEventUsage lets Redis remove the usage at its ExpiryTime
+func (rs *RedisStorage) SetTierEventUsage(teu *TierEventUsage) (err error) {
+	ttl := teu.ExpiryTime.Sub(time.Now())
+	if ttl <= 0 {
+		return rs.RemoveTierEventUsage(teu.ID)
+	}
+	result, err := rs.ms.Marshal(teu)
+	if err != nil {
+		return err
+	}
+	return rs.Cmd("SET", utils.TierEventUsagePrefix+teu.ID, result, "PX", int64(ttl/time.Millisecond)).Err
+}
+
+func (rs *RedisStorage) RemoveTierEventUsage(id string) (err error) {
+	return rs.Cmd("DEL", utils.TierEventUsagePrefix+id).Err
+}
+
+func (rs *RedisStorage) GetExchangeRate(id string) (fx *ExchangeRate, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.ExchangeRatePrefix+id).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &fx)
+	return
+}
+
+func (rs *RedisStorage) SetExchangeRate(fx *ExchangeRate) (err error) {
+	result, err := rs.ms.Marshal(fx)
+	if err != nil {
+		return err
+	}
+	return rs.Cmd("SET", utils.ExchangeRatePrefix+fx.ID, result).Err
+}
+
+func (rs *RedisStorage) RemoveExchangeRate(id string) (err error) {
+	return rs.Cmd("DEL", utils.ExchangeRatePrefix+id).Err
+}
+
+func (rs *RedisStorage) GetTaxRates(tenant string) (txrs TaxRates, err error) {
+	var values []byte
+	if values, err = rs.Cmd("GET", utils.TaxRatesPrefix+tenant).Bytes(); err != nil {
+		if err == redis.ErrRespNil {
+			err = utils.ErrNotFound
+		}
+		return
+	}
+	err = rs.ms.Unmarshal(values, &txrs)
+	return
+}
+
+func (rs *RedisStorage) SetTaxRates(tenant string, txrs TaxRates) (err error) {
+	result, err := rs.ms.Marshal(txrs)
+	if err != nil {
+		return err
+	}
+	return rs.Cmd("SET", utils.TaxRatesPrefix+tenant, result).Err
+}
+
+func (rs *RedisStorage) RemoveTaxRates(tenant string) (err error) {
+	return rs.Cmd("DEL", utils.TaxRatesPrefix+tenant).Err
+}
@@ -23,7 +23,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"path"
 	"strings"
 	"time"
@@ -118,7 +120,7 @@ func (self *SQLStorage) IsDBEmpty() (resp bool, err error) {
 // Return a list with all TPids defined in the system, even if incomplete, isolated in some table.
 func (self *SQLStorage) GetTpIds() ([]string, error) {
 	rows, err := self.Db.Query(
-		fmt.Sprintf("(SELECT tpid FROM %s) UNION (SELECT tpid FROM %s) UNION (SELECT tpid FROM %s) UNION (SELECT tpid FROM %s) UNION (SELECT tpid FROM %s) UNION (SELECT tpid FROM %s)",
+		fmt.Sprintf("SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s", // no parenthesis, SQLite does not support them in compound selects
 			utils.TBLTPTimings,
 			utils.TBLTPDestinations,
 			utils.TBLTPRates,
@@ -209,7 +211,7 @@ func (self *SQLStorage) RemTpData(table, tpid string, args map[string]string) er
 	if len(table) == 0 { // Remove tpid out of all tables
 		for _, tblName := range []string{utils.TBLTPTimings, utils.TBLTPDestinations, utils.TBLTPRates, utils.TBLTPDestinationRates, utils.TBLTPRatingPlans, utils.TBLTPRateProfiles,
 			utils.TBLTPSharedGroups, utils.TBLTPCdrStats, utils.TBLTPLcrs, utils.TBLTPActions, utils.TBLTPActionPlans, utils.TBLTPActionTriggers, utils.TBLTPAccountActions,
-			utils.TBLTPDerivedChargers, utils.TBLTPAliases, utils.TBLTPUsers, utils.TBLTPResources, utils.TBLTPStats, utils.TBLTPFilters} {
+			utils.TBLTPDerivedChargers, utils.TBLTPAliases, utils.TBLTPUsers, utils.TBLTPResources, utils.TBLTPStats, utils.TBLTPFilters, utils.TBLTPExchangeRates, utils.TBLTPTaxRates} {
 			if err := tx.Table(tblName).Where("tpid = ?", tpid).Delete(nil).Error; err != nil {
 				tx.Rollback()
 				return err
@@ -664,6 +666,49 @@ func (self *SQLStorage) SetTPFilter(ths []*utils.TPFilter) error {
 	return nil
 }
 
+func (self *SQLStorage) SetTPExchangeRates(fxs []*utils.TPExchangeRate) error {
+	if len(fxs) == 0 {
+		return nil
+	}
+	tx := self.db.Begin()
+	for _, fx := range fxs {
+		// Remove previous
+		if err := tx.Where(&TpExchangeRate{Tpid: fx.TPid, FromCurrency: fx.FromCurrency, ToCurrency: fx.ToCurrency}).Delete(TpExchangeRate{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, mst := range APItoModelTPExchangeRate(fx) {
+			if err := tx.Save(&mst).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+func (self *SQLStorage) SetTPTaxRates(txrs []*utils.TPTaxRate) error {
+	if len(txrs) == 0 {
+		return nil
+	}
+	tx := self.db.Begin()
+	for _, txr := range txrs {
+		// Remove previous
+		if err := tx.Where(&TpTaxRate{Tpid: txr.TPid, Tenant: txr.Tenant, ID: txr.ID}).Delete(TpTaxRate{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		mdl := APItoModelTPTaxRate(txr)
+		if err := tx.Save(mdl).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
 func (self *SQLStorage) SetSMCost(smc *SMCost) error {
 	if smc.CostDetails == nil {
 		return nil
@@ -748,6 +793,58 @@ func (self *SQLStorage) GetSMCosts(cgrid, runid, originHost, originIDPrefix stri
 	return smCosts, nil
 }
 
+func (self *SQLStorage) SetStatQueueMetrics(sqm *StatQueueMetrics) error {
+	metrics, err := json.Marshal(sqm.Metrics)
+	if err != nil {
+		return err
+	}
+	tx := self.db.Begin()
+	if err := tx.Save(&TBLStatMetrics{
+		Tenant:       sqm.Tenant,
+		QueueID:      sqm.ID,
+		Metrics:      string(metrics),
+		SnapshotTime: sqm.Time,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// GetStatQueueMetrics returns the metrics history of a StatQueue, ordered by time
+// from is inclusive, to exclusive, zero values are not limiting the query
+func (self *SQLStorage) GetStatQueueMetrics(tenant, id string, from, to time.Time) (sqms []*StatQueueMetrics, err error) {
+	q := self.db.Where(&TBLStatMetrics{Tenant: tenant, QueueID: id})
+	if !from.IsZero() {
+		q = q.Where("snapshot_time >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("snapshot_time < ?", to)
+	}
+	results := make([]*TBLStatMetrics, 0)
+	if err = q.Order("snapshot_time").Find(&results).Error; err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		sqm := &StatQueueMetrics{Tenant: result.Tenant, ID: result.QueueID,
+			Time: result.SnapshotTime}
+		if err = json.Unmarshal([]byte(result.Metrics), &sqm.Metrics); err != nil {
+			return nil, err
+		}
+		sqms = append(sqms, sqm)
+	}
+	if len(sqms) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return
+}
+
+// RemStatQueueMetrics removes the metrics history older than before
+func (self *SQLStorage) RemStatQueueMetrics(before time.Time) error {
+	return self.db.Where("snapshot_time < ?", before).Delete(TBLStatMetrics{}).Error
+}
+
 func (self *SQLStorage) LogActionTrigger(ubId, source string, at *ActionTrigger, as Actions) (err error) {
 	return
 }
@@ -834,8 +931,17 @@ func (self *SQLStorage) SetCDR(cdr *CDR, allowUpdate bool) error {
 
 // GetCDRs has ability to remove the selected CDRs, count them or simply return them
 // qryFltr.Unscoped will ignore soft deletes or delete records permanently
-func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
-	var cdrs []*CDR
+// cdrsQuery builds the SQL query matching the CDRs filter
+// sqlCDRsOrder are the columns of the fields the CDRs can be sorted on
+// the order is applied only when reading the CDRs, counting or removing them with it being rejected by some databases
+var sqlCDRsOrder = map[string]string{
+	utils.SETUP_TIME: "setup_time",
+}
+
+func (self *SQLStorage) cdrsQuery(qryFltr *utils.CDRsFilter) (*gorm.DB, error) {
+	if err := checkCDRsOrder(qryFltr.OrderBy); err != nil {
+		return nil, err
+	}
 	q := self.db.Table(utils.TBLCDRs).Select("*")
 	if qryFltr.Unscoped {
 		q = q.Unscoped()
@@ -1013,7 +1119,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MinUsage) != 0 {
 		if minUsage, err := utils.ParseDurationWithSecs(qryFltr.MinUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			if self.db.Dialect().GetName() == utils.MYSQL { // MySQL needs escaping for usage
 				q = q.Where("`usage` >= ?", minUsage.Seconds())
@@ -1024,7 +1130,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MaxUsage) != 0 {
 		if maxUsage, err := utils.ParseDurationWithSecs(qryFltr.MaxUsage); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			if self.db.Dialect().GetName() == utils.MYSQL { // MySQL needs escaping for usage
 				q = q.Where("`usage` < ?", maxUsage.Seconds())
@@ -1036,7 +1142,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MinPDD) != 0 {
 		if minPDD, err := utils.ParseDurationWithSecs(qryFltr.MinPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			q = q.Where("pdd >= ?", minPDD.Seconds())
 		}
@@ -1044,7 +1150,7 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	}
 	if len(qryFltr.MaxPDD) != 0 {
 		if maxPDD, err := utils.ParseDurationWithSecs(qryFltr.MaxPDD); err != nil {
-			return nil, 0, err
+			return nil, err
 		} else {
 			q = q.Where("pdd < ?", maxPDD.Seconds())
 		}
@@ -1070,13 +1176,77 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 		q = q.Limit(*qryFltr.Paginator.Limit)
 	}
 	if qryFltr.Paginator.Offset != nil {
+		if qryFltr.Paginator.Limit == nil && self.db.Dialect().GetName() == sqliteDialect { // SQLite accepts OFFSET only together with LIMIT
+			q = q.Limit(math.MaxInt64)
+		}
 		q = q.Offset(*qryFltr.Paginator.Offset)
 	}
+	return q, nil
+}
+
+// newCDRFromTBLCDRs converts one row of the CDRs table into CDR
+func newCDRFromTBLCDRs(result *TBLCDRs) (*CDR, error) {
+	extraFieldsMp := make(map[string]string)
+	if result.ExtraFields != "" {
+		if err := json.Unmarshal([]byte(result.ExtraFields), &extraFieldsMp); err != nil {
+			return nil, fmt.Errorf("JSON unmarshal error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
+		}
+	}
+	var callCost CallCost
+	if result.CostDetails != "" {
+		if err := json.Unmarshal([]byte(result.CostDetails), &callCost); err != nil {
+			return nil, fmt.Errorf("JSON unmarshal callcost error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
+		}
+	}
+	acntSummary, err := NewAccountSummaryFromJSON(result.AccountSummary)
+	if err != nil {
+		return nil, fmt.Errorf("JSON unmarshal account summary error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
+	}
+	usageDur := time.Duration(result.Usage * utils.NANO_MULTIPLIER)
+	pddDur := time.Duration(result.Pdd * utils.NANO_MULTIPLIER)
+	return &CDR{
+		CGRID:           result.Cgrid,
+		RunID:           result.RunID,
+		OrderID:         result.ID,
+		OriginHost:      result.OriginHost,
+		Source:          result.Source,
+		OriginID:        result.OriginID,
+		ToR:             result.Tor,
+		RequestType:     result.RequestType,
+		Direction:       result.Direction,
+		Tenant:          result.Tenant,
+		Category:        result.Category,
+		Account:         result.Account,
+		Subject:         result.Subject,
+		Destination:     result.Destination,
+		SetupTime:       result.SetupTime,
+		PDD:             pddDur,
+		AnswerTime:      result.AnswerTime,
+		Usage:           usageDur,
+		Supplier:        result.Supplier,
+		DisconnectCause: result.DisconnectCause,
+		ExtraFields:     extraFieldsMp,
+		CostSource:      result.CostSource,
+		Cost:            result.Cost,
+		CostDetails:     &callCost,
+		AccountSummary:  acntSummary,
+		ExtraInfo:       result.ExtraInfo,
+	}, nil
+}
+
+func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR, int64, error) {
+	var cdrs []*CDR
+	q, err := self.cdrsQuery(qryFltr)
+	if err != nil {
+		return nil, 0, err
+	}
 	if remove { // Remove CDRs instead of querying them
-		if err := q.Delete(nil).Error; err != nil {
+		res := q.Delete(nil)
+		if err := res.Error; err != nil {
 			q.Rollback()
 			return nil, 0, err
 		}
+		return nil, res.RowsAffected, nil
 	}
 	if qryFltr.Count { // Count CDRs
 		var cnt int64
@@ -1086,57 +1256,18 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 		}
 		return nil, cnt, nil
 	}
+	if qryFltr.OrderBy != "" {
+		q = q.Order(sqlCDRsOrder[qryFltr.OrderBy])
+	}
 	// Execute query
 	results := make([]*TBLCDRs, 0)
 	if err := q.Find(&results).Error; err != nil {
 		return nil, 0, err
 	}
 	for _, result := range results {
-		extraFieldsMp := make(map[string]string)
-		if result.ExtraFields != "" {
-			if err := json.Unmarshal([]byte(result.ExtraFields), &extraFieldsMp); err != nil {
-				return nil, 0, fmt.Errorf("JSON unmarshal error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
-			}
-		}
-		var callCost CallCost
-		if result.CostDetails != "" {
-			if err := json.Unmarshal([]byte(result.CostDetails), &callCost); err != nil {
-				return nil, 0, fmt.Errorf("JSON unmarshal callcost error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
-			}
-		}
-		acntSummary, err := NewAccountSummaryFromJSON(result.AccountSummary)
+		storCdr, err := newCDRFromTBLCDRs(result)
 		if err != nil {
-			return nil, 0, fmt.Errorf("JSON unmarshal account summary error for cgrid: %s, runid: %v, error: %s", result.Cgrid, result.RunID, err.Error())
-		}
-		usageDur := time.Duration(result.Usage * utils.NANO_MULTIPLIER)
-		pddDur := time.Duration(result.Pdd * utils.NANO_MULTIPLIER)
-		storCdr := &CDR{
-			CGRID:           result.Cgrid,
-			RunID:           result.RunID,
-			OrderID:         result.ID,
-			OriginHost:      result.OriginHost,
-			Source:          result.Source,
-			OriginID:        result.OriginID,
-			ToR:             result.Tor,
-			RequestType:     result.RequestType,
-			Direction:       result.Direction,
-			Tenant:          result.Tenant,
-			Category:        result.Category,
-			Account:         result.Account,
-			Subject:         result.Subject,
-			Destination:     result.Destination,
-			SetupTime:       result.SetupTime,
-			PDD:             pddDur,
-			AnswerTime:      result.AnswerTime,
-			Usage:           usageDur,
-			Supplier:        result.Supplier,
-			DisconnectCause: result.DisconnectCause,
-			ExtraFields:     extraFieldsMp,
-			CostSource:      result.CostSource,
-			Cost:            result.Cost,
-			CostDetails:     &callCost,
-			AccountSummary:  acntSummary,
-			ExtraInfo:       result.ExtraInfo,
+			return nil, 0, err
 		}
 		cdrs = append(cdrs, storCdr)
 	}
@@ -1146,6 +1277,137 @@ func (self *SQLStorage) GetCDRs(qryFltr *utils.CDRsFilter, remove bool) ([]*CDR,
 	return cdrs, 0, nil
 }
 
+// ArchiveCDRs moves the CDRs matching the filter into the archive table, the Paginator is ignored
+func (self *SQLStorage) ArchiveCDRs(qryFltr *utils.CDRsFilter) (archived int64, err error) {
+	fltr := *qryFltr
+	fltr.Paginator = utils.Paginator{}
+	q, err := self.cdrsQuery(&fltr)
+	if err != nil {
+		return 0, err
+	}
+	scope := q.NewScope(nil)
+	cond := scope.CombinedConditionSql() // same conditions for both copy and delete
+	tx, err := self.Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s %s",
+		utils.TBLCDRsArchive, utils.TBLCDRs, cond), scope.SQLVars...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if archived, err = res.RowsAffected(); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if _, err = tx.Exec(fmt.Sprintf("DELETE FROM %s %s", utils.TBLCDRs, cond), scope.SQLVars...); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return archived, tx.Commit()
+}
+
+// sqlCDRsPartitioner is implemented by the SQL dialects able to partition the cdrs table
+type sqlCDRsPartitioner interface {
+	cdrsPartitions() (map[string]bool, error)
+	createCDRsPartition(name string, start, end time.Time) error
+}
+
+// CreateCDRsPartitions implements CDRsPartitioner, returns utils.ErrNotImplemented for dialects without partitions
+// a failed partition does not stop the creation of the following ones, the failures are returned together
+func (self *SQLStorage) CreateCDRsPartitions(interval string, now time.Time, premake int) (created []string, err error) {
+	partitioner, canPartition := self.SQLImpl.(sqlCDRsPartitioner)
+	if !canPartition {
+		return nil, utils.ErrNotImplemented
+	}
+	existing, err := partitioner.cdrsPartitions()
+	if err != nil {
+		return nil, err
+	}
+	var failed []string
+	start := cdrsPartitionStart(interval, now.UTC())
+	for i := 0; i <= premake; i++ {
+		end := cdrsPartitionEnd(interval, start)
+		name := cdrsPartitionName(interval, start)
+		if !existing[name] {
+			if errCreate := partitioner.createCDRsPartition(name, start, end); errCreate != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", name, errCreate.Error()))
+			} else {
+				created = append(created, name)
+			}
+		}
+		start = end
+	}
+	if len(failed) != 0 {
+		err = fmt.Errorf("failed creating partitions: %s", strings.Join(failed, "; "))
+	}
+	return
+}
+
+// cdrsPartitionStart returns the beginning of the partition containing t
+func cdrsPartitionStart(interval string, t time.Time) time.Time {
+	if interval == utils.MetaDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func cdrsPartitionEnd(interval string, start time.Time) time.Time {
+	if interval == utils.MetaDaily {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// cdrsPartitionName names the partitions after their start: p201712 for *monthly and p20171201 for *daily ones
+func cdrsPartitionName(interval string, start time.Time) string {
+	if interval == utils.MetaDaily {
+		return "p" + start.Format("20060102")
+	}
+	return "p" + start.Format("200601")
+}
+
+// GetCDRsIterator streams the CDRs matching the filter out of an open database cursor
+func (self *SQLStorage) GetCDRsIterator(qryFltr *utils.CDRsFilter) (CDRsIterator, error) {
+	q, err := self.cdrsQuery(qryFltr)
+	if err != nil {
+		return nil, err
+	}
+	if qryFltr.OrderBy != "" {
+		q = q.Order(sqlCDRsOrder[qryFltr.OrderBy])
+	}
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlCDRsIterator{db: self.db, rows: rows}, nil
+}
+
+// sqlCDRsIterator reads the CDRs one row at a time
+type sqlCDRsIterator struct {
+	db   *gorm.DB
+	rows *sql.Rows
+}
+
+func (it *sqlCDRsIterator) Next() (*CDR, error) {
+	if !it.rows.Next() {
+		if err := it.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	var result TBLCDRs
+	if err := it.db.ScanRows(it.rows, &result); err != nil {
+		return nil, err
+	}
+	return newCDRFromTBLCDRs(&result)
+}
+
+func (it *sqlCDRsIterator) Close() error {
+	return it.rows.Close()
+}
+
 func (self *SQLStorage) GetTPDestinations(tpid, id string) (uTPDsts []*utils.TPDestination, err error) {
 	var tpDests TpDestinations
 	q := self.db.Where("tpid = ?", tpid)
@@ -1642,6 +1904,41 @@ func (self *SQLStorage) GetTPFilter(tpid, id string) ([]*utils.TPFilter, error)
 	return aths, nil
 }
 
+func (self *SQLStorage) GetTPExchangeRates(tpid, fromCurrency, toCurrency string) ([]*utils.TPExchangeRate, error) {
+	var fxs TpExchangeRates
+	q := self.db.Where("tpid = ?", tpid)
+	if len(fromCurrency) != 0 {
+		q = q.Where("from_currency = ?", fromCurrency)
+	}
+	if len(toCurrency) != 0 {
+		q = q.Where("to_currency = ?", toCurrency)
+	}
+	if err := q.Find(&fxs).Error; err != nil {
+		return nil, err
+	}
+	afxs := fxs.AsTPExchangeRates()
+	if len(afxs) == 0 {
+		return afxs, utils.ErrNotFound
+	}
+	return afxs, nil
+}
+
+func (self *SQLStorage) GetTPTaxRates(tpid, id string) ([]*utils.TPTaxRate, error) {
+	var txrs TpTaxRates
+	q := self.db.Where("tpid = ?", tpid)
+	if len(id) != 0 {
+		q = q.Where("id = ?", id)
+	}
+	if err := q.Find(&txrs).Error; err != nil {
+		return nil, err
+	}
+	atxrs := txrs.AsTPTaxRates()
+	if len(atxrs) == 0 {
+		return atxrs, utils.ErrNotFound
+	}
+	return atxrs, nil
+}
+
 // GetVersions returns slice of all versions or a specific version if tag is specified
 func (self *SQLStorage) GetVersions(itm string) (vrs Versions, err error) {
 	q := self.db.Model(&TBLVersion{})
@@ -0,0 +1,104 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteDialect = "sqlite3" // name of the SQLite dialect inside gorm
+
+type SQLiteStorage struct {
+	SQLStorage
+}
+
+// NewSQLiteStorage opens the SQLite database file at dbPath
+// busy_timeout makes concurrent writers wait for the file lock instead of failing with SQLITE_BUSY
+// WAL journal allows writing while CDRs are streamed out of an open cursor
+func NewSQLiteStorage(dbPath string, maxConn, maxIdleConn, connMaxLifetime int) (*SQLStorage, error) {
+	connectString := fmt.Sprintf("file:%s?_busy_timeout=5000&_loc=auto", dbPath)
+	db, err := gorm.Open(sqliteDialect, connectString)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.DB().Ping(); err != nil {
+		return nil, err
+	}
+	if err = db.Exec("PRAGMA journal_mode=WAL").Error; err != nil { // persistent inside the database file
+		return nil, err
+	}
+	db.DB().SetMaxIdleConns(maxIdleConn)
+	db.DB().SetMaxOpenConns(maxConn)
+	db.DB().SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
+	//db.LogMode(true)
+	sqliteStorage := new(SQLiteStorage)
+	sqliteStorage.db = db
+	sqliteStorage.Db = db.DB()
+	return &SQLStorage{db.DB(), db, sqliteStorage, sqliteStorage}, nil
+}
+
+// SetVersions will set a slice of versions, updating existing
+func (self *SQLiteStorage) SetVersions(vrs Versions, overwrite bool) (err error) {
+	tx := self.db.Begin()
+	if overwrite {
+		tx.Table(utils.TBLVersions).Delete(nil)
+	}
+	for key, val := range vrs {
+		vrModel := &TBLVersion{Item: key, Version: val}
+		if !overwrite {
+			if err = tx.Model(&TBLVersion{}).Where(
+				TBLVersion{Item: vrModel.Item}).Delete(TBLVersion{Version: val}).Error; err != nil {
+				tx.Rollback()
+				return
+			}
+		}
+		if err = tx.Save(vrModel).Error; err != nil {
+			tx.Rollback()
+			return
+		}
+	}
+	tx.Commit()
+	return
+}
+
+// extra_fields are kept as JSON text, queried with LIKE since the json1 extension is optional in SQLite
+func (self *SQLiteStorage) extraFieldsExistsQry(field string) string {
+	return fmt.Sprintf(" extra_fields LIKE '%%\"%s\":%%'", field)
+}
+
+func (self *SQLiteStorage) extraFieldsValueQry(field, value string) string {
+	return fmt.Sprintf(" extra_fields LIKE '%%\"%s\":\"%s\"%%'", field, value)
+}
+
+func (self *SQLiteStorage) notExtraFieldsExistsQry(field string) string {
+	return fmt.Sprintf(" extra_fields NOT LIKE '%%\"%s\":%%'", field)
+}
+
+func (self *SQLiteStorage) notExtraFieldsValueQry(field, value string) string {
+	return fmt.Sprintf(" extra_fields NOT LIKE '%%\"%s\":\"%s\"%%'", field, value)
+}
+
+func (self *SQLiteStorage) GetStorageType() string {
+	return utils.SQLITE
+}
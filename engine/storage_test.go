@@ -306,6 +306,70 @@ func TestStorageTask(t *testing.T) {
 	}
 }
 
+func TestStorageSetAccountRevision(t *testing.T) {
+	acnt := &Account{ID: "cgrates.org:revision",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 10}}}}
+	if err := dm.DataDB().SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	acnt1, err := dm.DataDB().GetAccount(acnt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acnt2, err := dm.DataDB().GetAccount(acnt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acnt1.BalanceMap[utils.MONETARY][0].Value = 7
+	if err := dm.DataDB().SetAccount(acnt1); err != nil {
+		t.Error(err)
+	} else if acnt1.Revision != acnt2.Revision+1 {
+		t.Errorf("Expecting revision: %d, received: %d", acnt2.Revision+1, acnt1.Revision)
+	}
+	acnt2.BalanceMap[utils.MONETARY][0].Value = 5
+	if err := dm.DataDB().SetAccount(acnt2); err != utils.ErrRevisionConflict {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrRevisionConflict, err)
+	}
+	if rcv, err := dm.DataDB().GetAccount(acnt.ID); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 7 || rcv.Revision != acnt1.Revision {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+}
+
+func TestStorageSetAccountsRevision(t *testing.T) {
+	acnt := &Account{ID: "cgrates.org:revision_main",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 10}}}}
+	shared := &Account{ID: "cgrates.org:revision_shared",
+		BalanceMap: map[string]Balances{utils.MONETARY: Balances{&Balance{Value: 10}}}}
+	if err := dm.DataDB().SetAccounts([]*Account{acnt, shared}); err != nil {
+		t.Fatal(err)
+	}
+	sharedChanged, err := dm.DataDB().GetAccount(shared.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedChanged.BalanceMap[utils.MONETARY][0].Value = 7
+	if err := dm.DataDB().SetAccount(sharedChanged); err != nil {
+		t.Fatal(err)
+	}
+	acnt.BalanceMap[utils.MONETARY][0].Value = 5
+	shared.BalanceMap[utils.MONETARY][0].Value = 5
+	if err := dm.DataDB().SetAccounts([]*Account{acnt, shared}); err != utils.ErrRevisionConflict {
+		t.Errorf("Expecting: %v, received: %v", utils.ErrRevisionConflict, err)
+	}
+	if rcv, err := dm.DataDB().GetAccount(acnt.ID); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 10 {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+	if rcv, err := dm.DataDB().GetAccount(shared.ID); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY][0].Value != 7 || rcv.Revision != sharedChanged.Revision {
+		t.Errorf("Received: %s", utils.ToJSON(rcv))
+	}
+}
+
 /************************** Benchmarks *****************************/
 
 func GetUB() *Account {
@@ -21,6 +21,7 @@ package engine
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/cgrates/cgrates/config"
@@ -29,6 +30,14 @@ import (
 
 // Various helpers to deal with database
 
+// checkCDRsOrder validates the field the CDRs are requested sorted on
+func checkCDRsOrder(orderBy string) error {
+	if orderBy != "" && orderBy != utils.SETUP_TIME {
+		return fmt.Errorf("unsupported CDRs order: <%s>", orderBy)
+	}
+	return nil
+}
+
 func ConfigureDataStorage(db_type, host, port, name, user, pass, marshaler string, cacheCfg config.CacheConfig, loadHistorySize int) (dm *DataManager, err error) {
 	var d DataDB
 	switch db_type {
@@ -47,9 +56,13 @@ func ConfigureDataStorage(db_type, host, port, name, user, pass, marshaler strin
 	case utils.MONGO:
 		d, err = NewMongoStorage(host, port, name, user, pass, utils.DataDB, nil, cacheCfg, loadHistorySize)
 		dm = NewDataManager(d.(DataDB))
+	case utils.MetaInternalPersistent:
+		d, err = NewMapStoragePersistent(config.CgrConfig().DataDbPath, marshaler,
+			config.CgrConfig().DataDbSnapshotInterval, config.CgrConfig().DataDbSyncInterval)
+		dm = NewDataManager(d.(DataDB))
 	default:
-		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are '%s' or '%s'",
-			db_type, utils.REDIS, utils.MONGO))
+		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s]",
+			db_type, utils.REDIS, utils.MONGO, utils.MetaInternalPersistent))
 	}
 	if err != nil {
 		return nil, err
@@ -66,9 +79,13 @@ func ConfigureStorStorage(db_type, host, port, name, user, pass, marshaler strin
 		d, err = NewPostgresStorage(host, port, name, user, pass, maxConn, maxIdleConn, connMaxLifetime)
 	case utils.MYSQL:
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn, connMaxLifetime)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn, connMaxLifetime)
+	case utils.MetaInternal:
+		d, err = NewInternalStorDB(config.CgrConfig().StorDBPath)
 	default:
-		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s]",
-			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES))
+		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s, %s, %s]",
+			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES, utils.SQLITE, utils.MetaInternal))
 	}
 	if err != nil {
 		return nil, err
@@ -85,9 +102,13 @@ func ConfigureLoadStorage(db_type, host, port, name, user, pass, marshaler strin
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn, connMaxLifetime)
 	case utils.MONGO:
 		d, err = NewMongoStorage(host, port, name, user, pass, utils.StorDB, cdrsIndexes, nil, 1)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn, connMaxLifetime)
+	case utils.MetaInternal:
+		d, err = NewInternalStorDB(config.CgrConfig().StorDBPath)
 	default:
-		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s]",
-			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES))
+		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s, %s, %s]",
+			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES, utils.SQLITE, utils.MetaInternal))
 	}
 	if err != nil {
 		return nil, err
@@ -104,9 +125,13 @@ func ConfigureCdrStorage(db_type, host, port, name, user, pass string, maxConn,
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn, connMaxLifetime)
 	case utils.MONGO:
 		d, err = NewMongoStorage(host, port, name, user, pass, utils.StorDB, cdrsIndexes, nil, 1)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn, connMaxLifetime)
+	case utils.MetaInternal:
+		d, err = NewInternalStorDB(config.CgrConfig().StorDBPath)
 	default:
-		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s]",
-			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES))
+		err = errors.New(fmt.Sprintf("Unknown db '%s' valid options are [%s, %s, %s, %s, %s]",
+			db_type, utils.MYSQL, utils.MONGO, utils.POSTGRES, utils.SQLITE, utils.MetaInternal))
 	}
 	if err != nil {
 		return nil, err
@@ -144,3 +169,27 @@ type V2SMCost struct {
 	Usage       float64
 	CostDetails *EventCost
 }
+
+// NewCDRsSliceIterator returns a CDRsIterator over CDRs already in memory
+func NewCDRsSliceIterator(cdrs []*CDR) CDRsIterator {
+	return &cdrsSliceIterator{cdrs: cdrs}
+}
+
+type cdrsSliceIterator struct {
+	cdrs []*CDR
+	idx  int
+}
+
+func (it *cdrsSliceIterator) Next() (cdr *CDR, err error) {
+	if it.idx >= len(it.cdrs) {
+		return nil, io.EOF
+	}
+	cdr = it.cdrs[it.idx]
+	it.idx++
+	return
+}
+
+func (it *cdrsSliceIterator) Close() error {
+	it.cdrs = nil
+	return nil
+}
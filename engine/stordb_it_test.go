@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/utils"
@@ -60,6 +61,7 @@ var sTestsStorDBit = []func(t *testing.T){
 	testStorDBitCRUDTpStats,
 	testStorDBitCRUDCDRs,
 	testStorDBitCRUDSMCosts,
+	testStorDBitCRUDStatQueueMetrics,
 }
 
 func TestStorDBitMySQL(t *testing.T) {
@@ -96,6 +98,23 @@ func TestStorDBitPostgresSQL(t *testing.T) {
 	}
 }
 
+func TestStorDBitSQLite(t *testing.T) {
+	if cfg, err = config.NewCGRConfigFromFolder(path.Join(*dataDir, "conf", "samples", "storage", "sqlite")); err != nil {
+		t.Fatal(err)
+	}
+	if storDB, err = NewSQLiteStorage(cfg.StorDBName,
+		cfg.StorDBMaxOpenConns, cfg.StorDBMaxIdleConns, cfg.StorDBConnMaxLifetime); err != nil {
+		t.Fatal(err)
+	}
+	storDB2ndDBname = "sqlite"
+	for _, stest := range sTestsStorDBit {
+		stestFullName := runtime.FuncForPC(reflect.ValueOf(stest).Pointer()).Name()
+		split := strings.Split(stestFullName, ".")
+		stestName := split[len(split)-1]
+		t.Run(stestName, stest)
+	}
+}
+
 func TestStorDBitMongo(t *testing.T) {
 	if cfg, err = config.NewCGRConfigFromFolder(path.Join(*dataDir, "conf", "samples", "storage", "mongo")); err != nil {
 		t.Fatal(err)
@@ -126,7 +145,7 @@ func testStorDBitIsDBEmpty(t *testing.T) {
 		} else if test != true {
 			t.Errorf("\nExpecting: true got :%+v", test)
 		}
-	case utils.POSTGRES, utils.MYSQL:
+	case utils.POSTGRES, utils.MYSQL, utils.SQLITE:
 		test, err := storDB.IsDBEmpty()
 		if err != nil {
 			t.Error(err)
@@ -1910,3 +1929,38 @@ func testStorDBitCRUDVersions(t *testing.T) {
 	}
 
 }
+
+func testStorDBitCRUDStatQueueMetrics(t *testing.T) {
+	// READ
+	if _, err := storDB.GetStatQueueMetrics("cgrates.org", "SQ1", time.Time{}, time.Time{}); err != utils.ErrNotFound {
+		t.Error(err)
+	}
+	// WRITE
+	from := time.Date(2017, 10, 1, 10, 0, 0, 0, time.UTC)
+	snd := []*StatQueueMetrics{
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from,
+			Metrics: map[string]float64{utils.MetaASR: 50, utils.MetaACD: 60}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ1", Time: from.Add(time.Minute),
+			Metrics: map[string]float64{utils.MetaASR: 100, utils.MetaACD: 30}},
+		&StatQueueMetrics{Tenant: "cgrates.org", ID: "SQ2", Time: from,
+			Metrics: map[string]float64{utils.MetaASR: 10}},
+	}
+	for _, sqm := range snd {
+		if err := storDB.SetStatQueueMetrics(sqm); err != nil {
+			t.Error(err)
+		}
+	}
+	// READ
+	if rcv, err := storDB.GetStatQueueMetrics("cgrates.org", "SQ1", time.Time{}, time.Time{}); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 2 {
+		t.Errorf("received: %s", utils.ToJSON(rcv))
+	} else if !rcv[0].Time.Equal(from) || !reflect.DeepEqual(snd[0].Metrics, rcv[0].Metrics) {
+		t.Errorf("expecting: %s, received: %s", utils.ToJSON(snd[0]), utils.ToJSON(rcv[0]))
+	}
+	if rcv, err := storDB.GetStatQueueMetrics("cgrates.org", "SQ1", from.Add(time.Second), from.Add(time.Hour)); err != nil {
+		t.Error(err)
+	} else if len(rcv) != 1 || !reflect.DeepEqual(snd[1].Metrics, rcv[0].Metrics) {
+		t.Errorf("received: %s", utils.ToJSON(rcv))
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// TaxProvider calculates the taxes of the rated CDRs
+type TaxProvider interface {
+	ProcessCDR(cdr *CDR) error
+}
+
+// NewTaxProvider returns the TaxProvider configured in CDRS, nil if taxes are not calculated
+func NewTaxProvider(providerType string, dm *DataManager) (TaxProvider, error) {
+	switch providerType {
+	case "":
+		return nil, nil
+	case utils.MetaLocal:
+		return &LocalTaxProvider{dm: dm}, nil
+	case utils.META_SURETAX:
+		return new(SureTaxProvider), nil
+	}
+	return nil, fmt.Errorf("unsupported tax provider: %s", providerType)
+}
+
+// SureTaxProvider queries the SureTax API for the taxes of a CDR
+type SureTaxProvider struct{}
+
+func (stp *SureTaxProvider) ProcessCDR(cdr *CDR) error {
+	return SureTaxProcessCdr(cdr)
+}
+
+// LocalTaxProvider calculates the taxes out of the TaxRates stored in DataDB
+type LocalTaxProvider struct {
+	dm *DataManager
+}
+
+// ProcessCDR stores the taxes matching the CDR in its ExtraFields, the tax lines and their total,
+// the Cost being left as debited out of the account so it stays consistent with the CostDetails
+func (ltp *LocalTaxProvider) ProcessCDR(cdr *CDR) (err error) {
+	if cdr.Cost < 0 { // not rated
+		return
+	}
+	txRates, err := ltp.dm.DataDB().GetTaxRates(cdr.Tenant)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	var matched TaxRates
+	for _, txRate := range txRates {
+		if txRate.matchesCDR(ltp.dm, cdr) {
+			matched = append(matched, txRate)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+	roundingDecimals := config.CgrConfig().RoundingDecimals
+	taxLines, total := matched.Calculate(cdr.Cost, roundingDecimals)
+	jsnLines, err := json.Marshal(taxLines)
+	if err != nil {
+		return
+	}
+	if cdr.ExtraFields == nil {
+		cdr.ExtraFields = make(map[string]string)
+	}
+	cdr.ExtraFields[utils.MetaTaxes] = string(jsnLines)
+	cdr.ExtraFields[utils.MetaTaxTotal] = strconv.FormatFloat(total, 'f', -1, 64)
+	return
+}
+
+// TaxRate is one tax applied on the cost of the CDRs matching its filters
+type TaxRate struct {
+	ID             string
+	Categories     utils.StringMap // empty to match all categories
+	DestinationIDs utils.StringMap // empty to match all destinations
+	Accounts       utils.StringMap // empty to match all accounts
+	Type           string          // <*percent|*fixed>
+	Value          float64
+	Compound       bool // the percentage is applied on the cost including the taxes with higher weight
+	Weight         float64
+}
+
+// matchesCDR checks the filters of the tax against the CDR
+func (txr *TaxRate) matchesCDR(dm *DataManager, cdr *CDR) bool {
+	if len(txr.Categories) != 0 && !txr.Categories[cdr.Category] {
+		return false
+	}
+	if len(txr.Accounts) != 0 && !txr.Accounts[cdr.Account] {
+		return false
+	}
+	if len(txr.DestinationIDs) == 0 {
+		return true
+	}
+	for _, p := range utils.SplitPrefix(cdr.Destination, MIN_PREFIX_MATCH) {
+		if destIDs, err := dm.DataDB().GetReverseDestination(p, false, utils.NonTransactional); err == nil {
+			for _, dID := range destIDs {
+				if txr.DestinationIDs[dID] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// TaxRates are the taxes defined for one tenant
+type TaxRates []*TaxRate
+
+// Sort orders the taxes descending on weight, the order in which they are applied
+func (txrs TaxRates) Sort() {
+	sort.SliceStable(txrs, func(i, j int) bool { return txrs[i].Weight > txrs[j].Weight })
+}
+
+// Calculate returns the tax lines of all the taxes applied on cost and their total
+func (txrs TaxRates) Calculate(cost float64, roundingDecimals int) (taxLines []*TaxLine, total float64) {
+	txrs.Sort()
+	for _, txr := range txrs {
+		var amount float64
+		switch txr.Type {
+		case utils.MetaFixed:
+			amount = txr.Value
+		case utils.MetaPercent:
+			base := cost
+			if txr.Compound {
+				base += total
+			}
+			amount = base * txr.Value / 100
+		default:
+			continue
+		}
+		amount = utils.Round(amount, roundingDecimals, utils.ROUNDING_MIDDLE)
+		taxLines = append(taxLines, &TaxLine{ID: txr.ID, Type: txr.Type, Value: txr.Value, Amount: amount})
+		total += amount
+	}
+	return taxLines, utils.Round(total, roundingDecimals, utils.ROUNDING_MIDDLE)
+}
+
+// TaxLine is the tax amount calculated for a CDR by one TaxRate
+type TaxLine struct {
+	ID     string
+	Type   string
+	Value  float64
+	Amount float64
+}
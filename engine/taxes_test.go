@@ -0,0 +1,133 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestTaxRatesCalculate(t *testing.T) {
+	txrs := TaxRates{
+		&TaxRate{ID: "TAX_LINE", Type: utils.MetaFixed, Value: 0.5},
+		&TaxRate{ID: "TAX_STATE", Type: utils.MetaPercent, Value: 10, Compound: true, Weight: 10},
+		&TaxRate{ID: "TAX_FEDERAL", Type: utils.MetaPercent, Value: 5, Weight: 20},
+	}
+	eLines := []*TaxLine{
+		&TaxLine{ID: "TAX_FEDERAL", Type: utils.MetaPercent, Value: 5, Amount: 0.5},
+		&TaxLine{ID: "TAX_STATE", Type: utils.MetaPercent, Value: 10, Amount: 1.05},
+		&TaxLine{ID: "TAX_LINE", Type: utils.MetaFixed, Value: 0.5, Amount: 0.5},
+	}
+	if lines, total := txrs.Calculate(10, 4); !reflect.DeepEqual(eLines, lines) {
+		t.Errorf("Expecting: %s, received: %s", utils.ToJSON(eLines), utils.ToJSON(lines))
+	} else if total != 2.05 {
+		t.Errorf("Expecting 2.05, received: %v", total)
+	}
+}
+
+func TestLocalTaxProviderProcessCDR(t *testing.T) {
+	if err := dm.DataDB().SetTaxRates("taxes.org", TaxRates{
+		&TaxRate{ID: "TAX_NAT", Categories: utils.NewStringMap("call"), DestinationIDs: utils.NewStringMap("NAT"),
+			Type: utils.MetaPercent, Value: 20, Weight: 10},
+		&TaxRate{ID: "TAX_1001", Accounts: utils.NewStringMap("1001"), Type: utils.MetaFixed, Value: 0.1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer dm.DataDB().RemoveTaxRates("taxes.org")
+	txPrv, err := NewTaxProvider(utils.MetaLocal, dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdr := &CDR{Tenant: "taxes.org", Category: "call", Account: "1002", Destination: "0723045326", Cost: 1.5}
+	if err := txPrv.ProcessCDR(cdr); err != nil {
+		t.Fatal(err)
+	}
+	if cdr.Cost != 1.5 {
+		t.Errorf("Expecting cost not to include taxes, received: %v", cdr.Cost)
+	} else if cdr.ExtraFields[utils.MetaTaxTotal] != "0.3" {
+		t.Errorf("Expecting tax total 0.3, received: %s", cdr.ExtraFields[utils.MetaTaxTotal])
+	}
+	eTaxes := `[{"ID":"TAX_NAT","Type":"*percent","Value":20,"Amount":0.3}]`
+	if cdr.ExtraFields[utils.MetaTaxes] != eTaxes {
+		t.Errorf("Expecting: %s, received: %s", eTaxes, cdr.ExtraFields[utils.MetaTaxes])
+	}
+	cdr = &CDR{Tenant: "taxes.org", Category: "call", Account: "1002", Destination: "+4031", Cost: 1.5}
+	if err := txPrv.ProcessCDR(cdr); err != nil {
+		t.Fatal(err)
+	} else if cdr.Cost != 1.5 || len(cdr.ExtraFields) != 0 {
+		t.Errorf("Unexpected taxes: %+v", cdr)
+	}
+	cdr = &CDR{Tenant: "taxes.org", Category: "call", Account: "1001", Destination: "0723045326", Cost: -1}
+	if err := txPrv.ProcessCDR(cdr); err != nil {
+		t.Fatal(err)
+	} else if cdr.Cost != -1 {
+		t.Errorf("Expecting unrated CDR not to be taxed, received cost: %v", cdr.Cost)
+	}
+}
+
+func TestCDRsTaxPrepaidCDR(t *testing.T) {
+	if err := dm.DataDB().SetTaxRates("taxes.org", TaxRates{
+		&TaxRate{ID: "TAX_ALL", Type: utils.MetaPercent, Value: 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer dm.DataDB().RemoveTaxRates("taxes.org")
+	cfg, _ := config.NewDefaultCGRConfig()
+	cfg.CDRSTaxProvider = utils.MetaLocal
+	iDB, err := NewInternalStorDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := iDB.SetSMCost(&SMCost{CGRID: "CGRID_TAX", RunID: utils.META_DEFAULT, OriginHost: "127.0.0.1",
+		OriginID: "call1", CostSource: utils.SESSION_MANAGER_SOURCE, Usage: 60,
+		CostDetails: &CallCost{Direction: utils.OUT, Destination: "1002", Cost: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	cdrS, err := NewCdrServer(cfg, iDB, dm, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdrs, err := cdrS.rateCDR(&CDR{CGRID: "CGRID_TAX", RunID: utils.META_DEFAULT, OriginHost: "127.0.0.1",
+		RequestType: utils.META_PREPAID, Tenant: "taxes.org", Category: "call", Account: "1001",
+		Destination: "1002", Usage: time.Minute, ExtraFields: make(map[string]string)})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(cdrs) != 1 {
+		t.Fatalf("Expecting one rated CDR, received: %s", utils.ToJSON(cdrs))
+	}
+	cdrS.taxCDR(cdrs[0])
+	if cdrs[0].Cost != 2 || cdrs[0].Cost != cdrs[0].CostDetails.Cost {
+		t.Errorf("Expecting cost to match the debited one, received: %v, cost details: %v", cdrs[0].Cost, cdrs[0].CostDetails.Cost)
+	} else if cdrs[0].ExtraFields[utils.MetaTaxTotal] != "0.2" {
+		t.Errorf("Expecting tax total 0.2, received: %s", cdrs[0].ExtraFields[utils.MetaTaxTotal])
+	}
+}
+
+func TestNewTaxProviderUnsupported(t *testing.T) {
+	if _, err := NewTaxProvider("*unsupported", dm); err == nil {
+		t.Error("Expecting error for unsupported tax provider")
+	}
+	if txPrv, err := NewTaxProvider("", dm); err != nil || txPrv != nil {
+		t.Errorf("Expecting nil tax provider, received: %v, %v", txPrv, err)
+	}
+}
@@ -42,6 +42,9 @@ type ThresholdProfile struct {
 	Blocker            bool    // blocker flag to stop processing on filters matched
 	Weight             float64 // Weight to sort the thresholds
 	ActionIDs          []string
+	MinHits            int      // number of matching events needed before executing the actions
+	MaxHits            int      // disable the threshold after executing the actions MaxHits times, 0 for unlimited
+	RecoverActionIDs   []string // actions executed when a raised threshold stops matching
 }
 
 func (tp *ThresholdProfile) TenantID() string {
@@ -50,9 +53,10 @@ func (tp *ThresholdProfile) TenantID() string {
 
 // ThresholdEvent is an event processed by ThresholdService
 type ThresholdEvent struct {
-	Tenant string
-	ID     string
-	Fields map[string]interface{}
+	Tenant       string
+	ID           string
+	Fields       map[string]interface{}
+	ThresholdIDs []string // restricts the thresholds checked to these ones, all matching ones if empty
 }
 
 func (te *ThresholdEvent) TenantID() string {
@@ -76,6 +80,9 @@ type Threshold struct {
 	Tenant string
 	ID     string
 	Snooze time.Time // prevent threshold to run too early
+	Hits   int       // number of matching events since last reset or recovery
+	Fires  int       // number of times the actions were executed since last reset
+	Raised bool      // actions were executed and the threshold did not recover yet
 
 	tPrfl *ThresholdProfile
 	dirty *bool // needs save
@@ -85,21 +92,58 @@ func (t *Threshold) TenantID() string {
 	return utils.ConcatenatedKey(t.Tenant, t.ID)
 }
 
+// Disabled returns true if the threshold executed its actions for MaxHits times
+func (t *Threshold) Disabled() bool {
+	return t.tPrfl.MaxHits > 0 && t.Fires >= t.tPrfl.MaxHits
+}
+
+// Reset clears the counters of the threshold
+func (t *Threshold) Reset() {
+	t.Snooze = time.Time{}
+	t.Hits = 0
+	t.Fires = 0
+	t.Raised = false
+}
+
 // ProcessEvent processes an ThresholdEvent
-// concurrentActions limits the number of simultaneous action sets executed
+// actions are executed only after MinHits matching events and if the threshold is not snoozed or disabled
 func (t *Threshold) ProcessEvent(ev *ThresholdEvent, dm *DataManager) (err error) {
-	if t.Snooze.After(time.Now()) { // ignore the event
+	if t.Disabled() {
+		return
+	}
+	t.Hits++
+	if t.Hits < t.tPrfl.MinHits ||
+		t.Snooze.After(time.Now()) { // ignore the event
+		return
+	}
+	t.Fires++
+	t.Raised = true
+	t.Snooze = time.Now().Add(t.tPrfl.MinSleep)
+	return t.executeActions(t.tPrfl.ActionIDs, ev)
+}
+
+// Recover executes the recover actions of a raised threshold which stopped matching
+func (t *Threshold) Recover(ev *ThresholdEvent, dm *DataManager) (err error) {
+	if !t.Raised {
 		return
 	}
+	t.Hits = 0
+	t.Raised = false
+	return t.executeActions(t.tPrfl.RecoverActionIDs, ev)
+}
+
+// executeActions executes the action sets in the context of the event account
+func (t *Threshold) executeActions(actionSetIDs []string, ev *ThresholdEvent) (err error) {
 	acnt, _ := ev.Account()
 	var acntID string
 	if acnt != "" {
 		acntID = utils.ConcatenatedKey(ev.Tenant, acnt)
 	}
-	for _, actionSetID := range t.tPrfl.ActionIDs {
+	for _, actionSetID := range actionSetIDs {
 		at := &ActionTiming{
 			Uuid:      utils.GenUUID(),
 			ActionsID: actionSetID,
+			thEv:      ev,
 		}
 		if acntID != "" {
 			at.accountIDs = utils.NewStringMap(acntID)
@@ -126,6 +170,8 @@ func NewThresholdService(dm *DataManager, filteredFields []string, storeInterval
 		filteredFields: filteredFields,
 		storeInterval:  storeInterval,
 		statS:          statS,
+		storedTdIDs:    make(utils.StringMap),
+		raisedTIDs:     make(map[string]utils.StringMap),
 		stopBackup:     make(chan struct{})}, nil
 }
 
@@ -136,8 +182,10 @@ type ThresholdService struct {
 	storeInterval  time.Duration
 	statS          *rpcclient.RpcClientPool // allows applying filters based on stats
 	stopBackup     chan struct{}
-	storedTdIDs    utils.StringMap // keep a record of stats which need saving, map[statsTenantID]bool
-	stMux          sync.RWMutex    // protects storedTdIDs
+	storedTdIDs    utils.StringMap            // keep a record of stats which need saving, map[statsTenantID]bool
+	stMux          sync.RWMutex               // protects storedTdIDs
+	raisedTIDs     map[string]utils.StringMap // IDs of the raised thresholds per tenant, checked for recovery on every event
+	rtMux          sync.Mutex                 // protects raisedTIDs
 }
 
 // Called to start the service
@@ -208,7 +256,12 @@ func (tS *ThresholdService) StoreThreshold(t *Threshold) (err error) {
 	if t.dirty == nil || !*t.dirty {
 		return
 	}
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, utils.ThresholdPrefix+t.TenantID())
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, utils.ThresholdPrefix+t.TenantID()); err != nil {
+		utils.Logger.Warning(
+			fmt.Sprintf("<ThresholdS> failed locking Threshold with tenant: %s and ID: %s, error: %s",
+				t.Tenant, t.ID, err.Error()))
+		return
+	}
 	defer guardian.Guardian.UnguardIDs(utils.ThresholdPrefix + t.TenantID())
 	if err = tS.dm.DataDB().SetThreshold(t); err != nil {
 		utils.Logger.Warning(
@@ -221,15 +274,83 @@ func (tS *ThresholdService) StoreThreshold(t *Threshold) (err error) {
 	return
 }
 
+// raisedThresholdIDs returns the IDs of the raised thresholds of a tenant
+// they are read out of dataDB on the first event of the tenant, updated on fire and recover afterwards
+func (tS *ThresholdService) raisedThresholdIDs(tenant string) (tIDs utils.StringMap, err error) {
+	tS.rtMux.Lock()
+	defer tS.rtMux.Unlock()
+	if raised, has := tS.raisedTIDs[tenant]; has {
+		return raised.Clone(), nil
+	}
+	prfx := utils.ThresholdPrefix + tenant + ":"
+	keys, err := tS.dm.DataDB().GetKeysForPrefix(prfx)
+	if err != nil {
+		return nil, err
+	}
+	raised := make(utils.StringMap)
+	for _, key := range keys {
+		t, err := tS.dm.DataDB().GetThreshold(tenant, key[len(prfx):], false, utils.NonTransactional)
+		if err != nil {
+			if err == utils.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if t.Raised {
+			raised[t.ID] = true
+		}
+	}
+	tS.raisedTIDs[tenant] = raised
+	return raised.Clone(), nil
+}
+
+// setRaised records whether the threshold is raised
+func (tS *ThresholdService) setRaised(tenant, tID string, raised bool) {
+	tS.rtMux.Lock()
+	defer tS.rtMux.Unlock()
+	tIDs, has := tS.raisedTIDs[tenant]
+	if !has { // not loaded yet, the stored threshold is read on load
+		return
+	}
+	if raised {
+		tIDs[tID] = true
+	} else {
+		delete(tIDs, tID)
+	}
+}
+
 // matchingThresholdsForEvent returns ordered list of matching thresholds which are active for an Event
 func (tS *ThresholdService) matchingThresholdsForEvent(ev *ThresholdEvent) (ts Thresholds, err error) {
+	ts, _, err = tS.thresholdsForEvent(ev)
+	return
+}
+
+// thresholdsForEvent returns ordered list of matching thresholds which are active for an Event
+// together with the raised thresholds having recover actions which did not pass the filters
+func (tS *ThresholdService) thresholdsForEvent(ev *ThresholdEvent) (ts, recTs Thresholds, err error) {
 	matchingTs := make(map[string]*Threshold)
-	tIDs, err := matchingItemIDsForEvent(ev.Fields, tS.dm, utils.ThresholdsIndex+ev.Tenant)
+	idxTIDs, err := matchingItemIDsForEvent(ev.Fields, tS.dm, utils.ThresholdsIndex+ev.Tenant)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	// raised thresholds need to recover on events which are not indexed for them
+	tIDs, err := tS.raisedThresholdIDs(ev.Tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+	tIDs.Join(idxTIDs)
+	if len(ev.ThresholdIDs) != 0 { // restricted by the notifying StatQueue or Resource
+		checkedTIDs := utils.NewStringMap(ev.ThresholdIDs...)
+		for tID := range tIDs {
+			if !checkedTIDs[tID] {
+				delete(tIDs, tID)
+			}
+		}
 	}
 	lockIDs := utils.PrefixSliceItems(tIDs.Slice(), utils.ThresholdsIndex)
-	guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...)
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockIDs...); err != nil {
+		return nil, nil, err
+	}
 	defer guardian.Guardian.UnguardIDs(lockIDs...)
 	for tID := range tIDs {
 		tPrfl, err := tS.dm.DataDB().GetThresholdProfile(ev.Tenant, tID, false, utils.NonTransactional)
@@ -237,36 +358,51 @@ func (tS *ThresholdService) matchingThresholdsForEvent(ev *ThresholdEvent) (ts T
 			if err == utils.ErrNotFound {
 				continue
 			}
-			return nil, err
+			return nil, nil, err
 		}
 		if tPrfl.ActivationInterval != nil &&
 			!tPrfl.ActivationInterval.IsActiveAtTime(time.Now()) { // not active
 			continue
 		}
-		passAllFilters := true
+		passAllFilters := idxTIDs[tID] // thresholds not indexed for the event are checked only for recovery
 		for _, fltr := range tPrfl.Filters {
+			if !passAllFilters {
+				break
+			}
 			if pass, err := fltr.Pass(ev.Fields, "", tS.statS); err != nil {
-				return nil, err
+				return nil, nil, err
 			} else if !pass {
 				passAllFilters = false
 				continue
 			}
 		}
-		if !passAllFilters {
+		if !passAllFilters && len(tPrfl.RecoverActionIDs) == 0 {
 			continue
 		}
 		lockThreshold := utils.ThresholdPrefix + tPrfl.TenantID()
-		guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockThreshold)
+		if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockThreshold); err != nil {
+			return nil, nil, err
+		}
 		t, err := tS.dm.DataDB().GetThreshold(tPrfl.Tenant, tPrfl.ID, false, "")
 		if err != nil {
 			guardian.Guardian.UnguardIDs(lockThreshold)
-			return nil, err
+			if !passAllFilters && err == utils.ErrNotFound { // removed one time threshold
+				tS.setRaised(tPrfl.Tenant, tPrfl.ID, false)
+				continue
+			}
+			return nil, nil, err
 		}
-		if tPrfl.Recurrent && t.dirty == nil {
+		if t.dirty == nil {
 			t.dirty = utils.BoolPointer(false)
 		}
 		t.tPrfl = tPrfl
 		guardian.Guardian.UnguardIDs(lockThreshold)
+		if !passAllFilters {
+			if t.Raised {
+				recTs = append(recTs, t)
+			}
+			continue
+		}
 		matchingTs[tPrfl.ID] = t
 	}
 	// All good, convert from Map to Slice so we can sort
@@ -288,13 +424,23 @@ func (tS *ThresholdService) matchingThresholdsForEvent(ev *ThresholdEvent) (ts T
 
 // processEvent processes a new event, dispatching to matching thresholds
 func (tS *ThresholdService) processEvent(ev *ThresholdEvent) (err error) {
-	matchTs, err := tS.matchingThresholdsForEvent(ev)
+	matchTs, recTs, err := tS.thresholdsForEvent(ev)
 	if err != nil {
 		return err
-	} else if len(matchTs) == 0 {
+	} else if len(matchTs) == 0 && len(recTs) == 0 {
 		return utils.ErrNotFound
 	}
 	var withErrors bool
+	for _, t := range recTs {
+		if err = t.Recover(ev, tS.dm); err != nil {
+			utils.Logger.Warning(
+				fmt.Sprintf("<ThresholdService> threshold: %s, failed recovering on event: %s, error: %s",
+					t.TenantID(), ev.TenantID(), err.Error()))
+			withErrors = true
+		}
+		tS.setRaised(t.Tenant, t.ID, t.Raised)
+		tS.storeOrMarkThreshold(t)
+	}
 	for _, t := range matchTs {
 		err = t.ProcessEvent(ev, tS.dm)
 		if err != nil {
@@ -302,31 +448,30 @@ func (tS *ThresholdService) processEvent(ev *ThresholdEvent) (err error) {
 				fmt.Sprintf("<ThresholdService> threshold: %s, ignoring event: %s, error: %s",
 					t.TenantID(), ev.TenantID(), err.Error()))
 			withErrors = true
-			continue
 		}
-		if t.dirty == nil { // one time threshold
+		tS.setRaised(t.Tenant, t.ID, t.Raised)
+		if !t.tPrfl.Recurrent && t.Fires != 0 { // one time threshold
 			lockThreshold := utils.ThresholdPrefix + t.TenantID()
-			guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockThreshold)
+			if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockThreshold); err != nil {
+				utils.Logger.Warning(
+					fmt.Sprintf("<ThresholdService> failed locking non-recurrent threshold: %s, error: %s",
+						t.TenantID(), err.Error()))
+				withErrors = true
+				continue
+			}
 			if err = tS.dm.DataDB().RemoveThreshold(t.Tenant, t.ID, utils.NonTransactional); err != nil {
 				utils.Logger.Warning(
 					fmt.Sprintf("<ThresholdService> failed removing non-recurrent threshold: %s, error: %s",
 						t.TenantID(), err.Error()))
 				withErrors = true
 
+			} else {
+				tS.setRaised(t.Tenant, t.ID, false)
 			}
 			guardian.Guardian.UnguardIDs(lockThreshold)
 			continue
 		}
-		t.Snooze = time.Now().Add(t.tPrfl.MinSleep)
-		// recurrent threshold
-		if tS.storeInterval == -1 {
-			tS.StoreThreshold(t)
-		} else {
-			*t.dirty = true // mark it to be saved
-			tS.stMux.Lock()
-			tS.storedTdIDs[t.TenantID()] = true
-			tS.stMux.Unlock()
-		}
+		tS.storeOrMarkThreshold(t)
 	}
 	if withErrors {
 		err = utils.ErrPartiallyExecuted
@@ -334,6 +479,18 @@ func (tS *ThresholdService) processEvent(ev *ThresholdEvent) (err error) {
 	return
 }
 
+// storeOrMarkThreshold stores the threshold immediately or marks it for the next backup, based on storeInterval
+func (tS *ThresholdService) storeOrMarkThreshold(t *Threshold) {
+	*t.dirty = true // mark it to be saved
+	if tS.storeInterval == -1 {
+		tS.StoreThreshold(t)
+		return
+	}
+	tS.stMux.Lock()
+	tS.storedTdIDs[t.TenantID()] = true
+	tS.stMux.Unlock()
+}
+
 // V1ProcessEvent implements ThresholdService method for processing an Event
 func (tS *ThresholdService) V1ProcessEvent(ev *ThresholdEvent, reply *string) (err error) {
 	if missing := utils.MissingStructFields(ev, []string{"Tenant", "ID"}); len(missing) != 0 { //Params missing
@@ -381,3 +538,29 @@ func (tS *ThresholdService) V1GetThreshold(tntID *utils.TenantID, t *Threshold)
 	}
 	return
 }
+
+// V1ResetThreshold clears the counters of a Threshold, re-enabling it if disabled by MaxHits
+func (tS *ThresholdService) V1ResetThreshold(tntID *utils.TenantID, reply *string) (err error) {
+	if missing := utils.MissingStructFields(tntID, []string{"Tenant", "ID"}); len(missing) != 0 { //Params missing
+		return utils.NewErrMandatoryIeMissing(missing...)
+	}
+	lockThreshold := utils.ThresholdPrefix + tntID.TenantID()
+	if err = guardian.Guardian.GuardIDs(config.CgrConfig().LockingTimeout, lockThreshold); err != nil {
+		return
+	}
+	defer guardian.Guardian.UnguardIDs(lockThreshold)
+	t, err := tS.dm.DataDB().GetThreshold(tntID.Tenant, tntID.ID, false, "")
+	if err != nil {
+		return err
+	}
+	rstT := *t // cached threshold can be in use by events being processed
+	rstT.Reset()
+	rstT.dirty = utils.BoolPointer(false) // stored below, no need for backup
+	if err = tS.dm.DataDB().SetThreshold(&rstT); err != nil {
+		return utils.NewErrServerError(err)
+	}
+	cache.Set(utils.ThresholdPrefix+rstT.TenantID(), &rstT, true, "")
+	tS.setRaised(rstT.Tenant, rstT.ID, false)
+	*reply = utils.OK
+	return
+}
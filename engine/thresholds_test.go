@@ -20,6 +20,11 @@ package engine
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/cache"
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
 )
 
 func TestThresholdsSort(t *testing.T) {
@@ -40,3 +45,203 @@ func TestThresholdsSort(t *testing.T) {
 		t.Errorf("expecting: %+v, received: %+v", eInst, ts)
 	}
 }
+
+func TestThresholdProcessEventHits(t *testing.T) {
+	th := &Threshold{Tenant: "cgrates.org", ID: "TH_HITS",
+		tPrfl: &ThresholdProfile{Tenant: "cgrates.org", ID: "TH_HITS",
+			Recurrent: true, MinHits: 2, MaxHits: 2}}
+	ev := &ThresholdEvent{Tenant: "cgrates.org", ID: "ev1",
+		Fields: map[string]interface{}{utils.ACCOUNT: "1001"}}
+	eHits := []struct {
+		hits, fires int
+		raised      bool
+	}{
+		{1, 0, false}, // below MinHits
+		{2, 1, true},
+		{3, 2, true},
+		{3, 2, true}, // disabled by MaxHits
+	}
+	for i, eH := range eHits {
+		if err := th.ProcessEvent(ev, nil); err != nil {
+			t.Fatal(err)
+		}
+		if th.Hits != eH.hits || th.Fires != eH.fires || th.Raised != eH.raised {
+			t.Errorf("event: %d, expecting: %+v, received: %+v", i, eH, th)
+		}
+	}
+	if !th.Disabled() {
+		t.Error("threshold should be disabled")
+	}
+	if err := th.Recover(ev, nil); err != nil {
+		t.Error(err)
+	} else if th.Raised || th.Hits != 0 || th.Fires != 2 {
+		t.Errorf("unexpected threshold after recover: %+v", th)
+	}
+	th.Reset()
+	if th.Disabled() || !th.Snooze.IsZero() {
+		t.Errorf("unexpected threshold after reset: %+v", th)
+	}
+}
+
+func TestThresholdProcessEventSnooze(t *testing.T) {
+	th := &Threshold{Tenant: "cgrates.org", ID: "TH_SNOOZE",
+		tPrfl: &ThresholdProfile{Tenant: "cgrates.org", ID: "TH_SNOOZE",
+			Recurrent: true, MinSleep: time.Hour}}
+	ev := &ThresholdEvent{Tenant: "cgrates.org", ID: "ev1"}
+	for i := 0; i < 2; i++ {
+		if err := th.ProcessEvent(ev, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if th.Hits != 2 || th.Fires != 1 {
+		t.Errorf("unexpected threshold: %+v", th)
+	}
+}
+
+func TestThresholdServiceV1ResetThreshold(t *testing.T) {
+	data, _ := NewMapStorage()
+	dmTh := NewDataManager(data)
+	tS, err := NewThresholdService(dmTh, nil, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dmTh.DataDB().SetThreshold(&Threshold{Tenant: "cgrates.org", ID: "TH_RESET",
+		Snooze: time.Now().Add(time.Hour), Hits: 5, Fires: 3, Raised: true}); err != nil {
+		t.Fatal(err)
+	}
+	inUseTh, err := dmTh.DataDB().GetThreshold("cgrates.org", "TH_RESET", false, utils.NonTransactional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply string
+	if err := tS.V1ResetThreshold(&utils.TenantID{Tenant: "cgrates.org"}, &reply); err == nil {
+		t.Error("expecting mandatory error")
+	}
+	if err := tS.V1ResetThreshold(&utils.TenantID{Tenant: "cgrates.org", ID: "TH_RESET"}, &reply); err != nil {
+		t.Fatal(err)
+	} else if reply != utils.OK {
+		t.Errorf("received reply: %s", reply)
+	}
+	eTh := &Threshold{Tenant: "cgrates.org", ID: "TH_RESET"}
+	if th, err := dmTh.DataDB().GetThreshold("cgrates.org", "TH_RESET", true, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eTh, th) {
+		t.Errorf("expecting: %+v, received: %+v", eTh, th)
+	}
+	if inUseTh.Hits != 5 || inUseTh.Fires != 3 || !inUseTh.Raised {
+		t.Errorf("threshold in use was modified: %+v", inUseTh)
+	}
+	if th, err := dmTh.DataDB().GetThreshold("cgrates.org", "TH_RESET", false, utils.NonTransactional); err != nil {
+		t.Error(err)
+	} else if th.Hits != 0 || th.Fires != 0 || th.Raised {
+		t.Errorf("cached threshold not reset: %+v", th)
+	}
+}
+
+// thresholdsCachePartitions keeps the thresholds and their profiles, sharing the IDs, in own cache partitions
+// returns the function restoring the default cache
+func thresholdsCachePartitions() func() {
+	dfltCfg, _ := config.NewDefaultCGRConfig()
+	cacheCfg := config.CacheConfig{
+		utils.ThresholdPrefix:        &config.CacheParamConfig{Limit: -1},
+		utils.ThresholdProfilePrefix: &config.CacheParamConfig{Limit: -1}}
+	for prfx, cacheParam := range dfltCfg.CacheConfig {
+		cacheCfg[prfx] = cacheParam
+	}
+	cache.NewCache(cacheCfg)
+	return func() { cache.NewCache(dfltCfg.CacheConfig) }
+}
+
+func TestThresholdsForEventRestricted(t *testing.T) {
+	defer thresholdsCachePartitions()()
+	data, _ := NewMapStorage()
+	dmTh := NewDataManager(data)
+	tS, err := NewThresholdService(dmTh, nil, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfi, err := NewReqFilterIndexer(dmTh, utils.ThresholdsIndex+"cgrates.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tID := range []string{"TH_1", "TH_2"} {
+		if err := dmTh.DataDB().SetThresholdProfile(&ThresholdProfile{Tenant: "cgrates.org", ID: tID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := dmTh.DataDB().SetThreshold(&Threshold{Tenant: "cgrates.org", ID: tID}); err != nil {
+			t.Fatal(err)
+		}
+		rfi.IndexFilters(tID, nil)
+	}
+	if err := rfi.StoreIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	ev := &ThresholdEvent{Tenant: "cgrates.org", ID: "restricted", Fields: map[string]interface{}{utils.ACCOUNT: "1001"}}
+	if ts, err := tS.matchingThresholdsForEvent(ev); err != nil {
+		t.Fatal(err)
+	} else if len(ts) != 2 {
+		t.Errorf("expecting 2 thresholds, received: %s", utils.ToJSON(ts))
+	}
+	ev.ThresholdIDs = []string{"TH_2", "TH_MISSING"}
+	if ts, err := tS.matchingThresholdsForEvent(ev); err != nil {
+		t.Fatal(err)
+	} else if len(ts) != 1 || ts[0].ID != "TH_2" {
+		t.Errorf("expecting TH_2 only, received: %s", utils.ToJSON(ts))
+	}
+}
+
+func TestThresholdsForEventRaised(t *testing.T) {
+	defer thresholdsCachePartitions()()
+	data, _ := NewMapStorage()
+	dmTh := NewDataManager(data)
+	tS, err := NewThresholdService(dmTh, nil, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fltr, err := NewFilter(MetaString, utils.ACCOUNT, []string{"1001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfi, err := NewReqFilterIndexer(dmTh, utils.ThresholdsIndex+"cgrates.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, th := range []*Threshold{
+		&Threshold{Tenant: "cgrates.org", ID: "TH_RAISED", Raised: true},
+		&Threshold{Tenant: "cgrates.org", ID: "TH_NOT_RAISED"},
+	} {
+		if err := dmTh.DataDB().SetThresholdProfile(&ThresholdProfile{Tenant: th.Tenant, ID: th.ID,
+			Filters: []*Filter{fltr}, RecoverActionIDs: []string{"ACT_RECOVER"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := dmTh.DataDB().SetThreshold(th); err != nil {
+			t.Fatal(err)
+		}
+		rfi.IndexFilters(th.ID, []*Filter{fltr})
+	}
+	if err := rfi.StoreIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	ev := &ThresholdEvent{Tenant: "cgrates.org", ID: "raised", Fields: map[string]interface{}{utils.ACCOUNT: "1002"}}
+	if ts, recTs, err := tS.thresholdsForEvent(ev); err != nil {
+		t.Fatal(err)
+	} else if len(ts) != 0 || len(recTs) != 1 || recTs[0].ID != "TH_RAISED" {
+		t.Errorf("expecting TH_RAISED to recover, received: %s, %s", utils.ToJSON(ts), utils.ToJSON(recTs))
+	}
+	if raised, err := tS.raisedThresholdIDs("cgrates.org"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(utils.NewStringMap("TH_RAISED"), raised) {
+		t.Errorf("unexpected raised thresholds: %+v", raised)
+	}
+	if err := tS.processEvent(ev); err != utils.ErrPartiallyExecuted { // ACT_RECOVER is missing
+		t.Error(err)
+	}
+	if raised, err := tS.raisedThresholdIDs("cgrates.org"); err != nil {
+		t.Fatal(err)
+	} else if len(raised) != 0 {
+		t.Errorf("unexpected raised thresholds: %+v", raised)
+	}
+	if err := tS.processEvent(ev); err != utils.ErrNotFound { // recovered, nothing to process
+		t.Errorf("expecting: %v, received: %v", utils.ErrNotFound, err)
+	}
+}
@@ -78,6 +78,8 @@ type MonetaryInfo struct {
 	UUID         string
 	ID           string
 	Value        float64
+	Currency     string  // currency of the balance
+	ExchangeRate float64 // converting the cost into the currency of the balance, 0 if not converted
 	RateInterval *RateInterval
 }
 
@@ -94,6 +96,7 @@ func (mi *MonetaryInfo) Equal(other *MonetaryInfo) bool {
 		return false
 	}
 	return mi.UUID == other.UUID &&
+		mi.ExchangeRate == other.ExchangeRate &&
 		reflect.DeepEqual(mi.RateInterval, other.RateInterval)
 }
 
@@ -58,6 +58,8 @@ type TpReader struct {
 	sqProfiles       map[string]map[string]*utils.TPStats
 	thProfiles       map[string]map[string]*utils.TPThreshold
 	flProfiles       map[string]map[string]*utils.TPFilter
+	exchangeRates    map[string]*ExchangeRate
+	taxRates         map[string]TaxRates
 	resources        []*utils.TenantID // IDs of resources which need creation based on resourceProfiles
 	statQueues       []*utils.TenantID // IDs of statQueues which need creation based on statQueueProfiles
 	thresholds       []*utils.TenantID // IDs of thresholds which need creation based on thresholdProfiles
@@ -137,6 +139,8 @@ func (tpr *TpReader) Init() {
 	tpr.sqProfiles = make(map[string]map[string]*utils.TPStats)
 	tpr.thProfiles = make(map[string]map[string]*utils.TPThreshold)
 	tpr.flProfiles = make(map[string]map[string]*utils.TPFilter)
+	tpr.exchangeRates = make(map[string]*ExchangeRate)
+	tpr.taxRates = make(map[string]TaxRates)
 	tpr.revDests = make(map[string][]string)
 	tpr.revAliases = make(map[string][]string)
 	tpr.acntActionPlans = make(map[string][]string)
@@ -294,7 +298,6 @@ func (tpr *TpReader) LoadRatingPlansFiltered(tag string) (bool, error) {
 				}
 
 				drate.Rate = rt[drate.RateId]
-				ratingPlan.AddRateInterval(drate.DestinationId, GetRateInterval(rp, drate))
 				if drate.DestinationId == utils.ANY {
 					continue // no need of loading the destinations in this case
 				}
@@ -323,6 +326,9 @@ func (tpr *TpReader) LoadRatingPlansFiltered(tag string) (bool, error) {
 					tpr.dataStorage.SetReverseDestination(destination, utils.NonTransactional)
 				}
 			}
+			if err = AddRatingPlanRates(ratingPlan, rp, drm[rp.DestinationRatesId].DestinationRates); err != nil {
+				return false, fmt.Errorf("rating plan %s: %s", tag, err.Error())
+			}
 		}
 		if err := tpr.dataStorage.SetRatingPlan(ratingPlan, utils.NonTransactional); err != nil {
 			return false, err
@@ -353,8 +359,8 @@ func (tpr *TpReader) LoadRatingPlans() (err error) {
 				plan = &RatingPlan{Id: tag}
 				tpr.ratingPlans[plan.Id] = plan
 			}
-			for _, dr := range drs.DestinationRates {
-				plan.AddRateInterval(dr.DestinationId, GetRateInterval(rplBnd, dr))
+			if err = AddRatingPlanRates(plan, rplBnd, drs.DestinationRates); err != nil {
+				return fmt.Errorf("rating plan %s: %s", tag, err.Error())
 			}
 		}
 	}
@@ -1719,6 +1725,44 @@ func (tpr *TpReader) LoadFilter() error {
 	return tpr.LoadFilterFiltered("")
 }
 
+func (tpr *TpReader) LoadExchangeRatesFiltered(fromCurrency, toCurrency string) error {
+	tps, err := tpr.lr.GetTPExchangeRates(tpr.tpid, fromCurrency, toCurrency)
+	if err != nil {
+		return err
+	}
+	for _, tpFx := range tps {
+		fx, err := APItoExchangeRate(tpFx, tpr.timezone)
+		if err != nil {
+			return err
+		}
+		tpr.exchangeRates[fx.ID] = fx
+	}
+	return nil
+}
+
+func (tpr *TpReader) LoadExchangeRates() error {
+	return tpr.LoadExchangeRatesFiltered("", "")
+}
+
+func (tpr *TpReader) LoadTaxRatesFiltered(tag string) error {
+	tps, err := tpr.lr.GetTPTaxRates(tpr.tpid, tag)
+	if err != nil {
+		return err
+	}
+	for _, tpTxr := range tps {
+		txr, err := APItoTaxRate(tpTxr)
+		if err != nil {
+			return err
+		}
+		tpr.taxRates[tpTxr.Tenant] = append(tpr.taxRates[tpTxr.Tenant], txr)
+	}
+	return nil
+}
+
+func (tpr *TpReader) LoadTaxRates() error {
+	return tpr.LoadTaxRatesFiltered("")
+}
+
 func (tpr *TpReader) LoadAll() (err error) {
 	if err = tpr.LoadDestinations(); err != nil && err.Error() != utils.NotFoundCaps {
 		return
@@ -1780,6 +1824,12 @@ func (tpr *TpReader) LoadAll() (err error) {
 	if err = tpr.LoadFilter(); err != nil && err.Error() != utils.NotFoundCaps {
 		return
 	}
+	if err = tpr.LoadExchangeRates(); err != nil && err.Error() != utils.NotFoundCaps {
+		return
+	}
+	if err = tpr.LoadTaxRates(); err != nil && err.Error() != utils.NotFoundCaps {
+		return
+	}
 	return nil
 }
 
@@ -2122,6 +2172,29 @@ func (tpr *TpReader) WriteToDatabase(flush, verbose, disable_reverse bool) (err
 			}
 		}
 	}
+	if verbose {
+		log.Print("Exchange Rates:")
+	}
+	for _, fx := range tpr.exchangeRates {
+		if err = tpr.dataStorage.SetExchangeRate(fx); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", fx.ID)
+		}
+	}
+	if verbose {
+		log.Print("Tax Rates:")
+	}
+	for tenant, txrs := range tpr.taxRates {
+		txrs.Sort()
+		if err = tpr.dataStorage.SetTaxRates(tenant, txrs); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", tenant)
+		}
+	}
 	if verbose {
 		log.Print("Timings:")
 	}
@@ -2297,6 +2370,10 @@ func (tpr *TpReader) ShowStatistics() {
 	log.Print("Stats: ", len(tpr.sqProfiles))
 	// thresholds
 	log.Print("Thresholds: ", len(tpr.thProfiles))
+	// exchange rates
+	log.Print("Exchange rates: ", len(tpr.exchangeRates))
+	// tax rates
+	log.Print("Tax rates: ", len(tpr.taxRates))
 }
 
 // Returns the identities loaded for a specific category, useful for cache reloads
@@ -260,6 +260,25 @@ func (self *TPExporter) Run() error {
 		toExportMap[utils.FiltersCsv][i] = sdModel[0]
 	}
 
+	storDataExchangeRates, err := self.storDb.GetTPExchangeRates(self.tpID, "", "")
+	if err != nil && err.Error() != utils.ErrNotFound.Error() {
+		return err
+	}
+	for _, sd := range storDataExchangeRates {
+		for _, sdModel := range APItoModelTPExchangeRate(sd) {
+			toExportMap[utils.ExchangeRatesCsv] = append(toExportMap[utils.ExchangeRatesCsv], sdModel)
+		}
+	}
+
+	storDataTaxRates, err := self.storDb.GetTPTaxRates(self.tpID, "")
+	if err != nil && err.Error() != utils.ErrNotFound.Error() {
+		return err
+	}
+	toExportMap[utils.TaxRatesCsv] = make([]interface{}, len(storDataTaxRates))
+	for i, sd := range storDataTaxRates {
+		toExportMap[utils.TaxRatesCsv][i] = APItoModelTPTaxRate(sd)
+	}
+
 	storDataUsers, err := self.storDb.GetTPUsers(&utils.TPUsers{TPid: self.tpID})
 	if err != nil && err.Error() != utils.ErrNotFound.Error() {
 		return err
@@ -61,6 +61,8 @@ var fileHandlers = map[string]func(*TPCSVImporter, string) error{
 	utils.StatsCsv:              (*TPCSVImporter).importStats,
 	utils.ThresholdsCsv:         (*TPCSVImporter).importThresholds,
 	utils.FiltersCsv:            (*TPCSVImporter).importFilters,
+	utils.ExchangeRatesCsv:      (*TPCSVImporter).importExchangeRates,
+	utils.TaxRatesCsv:           (*TPCSVImporter).importTaxRates,
 }
 
 func (self *TPCSVImporter) Run() error {
@@ -85,6 +87,8 @@ func (self *TPCSVImporter) Run() error {
 		path.Join(self.DirPath, utils.StatsCsv),
 		path.Join(self.DirPath, utils.ThresholdsCsv),
 		path.Join(self.DirPath, utils.FiltersCsv),
+		path.Join(self.DirPath, utils.ExchangeRatesCsv),
+		path.Join(self.DirPath, utils.TaxRatesCsv),
 	)
 	files, _ := ioutil.ReadDir(self.DirPath)
 	for _, f := range files {
@@ -397,3 +401,25 @@ func (self *TPCSVImporter) importFilters(fn string) error {
 	}
 	return self.StorDb.SetTPFilter(sts)
 }
+
+func (self *TPCSVImporter) importExchangeRates(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	fxs, err := self.csvr.GetTPExchangeRates(self.TPid, "", "")
+	if err != nil {
+		return err
+	}
+	return self.StorDb.SetTPExchangeRates(fxs)
+}
+
+func (self *TPCSVImporter) importTaxRates(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	txrs, err := self.csvr.GetTPTaxRates(self.TPid, "")
+	if err != nil {
+		return err
+	}
+	return self.StorDb.SetTPTaxRates(txrs)
+}
@@ -89,7 +89,7 @@ func (vers Versions) Compare(curent Versions, storType string) string {
 	switch storType {
 	case utils.MONGO:
 		x = m
-	case utils.POSTGRES, utils.MYSQL:
+	case utils.POSTGRES, utils.MYSQL, utils.SQLITE, utils.MetaInternal:
 		x = stor
 	case utils.REDIS:
 		x = data
@@ -119,7 +119,7 @@ func CurrentDBVersions(storType string) Versions {
 	switch storType {
 	case utils.MONGO, utils.MAPSTOR:
 		return allVersions
-	case utils.POSTGRES, utils.MYSQL:
+	case utils.POSTGRES, utils.MYSQL, utils.SQLITE, utils.MetaInternal:
 		return storDbVersions
 	case utils.REDIS:
 		return dataDbVersions
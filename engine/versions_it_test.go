@@ -162,7 +162,7 @@ func testVersion(t *testing.T) {
 		currentVersion = Versions{utils.Accounts: 2, utils.Actions: 2, utils.ActionTriggers: 2, utils.ActionPlans: 2, utils.SharedGroups: 2, utils.COST_DETAILS: 2}
 		testVersion = Versions{utils.Accounts: 1, utils.Actions: 2, utils.ActionTriggers: 2, utils.ActionPlans: 2, utils.SharedGroups: 2, utils.COST_DETAILS: 2}
 		test = "Migration needed: please backup cgr data and run : <cgr-migrator -migrate=*accounts>"
-	case utils.POSTGRES, utils.MYSQL:
+	case utils.POSTGRES, utils.MYSQL, utils.SQLITE:
 		currentVersion = CurrentStorDBVersions()
 		testVersion = Versions{utils.COST_DETAILS: 1}
 		test = "Migration needed: please backup cgr data and run : <cgr-migrator -migrate=*cost_details>"
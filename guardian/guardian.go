@@ -19,6 +19,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package guardian
 
 import (
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,26 +28,98 @@ import (
 // global package variable
 var Guardian = &GuardianLock{locksMap: make(map[string]*itemLock)}
 
+// interval between the attempts to acquire a lock held in backend by other process
+var backendLockPollInterval = 10 * time.Millisecond
+
+var (
+	ErrLockTimeout = errors.New("LOCK_TIMEOUT") // backend lock held by other process for longer than the ttl
+	ErrLockLost    = errors.New("LOCK_LOST")    // backend lock expired and possibly taken over by other process
+)
+
+// LockBackend shares the locks with the other processes using it (ie: engines sharing the same DataDB)
+type LockBackend interface {
+	// TryLockID attempts once to acquire lockID for ttl, returning the fencing token identifying the owner
+	TryLockID(lockID string, ttl time.Duration) (token int64, acquired bool, err error)
+	// RenewLockID extends lockID with ttl only if it is still owned by token
+	RenewLockID(lockID string, token int64, ttl time.Duration) (renewed bool, err error)
+	// UnlockID releases lockID only if it is still owned by token
+	UnlockID(lockID string, token int64) error
+}
+
 func newItemLock(keyID string) *itemLock {
 	return &itemLock{keyID: keyID}
 }
 
 // itemLock represents one lock with key autodestroy
 type itemLock struct {
-	keyID string // store it so we know what to destroy
-	cnt   int64
+	keyID     string // store it so we know what to destroy
+	cnt       int64
+	backend   LockBackend   // set while the lock is also held in backend
+	token     int64         // fencing token received from backend, accessed atomically
+	stopRenew chan struct{} // stops renewing the backend lock
 	sync.Mutex
 }
 
-// unlock() executes combined lock with autoremoving lock from Guardian
-func (il *itemLock) unlock() {
+// lockBackend acquires the lock also in backend, polling while held by other process
+// failing with ErrLockTimeout if ttl passes without success
+func (il *itemLock) lockBackend(backend LockBackend, ttl time.Duration) (err error) {
+	for tStart := time.Now(); ; time.Sleep(backendLockPollInterval) {
+		token, acquired, err := backend.TryLockID(il.keyID, ttl)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			il.backend = backend
+			atomic.StoreInt64(&il.token, token)
+			il.stopRenew = make(chan struct{})
+			go il.renewBackend(backend, token, ttl, il.stopRenew)
+			return nil
+		}
+		if time.Now().Sub(tStart) >= ttl {
+			return ErrLockTimeout
+		}
+	}
+}
+
+// renewBackend keeps the backend lock for as long as it is held by the critical section,
+// stopping once the lock was lost so the writes fenced with its token fail
+func (il *itemLock) renewBackend(backend LockBackend, token int64, ttl time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if renewed, err := backend.RenewLockID(il.keyID, token, ttl); err == nil && !renewed {
+				return
+			} // on errors try again, the lock is kept in backend for one more ttl
+		}
+	}
+}
+
+// unlockBackend releases the backend lock, if held
+// errors are ignored since the lock expires in backend after its ttl anyway
+func (il *itemLock) unlockBackend() {
+	if il.backend == nil {
+		return
+	}
+	close(il.stopRenew)
+	il.backend.UnlockID(il.keyID, atomic.LoadInt64(&il.token))
+	il.backend, il.stopRenew = nil, nil
+	atomic.StoreInt64(&il.token, 0)
+}
+
+// unlock() executes combined lock with autoremoving lock from guard
+func (il *itemLock) unlock(guard *GuardianLock) {
+	il.unlockBackend()
 	atomic.AddInt64(&il.cnt, -1)
 	if atomic.LoadInt64(&il.cnt) == 0 { // last lock in the queue
-		Guardian.Lock()
+		guard.Lock()
 		if il.cnt == 0 { // assurance that our counter was not modified in between read and lock
-			delete(Guardian.locksMap, il.keyID)
+			delete(guard.locksMap, il.keyID)
 		}
-		Guardian.Unlock()
+		guard.Unlock()
 	}
 	il.Unlock() // will unlock a single count so the next one waiting for lock can proceed
 }
@@ -54,12 +127,35 @@ func (il *itemLock) unlock() {
 // GuardianLock is an optimized locking system per locking key
 type GuardianLock struct {
 	locksMap     map[string]*itemLock
-	sync.RWMutex // protects the maps
+	backend      LockBackend   // shares the locks with other processes, nil for process local locks
+	backendTTL   time.Duration // backend locks expire automatically after it, in case of owner going away
+	sync.RWMutex               // protects the maps
+}
+
+// SetLockBackend makes the locks shared with the other processes using the same backend
+func (guard *GuardianLock) SetLockBackend(backend LockBackend, ttl time.Duration) {
+	guard.Lock()
+	guard.backend, guard.backendTTL = backend, ttl
+	guard.Unlock()
+}
+
+// FencingToken returns the token of the backend lock held on lockID,
+// used to reject the writes done after the lock was lost
+func (guard *GuardianLock) FencingToken(lockID string) (token int64, has bool) {
+	guard.RLock()
+	itmLock, exists := guard.locksMap[lockID]
+	guard.RUnlock()
+	if !exists {
+		return
+	}
+	token = atomic.LoadInt64(&itmLock.token)
+	return token, token != 0
 }
 
 // lockItems locks a set of lockIDs
 // returning the lock structs so they can be later unlocked
-func (guard *GuardianLock) lockItems(lockIDs []string) (itmLocks []*itemLock) {
+// on backend errors none of the locks is kept
+func (guard *GuardianLock) lockItems(lockIDs []string) (itmLocks []*itemLock, err error) {
 	guard.Lock()
 	for _, lockID := range lockIDs {
 		var itmLock *itemLock
@@ -71,22 +167,35 @@ func (guard *GuardianLock) lockItems(lockIDs []string) (itmLocks []*itemLock) {
 		atomic.AddInt64(&itmLock.cnt, 1)
 		itmLocks = append(itmLocks, itmLock)
 	}
+	backend, backendTTL := guard.backend, guard.backendTTL
 	guard.Unlock()
 	for _, itmLock := range itmLocks {
 		itmLock.Lock()
 	}
+	if backend == nil {
+		return
+	}
+	for _, itmLock := range itmLocks {
+		if err = itmLock.lockBackend(backend, backendTTL); err != nil {
+			guard.unlockItems(itmLocks)
+			return nil, err
+		}
+	}
 	return
 }
 
 // unlockItems will unlock the items provided
 func (guard *GuardianLock) unlockItems(itmLocks []*itemLock) {
 	for _, itmLock := range itmLocks {
-		itmLock.unlock()
+		itmLock.unlock(guard)
 	}
 }
 
 func (guard *GuardianLock) Guard(handler func() (interface{}, error), timeout time.Duration, lockIDs ...string) (reply interface{}, err error) {
-	itmLocks := guard.lockItems(lockIDs)
+	itmLocks, err := guard.lockItems(lockIDs)
+	if err != nil {
+		return
+	}
 
 	rplyChan := make(chan interface{})
 	errChan := make(chan error)
@@ -116,8 +225,10 @@ func (guard *GuardianLock) Guard(handler func() (interface{}, error), timeout ti
 }
 
 // GuardTimed aquires a lock for duration
-func (guard *GuardianLock) GuardIDs(timeout time.Duration, lockIDs ...string) {
-	guard.lockItems(lockIDs)
+func (guard *GuardianLock) GuardIDs(timeout time.Duration, lockIDs ...string) (err error) {
+	if _, err = guard.lockItems(lockIDs); err != nil {
+		return
+	}
 	if timeout != 0 {
 		go func(timeout time.Duration, lockIDs ...string) {
 			time.Sleep(timeout)
@@ -133,7 +244,7 @@ func (guard *GuardianLock) UnguardIDs(lockIDs ...string) {
 	guard.RLock()
 	for _, lockID := range lockIDs {
 		var itmLock *itemLock
-		itmLock, exists := Guardian.locksMap[lockID]
+		itmLock, exists := guard.locksMap[lockID]
 		if exists {
 			itmLocks = append(itmLocks, itmLock)
 		}
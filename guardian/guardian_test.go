@@ -158,6 +158,121 @@ func TestGuardianGuardIDs(t *testing.T) {
 	Guardian.RUnlock()
 }
 
+// mapLockBackend simulates a backend shared by multiple processes
+type mapLockBackend struct {
+	sync.Mutex
+	locks     map[string]int64
+	expiries  map[string]time.Time
+	lastToken int64
+}
+
+func (lb *mapLockBackend) TryLockID(lockID string, ttl time.Duration) (token int64, acquired bool, err error) {
+	lb.Lock()
+	defer lb.Unlock()
+	lb.lastToken++
+	if _, has := lb.locks[lockID]; has && time.Now().Before(lb.expiries[lockID]) {
+		return lb.lastToken, false, nil
+	}
+	lb.locks[lockID] = lb.lastToken
+	lb.expiries[lockID] = time.Now().Add(ttl)
+	return lb.lastToken, true, nil
+}
+
+func (lb *mapLockBackend) RenewLockID(lockID string, token int64, ttl time.Duration) (bool, error) {
+	lb.Lock()
+	defer lb.Unlock()
+	if lb.locks[lockID] != token {
+		return false, nil
+	}
+	lb.expiries[lockID] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (lb *mapLockBackend) UnlockID(lockID string, token int64) error {
+	lb.Lock()
+	defer lb.Unlock()
+	if lb.locks[lockID] == token {
+		delete(lb.locks, lockID)
+	}
+	return nil
+}
+
+// Two guardians sharing the backend, as two engines sharing the DataDB, should serialise on the same key
+func TestGuardianLockBackend(t *testing.T) {
+	lb := &mapLockBackend{locks: make(map[string]int64), expiries: make(map[string]time.Time)}
+	guard1 := &GuardianLock{locksMap: make(map[string]*itemLock)}
+	guard1.SetLockBackend(lb, time.Second)
+	guard2 := &GuardianLock{locksMap: make(map[string]*itemLock)}
+	guard2.SetLockBackend(lb, time.Second)
+	tStart := time.Now()
+	sg := new(sync.WaitGroup)
+	for _, guard := range []*GuardianLock{guard1, guard2} {
+		sg.Add(1)
+		go func(guard *GuardianLock) {
+			guard.Guard(delayHandler, 0, "test1")
+			sg.Done()
+		}(guard)
+	}
+	sg.Wait()
+	if execTime := time.Now().Sub(tStart); execTime < 200*time.Millisecond {
+		t.Errorf("Execution took: %v", execTime)
+	}
+	if len(lb.locks) != 0 {
+		t.Errorf("Backend locks not released: %+v", lb.locks)
+	}
+	// lock expired and taken over by other owner should not be released
+	guard1.GuardIDs(0, "test2")
+	lb.locks["test2"] = lb.lastToken + 1
+	guard1.UnguardIDs("test2")
+	if _, has := lb.locks["test2"]; !has {
+		t.Error("Lock of other owner released")
+	}
+	guard1.RLock()
+	if len(guard1.locksMap) != 0 {
+		t.Errorf("locksMap should have 0 elements, has: %+v", guard1.locksMap)
+	}
+	guard1.RUnlock()
+}
+
+// The backend lock is renewed while the handler runs longer than its ttl, the other process failing to acquire it
+func TestGuardianLockBackendRenewAndTimeout(t *testing.T) {
+	lb := &mapLockBackend{locks: make(map[string]int64), expiries: make(map[string]time.Time)}
+	guard1 := &GuardianLock{locksMap: make(map[string]*itemLock)}
+	guard1.SetLockBackend(lb, 30*time.Millisecond)
+	guard2 := &GuardianLock{locksMap: make(map[string]*itemLock)}
+	guard2.SetLockBackend(lb, 30*time.Millisecond)
+	var token int64
+	var hasToken bool
+	go guard1.Guard(func() (interface{}, error) {
+		token, hasToken = guard1.FencingToken("test1")
+		time.Sleep(100 * time.Millisecond)
+		return nil, nil
+	}, 0, "test1")
+	time.Sleep(50 * time.Millisecond) // past the ttl of guard1 lock
+	var executed bool
+	if _, err := guard2.Guard(func() (interface{}, error) {
+		executed = true
+		return nil, nil
+	}, 0, "test1"); err != ErrLockTimeout {
+		t.Errorf("Expecting: %v, received: %v", ErrLockTimeout, err)
+	}
+	if executed {
+		t.Error("Handler executed without holding the lock")
+	}
+	if !hasToken || token != lb.locks["test1"] {
+		t.Errorf("Expecting fencing token: %d, received: %d", lb.locks["test1"], token)
+	}
+	guard2.RLock()
+	if len(guard2.locksMap) != 0 {
+		t.Errorf("locksMap should have 0 elements, has: %+v", guard2.locksMap)
+	}
+	guard2.RUnlock()
+	time.Sleep(60 * time.Millisecond)
+	if _, err := guard2.Guard(delayHandler, 0, "test1"); err != nil {
+		t.Error(err)
+	}
+}
+
 func BenchmarkGuard(b *testing.B) {
 	for i := 0; i < 100; i++ {
 		go Guardian.Guard(func() (interface{}, error) {
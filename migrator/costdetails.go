@@ -58,6 +58,8 @@ func (m *Migrator) migrateCostDetails() (err error) {
 		storSQL = m.storDB.(*engine.SQLStorage).Db
 	case utils.POSTGRES:
 		storSQL = m.storDB.(*engine.SQLStorage).Db
+	case utils.SQLITE:
+		storSQL = m.storDB.(*engine.SQLStorage).Db
 	default:
 		return utils.NewCGRError(utils.Migrator,
 			utils.MandatoryIEMissingCaps,
@@ -0,0 +1,86 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package migrator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// dumpDataDB writes the content of dataDB into the dump file, portable between DataDB types
+func (m *Migrator) dumpDataDB() (err error) {
+	if m.dataDBDumpPath == "" {
+		return utils.NewCGRError(utils.Migrator,
+			utils.MandatoryIEMissingCaps,
+			"DataDBDumpPath",
+			"no dump file provided for DataDB dump")
+	}
+	if m.dryRun {
+		log.Print("Cannot dryRun DataDB dump!")
+		return
+	}
+	f, err := os.Create(m.dataDBDumpPath)
+	if err != nil {
+		return err
+	}
+	rpt, err := engine.DumpDataDB(m.dm.DataDB(), f)
+	if err != nil {
+		f.Close()
+		return utils.NewCGRError(utils.Migrator,
+			utils.ServerErrorCaps,
+			err.Error(),
+			fmt.Sprintf("error: <%s> when dumping dataDB", err.Error()))
+	}
+	log.Printf("DataDB dump started at %s, finished at %s, items changed meanwhile might reflect any moment in between",
+		rpt.StartTime.Format(time.RFC3339), rpt.FinishTime.Format(time.RFC3339))
+	for prfx, cnt := range rpt.Items {
+		m.stats[prfx] += cnt
+	}
+	return f.Close()
+}
+
+// restoreDataDB writes into dataDB the items out of the dump file, with dryRun only checking the dump
+func (m *Migrator) restoreDataDB() (err error) {
+	if m.dataDBDumpPath == "" {
+		return utils.NewCGRError(utils.Migrator,
+			utils.MandatoryIEMissingCaps,
+			"DataDBDumpPath",
+			"no dump file provided for DataDB restore")
+	}
+	f, err := os.Open(m.dataDBDumpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stats, err := engine.RestoreDataDB(m.dm.DataDB(), f, m.dryRun)
+	if err != nil {
+		return utils.NewCGRError(utils.Migrator,
+			utils.ServerErrorCaps,
+			err.Error(),
+			fmt.Sprintf("error: <%s> when restoring dataDB", err.Error()))
+	}
+	for prfx, cnt := range stats {
+		m.stats[prfx] += cnt
+	}
+	return
+}
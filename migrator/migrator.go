@@ -26,7 +26,7 @@ import (
 	"github.com/cgrates/cgrates/utils"
 )
 
-func NewMigrator(dm *engine.DataManager, dataDBType, dataDBEncoding string, storDB engine.Storage, storDBType string, oldDataDB V1DataDB, oldDataDBType, oldDataDBEncoding string, oldStorDB engine.Storage, oldStorDBType string, dryRun bool) (m *Migrator, err error) {
+func NewMigrator(dm *engine.DataManager, dataDBType, dataDBEncoding string, storDB engine.Storage, storDBType string, oldDataDB V1DataDB, oldDataDBType, oldDataDBEncoding string, oldStorDB engine.Storage, oldStorDBType string, dryRun bool, dataDBDumpPath string) (m *Migrator, err error) {
 	var mrshlr engine.Marshaler
 	var oldmrshlr engine.Marshaler
 	if dataDBEncoding == utils.MSGPACK {
@@ -45,24 +45,25 @@ func NewMigrator(dm *engine.DataManager, dataDBType, dataDBEncoding string, stor
 		storDB: storDB, storDBType: storDBType, mrshlr: mrshlr,
 		oldDataDB: oldDataDB, oldDataDBType: oldDataDBType,
 		oldStorDB: oldStorDB, oldStorDBType: oldStorDBType,
-		oldmrshlr: oldmrshlr, dryRun: dryRun, stats: stats,
+		oldmrshlr: oldmrshlr, dryRun: dryRun, stats: stats, dataDBDumpPath: dataDBDumpPath,
 	}
 	return m, err
 }
 
 type Migrator struct {
-	dm            *engine.DataManager
-	dataDBType    string
-	storDB        engine.Storage
-	storDBType    string
-	mrshlr        engine.Marshaler
-	oldDataDB     V1DataDB
-	oldDataDBType string
-	oldStorDB     engine.Storage
-	oldStorDBType string
-	oldmrshlr     engine.Marshaler
-	dryRun        bool
-	stats         map[string]int
+	dm             *engine.DataManager
+	dataDBType     string
+	storDB         engine.Storage
+	storDBType     string
+	mrshlr         engine.Marshaler
+	oldDataDB      V1DataDB
+	oldDataDBType  string
+	oldStorDB      engine.Storage
+	oldStorDBType  string
+	oldmrshlr      engine.Marshaler
+	dryRun         bool
+	stats          map[string]int
+	dataDBDumpPath string // file used by *dump_datadb and *restore_datadb
 }
 
 // Migrate implements the tasks to migrate, used as a dispatcher to the individual methods
@@ -105,6 +106,10 @@ func (m *Migrator) Migrate(taskIDs []string) (err error, stats map[string]int) {
 			err = m.migrateActions()
 		case utils.MetaSharedGroups:
 			err = m.migrateSharedGroups()
+		case utils.MetaDumpDataDB:
+			err = m.dumpDataDB()
+		case utils.MetaRestoreDataDB:
+			err = m.restoreDataDB()
 		}
 	}
 	for k, v := range m.stats {
@@ -253,6 +253,17 @@ func (s *Scheduler) GetScheduledActions(fltr ArgsGetScheduledActions) (schedActi
 	return
 }
 
+// PrometheusMetrics exports the number of action timings queued
+func (s *Scheduler) PrometheusMetrics() (pms []*utils.PrometheusMetric, err error) {
+	s.RLock()
+	queueLen := len(s.queue)
+	s.RUnlock()
+	return []*utils.PrometheusMetric{
+		&utils.PrometheusMetric{Name: "cgrates_scheduler_queue_length", Help: "Action timings queued in the scheduler",
+			Type: utils.MetricGauge, Value: float64(queueLen)},
+	}, nil
+}
+
 func (s *Scheduler) Shutdown() {
 	s.schedulerStarted = false // disable loop on next run
 	s.restartLoop <- true      // cancel waiting tasks
@@ -525,6 +525,22 @@ func (smg *SMGeneric) getSessions(cgrID string, passiveSessions bool) (aSS map[s
 	return
 }
 
+// PrometheusMetrics exports the number of active and passive sessions
+func (smg *SMGeneric) PrometheusMetrics() (pms []*utils.PrometheusMetric, err error) {
+	smg.aSessionsMux.RLock()
+	aSessions := len(smg.activeSessions)
+	smg.aSessionsMux.RUnlock()
+	smg.pSessionsMux.RLock()
+	pSessions := len(smg.passiveSessions)
+	smg.pSessionsMux.RUnlock()
+	return []*utils.PrometheusMetric{
+		&utils.PrometheusMetric{Name: "cgrates_smg_active_sessions", Help: "Sessions active in SMGeneric",
+			Type: utils.MetricGauge, Value: float64(aSessions)},
+		&utils.PrometheusMetric{Name: "cgrates_smg_passive_sessions", Help: "Sessions passive in SMGeneric",
+			Type: utils.MetricGauge, Value: float64(pSessions)},
+	}, nil
+}
+
 // setPassiveSession is called when a session is set via RPC in passive sessions table
 func (smg *SMGeneric) setPassiveSessions(cgrID string, ss []*SMGSession) (err error) {
 	if len(ss) == 0 {
@@ -151,6 +151,9 @@ type DestinationRate struct {
 	RoundingDecimals int
 	MaxCost          float64
 	MaxCostStrategy  string
+	TierUsageStart   string // usage cumulated within TierPeriod starting with which RateId applies, empty for the base rate
+	TierPeriod       string // period resetting the usage of tiered rates: <*daily|*weekly|*monthly|*yearly>
+	Currency         string // currency of the rate, empty for the one of the balances
 }
 
 type ApierTPTiming struct {
@@ -1045,6 +1048,7 @@ type CDRsFilter struct {
 	MaxCost                *float64          // End of the usage interval (<)
 	Unscoped               bool              // Include soft-deleted records in results
 	Count                  bool              // If true count the items instead of returning data
+	OrderBy                string            // Sort the CDRs ascending on this field, applied before pagination: <""|SetupTime>
 	Paginator
 }
 
@@ -1266,6 +1270,7 @@ type AttrSetBalance struct {
 	SharedGroups   *string
 	Blocker        *bool
 	Disabled       *bool
+	Currency       *string
 }
 
 type TPResource struct {
@@ -1353,6 +1358,7 @@ type TPStats struct {
 	Weight             float64
 	MinItems           int
 	Thresholds         []string
+	BucketSize         string // aggregate events into buckets of this size, empty for per event queues
 }
 
 type TPThreshold struct {
@@ -1366,6 +1372,9 @@ type TPThreshold struct {
 	Blocker            bool    // blocker flag to stop processing on filters matched
 	Weight             float64 // Weight to sort the thresholds
 	ActionIDs          []string
+	MinHits            int      // number of matching events needed before executing actions
+	MaxHits            int      // number of executions after which the threshold is disabled, 0 for unlimited
+	RecoverActionIDs   []string // actions executed when the threshold stops matching
 }
 
 type TPFilter struct {
@@ -1377,3 +1386,30 @@ type TPFilter struct {
 	FilterFielValues []string // Filter definition
 
 }
+
+// TPExchangeRate is the conversion rate between two currencies, changing in time
+type TPExchangeRate struct {
+	TPid         string
+	FromCurrency string
+	ToCurrency   string
+	Rates        []*TPExchangeRateValue
+}
+
+type TPExchangeRateValue struct {
+	ActivationTime string  // time starting with which the Rate applies
+	Rate           float64 // units of ToCurrency for one unit of FromCurrency
+}
+
+// TPTaxRate is one tax applied on the cost of the matching CDRs
+type TPTaxRate struct {
+	TPid           string
+	Tenant         string
+	ID             string
+	Categories     []string // empty to match all categories
+	DestinationIDs []string // empty to match all destinations
+	Accounts       []string // empty to match all accounts
+	Type           string   // <*percent|*fixed>
+	Value          float64  // percentage out of the cost or fixed amount
+	Compound       bool     // the percentage is applied on the cost including the taxes with higher weight
+	Weight         float64  // taxes are applied in descending order of weight
+}
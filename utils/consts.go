@@ -73,6 +73,7 @@ const (
 	REDIS_MAX_CONNS               = 10
 	POSTGRES                      = "postgres"
 	MYSQL                         = "mysql"
+	SQLITE                        = "sqlite"
 	MONGO                         = "mongo"
 	REDIS                         = "redis"
 	MAPSTOR                       = "mapstor"
@@ -109,8 +110,12 @@ const (
 	TBLTPStats                    = "tp_stats"
 	TBLTPThresholds               = "tp_thresholds"
 	TBLTPFilters                  = "tp_filters"
+	TBLTPExchangeRates            = "tp_exchange_rates"
+	TBLTPTaxRates                 = "tp_tax_rates"
 	TBLSMCosts                    = "sm_costs"
+	TBLStatMetrics                = "stat_metrics"
 	TBLCDRs                       = "cdrs"
+	TBLCDRsArchive                = "cdrs_archive"
 	TBLVersions                   = "versions"
 	TIMINGS_CSV                   = "Timings.csv"
 	DESTINATIONS_CSV              = "Destinations.csv"
@@ -132,6 +137,8 @@ const (
 	StatsCsv                      = "Stats.csv"
 	ThresholdsCsv                 = "Thresholds.csv"
 	FiltersCsv                    = "Filters.csv"
+	ExchangeRatesCsv              = "ExchangeRates.csv"
+	TaxRatesCsv                   = "TaxRates.csv"
 	ROUNDING_UP                   = "*up"
 	ROUNDING_MIDDLE               = "*middle"
 	ROUNDING_DOWN                 = "*down"
@@ -190,6 +197,15 @@ const (
 	DRYRUN                        = "dry_run"
 	META_COMBIMED                 = "*combimed"
 	MetaInternal                  = "*internal"
+	MetaInternalPersistent        = "*internal_persistent"
+	MetaDataDB                    = "*datadb"
+	MetaRPC                       = "*rpc"
+	MetaTable                     = "*table"
+	MetaFile                      = "*file"
+	MetaDaily                     = "*daily"
+	MetaWeekly                    = "*weekly"
+	MetaMonthly                   = "*monthly"
+	MetaYearly                    = "*yearly"
 	ZERO_RATING_SUBJECT_PREFIX    = "*zero"
 	OK                            = "OK"
 	CDRE_FIXED_WIDTH              = "fwv"
@@ -263,6 +279,10 @@ const (
 	StatQueueProfilePrefix        = "sqp_"
 	ThresholdProfilePrefix        = "thp_"
 	StatQueuePrefix               = "stq_"
+	TierCounterPrefix             = "tcn_"
+	TierEventUsagePrefix          = "teu_"
+	ExchangeRatePrefix            = "fxr_"
+	TaxRatesPrefix                = "txr_"
 	LOADINST_KEY                  = "load_history"
 	SESSION_MANAGER_SOURCE        = "SMR"
 	MEDIATOR_SOURCE               = "MED"
@@ -399,6 +419,8 @@ const (
 	NoStorDBConnection           = "not connected to StorDB"
 	UndefinedVersion             = "undefined version"
 	MetaSetVersions              = "*set_versions"
+	MetaDumpDataDB               = "*dump_datadb"
+	MetaRestoreDataDB            = "*restore_datadb"
 	UnsupportedDB                = "unsupported database"
 	ACCOUNT_SUMMARY              = "AccountSummary"
 	TxtSuffix                    = ".txt"
@@ -410,6 +432,10 @@ const (
 	CONTENT_FORM                 = "form"
 	CONTENT_TEXT                 = "text"
 	FileLockPrefix               = "file_"
+	LockPrefix                   = "lck_"
+	LockFencingTokenKey          = "lck_fencing_token"
+	CacheInvalidation            = "CacheInvalidation"
+	CacheInvalidationChannel     = "cgr_cache_invalidation"
 	ActionsPoster                = "act"
 	CDRPoster                    = "cdr"
 	MetaFileCSV                  = "*file_csv"
@@ -430,6 +456,10 @@ const (
 	MetaTCC                      = "*tcc"
 	MetaPDD                      = "*pdd"
 	MetaDDC                      = "*ddc"
+	MetaSum                      = "*sum"
+	MetaAverage                  = "*average"
+	MetaDistinct                 = "*distinct"
+	MetaPercentilePrefix         = "*p" // ie: *p95
 	CacheDestinations            = "destinations"
 	CacheReverseDestinations     = "reverse_destinations"
 	CacheRatingPlans             = "rating_plans"
@@ -450,6 +480,8 @@ const (
 	StatS                        = "stats"
 	StatService                  = "StatS"
 	RALService                   = "RALs"
+	CDRService                   = "CDRs"
+	SchedulerService             = "SchedulerS"
 	CostSource                   = "CostSource"
 	ExtraInfo                    = "ExtraInfo"
 	MetaPrefix                   = "*"
@@ -470,6 +502,12 @@ const (
 	BalanceID                    = "BalanceID"
 	BalanceValue                 = "BalanceValue"
 	ResourceS                    = "ResourceS"
+	CDRsRetention                = "CDRsRetention"
+	MetaLocal                    = "*local"
+	MetaPercent                  = "*percent"
+	MetaFixed                    = "*fixed"
+	MetaTaxes                    = "*taxes"
+	MetaTaxTotal                 = "*tax_total"
 )
 
 func buildCacheInstRevPrefixes() {
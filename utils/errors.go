@@ -46,6 +46,8 @@ var (
 	ErrResourceUnavailable     = errors.New("RESOURCE_UNAVAILABLE")
 	ErrNoActiveSession         = errors.New("NO_ACTIVE_SESSION")
 	ErrPartiallyExecuted       = errors.New("PARTIALLY_EXECUTED")
+	ErrRevisionConflict        = errors.New("REVISION_CONFLICT")
+	ErrExchangeRateNotFound    = errors.New("EXCHANGE_RATE_NOT_FOUND")
 )
 
 // NewCGRError initialises a new CGRError
@@ -92,6 +94,10 @@ func NewErrServerError(err error) error {
 	return fmt.Errorf("SERVER_ERROR: %s", err)
 }
 
+func NewErrNotConnected(serv string) error {
+	return fmt.Errorf("NOT_CONNECTED: %s", serv)
+}
+
 // Centralized returns for APIs
 func APIErrorHandler(errIn error) (err error) {
 	cgrErr, ok := errIn.(*CGRError)
@@ -0,0 +1,329 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net/http"
+	"net/rpc"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MetricCounter   = "counter"
+	MetricGauge     = "gauge"
+	MetricHistogram = "histogram"
+)
+
+// rpcLatencyBuckets are the upper bounds, in seconds, of the RPC latency histogram
+var rpcLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusMetric is one sample exported on the metrics endpoint
+// histogram samples carry the _bucket, _sum or _count suffix in their Name
+type PrometheusMetric struct {
+	Name   string
+	Help   string
+	Type   string // <MetricCounter|MetricGauge|MetricHistogram>
+	Labels map[string]string
+	Value  float64
+}
+
+// family returns the metric name used in HELP and TYPE lines
+func (pm *PrometheusMetric) family() string {
+	if pm.Type != MetricHistogram {
+		return pm.Name
+	}
+	for _, sfx := range []string{"_bucket", "_sum", "_count"} {
+		if strings.HasSuffix(pm.Name, sfx) {
+			return strings.TrimSuffix(pm.Name, sfx)
+		}
+	}
+	return pm.Name
+}
+
+// labelsString returns the labels in exposition format, sorted by label name
+func (pm *PrometheusMetric) labelsString() string {
+	if len(pm.Labels) == 0 {
+		return ""
+	}
+	lblNames := make([]string, 0, len(pm.Labels))
+	for lblName := range pm.Labels {
+		lblNames = append(lblNames, lblName)
+	}
+	sort.Strings(lblNames)
+	lbls := make([]string, len(lblNames))
+	for i, lblName := range lblNames {
+		lbls[i] = fmt.Sprintf("%s=\"%s\"", lblName, escapeMetricLabel(pm.Labels[lblName]))
+	}
+	return "{" + strings.Join(lbls, ",") + "}"
+}
+
+var metricLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeMetricLabel(lbl string) string {
+	return metricLabelReplacer.Replace(lbl)
+}
+
+func formatMetricValue(val float64) string {
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+// WritePrometheusMetrics writes the metrics in Prometheus text exposition format
+// metrics of the same family are grouped together, sorted by name and labels
+func WritePrometheusMetrics(w io.Writer, metrics []*PrometheusMetric) (err error) {
+	type sample struct {
+		metric *PrometheusMetric
+		lbls   string
+	}
+	families := make(map[string][]*sample)
+	var famNames []string
+	for _, pm := range metrics {
+		fam := pm.family()
+		if _, has := families[fam]; !has {
+			famNames = append(famNames, fam)
+		}
+		families[fam] = append(families[fam], &sample{metric: pm, lbls: pm.labelsString()})
+	}
+	sort.Strings(famNames)
+	var buf bytes.Buffer
+	for _, fam := range famNames {
+		smpls := families[fam]
+		sort.SliceStable(smpls, func(i, j int) bool {
+			if smpls[i].metric.Name != smpls[j].metric.Name {
+				return smpls[i].metric.Name < smpls[j].metric.Name
+			}
+			return smpls[i].lbls < smpls[j].lbls
+		})
+		if smpls[0].metric.Help != "" {
+			fmt.Fprintf(&buf, "# HELP %s %s\n", fam, smpls[0].metric.Help)
+		}
+		if smpls[0].metric.Type != "" {
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", fam, smpls[0].metric.Type)
+		}
+		for _, smpl := range smpls {
+			fmt.Fprintf(&buf, "%s%s %s\n", smpl.metric.Name, smpl.lbls, formatMetricValue(smpl.metric.Value))
+		}
+	}
+	_, err = w.Write(buf.Bytes())
+	return
+}
+
+// MetricsCollector returns the current samples of a subsystem
+type MetricsCollector func() ([]*PrometheusMetric, error)
+
+// NewMetricsHandler returns a MetricsHandler with RPC metrics enabled
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{
+		rpcMetrics: make(map[string]*rpcMethodMetrics),
+		collectors: make(map[string]MetricsCollector),
+	}
+}
+
+// MetricsHandler gathers the metrics of the engine and exposes them over HTTP
+type MetricsHandler struct {
+	rpcMux     sync.RWMutex
+	rpcMetrics map[string]*rpcMethodMetrics // metrics per RPC method
+	colMux     sync.RWMutex
+	collectors map[string]MetricsCollector // collectors indexed on subsystem name
+}
+
+// rpcMethodMetrics holds the counters for one RPC method
+type rpcMethodMetrics struct {
+	calls   uint64
+	errors  uint64
+	latency float64  // sum of the call durations, in seconds
+	buckets []uint64 // cumulative counts per rpcLatencyBuckets
+}
+
+// AddCollector registers (or replaces) the collector of a subsystem
+func (mh *MetricsHandler) AddCollector(subsystem string, collector MetricsCollector) {
+	mh.colMux.Lock()
+	mh.collectors[subsystem] = collector
+	mh.colMux.Unlock()
+}
+
+// RecordRPCCall accounts one RPC call executed in dur
+func (mh *MetricsHandler) RecordRPCCall(method string, dur time.Duration, failed bool) {
+	secs := dur.Seconds()
+	mh.rpcMux.Lock()
+	defer mh.rpcMux.Unlock()
+	mm, has := mh.rpcMetrics[method]
+	if !has {
+		mm = &rpcMethodMetrics{buckets: make([]uint64, len(rpcLatencyBuckets))}
+		mh.rpcMetrics[method] = mm
+	}
+	mm.calls++
+	if failed {
+		mm.errors++
+	}
+	mm.latency += secs
+	for i, upperBound := range rpcLatencyBuckets {
+		if secs <= upperBound {
+			mm.buckets[i]++
+		}
+	}
+}
+
+// rpcPrometheusMetrics returns the samples of the RPC metrics
+func (mh *MetricsHandler) rpcPrometheusMetrics() (pms []*PrometheusMetric) {
+	mh.rpcMux.RLock()
+	defer mh.rpcMux.RUnlock()
+	for method, mm := range mh.rpcMetrics {
+		lbls := map[string]string{"method": method}
+		pms = append(pms,
+			&PrometheusMetric{Name: "cgrates_rpc_requests_total", Help: "RPC requests served, per method",
+				Type: MetricCounter, Labels: lbls, Value: float64(mm.calls)},
+			&PrometheusMetric{Name: "cgrates_rpc_request_errors_total", Help: "RPC requests returning errors, per method",
+				Type: MetricCounter, Labels: lbls, Value: float64(mm.errors)})
+		for i, upperBound := range rpcLatencyBuckets {
+			pms = append(pms, &PrometheusMetric{Name: "cgrates_rpc_request_duration_seconds_bucket",
+				Help: "RPC request latencies, per method", Type: MetricHistogram,
+				Labels: map[string]string{"method": method, "le": formatMetricValue(upperBound)},
+				Value:  float64(mm.buckets[i])})
+		}
+		pms = append(pms,
+			&PrometheusMetric{Name: "cgrates_rpc_request_duration_seconds_bucket", Type: MetricHistogram,
+				Labels: map[string]string{"method": method, "le": "+Inf"}, Value: float64(mm.calls)},
+			&PrometheusMetric{Name: "cgrates_rpc_request_duration_seconds_sum", Type: MetricHistogram,
+				Labels: lbls, Value: mm.latency},
+			&PrometheusMetric{Name: "cgrates_rpc_request_duration_seconds_count", Type: MetricHistogram,
+				Labels: lbls, Value: float64(mm.calls)})
+	}
+	return
+}
+
+// PrometheusMetrics returns the RPC metrics together with the ones of the registered collectors
+func (mh *MetricsHandler) PrometheusMetrics() (pms []*PrometheusMetric) {
+	pms = mh.rpcPrometheusMetrics()
+	mh.colMux.RLock()
+	defer mh.colMux.RUnlock()
+	for subsystem, collector := range mh.collectors {
+		colPms, err := collector()
+		if err != nil {
+			Logger.Warning(fmt.Sprintf("<Metrics> failed collecting metrics for: %s, error: %s", subsystem, err.Error()))
+			continue
+		}
+		pms = append(pms, colPms...)
+	}
+	return
+}
+
+// ServeHTTP implements http.Handler interface
+func (mh *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := WritePrometheusMetrics(w, mh.PrometheusMetrics()); err != nil {
+		Logger.Warning(fmt.Sprintf("<Metrics> failed writing metrics, error: %s", err.Error()))
+	}
+}
+
+// NewMetricsServerCodec wraps a rpc.ServerCodec, recording the calls it serves into mh
+func NewMetricsServerCodec(sc rpc.ServerCodec, mh *MetricsHandler) rpc.ServerCodec {
+	return &metricsServerCodec{ServerCodec: sc, mh: mh,
+		pending: make(map[uint64]*pendingRPCCall)}
+}
+
+type pendingRPCCall struct {
+	method    string
+	startTime time.Time
+}
+
+// metricsServerCodec measures the time between reading a request and writing its response
+type metricsServerCodec struct {
+	rpc.ServerCodec
+	mh      *MetricsHandler
+	mux     sync.Mutex
+	pending map[uint64]*pendingRPCCall // requests in progress, indexed on sequence
+}
+
+func (msc *metricsServerCodec) ReadRequestHeader(r *rpc.Request) (err error) {
+	if err = msc.ServerCodec.ReadRequestHeader(r); err != nil {
+		return
+	}
+	msc.mux.Lock()
+	msc.pending[r.Seq] = &pendingRPCCall{method: r.ServiceMethod, startTime: time.Now()}
+	msc.mux.Unlock()
+	return
+}
+
+func (msc *metricsServerCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	msc.mux.Lock()
+	call, has := msc.pending[r.Seq]
+	delete(msc.pending, r.Seq)
+	msc.mux.Unlock()
+	if has {
+		msc.mh.RecordRPCCall(call.method, time.Since(call.startTime), r.Error != "")
+	}
+	return msc.ServerCodec.WriteResponse(r, body)
+}
+
+// NewGobServerCodec returns the gob rpc.ServerCodec used by rpc.ServeConn
+// needed since net/rpc does not export it
+func NewGobServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	buf := bufio.NewWriter(conn)
+	return &gobServerCodec{rwc: conn, dec: gob.NewDecoder(conn),
+		enc: gob.NewEncoder(buf), encBuf: buf}
+}
+
+type gobServerCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+}
+
+func (c *gobServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	return c.dec.Decode(r)
+}
+
+func (c *gobServerCodec) ReadRequestBody(body interface{}) error {
+	return c.dec.Decode(body)
+}
+
+func (c *gobServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil { // gob couldn't encode the header, shut down
+			c.Close()
+		}
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil { // was a gob problem encoding the body but the header has been written
+			c.Close()
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *gobServerCodec) Close() error {
+	if c.closed {
+		return nil // only close the connection once
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
@@ -0,0 +1,88 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheusMetrics(t *testing.T) {
+	metrics := []*PrometheusMetric{
+		&PrometheusMetric{Name: "cgrates_cache_entries", Help: "Cached items",
+			Type: MetricGauge, Labels: map[string]string{"partition": "*destinations"}, Value: 2},
+		&PrometheusMetric{Name: "cgrates_cache_entries", Help: "Cached items",
+			Type: MetricGauge, Labels: map[string]string{"partition": "*actions"}, Value: 1},
+		&PrometheusMetric{Name: "cgrates_cdrs_processed_total", Help: "CDRs processed",
+			Type: MetricCounter, Value: 10},
+		&PrometheusMetric{Name: "cgrates_label_test", Type: MetricGauge,
+			Labels: map[string]string{"b": "2", "a": "quote\"d"}, Value: 0.5},
+	}
+	eOut := `# HELP cgrates_cache_entries Cached items
+# TYPE cgrates_cache_entries gauge
+cgrates_cache_entries{partition="*actions"} 1
+cgrates_cache_entries{partition="*destinations"} 2
+# HELP cgrates_cdrs_processed_total CDRs processed
+# TYPE cgrates_cdrs_processed_total counter
+cgrates_cdrs_processed_total 10
+# TYPE cgrates_label_test gauge
+cgrates_label_test{a="quote\"d",b="2"} 0.5
+`
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf, metrics); err != nil {
+		t.Error(err)
+	} else if buf.String() != eOut {
+		t.Errorf("Expecting:\n%s\nreceived:\n%s", eOut, buf.String())
+	}
+}
+
+func TestMetricsHandlerPrometheusMetrics(t *testing.T) {
+	mh := NewMetricsHandler()
+	mh.RecordRPCCall("ApierV1.GetAccount", time.Duration(3*time.Millisecond), false)
+	mh.RecordRPCCall("ApierV1.GetAccount", time.Duration(2*time.Second), true)
+	mh.AddCollector("TestS", func() ([]*PrometheusMetric, error) {
+		return []*PrometheusMetric{
+			&PrometheusMetric{Name: "cgrates_test_gauge", Type: MetricGauge, Value: 7}}, nil
+	})
+	mh.AddCollector("FailingS", func() ([]*PrometheusMetric, error) {
+		return nil, errors.New("collector failure")
+	})
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf, mh.PrometheusMetrics()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE cgrates_rpc_request_duration_seconds histogram",
+		`cgrates_rpc_requests_total{method="ApierV1.GetAccount"} 2`,
+		`cgrates_rpc_request_errors_total{method="ApierV1.GetAccount"} 1`,
+		`cgrates_rpc_request_duration_seconds_bucket{le="0.001",method="ApierV1.GetAccount"} 0`,
+		`cgrates_rpc_request_duration_seconds_bucket{le="0.005",method="ApierV1.GetAccount"} 1`,
+		`cgrates_rpc_request_duration_seconds_bucket{le="2.5",method="ApierV1.GetAccount"} 2`,
+		`cgrates_rpc_request_duration_seconds_bucket{le="+Inf",method="ApierV1.GetAccount"} 2`,
+		`cgrates_rpc_request_duration_seconds_count{method="ApierV1.GetAccount"} 2`,
+		"cgrates_test_gauge 7",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing line: %s, in output:\n%s", line, out)
+		}
+	}
+}
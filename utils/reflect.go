@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 )
 
 func CastFieldIfToString(fld interface{}) (string, bool) {
@@ -53,39 +54,58 @@ func CastFieldIfToString(fld interface{}) (string, bool) {
 	return strVal, converted
 }
 
-// ReflectFieldAsString parses intf and attepting to return the field as string or error otherwise
+// reflectField returns the reflect.Value of the field with fldName out of intf
 // Supports "ExtraFields" where additional fields are dynamically inserted in map with field name: extraFieldsLabel
-func ReflectFieldAsString(intf interface{}, fldName, extraFieldsLabel string) (string, error) {
+func reflectField(intf interface{}, fldName, extraFieldsLabel string) (field reflect.Value, err error) {
 	v := reflect.ValueOf(intf)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
-	var field reflect.Value
 	switch v.Kind() {
 	case reflect.Struct:
 		field = v.FieldByName(fldName)
 	case reflect.Map:
 		field = v.MapIndex(reflect.ValueOf(fldName))
 		if !field.IsValid() { // Not looking in extra fields anymore
-			return "", ErrNotFound
+			return field, ErrNotFound
 		}
 	default:
-		return "", fmt.Errorf("Unsupported field kind: %v", v.Kind())
+		return field, fmt.Errorf("Unsupported field kind: %v", v.Kind())
 	}
 
 	if !field.IsValid() {
 		if extraFieldsLabel == "" {
-			return "", ErrNotFound
+			return field, ErrNotFound
 		}
 		mpVal := v.FieldByName(extraFieldsLabel)
 		if !mpVal.IsValid() || mpVal.Kind() != reflect.Map {
-			return "", ErrNotFound
+			return field, ErrNotFound
 		}
 		field = mpVal.MapIndex(reflect.ValueOf(fldName))
 		if !field.IsValid() {
-			return "", ErrNotFound
+			return field, ErrNotFound
 		}
 	}
+	return
+}
+
+// ReflectFieldInterface parses intf attepting to return the field value or error otherwise
+// Supports "ExtraFields" where additional fields are dynamically inserted in map with field name: extraFieldsLabel
+func ReflectFieldInterface(intf interface{}, fldName, extraFieldsLabel string) (retIf interface{}, err error) {
+	field, err := reflectField(intf, fldName, extraFieldsLabel)
+	if err != nil {
+		return nil, err
+	}
+	return field.Interface(), nil
+}
+
+// ReflectFieldAsString parses intf and attepting to return the field as string or error otherwise
+// Supports "ExtraFields" where additional fields are dynamically inserted in map with field name: extraFieldsLabel
+func ReflectFieldAsString(intf interface{}, fldName, extraFieldsLabel string) (string, error) {
+	field, err := reflectField(intf, fldName, extraFieldsLabel)
+	if err != nil {
+		return "", err
+	}
 	switch field.Kind() {
 	case reflect.String:
 		return field.String(), nil
@@ -121,3 +141,97 @@ func AsMapStringIface(item interface{}) (map[string]interface{}, error) {
 	}
 	return out, nil
 }
+
+// StringToInterface will parse string into supported types
+// if no other conversion possible, original string will be returned
+func StringToInterface(s string) interface{} {
+	if s == "" {
+		return s
+	}
+	// int64
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	// float64
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	// time.Duration
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	// time.Time
+	if t, err := ParseTimeDetectLayout(s, ""); err == nil {
+		return t
+	}
+	// string
+	return s
+}
+
+// comparableItem converts numeric values to float64 so they can be compared between them
+func comparableItem(item interface{}) interface{} {
+	switch it := item.(type) {
+	case int:
+		return float64(it)
+	case int32:
+		return float64(it)
+	case int64:
+		return float64(it)
+	case uint:
+		return float64(it)
+	case uint32:
+		return float64(it)
+	case uint64:
+		return float64(it)
+	case float32:
+		return float64(it)
+	}
+	return item
+}
+
+// GreaterThan attempts to compare two items
+// returns the result or error if not comparable
+func GreaterThan(item, oItem interface{}, orEqual bool) (gte bool, err error) {
+	item, oItem = comparableItem(item), comparableItem(oItem)
+	// numbers compared with durations are considered seconds
+	if f, canCast := item.(float64); canCast {
+		if _, isDur := oItem.(time.Duration); isDur {
+			item = time.Duration(f * float64(time.Second))
+		}
+	} else if _, isDur := item.(time.Duration); isDur {
+		if f, canCast := oItem.(float64); canCast {
+			oItem = time.Duration(f * float64(time.Second))
+		}
+	}
+	if reflect.TypeOf(item) != reflect.TypeOf(oItem) {
+		return false, fmt.Errorf("incomparable: <%+v> with <%+v>", item, oItem)
+	}
+	switch tVal := item.(type) {
+	case float64:
+		if orEqual {
+			gte = tVal >= oItem.(float64)
+		} else {
+			gte = tVal > oItem.(float64)
+		}
+	case time.Duration:
+		if orEqual {
+			gte = tVal >= oItem.(time.Duration)
+		} else {
+			gte = tVal > oItem.(time.Duration)
+		}
+	case time.Time:
+		gte = tVal.After(oItem.(time.Time))
+		if orEqual && !gte {
+			gte = tVal.Equal(oItem.(time.Time))
+		}
+	case string:
+		if orEqual {
+			gte = tVal >= oItem.(string)
+		} else {
+			gte = tVal > oItem.(string)
+		}
+	default:
+		err = fmt.Errorf("unsupported comparison type: %v", reflect.TypeOf(item))
+	}
+	return
+}
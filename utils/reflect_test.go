@@ -20,6 +20,7 @@ package utils
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestReflectFieldAsStringOnStruct(t *testing.T) {
@@ -138,3 +139,64 @@ func TestReflectAsMapStringIface(t *testing.T) {
 		t.Errorf("Expecting: %+v, received: %+v", expectOutMp, outMp)
 	}
 }
+
+func TestStringToInterface(t *testing.T) {
+	if res := StringToInterface("1"); res != int64(1) {
+		t.Errorf("received: %+v", res)
+	}
+	if res := StringToInterface("1.2"); res != 1.2 {
+		t.Errorf("received: %+v", res)
+	}
+	if res := StringToInterface("10s"); res != time.Duration(10*time.Second) {
+		t.Errorf("received: %+v", res)
+	}
+	eTime := time.Date(2017, 11, 8, 13, 15, 0, 0, time.UTC)
+	if res := StringToInterface("2017-11-08T13:15:00Z"); res != eTime {
+		t.Errorf("received: %+v", res)
+	}
+	if res := StringToInterface("cgrates.org"); res != "cgrates.org" {
+		t.Errorf("received: %+v", res)
+	}
+}
+
+func TestGreaterThan(t *testing.T) {
+	if gte, err := GreaterThan(1, 2, false); err != nil {
+		t.Error(err)
+	} else if gte {
+		t.Error("should not be greater than")
+	}
+	if gte, err := GreaterThan(2, 1.5, false); err != nil {
+		t.Error(err)
+	} else if !gte {
+		t.Error("should be greater than")
+	}
+	if gte, err := GreaterThan(int64(2), 2.0, true); err != nil {
+		t.Error(err)
+	} else if !gte {
+		t.Error("should be greater or equal")
+	}
+	if gte, err := GreaterThan(time.Duration(2*time.Second), time.Duration(2*time.Second), false); err != nil {
+		t.Error(err)
+	} else if gte {
+		t.Error("should not be greater than")
+	}
+	if gte, err := GreaterThan(time.Duration(2*time.Second), 1.5, false); err != nil {
+		t.Error(err)
+	} else if !gte {
+		t.Error("should be greater than")
+	}
+	if gte, err := GreaterThan(int64(2), time.Duration(2*time.Second), false); err != nil {
+		t.Error(err)
+	} else if gte {
+		t.Error("should not be greater than")
+	}
+	if gte, err := GreaterThan(time.Date(2017, 11, 8, 13, 15, 0, 0, time.UTC),
+		time.Date(2017, 11, 8, 13, 15, 0, 0, time.UTC), true); err != nil {
+		t.Error(err)
+	} else if !gte {
+		t.Error("should be greater or equal")
+	}
+	if _, err := GreaterThan(time.Duration(2*time.Second), "cgrates", false); err == nil {
+		t.Error("should not be comparable")
+	}
+}
@@ -40,6 +40,7 @@ type Server struct {
 	rpcEnabled  bool
 	httpEnabled bool
 	birpcSrv    *rpc2.Server
+	metrics     *MetricsHandler // records the RPC calls when not nil
 }
 
 func (s *Server) RpcRegister(rcvr interface{}) {
@@ -57,6 +58,22 @@ func (s *Server) RegisterHttpFunc(pattern string, handler func(http.ResponseWrit
 	s.httpEnabled = true
 }
 
+// RegisterMetricsHandler exposes the metrics on pattern and starts recording the RPC calls
+// needs to be called before serving RPC requests
+func (s *Server) RegisterMetricsHandler(pattern string, mh *MetricsHandler) {
+	s.metrics = mh
+	http.Handle(pattern, mh)
+	s.httpEnabled = true
+}
+
+// serveCodec serves the RPC requests over codec, recording them if metrics are enabled
+func (s *Server) serveCodec(codec rpc.ServerCodec) {
+	if s.metrics != nil {
+		codec = NewMetricsServerCodec(codec, s.metrics)
+	}
+	rpc.ServeCodec(codec)
+}
+
 // Registers a new BiJsonRpc name
 func (s *Server) BiRPCRegisterName(method string, handlerFunc interface{}) {
 	if s.birpcSrv == nil {
@@ -105,7 +122,7 @@ func (s *Server) ServeJSON(addr string) {
 			continue
 		}
 		//utils.Logger.Info(fmt.Sprintf("<CGRServer> New incoming connection: %v", conn.RemoteAddr()))
-		go jsonrpc.ServeConn(conn)
+		go s.serveCodec(jsonrpc.NewServerCodec(conn))
 	}
 
 }
@@ -138,15 +155,17 @@ func (s *Server) ServeGOB(addr string) {
 		}
 
 		//utils.Logger.Info(fmt.Sprintf("<CGRServer> New incoming connection: %v", conn.RemoteAddr()))
-		go rpc.ServeConn(conn)
+		go s.serveCodec(NewGobServerCodec(conn))
 	}
 }
 
-func handleRequest(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
-	res := NewRPCRequest(r.Body).Call()
-	io.Copy(w, res)
+	rpcReq := NewRPCRequest(r.Body)
+	go s.serveCodec(jsonrpc.NewServerCodec(rpcReq))
+	<-rpcReq.done
+	io.Copy(w, rpcReq.rw)
 }
 
 func (s *Server) ServeHTTP(addr string, jsonRPCURL string, wsRPCURL string, useBasicAuth bool, userList map[string]string) {
@@ -154,9 +173,9 @@ func (s *Server) ServeHTTP(addr string, jsonRPCURL string, wsRPCURL string, useB
 		s.httpEnabled = true
 		Logger.Info("<HTTP> enabling handler for JSON-RPC")
 		if useBasicAuth {
-			http.HandleFunc(jsonRPCURL, use(handleRequest, basicAuth(userList)))
+			http.HandleFunc(jsonRPCURL, use(s.handleRequest, basicAuth(userList)))
 		} else {
-			http.HandleFunc(jsonRPCURL, handleRequest)
+			http.HandleFunc(jsonRPCURL, s.handleRequest)
 		}
 	}
 
@@ -164,7 +183,7 @@ func (s *Server) ServeHTTP(addr string, jsonRPCURL string, wsRPCURL string, useB
 		s.httpEnabled = true
 		Logger.Info("<HTTP> enabling handler for WebSocket connections")
 		wsHandler := websocket.Handler(func(ws *websocket.Conn) {
-			jsonrpc.ServeConn(ws)
+			s.serveCodec(jsonrpc.NewServerCodec(ws))
 		})
 		if useBasicAuth {
 			http.HandleFunc(wsRPCURL, use(func(w http.ResponseWriter, r *http.Request) {
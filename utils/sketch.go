@@ -0,0 +1,184 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// NewQuantileSketch creates a QuantileSketch with the given relative accuracy (ie: 0.01 for 1%)
+func NewQuantileSketch(relativeAccuracy float64) (qs *QuantileSketch, err error) {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		return nil, fmt.Errorf("invalid relative accuracy: %v", relativeAccuracy)
+	}
+	return &QuantileSketch{Gamma: (1 + relativeAccuracy) / (1 - relativeAccuracy),
+		Positive: new(SketchStore), Negative: new(SketchStore)}, nil
+}
+
+// QuantileSketch estimates quantiles by counting values into logarithmic buckets
+// values are removable and sketches with the same Gamma are mergeable by summing up the buckets
+type QuantileSketch struct {
+	Gamma     float64 // ratio between bucket limits, (1+accuracy)/(1-accuracy)
+	Count     float64
+	ZeroCount float64
+	Positive  *SketchStore // buckets for values > 0
+	Negative  *SketchStore // buckets for the absolute of values < 0
+}
+
+// index returns the bucket index of a positive value
+func (qs *QuantileSketch) index(val float64) int {
+	return int(math.Ceil(math.Log(val) / math.Log(qs.Gamma)))
+}
+
+// value returns the representative value of a bucket
+func (qs *QuantileSketch) value(idx int) float64 {
+	return 2 * math.Pow(qs.Gamma, float64(idx)) / (qs.Gamma + 1)
+}
+
+// add increments the bucket of val with cnt (negative cnt for removals)
+func (qs *QuantileSketch) add(val, cnt float64) {
+	switch {
+	case val > 0:
+		qs.Positive.add(qs.index(val), cnt)
+	case val < 0:
+		qs.Negative.add(qs.index(-val), cnt)
+	default:
+		qs.ZeroCount += cnt
+	}
+	qs.Count += cnt
+}
+
+// Add counts a value into the sketch
+func (qs *QuantileSketch) Add(val float64) {
+	qs.add(val, 1)
+}
+
+// Remove discounts a value previously added
+func (qs *QuantileSketch) Remove(val float64) {
+	qs.add(val, -1)
+}
+
+// merge adds the buckets of oQs multiplied by sign to the ones of qs
+func (qs *QuantileSketch) merge(oQs *QuantileSketch, sign float64) (err error) {
+	if qs.Gamma != oQs.Gamma {
+		return errors.New("cannot merge sketches with different accuracy")
+	}
+	for i, cnt := range oQs.Positive.Counts {
+		qs.Positive.add(oQs.Positive.Offset+i, sign*cnt)
+	}
+	for i, cnt := range oQs.Negative.Counts {
+		qs.Negative.add(oQs.Negative.Offset+i, sign*cnt)
+	}
+	qs.ZeroCount += sign * oQs.ZeroCount
+	qs.Count += sign * oQs.Count
+	return
+}
+
+// Merge adds the buckets of oQs to the ones of qs
+func (qs *QuantileSketch) Merge(oQs *QuantileSketch) (err error) {
+	return qs.merge(oQs, 1)
+}
+
+// Subtract removes the buckets of oQs, previously merged, out of qs
+func (qs *QuantileSketch) Subtract(oQs *QuantileSketch) (err error) {
+	return qs.merge(oQs, -1)
+}
+
+// Quantile returns the estimated value at quantile q (0 <= q <= 1)
+func (qs *QuantileSketch) Quantile(q float64) (val float64, err error) {
+	if q < 0 || q > 1 {
+		return 0, fmt.Errorf("invalid quantile: %v", q)
+	}
+	if qs.Count <= 0 {
+		return 0, ErrNotFound
+	}
+	rank := q * (qs.Count - 1)
+	var cumul float64
+	for i := len(qs.Negative.Counts) - 1; i >= 0; i-- { // most negative values first
+		if cumul += qs.Negative.Counts[i]; cumul > rank {
+			return -qs.value(qs.Negative.Offset + i), nil
+		}
+	}
+	if cumul += qs.ZeroCount; cumul > rank {
+		return 0, nil
+	}
+	for i, cnt := range qs.Positive.Counts {
+		if cumul += cnt; cumul > rank {
+			return qs.value(qs.Positive.Offset + i), nil
+		}
+	}
+	return qs.value(qs.Positive.Offset + len(qs.Positive.Counts) - 1), nil
+}
+
+// sketchMaxBuckets limits the memory of a SketchStore, the lowest buckets being collapsed over it
+// with the default 1% accuracy it still covers values spread over 17 orders of magnitude
+const sketchMaxBuckets = 2048
+
+// SketchStore keeps contiguous bucket counts starting with bucket index Offset
+type SketchStore struct {
+	Offset    int
+	Counts    []float64
+	Collapsed bool // values lower than the first bucket are counted into it, removals included
+}
+
+// add increments bucket idx with cnt, growing the store if needed
+// values below the lowest bucket kept are counted into it
+func (ss *SketchStore) add(idx int, cnt float64) {
+	if len(ss.Counts) == 0 {
+		ss.Offset = idx
+		ss.Counts = []float64{0}
+	} else if idx >= ss.Offset+len(ss.Counts) {
+		ss.collapseBelow(idx - sketchMaxBuckets + 1)
+		ss.Counts = append(ss.Counts, make([]float64, idx-ss.Offset-len(ss.Counts)+1)...)
+	} else if idx < ss.Offset {
+		if ss.Collapsed { // the lower values were counted into the first bucket, so are their removals
+			idx = ss.Offset
+		} else if minIdx := ss.Offset + len(ss.Counts) - sketchMaxBuckets; idx < minIdx {
+			idx = minIdx
+			ss.Collapsed = true
+		}
+		if idx < ss.Offset {
+			ss.Counts = append(make([]float64, ss.Offset-idx), ss.Counts...)
+			ss.Offset = idx
+		}
+	}
+	ss.Counts[idx-ss.Offset] += cnt
+}
+
+// collapseBelow counts the buckets lower than idx into bucket idx
+func (ss *SketchStore) collapseBelow(idx int) {
+	nrLow := idx - ss.Offset
+	if nrLow <= 0 {
+		return
+	}
+	var lowCnt float64
+	for i := 0; i < nrLow && i < len(ss.Counts); i++ {
+		lowCnt += ss.Counts[i]
+	}
+	if nrLow >= len(ss.Counts) {
+		ss.Counts = []float64{lowCnt}
+	} else {
+		ss.Counts = append([]float64(nil), ss.Counts[nrLow:]...)
+		ss.Counts[0] += lowCnt
+	}
+	ss.Offset = idx
+	ss.Collapsed = true
+}
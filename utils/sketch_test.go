@@ -0,0 +1,157 @@
+/*
+Real-time Online/Offline Charging System (OCS) for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantileSketch(t *testing.T) {
+	if _, err := NewQuantileSketch(0); err == nil {
+		t.Error("expecting error for invalid accuracy")
+	}
+	qs, err := NewQuantileSketch(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := qs.Quantile(0.5); err != ErrNotFound {
+		t.Errorf("expecting: %v, received: %v", ErrNotFound, err)
+	}
+	for i := 1; i <= 1000; i++ {
+		qs.Add(float64(i))
+	}
+	for q, eVal := range map[float64]float64{0.5: 500, 0.95: 950, 0.99: 990} {
+		if val, err := qs.Quantile(q); err != nil {
+			t.Error(err)
+		} else if math.Abs(val-eVal)/eVal > 0.01 {
+			t.Errorf("quantile: %v, expecting: %v, received: %v", q, eVal, val)
+		}
+	}
+	for i := 501; i <= 1000; i++ {
+		qs.Remove(float64(i))
+	}
+	if val, err := qs.Quantile(1); err != nil {
+		t.Error(err)
+	} else if math.Abs(val-500)/500 > 0.01 {
+		t.Errorf("expecting: 500, received: %v", val)
+	}
+}
+
+func TestQuantileSketchNegativeAndZero(t *testing.T) {
+	qs, _ := NewQuantileSketch(0.01)
+	for _, val := range []float64{-10, -5, 0, 0, 5} {
+		qs.Add(val)
+	}
+	if val, err := qs.Quantile(0); err != nil {
+		t.Error(err)
+	} else if math.Abs(val+10)/10 > 0.01 {
+		t.Errorf("expecting: -10, received: %v", val)
+	}
+	if val, err := qs.Quantile(0.5); err != nil {
+		t.Error(err)
+	} else if val != 0 {
+		t.Errorf("expecting: 0, received: %v", val)
+	}
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+	qs1, _ := NewQuantileSketch(0.01)
+	qs2, _ := NewQuantileSketch(0.01)
+	for i := 1; i <= 100; i++ {
+		qs1.Add(float64(i))
+		qs2.Add(float64(i + 100))
+	}
+	if err := qs1.Merge(qs2); err != nil {
+		t.Fatal(err)
+	}
+	if qs1.Count != 200 {
+		t.Errorf("expecting: 200, received: %v", qs1.Count)
+	}
+	if val, err := qs1.Quantile(0.5); err != nil {
+		t.Error(err)
+	} else if math.Abs(val-100)/100 > 0.01 {
+		t.Errorf("expecting: 100, received: %v", val)
+	}
+	if err := qs1.Subtract(qs2); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := qs1.Quantile(1); err != nil {
+		t.Error(err)
+	} else if math.Abs(val-100)/100 > 0.01 {
+		t.Errorf("expecting: 100, received: %v", val)
+	}
+	qs3, _ := NewQuantileSketch(0.02)
+	if err := qs1.Merge(qs3); err == nil {
+		t.Error("expecting error on different accuracy")
+	}
+}
+
+func TestQuantileSketchMaxBuckets(t *testing.T) {
+	qs, _ := NewQuantileSketch(0.01)
+	qs.Add(1e-300)
+	qs.Add(1e300)
+	qs.Add(1e-200)
+	if len(qs.Positive.Counts) > sketchMaxBuckets {
+		t.Errorf("buckets: %d", len(qs.Positive.Counts))
+	}
+	if val, err := qs.Quantile(1); err != nil {
+		t.Error(err)
+	} else if math.Abs(val-1e300)/1e300 > 0.01 {
+		t.Errorf("expecting: 1e300, received: %v", val)
+	}
+	qs.Remove(1e-300)
+	qs.Remove(1e-200)
+	if qs.Count != 1 {
+		t.Errorf("expecting: 1, received: %v", qs.Count)
+	}
+	var cnt float64
+	for _, c := range qs.Positive.Counts {
+		cnt += c
+	}
+	if cnt != 1 {
+		t.Errorf("expecting: 1, received: %v", cnt)
+	}
+}
+
+func TestQuantileSketchRemoveCollapsed(t *testing.T) {
+	qs, _ := NewQuantileSketch(0.01)
+	var vals []float64
+	for i := 0; i < 3000; i++ { // one distinct bucket per value, more than sketchMaxBuckets
+		vals = append(vals, math.Pow(qs.Gamma, float64(i)))
+	}
+	for _, val := range vals {
+		qs.Add(val)
+	}
+	if len(qs.Positive.Counts) > sketchMaxBuckets || !qs.Positive.Collapsed {
+		t.Errorf("buckets: %d, collapsed: %v", len(qs.Positive.Counts), qs.Positive.Collapsed)
+	}
+	qs.Add(0.5) // lower than the collapsed buckets
+	qs.Remove(0.5)
+	for _, val := range vals {
+		qs.Remove(val)
+	}
+	if qs.Count != 0 {
+		t.Errorf("expecting: 0, received: %v", qs.Count)
+	}
+	for i, c := range qs.Positive.Counts {
+		if c != 0 {
+			t.Errorf("bucket %d, expecting: 0, received: %v", qs.Positive.Offset+i, c)
+		}
+	}
+}